@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"market/api/models"
+	"market/pkg/errs"
+	"market/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Actor takes the staff member who makes the request from X-Staff-ID header, so that
+// changes are recorded in the audit log under them. Handlers pass c.Request.Context()
+// to services to keep it.
+func (h Handler) Actor() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		staffID := c.GetHeader("X-Staff-ID")
+		if staffID == "" {
+			c.Next()
+			return
+		}
+
+		if _, err := uuid.Parse(staffID); err != nil {
+			handleResponse(c, h.log, "error is while reading X-Staff-ID header", http.StatusBadRequest,
+				errs.Validation("X-Staff-ID header should be uuid"))
+			c.Abort()
+			return
+		}
+
+		c.Request = c.Request.WithContext(service.WithActor(c.Request.Context(), staffID))
+		c.Next()
+	}
+}
+
+// GetAuditList godoc
+// @Router       /audit [GET]
+// @Summary      Get audit log
+// @Description  get changes made through the api, newest first
+// @Tags         audit
+// @Accept       json
+// @Produce      json
+// @Param 		 page query string false "page"
+// @Param 		 limit query string false "limit"
+// @Param 		 entity query string false "category, product, branch, repository, sale, basket, staff_tariff, staff, transaction, repository_transaction, customer or webhook"
+// @Param 		 entity_id query string false "entity_id"
+// @Param 		 actor_id query string false "staff id from X-Staff-ID header of the change"
+// @Success      200  {object}  models.AuditLogsResponse
+// @Failure      400  {object}  models.Response
+// @Failure      404  {object}  models.Response
+// @Failure      500  {object}  models.Response
+func (h Handler) GetAuditList(c *gin.Context) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil {
+		handleResponse(c, h.log, "error is while converting page", http.StatusBadRequest, err)
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil {
+		handleResponse(c, h.log, "error is while converting limit", http.StatusBadRequest, err)
+		return
+	}
+
+	auditLogs, err := h.services.Audit().GetList(c.Request.Context(), models.AuditListRequest{
+		Page:     page,
+		Limit:    limit,
+		Entity:   c.Query("entity"),
+		EntityID: c.Query("entity_id"),
+		ActorID:  c.Query("actor_id"),
+	})
+	if err != nil {
+		handleResponse(c, h.log, "error is while getting audit log", http.StatusInternalServerError, err)
+		return
+	}
+
+	handleResponse(c, h.log, "", http.StatusOK, auditLogs)
+}
@@ -1,7 +1,6 @@
 package handler
 
 import (
-	"context"
 	"net/http"
 	"strconv"
 	"market/api/models"
@@ -17,6 +16,7 @@ import (
 // @Accept       json
 // @Produce      json
 // @Param 		 basket body models.CreateBasket false "basket"
+// @Param 		 Idempotency-Key header string false "retries with the same key get the first response"
 // @Success      200  {object}  models.Basket
 // @Failure      400  {object}  models.Response
 // @Failure      404  {object}  models.Response
@@ -26,13 +26,13 @@ func (h *Handler) CreateBasket(c *gin.Context)  {
 	
 
 	if err :=  c.ShouldBindJSON(&basket); err != nil {
-		handleResponse(c, h.log, "error while reading body", http.StatusBadRequest, err.Error())
+		handleResponse(c, h.log, "error while reading body", http.StatusBadRequest, err)
 		return
 	}
 
-	resp, err :=  h.services.Basket().Create(context.Background(), basket)
+	resp, err :=  h.services.Basket().Create(c.Request.Context(), basket)
 	if err != nil {
-		handleResponse(c, h.log, "error is while creating basket", http.StatusInternalServerError, err.Error())
+		handleResponse(c, h.log, "error is while creating basket", http.StatusInternalServerError, err)
 		return 
 	}
 
@@ -54,12 +54,14 @@ func (h *Handler) CreateBasket(c *gin.Context)  {
 func (h Handler) GetBasket(c *gin.Context) {
 	uid := c.Param("id")
 
-	basket, err := h.services.Basket().Get(context.Background(), uid)
+	basket, err := h.services.Basket().Get(c.Request.Context(), uid)
 	if err != nil {
 		handleResponse(c, h.log, "error is while getting by id", http.StatusInternalServerError, err)
 		return
 	}
 
+	setETag(c, basket.Version)
+
 	handleResponse(c, h.log, "", http.StatusOK, basket)
 }
 
@@ -73,6 +75,8 @@ func (h Handler) GetBasket(c *gin.Context) {
 // @Param 		 page query string false "page"
 // @Param 		 limit query string false "limit"
 // @Param 		 search query string false "search"
+// @Param 		 include_deleted query bool false "list soft deleted rows too"
+// @Param 		 format query string false "csv or xlsx to download all rows as a file"
 // @Success      200  {object}  models.BasketsResponse
 // @Failure      400  {object}  models.Response
 // @Failure      404  {object}  models.Response
@@ -86,26 +90,62 @@ func (h Handler) GetBasketList(c *gin.Context) {
 	pageStr := c.DefaultQuery("page", "1")
 	page, err = strconv.Atoi(pageStr)
 	if err != nil {
-		handleResponse(c, h.log, "error while converting page", http.StatusBadRequest, err.Error())
+		handleResponse(c, h.log, "error while converting page", http.StatusBadRequest, err)
 		return
 	}
 
 	limitStr := c.DefaultQuery("limit", "10")
 	limit, err = strconv.Atoi(limitStr)
 	if err != nil {
-		handleResponse(c, h.log, "error while converting limit", http.StatusBadRequest, err.Error())
+		handleResponse(c, h.log, "error while converting limit", http.StatusBadRequest, err)
 		return
 	}
 
 	search := c.Query("search")
 
-	response, err := h.services.Basket().GetList(context.Background(), models.GetListRequest{
-		Page:   page,
-		Limit:  limit,
-		Search: search,
+	withDeleted, err := includeDeleted(c)
+	if err != nil {
+		handleResponse(c, h.log, "error is while converting include_deleted", http.StatusBadRequest, err)
+		return
+	}
+
+	if format := exportFormat(c); format != "" {
+		h.exportList(c, format, "baskets", []string{"id", "sale_id", "product_id", "quantity", "price", "created_at", "updated_at"},
+			func(after models.Cursor, limit int) ([][]string, models.Cursor, error) {
+				response, err := h.services.Basket().GetList(c.Request.Context(), models.GetListRequest{
+					After:          &after,
+					Limit:          limit,
+					Search:         search,
+					IncludeDeleted: withDeleted,
+				})
+				if err != nil {
+					return nil, after, err
+				}
+
+				rows := make([][]string, 0, len(response.Baskets))
+				for _, b := range response.Baskets {
+					rows = append(rows, []string{b.ID, b.SaleID, b.ProductID, strconv.Itoa(b.Quantity), strconv.Itoa(b.Price), b.CreatedAt, b.UpdatedAt})
+
+					createdAt, err := textTime(b.CreatedAt)
+					if err != nil {
+						return nil, after, err
+					}
+					after = models.Cursor{CreatedAt: createdAt, ID: b.ID}
+				}
+
+				return rows, after, nil
+			})
+		return
+	}
+
+	response, err := h.services.Basket().GetList(c.Request.Context(), models.GetListRequest{
+		Page:           page,
+		Limit:          limit,
+		Search:         search,
+		IncludeDeleted: withDeleted,
 	})
 	if err != nil {
-		handleResponse(c, h.log, "error while getting basket list", http.StatusInternalServerError, err.Error())
+		handleResponse(c, h.log, "error while getting basket list", http.StatusInternalServerError, err)
 		return
 	}
 
@@ -121,6 +161,7 @@ func (h Handler) GetBasketList(c *gin.Context) {
 // @Produce      json
 // @Param 		 id path string true "basket_id"
 // @Param 		 basket body models.UpdateBasket false "basket"
+// @Param 		 If-Match header string true "ETag of the entity from GET"
 // @Success      200  {object}  models.Basket
 // @Failure      400  {object}  models.Response
 // @Failure      404  {object}  models.Response
@@ -136,15 +177,65 @@ func (h Handler) UpdateBasket(c *gin.Context) {
 
 	updatedBasket.ID = uid
 
-	basket, err := h.services.Basket().Update(context.Background(), updatedBasket)
+	version, err := ifMatch(c)
+	if err != nil {
+		handleResponse(c, h.log, "error is while reading If-Match header", http.StatusPreconditionRequired, err)
+		return
+	}
+	updatedBasket.Version = version
+
+	basket, err := h.services.Basket().Update(c.Request.Context(), updatedBasket)
 	if err != nil {
 		handleResponse(c, h.log, "error is while updating basket", http.StatusInternalServerError, err)
 		return
 	}
 
+	setETag(c, basket.Version)
+
 	handleResponse(c, h.log, "", http.StatusOK, basket)
 }
 
+// PatchBasket godoc
+// @Router       /basket/{id} [PATCH]
+// @Summary      Patch basket
+// @Description  change only the given fields of basket, null clears a nullable field
+// @Tags         basket
+// @Accept       json
+// @Produce      json
+// @Param 		 id path string true "basket_id"
+// @Param 		 basket body models.PatchBasket false "basket"
+// @Param 		 If-Match header string true "ETag of the entity from GET"
+// @Success      200  {object}  models.Basket
+// @Failure      400  {object}  models.Response
+// @Failure      404  {object}  models.Response
+// @Failure      500  {object}  models.Response
+func (h Handler) PatchBasket(c *gin.Context) {
+	basket := models.PatchBasket{}
+	if err := bindPatch(c, &basket); err != nil {
+		handleResponse(c, h.log, "error is while reading body", http.StatusBadRequest, err)
+		return
+	}
+
+	basket.ID = c.Param("id")
+
+	version, err := ifMatch(c)
+	if err != nil {
+		handleResponse(c, h.log, "error is while reading If-Match header", http.StatusPreconditionRequired, err)
+		return
+	}
+	basket.Version = version
+
+	resp, err := h.services.Basket().Patch(c.Request.Context(), basket)
+	if err != nil {
+		handleResponse(c, h.log, "error is while patching basket", http.StatusInternalServerError, err)
+		return
+	}
+
+	setETag(c, resp.Version)
+
+	handleResponse(c, h.log, "", http.StatusOK, resp)
+}
+
 // DeleteBasket godoc
 // @Router       /basket/{id} [DELETE]
 // @Summary      Delete basket
@@ -160,10 +251,34 @@ func (h Handler) UpdateBasket(c *gin.Context) {
 func (h Handler) DeleteBasket(c *gin.Context) {
 	uid := c.Param("id")
 
-	if err := h.services.Basket().Delete(context.Background(), models.PrimaryKey{ID: uid}); err != nil {
+	if err := h.services.Basket().Delete(c.Request.Context(), models.PrimaryKey{ID: uid}); err != nil {
 		handleResponse(c, h.log, "error is while deleting basket", http.StatusInternalServerError, err)
 		return
 	}
 
 	handleResponse(c, h.log, "", http.StatusOK, nil)
+}
+
+// RestoreBasket godoc
+// @Router       /basket/{id}/restore [POST]
+// @Summary      Restore basket
+// @Description  bring back soft deleted basket
+// @Tags         basket
+// @Accept       json
+// @Produce      json
+// @Param 		 id path string true "basket_id"
+// @Success      200  {object}  models.Basket
+// @Failure      400  {object}  models.Response
+// @Failure      404  {object}  models.Response
+// @Failure      500  {object}  models.Response
+func (h Handler) RestoreBasket(c *gin.Context) {
+	basket, err := h.services.Basket().Restore(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		handleResponse(c, h.log, "error is while restoring basket", http.StatusInternalServerError, err)
+		return
+	}
+
+	setETag(c, basket.Version)
+
+	handleResponse(c, h.log, "", http.StatusOK, basket)
 }
\ No newline at end of file
@@ -1,7 +1,6 @@
 package handler
 
 import (
-	"context"
 	"github.com/gin-gonic/gin"
 	"net/http"
 	"strconv"
@@ -24,19 +23,13 @@ func (h Handler) CreateBranch(c *gin.Context) {
 	branch := models.CreateBranch{}
 
 	if err := c.ShouldBindJSON(&branch); err != nil {
-		handleResponse(c, "error is while reading body", http.StatusBadRequest, err.Error())
+		handleResponse(c, h.log, "error is while reading body", http.StatusBadRequest, err)
 		return
 	}
 
-	id, err := h.storage.Branch().Create(context.Background(), branch)
+	createdBranch, err := h.services.Branch().Create(c.Request.Context(), branch)
 	if err != nil {
-		handleResponse(c, h.log, "error is while creating branch", http.StatusInternalServerError, err.Error())
-		return
-	}
-
-	createdBranch, err := h.storage.Branch().GetByID(context.Background(), id)
-	if err != nil {
-		handleResponse(c, h.log, "error is while getting by id", http.StatusInternalServerError, err.Error())
+		handleResponse(c, h.log, "error is while creating branch", http.StatusInternalServerError, err)
 		return
 	}
 
@@ -58,12 +51,14 @@ func (h Handler) CreateBranch(c *gin.Context) {
 func (h Handler) GetBranch(c *gin.Context) {
 	uid := c.Param("id")
 
-	branch, err := h.storage.Branch().GetByID(context.Background(), uid)
+	branch, err := h.services.Branch().Get(c.Request.Context(), uid)
 	if err != nil {
-		handleResponse(c, h.log, "error is while getting by id", http.StatusInternalServerError, err.Error())
+		handleResponse(c, h.log, "error is while getting by id", http.StatusInternalServerError, err)
 		return
 	}
 
+	setETag(c, branch.Version)
+
 	handleResponse(c, h.log, "", http.StatusOK, branch)
 }
 
@@ -77,6 +72,8 @@ func (h Handler) GetBranch(c *gin.Context) {
 // @Param 		 page query string false "page"
 // @Param 		 limit query string false "limit"
 // @Param 		 search query string false "search"
+// @Param 		 include_deleted query bool false "list soft deleted rows too"
+// @Param 		 format query string false "csv or xlsx to download all rows as a file"
 // @Success      200  {object}  models.BranchResponse
 // @Failure      400  {object}  models.Response
 // @Failure      404  {object}  models.Response
@@ -91,25 +88,57 @@ func (h Handler) GetBranchList(c *gin.Context) {
 	pageStr := c.DefaultQuery("page", "1")
 	page, err = strconv.Atoi(pageStr)
 	if err != nil {
-		handleResponse(c, h.log, "error is while converting page", http.StatusBadRequest, err.Error())
+		handleResponse(c, h.log, "error is while converting page", http.StatusBadRequest, err)
 		return
 	}
 
 	limitStr := c.DefaultQuery("limit", "10")
 	limit, err = strconv.Atoi(limitStr)
 	if err != nil {
-		handleResponse(c, h.log, "error is while converting limit", http.StatusBadRequest, err.Error())
+		handleResponse(c, h.log, "error is while converting limit", http.StatusBadRequest, err)
 		return
 	}
 
 	search = c.Query("search")
 
-	branches, err := h.storage.Branch().GetList(context.Background(), models.GetListRequest{
-		Page:   page,
-		Limit:  limit,
-		Search: search,
+	withDeleted, err := includeDeleted(c)
+	if err != nil {
+		handleResponse(c, h.log, "error is while converting include_deleted", http.StatusBadRequest, err)
+		return
+	}
+
+	if format := exportFormat(c); format != "" {
+		h.exportList(c, format, "branches", []string{"id", "name", "address", "created_at", "updated_at"},
+			func(after models.Cursor, limit int) ([][]string, models.Cursor, error) {
+				branches, err := h.services.Branch().GetList(c.Request.Context(), models.GetListRequest{
+					After:          &after,
+					Limit:          limit,
+					Search:         search,
+					IncludeDeleted: withDeleted,
+				})
+				if err != nil {
+					return nil, after, err
+				}
+
+				rows := make([][]string, 0, len(branches.Branches))
+				for _, b := range branches.Branches {
+					rows = append(rows, []string{b.ID, b.Name, b.Address, exportTime(b.CreatedAt), exportTime(b.UpdatedAt)})
+					after = models.Cursor{CreatedAt: b.CreatedAt, ID: b.ID}
+				}
+
+				return rows, after, nil
+			})
+		return
+	}
+
+	branches, err := h.services.Branch().GetList(c.Request.Context(), models.GetListRequest{
+		Page:           page,
+		Limit:          limit,
+		Search:         search,
+		IncludeDeleted: withDeleted,
 	})
 	if err != nil {
+		handleResponse(c, h.log, "error is while getting branch list", http.StatusInternalServerError, err)
 		return
 	}
 
@@ -125,6 +154,7 @@ func (h Handler) GetBranchList(c *gin.Context) {
 // @Produce      json
 // @Param 		 id path string true "branch_id"
 // @Param 		 branch body models.UpdateBranch false "branch"
+// @Param 		 If-Match header string true "ETag of the entity from GET"
 // @Success      200  {object}  models.Branch
 // @Failure      400  {object}  models.Response
 // @Failure      404  {object}  models.Response
@@ -134,26 +164,71 @@ func (h Handler) UpdateBranch(c *gin.Context) {
 
 	branch := models.UpdateBranch{}
 	if err := c.ShouldBindJSON(&branch); err != nil {
-		handleResponse(c, h.log, "error is wile reading from body", http.StatusBadRequest, err.Error())
+		handleResponse(c, h.log, "error is wile reading from body", http.StatusBadRequest, err)
 		return
 	}
 
 	branch.ID = uid
-	id, err := h.storage.Branch().Update(context.Background(), branch)
+
+	version, err := ifMatch(c)
 	if err != nil {
-		handleResponse(c, h.log, "error is while updating branch", http.StatusInternalServerError, err.Error())
+		handleResponse(c, h.log, "error is while reading If-Match header", http.StatusPreconditionRequired, err)
 		return
 	}
+	branch.Version = version
 
-	updatedBranch, err := h.storage.Branch().GetByID(context.Background(), id)
+	updatedBranch, err := h.services.Branch().Update(c.Request.Context(), branch)
 	if err != nil {
-		handleResponse(c, h.log, "error is while getting by id", http.StatusInternalServerError, err.Error())
+		handleResponse(c, h.log, "error is while updating branch", http.StatusInternalServerError, err)
 		return
 	}
 
+	setETag(c, updatedBranch.Version)
+
 	handleResponse(c, h.log, "", http.StatusOK, updatedBranch)
 }
 
+// PatchBranch godoc
+// @Router       /branch/{id} [PATCH]
+// @Summary      Patch branch
+// @Description  change only the given fields of branch, null clears a nullable field
+// @Tags         branch
+// @Accept       json
+// @Produce      json
+// @Param 		 id path string true "branch_id"
+// @Param 		 branch body models.PatchBranch false "branch"
+// @Param 		 If-Match header string true "ETag of the entity from GET"
+// @Success      200  {object}  models.Branch
+// @Failure      400  {object}  models.Response
+// @Failure      404  {object}  models.Response
+// @Failure      500  {object}  models.Response
+func (h Handler) PatchBranch(c *gin.Context) {
+	branch := models.PatchBranch{}
+	if err := bindPatch(c, &branch); err != nil {
+		handleResponse(c, h.log, "error is while reading body", http.StatusBadRequest, err)
+		return
+	}
+
+	branch.ID = c.Param("id")
+
+	version, err := ifMatch(c)
+	if err != nil {
+		handleResponse(c, h.log, "error is while reading If-Match header", http.StatusPreconditionRequired, err)
+		return
+	}
+	branch.Version = version
+
+	resp, err := h.services.Branch().Patch(c.Request.Context(), branch)
+	if err != nil {
+		handleResponse(c, h.log, "error is while patching branch", http.StatusInternalServerError, err)
+		return
+	}
+
+	setETag(c, resp.Version)
+
+	handleResponse(c, h.log, "", http.StatusOK, resp)
+}
+
 // DeleteBranch godoc
 // @Router       /branch/{id} [DELETE]
 // @Summary      Delete branch
@@ -165,13 +240,38 @@ func (h Handler) UpdateBranch(c *gin.Context) {
 // @Success      200  {object}  models.Response
 // @Failure      400  {object}  models.Response
 // @Failure      404  {object}  models.Response
+// @Failure      409  {object}  models.Response
 // @Failure      500  {object}  models.Response
 func (h Handler) DeleteBranch(c *gin.Context) {
 	uid := c.Param("id")
 
-	if err := h.storage.Branch().Delete(context.Background(), uid); err != nil {
-		handleResponse(c, "error is while delteing branch", http.StatusInternalServerError, err.Error())
+	if err := h.services.Branch().Delete(c.Request.Context(), uid); err != nil {
+		handleResponse(c, h.log, "error is while delteing branch", http.StatusInternalServerError, err)
 		return
 	}
 	handleResponse(c, h.log, "", http.StatusOK, "branch deleted!")
 }
+
+// RestoreBranch godoc
+// @Router       /branch/{id}/restore [POST]
+// @Summary      Restore branch
+// @Description  bring back soft deleted branch
+// @Tags         branch
+// @Accept       json
+// @Produce      json
+// @Param 		 id path string true "branch_id"
+// @Success      200  {object}  models.Branch
+// @Failure      400  {object}  models.Response
+// @Failure      404  {object}  models.Response
+// @Failure      500  {object}  models.Response
+func (h Handler) RestoreBranch(c *gin.Context) {
+	branch, err := h.services.Branch().Restore(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		handleResponse(c, h.log, "error is while restoring branch", http.StatusInternalServerError, err)
+		return
+	}
+
+	setETag(c, branch.Version)
+
+	handleResponse(c, h.log, "", http.StatusOK, branch)
+}
@@ -1,7 +1,6 @@
 package handler
 
 import (
-	"context"
 	"github.com/gin-gonic/gin"
 	"net/http"
 	"strconv"
@@ -24,13 +23,13 @@ func (h Handler) CreateCategory(c *gin.Context) {
 	category := models.CreateCategory{}
 
 	if err := c.ShouldBindJSON(&category); err != nil {
-		handleResponse(c, h.log, "error is while reading body from client", http.StatusBadRequest, err.Error())
+		handleResponse(c, h.log, "error is while reading body from client", http.StatusBadRequest, err)
 		return
 	}
 
-	resp, err := h.services.Category().Create(context.Background(), category)
+	resp, err := h.services.Category().Create(c.Request.Context(), category)
 	if err != nil {
-		handleResponse(c, h.log, "error is while creating category", http.StatusInternalServerError, err.Error())
+		handleResponse(c, h.log, "error is while creating category", http.StatusInternalServerError, err)
 		return
 	}
 
@@ -51,12 +50,14 @@ func (h Handler) CreateCategory(c *gin.Context) {
 // @Failure      500  {object}  models.Response
 func (h Handler) GetCategory(c *gin.Context) {
 	uid := c.Param("id")
-	category, err := h.storage.Category().GetByID(context.Background(), uid)
+	category, err := h.services.Category().Get(c.Request.Context(), uid)
 	if err != nil {
-		handleResponse(c, "error is while getting by id", http.StatusInternalServerError, err.Error())
+		handleResponse(c, h.log, "error is while getting by id", http.StatusInternalServerError, err)
 		return
 	}
 
+	setETag(c, category.Version)
+
 	handleResponse(c, h.log, "", http.StatusOK, category)
 }
 
@@ -70,6 +71,8 @@ func (h Handler) GetCategory(c *gin.Context) {
 // @Param 		 page query string false "page"
 // @Param 		 limit query string false "limit"
 // @Param 		 search query string false "search"
+// @Param 		 include_deleted query bool false "list soft deleted rows too"
+// @Param 		 format query string false "csv or xlsx to download all rows as a file"
 // @Success      200  {object}  models.CategoryResponse
 // @Failure      400  {object}  models.Response
 // @Failure      404  {object}  models.Response
@@ -83,26 +86,57 @@ func (h Handler) GetCategoryList(c *gin.Context) {
 	pageStr := c.DefaultQuery("page", "1")
 	page, err = strconv.Atoi(pageStr)
 	if err != nil {
-		handleResponse(c, h.log, "error is while converting page", http.StatusBadRequest, err.Error())
+		handleResponse(c, h.log, "error is while converting page", http.StatusBadRequest, err)
 		return
 	}
 
 	limitStr := c.DefaultQuery("limit", "10")
 	limit, err = strconv.Atoi(limitStr)
 	if err != nil {
-		handleResponse(c, h.log, "error is while converting limit", http.StatusBadRequest, err.Error())
+		handleResponse(c, h.log, "error is while converting limit", http.StatusBadRequest, err)
 		return
 	}
 
 	search = c.Query("search")
 
-	categories, err := h.storage.Category().GetList(context.Background(), models.GetListRequest{
-		Page:   page,
-		Limit:  limit,
-		Search: search,
+	withDeleted, err := includeDeleted(c)
+	if err != nil {
+		handleResponse(c, h.log, "error is while converting include_deleted", http.StatusBadRequest, err)
+		return
+	}
+
+	if format := exportFormat(c); format != "" {
+		h.exportList(c, format, "categories", []string{"id", "name", "parent_id", "created_at", "updated_at"},
+			func(after models.Cursor, limit int) ([][]string, models.Cursor, error) {
+				categories, err := h.services.Category().GetList(c.Request.Context(), models.GetListRequest{
+					After:          &after,
+					Limit:          limit,
+					Search:         search,
+					IncludeDeleted: withDeleted,
+				})
+				if err != nil {
+					return nil, after, err
+				}
+
+				rows := make([][]string, 0, len(categories.Categories))
+				for _, ct := range categories.Categories {
+					rows = append(rows, []string{ct.ID, ct.Name, ct.ParentID, exportTime(ct.CreatedAt), exportTime(ct.UpdatedAt)})
+					after = models.Cursor{CreatedAt: ct.CreatedAt, ID: ct.ID}
+				}
+
+				return rows, after, nil
+			})
+		return
+	}
+
+	categories, err := h.services.Category().GetList(c.Request.Context(), models.GetListRequest{
+		Page:           page,
+		Limit:          limit,
+		Search:         search,
+		IncludeDeleted: withDeleted,
 	})
 	if err != nil {
-		handleResponse(c, h.log, "error is while getting list", http.StatusInternalServerError, err.Error())
+		handleResponse(c, h.log, "error is while getting list", http.StatusInternalServerError, err)
 		return
 	}
 
@@ -118,6 +152,7 @@ func (h Handler) GetCategoryList(c *gin.Context) {
 // @Produce      json
 // @Param 		 id path string true "category_id"
 // @Param 		 category body models.UpdateCategory false "category"
+// @Param 		 If-Match header string true "ETag of the entity from GET"
 // @Success      200  {object}  models.Category
 // @Failure      400  {object}  models.Response
 // @Failure      404  {object}  models.Response
@@ -127,27 +162,71 @@ func (h Handler) UpdateCategory(c *gin.Context) {
 	category := models.UpdateCategory{}
 
 	if err := c.ShouldBindJSON(&category); err != nil {
-		handleResponse(c, h.log, "error is while reading body", http.StatusBadRequest, err.Error())
+		handleResponse(c, h.log, "error is while reading body", http.StatusBadRequest, err)
 		return
 	}
 
 	category.ID = uid
 
-	id, err := h.storage.Category().Update(context.Background(), category)
+	version, err := ifMatch(c)
 	if err != nil {
-		handleResponse(c, h.log, "error is while updating category", http.StatusInternalServerError, err.Error())
+		handleResponse(c, h.log, "error is while reading If-Match header", http.StatusPreconditionRequired, err)
 		return
 	}
+	category.Version = version
 
-	updatedCategory, err := h.storage.Category().GetByID(context.Background(), id)
+	updatedCategory, err := h.services.Category().Update(c.Request.Context(), category)
 	if err != nil {
-		handleResponse(c, h.log, "error is while getting by id", http.StatusInternalServerError, err.Error())
+		handleResponse(c, h.log, "error is while updating category", http.StatusInternalServerError, err)
 		return
 	}
 
+	setETag(c, updatedCategory.Version)
+
 	handleResponse(c, h.log, "", http.StatusOK, updatedCategory)
 }
 
+// PatchCategory godoc
+// @Router       /category/{id} [PATCH]
+// @Summary      Patch category
+// @Description  change only the given fields of category, null clears a nullable field
+// @Tags         category
+// @Accept       json
+// @Produce      json
+// @Param 		 id path string true "category_id"
+// @Param 		 category body models.PatchCategory false "category"
+// @Param 		 If-Match header string true "ETag of the entity from GET"
+// @Success      200  {object}  models.Category
+// @Failure      400  {object}  models.Response
+// @Failure      404  {object}  models.Response
+// @Failure      500  {object}  models.Response
+func (h Handler) PatchCategory(c *gin.Context) {
+	category := models.PatchCategory{}
+	if err := bindPatch(c, &category); err != nil {
+		handleResponse(c, h.log, "error is while reading body", http.StatusBadRequest, err)
+		return
+	}
+
+	category.ID = c.Param("id")
+
+	version, err := ifMatch(c)
+	if err != nil {
+		handleResponse(c, h.log, "error is while reading If-Match header", http.StatusPreconditionRequired, err)
+		return
+	}
+	category.Version = version
+
+	resp, err := h.services.Category().Patch(c.Request.Context(), category)
+	if err != nil {
+		handleResponse(c, h.log, "error is while patching category", http.StatusInternalServerError, err)
+		return
+	}
+
+	setETag(c, resp.Version)
+
+	handleResponse(c, h.log, "", http.StatusOK, resp)
+}
+
 // DeleteCategory godoc
 // @Router       /category/{id} [DELETE]
 // @Summary      Delete category
@@ -162,10 +241,34 @@ func (h Handler) UpdateCategory(c *gin.Context) {
 // @Failure      500  {object}  models.Response
 func (h Handler) DeleteCategory(c *gin.Context) {
 	uid := c.Param("id")
-	if err := h.storage.Category().Delete(context.Background(), uid); err != nil {
-		handleResponse(c, h.log, "error is while deleting", http.StatusInternalServerError, err.Error())
+	if err := h.services.Category().Delete(c.Request.Context(), uid); err != nil {
+		handleResponse(c, h.log, "error is while deleting", http.StatusInternalServerError, err)
 		return
 	}
 
 	handleResponse(c, h.log, "", http.StatusOK, "category deleted!")
 }
+
+// RestoreCategory godoc
+// @Router       /category/{id}/restore [POST]
+// @Summary      Restore category
+// @Description  bring back soft deleted category
+// @Tags         category
+// @Accept       json
+// @Produce      json
+// @Param 		 id path string true "category_id"
+// @Success      200  {object}  models.Category
+// @Failure      400  {object}  models.Response
+// @Failure      404  {object}  models.Response
+// @Failure      500  {object}  models.Response
+func (h Handler) RestoreCategory(c *gin.Context) {
+	category, err := h.services.Category().Restore(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		handleResponse(c, h.log, "error is while restoring category", http.StatusInternalServerError, err)
+		return
+	}
+
+	setETag(c, category.Version)
+
+	handleResponse(c, h.log, "", http.StatusOK, category)
+}
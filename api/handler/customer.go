@@ -0,0 +1,301 @@
+package handler
+
+import (
+	"market/api/models"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateCustomer godoc
+// @Router       /customer [POST]
+// @Summary      Create a new customer
+// @Description  create a new customer
+// @Tags         customer
+// @Accept       json
+// @Produce      json
+// @Param 		 customer body models.CreateCustomer false "customer"
+// @Success      200  {object}  models.Customer
+// @Failure      400  {object}  models.Response
+// @Failure      404  {object}  models.Response
+// @Failure      500  {object}  models.Response
+func (h Handler) CreateCustomer(c *gin.Context) {
+	customer := models.CreateCustomer{}
+	if err := c.ShouldBindJSON(&customer); err != nil {
+		handleResponse(c, h.log, "error is while reading body", http.StatusBadRequest, err)
+		return
+	}
+
+	resp, err := h.services.Customer().Create(c.Request.Context(), customer)
+	if err != nil {
+		handleResponse(c, h.log, "error is while creating customer", http.StatusInternalServerError, err)
+		return
+	}
+
+	handleResponse(c, h.log, "", http.StatusCreated, resp)
+}
+
+// GetCustomer godoc
+// @Router       /customer/{id} [GET]
+// @Summary      Get customer by id
+// @Description  get customer by id
+// @Tags         customer
+// @Accept       json
+// @Produce      json
+// @Param 		 id path string true "customer_id"
+// @Success      200  {object}  models.Customer
+// @Failure      400  {object}  models.Response
+// @Failure      404  {object}  models.Response
+// @Failure      500  {object}  models.Response
+func (h Handler) GetCustomer(c *gin.Context) {
+	customer, err := h.services.Customer().Get(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		handleResponse(c, h.log, "error is while getting customer by id", http.StatusInternalServerError, err)
+		return
+	}
+
+	setETag(c, customer.Version)
+
+	handleResponse(c, h.log, "", http.StatusOK, customer)
+}
+
+// GetCustomerList godoc
+// @Router       /customers [GET]
+// @Summary      Get customer list
+// @Description  get customer list
+// @Tags         customer
+// @Accept       json
+// @Produce      json
+// @Param 		 page query string false "page"
+// @Param 		 limit query string false "limit"
+// @Param 		 search query string false "search"
+// @Param 		 include_deleted query bool false "list soft deleted rows too"
+// @Param 		 format query string false "csv or xlsx to download all rows as a file"
+// @Success      200  {object}  models.CustomersResponse
+// @Failure      400  {object}  models.Response
+// @Failure      404  {object}  models.Response
+// @Failure      500  {object}  models.Response
+func (h Handler) GetCustomerList(c *gin.Context) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil {
+		handleResponse(c, h.log, "error is while converting page", http.StatusBadRequest, err)
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil {
+		handleResponse(c, h.log, "error is while converting limit", http.StatusBadRequest, err)
+		return
+	}
+
+	withDeleted, err := includeDeleted(c)
+	if err != nil {
+		handleResponse(c, h.log, "error is while converting include_deleted", http.StatusBadRequest, err)
+		return
+	}
+
+	if format := exportFormat(c); format != "" {
+		h.exportList(c, format, "customers", []string{"id", "phone", "name", "branch_id", "points", "created_at", "updated_at"},
+			func(after models.Cursor, limit int) ([][]string, models.Cursor, error) {
+				customers, err := h.services.Customer().GetList(c.Request.Context(), models.GetListRequest{
+					After:          &after,
+					Limit:          limit,
+					Search:         c.Query("search"),
+					IncludeDeleted: withDeleted,
+				})
+				if err != nil {
+					return nil, after, err
+				}
+
+				rows := make([][]string, 0, len(customers.Customers))
+				for _, cs := range customers.Customers {
+					rows = append(rows, []string{cs.ID, cs.Phone, cs.Name, cs.BranchID, strconv.Itoa(cs.Points),
+						exportTime(cs.CreatedAt), exportTime(cs.UpdatedAt)})
+					after = models.Cursor{CreatedAt: cs.CreatedAt, ID: cs.ID}
+				}
+
+				return rows, after, nil
+			})
+		return
+	}
+
+	customers, err := h.services.Customer().GetList(c.Request.Context(), models.GetListRequest{
+		Page:           page,
+		Limit:          limit,
+		Search:         c.Query("search"),
+		IncludeDeleted: withDeleted,
+	})
+	if err != nil {
+		handleResponse(c, h.log, "error is while getting customer list", http.StatusInternalServerError, err)
+		return
+	}
+
+	handleResponse(c, h.log, "", http.StatusOK, customers)
+}
+
+// UpdateCustomer godoc
+// @Router       /customer/{id} [PUT]
+// @Summary      Update customer
+// @Description  update customer
+// @Tags         customer
+// @Accept       json
+// @Produce      json
+// @Param 		 id path string true "customer_id"
+// @Param 		 customer body models.UpdateCustomer false "customer"
+// @Param 		 If-Match header string true "ETag of the entity from GET"
+// @Success      200  {object}  models.Customer
+// @Failure      400  {object}  models.Response
+// @Failure      404  {object}  models.Response
+// @Failure      500  {object}  models.Response
+func (h Handler) UpdateCustomer(c *gin.Context) {
+	customer := models.UpdateCustomer{}
+	if err := c.ShouldBindJSON(&customer); err != nil {
+		handleResponse(c, h.log, "error is while reading body", http.StatusBadRequest, err)
+		return
+	}
+
+	customer.ID = c.Param("id")
+
+	version, err := ifMatch(c)
+	if err != nil {
+		handleResponse(c, h.log, "error is while reading If-Match header", http.StatusPreconditionRequired, err)
+		return
+	}
+	customer.Version = version
+
+	resp, err := h.services.Customer().Update(c.Request.Context(), customer)
+	if err != nil {
+		handleResponse(c, h.log, "error is while updating customer", http.StatusInternalServerError, err)
+		return
+	}
+
+	setETag(c, resp.Version)
+
+	handleResponse(c, h.log, "", http.StatusOK, resp)
+}
+
+// PatchCustomer godoc
+// @Router       /customer/{id} [PATCH]
+// @Summary      Patch customer
+// @Description  change only the given fields of customer, null clears a nullable field
+// @Tags         customer
+// @Accept       json
+// @Produce      json
+// @Param 		 id path string true "customer_id"
+// @Param 		 customer body models.PatchCustomer false "customer"
+// @Param 		 If-Match header string true "ETag of the entity from GET"
+// @Success      200  {object}  models.Customer
+// @Failure      400  {object}  models.Response
+// @Failure      404  {object}  models.Response
+// @Failure      500  {object}  models.Response
+func (h Handler) PatchCustomer(c *gin.Context) {
+	customer := models.PatchCustomer{}
+	if err := bindPatch(c, &customer); err != nil {
+		handleResponse(c, h.log, "error is while reading body", http.StatusBadRequest, err)
+		return
+	}
+
+	customer.ID = c.Param("id")
+
+	version, err := ifMatch(c)
+	if err != nil {
+		handleResponse(c, h.log, "error is while reading If-Match header", http.StatusPreconditionRequired, err)
+		return
+	}
+	customer.Version = version
+
+	resp, err := h.services.Customer().Patch(c.Request.Context(), customer)
+	if err != nil {
+		handleResponse(c, h.log, "error is while patching customer", http.StatusInternalServerError, err)
+		return
+	}
+
+	setETag(c, resp.Version)
+
+	handleResponse(c, h.log, "", http.StatusOK, resp)
+}
+
+// DeleteCustomer godoc
+// @Router       /customer/{id} [DELETE]
+// @Summary      Delete customer
+// @Description  delete customer
+// @Tags         customer
+// @Accept       json
+// @Produce      json
+// @Param 		 id path string true "customer_id"
+// @Success      200  {object}  models.Response
+// @Failure      400  {object}  models.Response
+// @Failure      404  {object}  models.Response
+// @Failure      500  {object}  models.Response
+func (h Handler) DeleteCustomer(c *gin.Context) {
+	if err := h.services.Customer().Delete(c.Request.Context(), c.Param("id")); err != nil {
+		handleResponse(c, h.log, "error is while deleting customer", http.StatusInternalServerError, err)
+		return
+	}
+
+	handleResponse(c, h.log, "", http.StatusOK, "customer deleted!")
+}
+
+// RestoreCustomer godoc
+// @Router       /customer/{id}/restore [POST]
+// @Summary      Restore customer
+// @Description  bring back soft deleted customer
+// @Tags         customer
+// @Accept       json
+// @Produce      json
+// @Param 		 id path string true "customer_id"
+// @Success      200  {object}  models.Customer
+// @Failure      400  {object}  models.Response
+// @Failure      404  {object}  models.Response
+// @Failure      500  {object}  models.Response
+func (h Handler) RestoreCustomer(c *gin.Context) {
+	customer, err := h.services.Customer().Restore(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		handleResponse(c, h.log, "error is while restoring customer", http.StatusInternalServerError, err)
+		return
+	}
+
+	setETag(c, customer.Version)
+
+	handleResponse(c, h.log, "", http.StatusOK, customer)
+}
+
+// GetCustomerHistory godoc
+// @Router       /customer/{id}/history [GET]
+// @Summary      Get customer purchase history
+// @Description  get past sales and baskets of customer
+// @Tags         customer
+// @Accept       json
+// @Produce      json
+// @Param 		 id path string true "customer_id"
+// @Param 		 page query string false "page"
+// @Param 		 limit query string false "limit"
+// @Success      200  {object}  models.CustomerHistory
+// @Failure      400  {object}  models.Response
+// @Failure      404  {object}  models.Response
+// @Failure      500  {object}  models.Response
+func (h Handler) GetCustomerHistory(c *gin.Context) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil {
+		handleResponse(c, h.log, "error is while converting page", http.StatusBadRequest, err)
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil {
+		handleResponse(c, h.log, "error is while converting limit", http.StatusBadRequest, err)
+		return
+	}
+
+	history, err := h.services.Customer().History(c.Request.Context(), c.Param("id"), models.GetListRequest{
+		Page:  page,
+		Limit: limit,
+	})
+	if err != nil {
+		handleResponse(c, h.log, "error is while getting customer history", http.StatusInternalServerError, err)
+		return
+	}
+
+	handleResponse(c, h.log, "", http.StatusOK, history)
+}
@@ -0,0 +1,30 @@
+package handler
+
+import (
+	"market/pkg/errs"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setETag sends the version of entity, clients send it back in If-Match header to update the entity.
+func setETag(c *gin.Context, version int) {
+	c.Header("ETag", strconv.Quote(strconv.Itoa(version)))
+}
+
+// ifMatch reads the version which update is based on, updates without it are rejected
+// so that nobody overwrites changes they have not seen.
+func ifMatch(c *gin.Context) (int, error) {
+	value := strings.TrimSpace(c.GetHeader("If-Match"))
+	if value == "" {
+		return 0, errs.PreconditionRequired("If-Match header with ETag of the entity is required")
+	}
+
+	version, err := strconv.Atoi(strings.Trim(strings.TrimPrefix(value, "W/"), `"`))
+	if err != nil || version <= 0 {
+		return 0, errs.Validation("If-Match header should be ETag of the entity")
+	}
+
+	return version, nil
+}
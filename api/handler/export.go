@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"fmt"
+	"market/api/models"
+	"market/pkg/export"
+	"market/pkg/logger"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// exportPageSize is how many rows are read from db at once while exporting a list.
+const exportPageSize = 500
+
+// exportFormat returns csv or xlsx when client asks for a file instead of json,
+// by ?format= query or by Accept: text/csv header.
+func exportFormat(c *gin.Context) string {
+	switch format := c.Query("format"); format {
+	case export.CSV, export.XLSX:
+		return format
+	}
+
+	if strings.Contains(c.GetHeader("Accept"), "text/csv") {
+		return export.CSV
+	}
+
+	return ""
+}
+
+// attachment returns the Content-Disposition header which downloads the file as filename.
+// Names of people can have any letters and quotes, so filename has the name in plain ascii
+// for old clients and filename* has it percent encoded in utf-8 as rfc 6266 says.
+func attachment(filename string) string {
+	plain := strings.Map(func(r rune) rune {
+		if r < 0x20 || r > 0x7e || r == '"' || r == '\\' || r == '/' || r == ';' {
+			return '_'
+		}
+		return r
+	}, filename)
+
+	encoded := strings.Builder{}
+	for _, b := range []byte(filename) {
+		if 'a' <= b && b <= 'z' || 'A' <= b && b <= 'Z' || '0' <= b && b <= '9' || strings.IndexByte("!#$&+-.^_`|~", b) >= 0 {
+			encoded.WriteByte(b)
+		} else {
+			fmt.Fprintf(&encoded, "%%%02X", b)
+		}
+	}
+
+	return fmt.Sprintf(`attachment; filename="%s"; filename*=UTF-8''%s`, plain, encoded.String())
+}
+
+// exportList streams every row which matches the list filters to the client. fetch is
+// called with the cursor of the last row it returned, starting with the zero cursor, until
+// it returns less rows than asked. Pages are read by keyset, so rows which are added while
+// the file is written do not shift pages into repeated or skipped rows.
+func (h Handler) exportList(c *gin.Context, format, name string, header []string, fetch func(after models.Cursor, limit int) ([][]string, models.Cursor, error)) {
+	filename := fmt.Sprintf("%s-%s.%s", name, time.Now().Format("2006-01-02"), format)
+
+	// the write timeout of the server is meant for usual responses, a file of every row can take longer
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		h.log.Warning("error is while clearing write deadline for export", logger.Error(err))
+	}
+
+	c.Header("Content-Type", export.ContentType(format))
+	c.Header("Content-Disposition", attachment(filename))
+
+	w, err := export.New(format, c.Writer, name)
+	if err != nil {
+		h.log.Error("error is while creating export writer", logger.Error(err))
+		c.AbortWithStatus(500)
+		return
+	}
+
+	if err = w.Write(header); err != nil {
+		h.log.Error("error is while writing export header", logger.Error(err))
+		return
+	}
+
+	after := models.Cursor{}
+	for {
+		rows, last, err := fetch(after, exportPageSize)
+		if err != nil {
+			// headers are already sent, so the only thing to do is to cut the file
+			h.log.Error("error is while fetching rows for export", logger.String("list", name), logger.Error(err))
+			return
+		}
+
+		for _, row := range rows {
+			if err = w.Write(row); err != nil {
+				h.log.Error("error is while writing export row", logger.Error(err))
+				return
+			}
+		}
+
+		c.Writer.Flush()
+
+		if len(rows) < exportPageSize {
+			break
+		}
+
+		after = last
+	}
+
+	if err = w.Close(); err != nil {
+		h.log.Error("error is while closing export writer", logger.Error(err))
+	}
+}
+
+func exportTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.Format("2006-01-02 15:04:05")
+}
+
+// textTime reads timestamps of models which keep them as text, like products, for export cursors.
+func textTime(value string) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return time.Parse("2006-01-02 15:04:05.999999", value)
+	}
+
+	return t, nil
+}
@@ -1,10 +1,14 @@
 package handler
 
 import (
+	"errors"
 	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
 	"market/api/models"
+	"market/pkg/errs"
 	"market/pkg/logger"
 	"market/service"
+	"net/http"
 )
 
 type Handler struct {
@@ -13,26 +17,68 @@ type Handler struct {
 }
 
 func New(services service.IServiceManager, log logger.ILogger) Handler {
+	registerValidations()
+
 	return Handler{
 		services: services,
 		log:      log,
 	}
 }
 
+// errorStatuses maps domain error codes to http status codes.
+var errorStatuses = map[errs.Code]int{
+	errs.CodeNotFound:             http.StatusNotFound,
+	errs.CodeConflict:             http.StatusConflict,
+	errs.CodeValidation:           http.StatusUnprocessableEntity,
+	errs.CodeInsufficientStock:    http.StatusConflict,
+	errs.CodeForbidden:            http.StatusForbidden,
+	errs.CodePreconditionRequired: http.StatusPreconditionRequired,
+}
+
+// handleResponse writes data in models.Response. If data is a domain error its code
+// decides the status code, binding validation errors are written as field errors
+// with 422, other errors are written with the given status code. Other data written
+// with 422, like the rows which failed an import, gets the validation code.
 func handleResponse(c *gin.Context, log logger.ILogger, msg string, statusCode int, data interface{}) {
 	resp := models.Response{}
 
+	if err, ok := data.(error); ok {
+		var validationErrors validator.ValidationErrors
+
+		switch code := errs.CodeOf(err); {
+		case errors.As(err, &validationErrors):
+			statusCode = http.StatusUnprocessableEntity
+			resp.Code = string(errs.CodeValidation)
+			data = fieldErrors(validationErrors)
+		case code != "":
+			statusCode = errorStatuses[code]
+			resp.Code = string(code)
+			data = err.Error()
+		default:
+			data = err.Error()
+		}
+	}
+
 	switch code := statusCode; {
 	case code < 400:
 		resp.Description = "OK"
 		log.Info("~~~~> OK", logger.String("msg", msg), logger.Any("status", code))
 	case code == 401:
 		resp.Description = "Unauthorized"
+		resp.Code = "unauthorized"
 	case code < 500:
-		resp.Description = "Bad Request"
+		resp.Description = http.StatusText(code)
+		switch {
+		case resp.Code != "":
+		case code == http.StatusUnprocessableEntity:
+			resp.Code = string(errs.CodeValidation)
+		default:
+			resp.Code = "bad_request"
+		}
 		log.Error("!!!!! BAD REQUEST", logger.String("msg", msg), logger.Any("status", code))
 	default:
 		resp.Description = "Internal Server Error"
+		resp.Code = "internal_error"
 		log.Error("!!!!! INTERNAL SERVER ERROR", logger.String("msg", msg), logger.Any("status", code))
 	}
 
@@ -40,4 +86,4 @@ func handleResponse(c *gin.Context, log logger.ILogger, msg string, statusCode i
 	resp.Data = data
 
 	c.JSON(resp.StatusCode, resp)
-}
\ No newline at end of file
+}
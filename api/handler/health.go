@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"market/pkg/logger"
+	"market/pkg/metrics"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Instrument counts requests and their latency by route for /metrics.
+func (h Handler) Instrument() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		c.Next()
+
+		// unknown paths share one route, so that scanners do not add a series per path
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		h.services.Metrics().ObserveRequest(c.Request.Method, route, c.Writer.Status(), time.Since(start))
+	}
+}
+
+// Healthz godoc
+// @Router       /healthz [GET]
+// @Summary      Liveness probe
+// @Description  answers 200 while the process is up
+// @Tags         health
+// @Produce      json
+// @Success      200  {object}  map[string]string
+func (h Handler) Healthz(c *gin.Context) {
+	// probes are answered without handleResponse, which would log every few seconds
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// Readyz godoc
+// @Router       /readyz [GET]
+// @Summary      Readiness probe
+// @Description  answers 200 when the database answers and its migrations are not dirty, 503 otherwise
+// @Tags         health
+// @Produce      json
+// @Success      200  {object}  map[string]string
+// @Failure      503  {object}  map[string]string
+func (h Handler) Readyz(c *gin.Context) {
+	if err := h.services.Health().Ready(c.Request.Context()); err != nil {
+		h.log.Warning("api is not ready", logger.Error(err))
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "not ready", "error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// GetMetrics godoc
+// @Router       /metrics [GET]
+// @Summary      Prometheus metrics
+// @Description  request counts and latency by route, connection pool stats and business counters
+// @Tags         health
+// @Produce      plain
+// @Success      200  {string}  string
+func (h Handler) GetMetrics(c *gin.Context) {
+	c.Header("Content-Type", metrics.ContentType)
+	c.Status(http.StatusOK)
+
+	if err := h.services.Metrics().Registry().Write(c.Writer); err != nil {
+		h.log.Error("error is while writing metrics", logger.Error(err))
+	}
+}
@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"market/api/models"
+	"market/pkg/logger"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// responseRecorder keeps a copy of the response body to store it with Idempotency-Key.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	r.body.Write(data)
+	return r.ResponseWriter.Write(data)
+}
+
+func (r *responseRecorder) WriteString(s string) (int, error) {
+	r.body.WriteString(s)
+	return r.ResponseWriter.WriteString(s)
+}
+
+// Idempotent makes create endpoints safe to retry. The first response of a request with
+// Idempotency-Key header is stored and sent again for retries with the same key and body,
+// with Idempotent-Replayed header. Requests without the header are handled as usual.
+// The key is released if the handler panics.
+func (h Handler) Idempotent() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader("Idempotency-Key")
+		if key == "" {
+			c.Next()
+			return
+		}
+
+		body, err := c.GetRawData()
+		if err != nil {
+			handleResponse(c, h.log, "error is while reading body", http.StatusBadRequest, err)
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		hash := sha256.Sum256(body)
+		request := models.IdempotentResponse{
+			Key:         key,
+			Endpoint:    c.Request.Method + " " + c.FullPath(),
+			RequestHash: hex.EncodeToString(hash[:]),
+		}
+
+		stored, replay, err := h.services.Idempotency().Begin(c.Request.Context(), request)
+		if err != nil {
+			handleResponse(c, h.log, "error is while checking Idempotency-Key", http.StatusInternalServerError, err)
+			c.Abort()
+			return
+		}
+
+		if replay {
+			contentType := stored.ContentType
+			if contentType == "" {
+				contentType = "application/json; charset=utf-8"
+			}
+
+			c.Header("Idempotent-Replayed", "true")
+			c.Data(stored.StatusCode, contentType, stored.Body)
+			c.Abort()
+			return
+		}
+
+		// the response is stored, or the key released, only while the request holds it,
+		// even if the client has gone by then
+		request = stored
+		ctx := context.WithoutCancel(c.Request.Context())
+
+		defer func() {
+			if r := recover(); r != nil {
+				if err := h.services.Idempotency().Release(ctx, request); err != nil {
+					h.log.Error("error is while releasing Idempotency-Key after panic", logger.Error(err))
+				}
+				panic(r)
+			}
+		}()
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+
+		c.Next()
+
+		request.StatusCode = recorder.Status()
+		request.ContentType = recorder.Header().Get("Content-Type")
+		request.Body = recorder.body.Bytes()
+
+		if err := h.services.Idempotency().Finish(ctx, request); err != nil {
+			h.log.Error("error is while saving response for Idempotency-Key", logger.Error(err))
+		}
+	}
+}
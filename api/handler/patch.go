@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"encoding/json"
+	"market/pkg/errs"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// bindPatch reads JSON merge patch body into obj, which is a pointer to struct with pointer fields.
+// Fields missing in the body stay nil and are not changed, null clears nullable text fields
+// and only the fields present in the body are validated.
+func bindPatch(c *gin.Context, obj interface{}) error {
+	body, err := c.GetRawData()
+	if err != nil {
+		return err
+	}
+
+	raw := map[string]json.RawMessage{}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return errs.Validation("body should be a json object")
+	}
+
+	if err := json.Unmarshal(body, obj); err != nil {
+		return errs.Validation("body has invalid field: %s", err.Error())
+	}
+
+	value := reflect.ValueOf(obj).Elem()
+	fields := map[string]int{}
+	for i := 0; i < value.NumField(); i++ {
+		name := strings.SplitN(value.Type().Field(i).Tag.Get("json"), ",", 2)[0]
+		if name != "" && name != "-" {
+			fields[name] = i
+		}
+	}
+
+	present := make([]string, 0, len(raw))
+	for key, data := range raw {
+		i, ok := fields[key]
+		if !ok {
+			return errs.Validation("%s can not be changed", key)
+		}
+
+		field := value.Field(i)
+		if string(data) == "null" {
+			if field.Type().Elem().Kind() != reflect.String {
+				return errs.Validation("%s can not be null", key)
+			}
+			field.Set(reflect.New(field.Type().Elem()))
+		}
+
+		present = append(present, value.Type().Field(i).Name)
+	}
+
+	if len(present) == 0 {
+		return nil
+	}
+
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return nil
+	}
+
+	return v.StructPartial(obj, present...)
+}
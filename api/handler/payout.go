@@ -0,0 +1,196 @@
+package handler
+
+import (
+	"encoding/csv"
+	"fmt"
+	"market/api/models"
+	"market/pkg/errs"
+	"market/pkg/logger"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// CreatePayout godoc
+// @Router       /payout [POST]
+// @Summary      Run a payroll payout
+// @Description  pay staff of the branch what they earned in the period, a period which overlaps an earlier payout of the branch is rejected
+// @Tags         payout
+// @Accept       json
+// @Produce      json
+// @Param 		 payout body models.CreatePayout false "payout"
+// @Success      200  {object}  models.Payout
+// @Failure      400  {object}  models.Response
+// @Failure      404  {object}  models.Response
+// @Failure      409  {object}  models.Response
+// @Failure      500  {object}  models.Response
+func (h Handler) CreatePayout(c *gin.Context) {
+	payout := models.CreatePayout{}
+	if err := c.ShouldBindJSON(&payout); err != nil {
+		handleResponse(c, h.log, "error is while reading body", http.StatusBadRequest, err)
+		return
+	}
+
+	resp, err := h.services.Payout().Run(c.Request.Context(), payout)
+	if err != nil {
+		handleResponse(c, h.log, "error is while running payout", http.StatusInternalServerError, err)
+		return
+	}
+
+	handleResponse(c, h.log, "", http.StatusCreated, resp)
+}
+
+// GetPayout godoc
+// @Router       /payout/{id} [GET]
+// @Summary      Get payout by id
+// @Description  get payout with items by id
+// @Tags         payout
+// @Accept       json
+// @Produce      json
+// @Param 		 id path string true "payout_id"
+// @Success      200  {object}  models.Payout
+// @Failure      400  {object}  models.Response
+// @Failure      404  {object}  models.Response
+// @Failure      500  {object}  models.Response
+func (h Handler) GetPayout(c *gin.Context) {
+	payout, err := h.services.Payout().Get(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		handleResponse(c, h.log, "error is while getting payout by id", http.StatusInternalServerError, err)
+		return
+	}
+
+	handleResponse(c, h.log, "", http.StatusOK, payout)
+}
+
+// GetPayoutList godoc
+// @Router       /payouts [GET]
+// @Summary      Get payout list
+// @Description  get payout list
+// @Tags         payout
+// @Accept       json
+// @Produce      json
+// @Param 		 page query string false "page"
+// @Param 		 limit query string false "limit"
+// @Param 		 branch_id query string false "branch_id"
+// @Param 		 format query string false "csv or xlsx to download all rows as a file"
+// @Success      200  {object}  models.PayoutsResponse
+// @Failure      400  {object}  models.Response
+// @Failure      404  {object}  models.Response
+// @Failure      500  {object}  models.Response
+func (h Handler) GetPayoutList(c *gin.Context) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil {
+		handleResponse(c, h.log, "error is while converting page", http.StatusBadRequest, err)
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil {
+		handleResponse(c, h.log, "error is while converting limit", http.StatusBadRequest, err)
+		return
+	}
+
+	if format := exportFormat(c); format != "" {
+		h.exportList(c, format, "payouts", []string{"id", "branch_id", "period_from", "period_to", "total", "created_at"},
+			func(after models.Cursor, limit int) ([][]string, models.Cursor, error) {
+				payouts, err := h.services.Payout().GetList(c.Request.Context(), models.GetListRequest{
+					After:  &after,
+					Limit:  limit,
+					Search: c.Query("branch_id"),
+				})
+				if err != nil {
+					return nil, after, err
+				}
+
+				rows := make([][]string, 0, len(payouts.Payouts))
+				for _, p := range payouts.Payouts {
+					rows = append(rows, []string{p.ID, p.BranchID, p.PeriodFrom, p.PeriodTo,
+						strconv.FormatFloat(p.Total, 'f', 2, 64), exportTime(p.CreatedAt)})
+					after = models.Cursor{CreatedAt: p.CreatedAt, ID: p.ID}
+				}
+
+				return rows, after, nil
+			})
+		return
+	}
+
+	payouts, err := h.services.Payout().GetList(c.Request.Context(), models.GetListRequest{
+		Page:   page,
+		Limit:  limit,
+		Search: c.Query("branch_id"),
+	})
+	if err != nil {
+		handleResponse(c, h.log, "error is while getting payout list", http.StatusInternalServerError, err)
+		return
+	}
+
+	handleResponse(c, h.log, "", http.StatusOK, payouts)
+}
+
+// GetPayrollStatement godoc
+// @Router       /payout/{id}/statement/{staff_id} [GET]
+// @Summary      Get payroll statement of staff
+// @Description  get payroll statement of staff as json or csv file
+// @Tags         payout
+// @Produce      json
+// @Produce      text/csv
+// @Param 		 id path string true "payout_id"
+// @Param 		 staff_id path string true "staff_id"
+// @Param 		 format query string false "json or csv"
+// @Success      200  {object}  models.PayrollStatement
+// @Failure      400  {object}  models.Response
+// @Failure      404  {object}  models.Response
+// @Failure      500  {object}  models.Response
+func (h Handler) GetPayrollStatement(c *gin.Context) {
+	payoutID, staffID := c.Param("id"), c.Param("staff_id")
+
+	// ids which are not uuids can not have a statement
+	for _, id := range []string{payoutID, staffID} {
+		if _, err := uuid.Parse(id); err != nil {
+			handleResponse(c, h.log, "error is while parsing uuid", http.StatusNotFound,
+				errs.NotFound("payroll statement of staff %s in payout %s not found", staffID, payoutID))
+			return
+		}
+	}
+
+	statement, err := h.services.Payout().Statement(c.Request.Context(), payoutID, staffID)
+	if err != nil {
+		handleResponse(c, h.log, "error is while getting payroll statement", http.StatusInternalServerError, err)
+		return
+	}
+
+	if c.DefaultQuery("format", "json") != "csv" {
+		handleResponse(c, h.log, "", http.StatusOK, statement)
+		return
+	}
+
+	c.Header("Content-Type", "text/csv")
+	c.Header("Content-Disposition", attachment(fmt.Sprintf("payroll-%s-%s.csv", statement.Item.StaffName, statement.Payout.PeriodTo)))
+
+	w := csv.NewWriter(c.Writer)
+	w.Write([]string{"staff", statement.Item.StaffName})
+	w.Write([]string{"period", statement.Payout.PeriodFrom, statement.Payout.PeriodTo})
+	w.Write([]string{})
+	w.Write([]string{"date", "type", "source", "amount", "description"})
+	for _, trans := range statement.Transactions {
+		w.Write([]string{
+			trans.CreatedAt.Format("2006-01-02 15:04"),
+			trans.TransactionType,
+			trans.SourceType,
+			strconv.FormatFloat(trans.Amount, 'f', 2, 64),
+			trans.Description,
+		})
+	}
+	w.Write([]string{})
+	w.Write([]string{"earned", strconv.FormatFloat(statement.Item.Earned, 'f', 2, 64)})
+	w.Write([]string{"balance before", strconv.Itoa(statement.Item.BalanceBefore)})
+	w.Write([]string{"paid", strconv.Itoa(statement.Item.Paid)})
+	w.Flush()
+
+	// the csv writer keeps its first error, headers are already sent so the file is only cut
+	if err = w.Error(); err != nil {
+		h.log.Error("error is while writing payroll statement", logger.Error(err))
+	}
+}
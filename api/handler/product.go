@@ -1,11 +1,14 @@
 package handler
 
 import (
-	"context"
+	"errors"
 	"github.com/gin-gonic/gin"
+	"io"
 	"net/http"
 	"strconv"
+	"strings"
 	"market/api/models"
+	"market/service"
 )
 
 // CreateProduct godoc
@@ -24,19 +27,13 @@ func (h Handler) CreateProduct(c *gin.Context) {
 	product := models.CreateProduct{}
 
 	if err := c.ShouldBindJSON(&product); err != nil {
-		handleResponse(c, h.log, "error is while reading body", http.StatusBadRequest, err.Error())
+		handleResponse(c, h.log, "error is while reading body", http.StatusBadRequest, err)
 		return
 	}
 
-	id, err := h.storage.Product().Create(context.Background(), product)
+	createdProduct, err := h.services.Product().Create(c.Request.Context(), product)
 	if err != nil {
-		handleResponse(c, h.log, "error is while creating product", http.StatusInternalServerError, err.Error())
-		return
-	}
-
-	createdProduct, err := h.storage.Product().GetByID(context.Background(), id)
-	if err != nil {
-		handleResponse(c, h.log, "error is while getting by id", http.StatusInternalServerError, err.Error())
+		handleResponse(c, h.log, "error is while creating product", http.StatusInternalServerError, err)
 		return
 	}
 
@@ -57,12 +54,14 @@ func (h Handler) CreateProduct(c *gin.Context) {
 // @Failure      500  {object}  models.Response
 func (h Handler) GetProduct(c *gin.Context) {
 	uid := c.Param("id")
-	product, err := h.storage.Product().GetByID(context.Background(), uid)
+	product, err := h.services.Product().Get(c.Request.Context(), uid)
 	if err != nil {
-		handleResponse(c, h.log, "error is while getting by id", http.StatusInternalServerError, err.Error())
+		handleResponse(c, h.log, "error is while getting by id", http.StatusInternalServerError, err)
 		return
 	}
 
+	setETag(c, product.Version)
+
 	handleResponse(c, h.log, "", http.StatusOK, product)
 }
 
@@ -77,6 +76,8 @@ func (h Handler) GetProduct(c *gin.Context) {
 // @Param 		 limit query string false "limit"
 // @Param 		 name query string false "name"
 // @Param 		 barcode query int false "barcode"
+// @Param 		 include_deleted query bool false "list soft deleted rows too"
+// @Param 		 format query string false "csv or xlsx to download all rows as a file"
 // @Success      200  {object}  models.ProductResponse
 // @Failure      400  {object}  models.Response
 // @Failure      404  {object}  models.Response
@@ -92,14 +93,14 @@ func (h Handler) GetProductList(c *gin.Context) {
 	pageStr := c.DefaultQuery("page", "1")
 	page, err = strconv.Atoi(pageStr)
 	if err != nil {
-		handleResponse(c, h.log, "error is while converting page", http.StatusBadRequest, err.Error())
+		handleResponse(c, h.log, "error is while converting page", http.StatusBadRequest, err)
 		return
 	}
 
 	limitStr := c.DefaultQuery("limit", "10")
 	limit, err = strconv.Atoi(limitStr)
 	if err != nil {
-		handleResponse(c, h.log, "error is while converting limit", http.StatusBadRequest, err.Error())
+		handleResponse(c, h.log, "error is while converting limit", http.StatusBadRequest, err)
 		return
 	}
 
@@ -107,18 +108,55 @@ func (h Handler) GetProductList(c *gin.Context) {
 
 	barcode, err = strconv.Atoi(c.DefaultQuery("barcode", "0"))
 	if err != nil {
-		handleResponse(c, h.log, "error is while converting barcode", http.StatusBadRequest, err.Error())
+		handleResponse(c, h.log, "error is while converting barcode", http.StatusBadRequest, err)
 		return
 	}
 
-	products, err := h.storage.Product().GetList(context.Background(), models.ProductGetListRequest{
-		Page:    page,
-		Limit:   limit,
-		Name:    name,
-		Barcode: barcode,
+	withDeleted, err := includeDeleted(c)
+	if err != nil {
+		handleResponse(c, h.log, "error is while converting include_deleted", http.StatusBadRequest, err)
+		return
+	}
+
+	if format := exportFormat(c); format != "" {
+		h.exportList(c, format, "products", []string{"id", "name", "price", "barcode", "category_id", "created_at", "updated_at"},
+			func(after models.Cursor, limit int) ([][]string, models.Cursor, error) {
+				products, err := h.services.Product().GetList(c.Request.Context(), models.ProductGetListRequest{
+					After:          &after,
+					Limit:          limit,
+					Name:           name,
+					Barcode:        barcode,
+					IncludeDeleted: withDeleted,
+				})
+				if err != nil {
+					return nil, after, err
+				}
+
+				rows := make([][]string, 0, len(products.Products))
+				for _, p := range products.Products {
+					rows = append(rows, []string{p.ID, p.Name, strconv.Itoa(p.Price), strconv.Itoa(p.Barcode), p.CategoryID, p.CreatedAt, p.UpdatedAt})
+
+					createdAt, err := textTime(p.CreatedAt)
+					if err != nil {
+						return nil, after, err
+					}
+					after = models.Cursor{CreatedAt: createdAt, ID: p.ID}
+				}
+
+				return rows, after, nil
+			})
+		return
+	}
+
+	products, err := h.services.Product().GetList(c.Request.Context(), models.ProductGetListRequest{
+		Page:           page,
+		Limit:          limit,
+		Name:           name,
+		Barcode:        barcode,
+		IncludeDeleted: withDeleted,
 	})
 	if err != nil {
-		handleResponse(c, h.log, "error is while getting list", http.StatusInternalServerError, err.Error())
+		handleResponse(c, h.log, "error is while getting list", http.StatusInternalServerError, err)
 		return
 	}
 
@@ -134,6 +172,7 @@ func (h Handler) GetProductList(c *gin.Context) {
 // @Produce      json
 // @Param 		 id path string true "product_id"
 // @Param 		 product body models.UpdateProduct false "product"
+// @Param 		 If-Match header string true "ETag of the entity from GET"
 // @Success      200  {object}  models.Product
 // @Failure      400  {object}  models.Response
 // @Failure      404  {object}  models.Response
@@ -143,26 +182,71 @@ func (h Handler) UpdateProduct(c *gin.Context) {
 	product := models.UpdateProduct{}
 
 	if err := c.ShouldBindJSON(&product); err != nil {
-		handleResponse(c, h.log, "error is while reading body", http.StatusBadRequest, err.Error())
+		handleResponse(c, h.log, "error is while reading body", http.StatusBadRequest, err)
 		return
 	}
 
 	product.ID = uid
-	id, err := h.storage.Product().Update(context.Background(), product)
+
+	version, err := ifMatch(c)
 	if err != nil {
-		handleResponse(c, h.log, "error is while updating", http.StatusInternalServerError, err.Error())
+		handleResponse(c, h.log, "error is while reading If-Match header", http.StatusPreconditionRequired, err)
 		return
 	}
+	product.Version = version
 
-	updatedProduct, err := h.storage.Product().GetByID(context.Background(), id)
+	updatedProduct, err := h.services.Product().Update(c.Request.Context(), product)
 	if err != nil {
-		handleResponse(c, h.log, "error is while getting by id", http.StatusInternalServerError, err.Error())
+		handleResponse(c, h.log, "error is while updating", http.StatusInternalServerError, err)
 		return
 	}
 
+	setETag(c, updatedProduct.Version)
+
 	handleResponse(c, h.log, "", http.StatusOK, updatedProduct)
 }
 
+// PatchProduct godoc
+// @Router       /product/{id} [PATCH]
+// @Summary      Patch product
+// @Description  change only the given fields of product, null clears a nullable field
+// @Tags         product
+// @Accept       json
+// @Produce      json
+// @Param 		 id path string true "product_id"
+// @Param 		 product body models.PatchProduct false "product"
+// @Param 		 If-Match header string true "ETag of the entity from GET"
+// @Success      200  {object}  models.Product
+// @Failure      400  {object}  models.Response
+// @Failure      404  {object}  models.Response
+// @Failure      500  {object}  models.Response
+func (h Handler) PatchProduct(c *gin.Context) {
+	product := models.PatchProduct{}
+	if err := bindPatch(c, &product); err != nil {
+		handleResponse(c, h.log, "error is while reading body", http.StatusBadRequest, err)
+		return
+	}
+
+	product.ID = c.Param("id")
+
+	version, err := ifMatch(c)
+	if err != nil {
+		handleResponse(c, h.log, "error is while reading If-Match header", http.StatusPreconditionRequired, err)
+		return
+	}
+	product.Version = version
+
+	resp, err := h.services.Product().Patch(c.Request.Context(), product)
+	if err != nil {
+		handleResponse(c, h.log, "error is while patching product", http.StatusInternalServerError, err)
+		return
+	}
+
+	setETag(c, resp.Version)
+
+	handleResponse(c, h.log, "", http.StatusOK, resp)
+}
+
 // DeleteProduct godoc
 // @Router       /product/{id} [DELETE]
 // @Summary      Delete product
@@ -174,13 +258,84 @@ func (h Handler) UpdateProduct(c *gin.Context) {
 // @Success      200  {object}  models.Product
 // @Failure      400  {object}  models.Response
 // @Failure      404  {object}  models.Response
+// @Failure      409  {object}  models.Response
 // @Failure      500  {object}  models.Response
 func (h Handler) DeleteProduct(c *gin.Context) {
 	uid := c.Param("id")
-	if err := h.storage.Product().Delete(context.Background(), uid); err != nil {
-		handleResponse(c, h.log, "error is while deleting", http.StatusInternalServerError, err.Error())
+	if err := h.services.Product().Delete(c.Request.Context(), uid); err != nil {
+		handleResponse(c, h.log, "error is while deleting", http.StatusInternalServerError, err)
 		return
 	}
 
 	handleResponse(c, h.log, "", http.StatusOK, "product deleted!")
 }
+
+// RestoreProduct godoc
+// @Router       /product/{id}/restore [POST]
+// @Summary      Restore product
+// @Description  bring back soft deleted product
+// @Tags         product
+// @Accept       json
+// @Produce      json
+// @Param 		 id path string true "product_id"
+// @Success      200  {object}  models.Product
+// @Failure      400  {object}  models.Response
+// @Failure      404  {object}  models.Response
+// @Failure      500  {object}  models.Response
+func (h Handler) RestoreProduct(c *gin.Context) {
+	product, err := h.services.Product().Restore(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		handleResponse(c, h.log, "error is while restoring product", http.StatusInternalServerError, err)
+		return
+	}
+
+	setETag(c, product.Version)
+
+	handleResponse(c, h.log, "", http.StatusOK, product)
+}
+
+// ImportProducts godoc
+// @Router       /products/import [POST]
+// @Summary      Import products from csv
+// @Description  create products with initial stock from csv, columns: name, price, barcode, category and one column per branch
+// @Tags         product
+// @Accept       multipart/form-data
+// @Accept       text/csv
+// @Produce      json
+// @Param 		 file formData file false "csv file"
+// @Success      201  {object}  models.ProductImportResult
+// @Failure      400  {object}  models.Response
+// @Failure      422  {object}  models.Response{data=models.ProductImportResult}
+// @Failure      500  {object}  models.Response
+func (h Handler) ImportProducts(c *gin.Context) {
+	var file io.Reader = c.Request.Body
+
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		fileHeader, err := c.FormFile("file")
+		if err != nil {
+			handleResponse(c, h.log, "error is while reading file", http.StatusBadRequest, err)
+			return
+		}
+
+		f, err := fileHeader.Open()
+		if err != nil {
+			handleResponse(c, h.log, "error is while opening file", http.StatusBadRequest, err)
+			return
+		}
+		defer f.Close()
+
+		file = f
+	}
+
+	result, err := h.services.Product().Import(c.Request.Context(), file)
+	if errors.Is(err, service.ErrInvalidImport) {
+		handleResponse(c, h.log, "products are not imported", http.StatusUnprocessableEntity, result)
+		return
+	}
+	if err != nil {
+		handleResponse(c, h.log, "error is while importing products", http.StatusInternalServerError, err)
+		return
+	}
+
+	handleResponse(c, h.log, "", http.StatusCreated, result)
+}
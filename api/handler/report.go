@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"market/api/models"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetRevenueReport godoc
+// @Router       /reports/revenue [GET]
+// @Summary      Get revenue report
+// @Description  get revenue of branches per day, week or month
+// @Tags         report
+// @Accept       json
+// @Produce      json
+// @Param 		 branch_id query string false "branch_id"
+// @Param 		 from query string false "from date, 2006-01-02"
+// @Param 		 to query string false "to date, 2006-01-02"
+// @Param 		 group query string false "day, week or month"
+// @Success      200  {object}  []models.RevenueReport
+// @Failure      400  {object}  models.Response
+// @Failure      404  {object}  models.Response
+// @Failure      500  {object}  models.Response
+func (h Handler) GetRevenueReport(c *gin.Context) {
+	reports, err := h.services.Report().Revenue(c.Request.Context(), reportRequest(c))
+	if err != nil {
+		handleResponse(c, h.log, "error is while getting revenue report", http.StatusInternalServerError, err)
+		return
+	}
+
+	handleResponse(c, h.log, "", http.StatusOK, reports)
+}
+
+// GetTopProductsReport godoc
+// @Router       /reports/top-products [GET]
+// @Summary      Get top selling products
+// @Description  get top selling products by quantity or revenue
+// @Tags         report
+// @Accept       json
+// @Produce      json
+// @Param 		 branch_id query string false "branch_id"
+// @Param 		 from query string false "from date, 2006-01-02"
+// @Param 		 to query string false "to date, 2006-01-02"
+// @Param 		 order_by query string false "quantity or revenue"
+// @Param 		 limit query string false "limit"
+// @Success      200  {object}  []models.ProductReport
+// @Failure      400  {object}  models.Response
+// @Failure      404  {object}  models.Response
+// @Failure      500  {object}  models.Response
+func (h Handler) GetTopProductsReport(c *gin.Context) {
+	request := reportRequest(c)
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil {
+		handleResponse(c, h.log, "error is while converting limit", http.StatusBadRequest, err)
+		return
+	}
+	request.Limit = limit
+
+	reports, err := h.services.Report().TopProducts(c.Request.Context(), request)
+	if err != nil {
+		handleResponse(c, h.log, "error is while getting top products report", http.StatusInternalServerError, err)
+		return
+	}
+
+	handleResponse(c, h.log, "", http.StatusOK, reports)
+}
+
+// GetStaffSalesReport godoc
+// @Router       /reports/staff [GET]
+// @Summary      Get sales per staff
+// @Description  get sales count and totals per cashier and shop assistant
+// @Tags         report
+// @Accept       json
+// @Produce      json
+// @Param 		 branch_id query string false "branch_id"
+// @Param 		 from query string false "from date, 2006-01-02"
+// @Param 		 to query string false "to date, 2006-01-02"
+// @Success      200  {object}  []models.StaffSalesReport
+// @Failure      400  {object}  models.Response
+// @Failure      404  {object}  models.Response
+// @Failure      500  {object}  models.Response
+func (h Handler) GetStaffSalesReport(c *gin.Context) {
+	reports, err := h.services.Report().StaffSales(c.Request.Context(), reportRequest(c))
+	if err != nil {
+		handleResponse(c, h.log, "error is while getting staff sales report", http.StatusInternalServerError, err)
+		return
+	}
+
+	handleResponse(c, h.log, "", http.StatusOK, reports)
+}
+
+// GetPaymentTypesReport godoc
+// @Router       /reports/payment-types [GET]
+// @Summary      Get card and cash split
+// @Description  get sales count and totals per payment type
+// @Tags         report
+// @Accept       json
+// @Produce      json
+// @Param 		 branch_id query string false "branch_id"
+// @Param 		 from query string false "from date, 2006-01-02"
+// @Param 		 to query string false "to date, 2006-01-02"
+// @Success      200  {object}  []models.PaymentTypeReport
+// @Failure      400  {object}  models.Response
+// @Failure      404  {object}  models.Response
+// @Failure      500  {object}  models.Response
+func (h Handler) GetPaymentTypesReport(c *gin.Context) {
+	reports, err := h.services.Report().PaymentTypes(c.Request.Context(), reportRequest(c))
+	if err != nil {
+		handleResponse(c, h.log, "error is while getting payment types report", http.StatusInternalServerError, err)
+		return
+	}
+
+	handleResponse(c, h.log, "", http.StatusOK, reports)
+}
+
+func reportRequest(c *gin.Context) models.ReportRequest {
+	return models.ReportRequest{
+		BranchID: c.Query("branch_id"),
+		From:     c.Query("from"),
+		To:       c.Query("to"),
+		Group:    c.Query("group"),
+		OrderBy:  c.Query("order_by"),
+	}
+}
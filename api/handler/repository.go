@@ -1,7 +1,6 @@
 package handler
 
 import (
-	"context"
 	"net/http"
 	"strconv"
 	"market/api/models"
@@ -25,21 +24,13 @@ func (h Handler) CreateRepository(c *gin.Context) {
 	repository := models.CreateRepository{}
 
 	if err := c.ShouldBindJSON(&repository); err != nil {
-		handleResponse(c, h.log, "error while reading body", http.StatusBadRequest, err.Error())
+		handleResponse(c, h.log, "error while reading body", http.StatusBadRequest, err)
 		return
 	}
 
-	id, err := h.storage.Repository().Create(context.Background(), repository)
+	createdRepository, err := h.services.Repository().Create(c.Request.Context(), repository)
 	if err != nil {
-		handleResponse(c, h.log, "error while creating repository", http.StatusInternalServerError, err.Error())
-		return
-	}
-
-	createdRepository, err := h.storage.Repository().GetByID(context.Background(), models.PrimaryKey{
-		ID: id,
-	})
-	if err != nil {
-		handleResponse(c, h.log, "error while getting by ID", http.StatusInternalServerError, err.Error())
+		handleResponse(c, h.log, "error while creating repository", http.StatusInternalServerError, err)
 		return
 	}
 
@@ -61,12 +52,14 @@ func (h Handler) CreateRepository(c *gin.Context) {
 func (h Handler) GetRepository(c *gin.Context) {
 	uid := c.Param("id")
 
-	repository, err := h.storage.Repository().GetByID(context.Background(), models.PrimaryKey{ID: uid})
+	repository, err := h.services.Repository().Get(c.Request.Context(), uid)
 	if err != nil {
-		handleResponse(c, h.log, "error while getting repository by ID", http.StatusInternalServerError, err.Error())
+		handleResponse(c, h.log, "error while getting repository by ID", http.StatusInternalServerError, err)
 		return
 	}
 
+	setETag(c, repository.Version)
+
 	handleResponse(c, h.log, "", http.StatusOK, repository)
 }
 
@@ -80,6 +73,8 @@ func (h Handler) GetRepository(c *gin.Context) {
 // @Param 		 page query string false "page"
 // @Param 		 limit query string false "limit"
 // @Param 		 search query string false "search"
+// @Param 		 include_deleted query bool false "list soft deleted rows too"
+// @Param 		 format query string false "csv or xlsx to download all rows as a file"
 // @Success      200  {object}  models.RepositoriesResponse
 // @Failure      400  {object}  models.Response
 // @Failure      404  {object}  models.Response
@@ -93,26 +88,57 @@ func (h Handler) GetRepositoryList(c *gin.Context) {
 	pageStr := c.DefaultQuery("page", "1")
 	page, err = strconv.Atoi(pageStr)
 	if err != nil {
-		handleResponse(c, h.log, "error while converting page", http.StatusBadRequest, err.Error())
+		handleResponse(c, h.log, "error while converting page", http.StatusBadRequest, err)
 		return
 	}
 
 	limitStr := c.DefaultQuery("limit", "10")
 	limit, err = strconv.Atoi(limitStr)
 	if err != nil {
-		handleResponse(c, h.log, "error while converting limit", http.StatusBadRequest, err.Error())
+		handleResponse(c, h.log, "error while converting limit", http.StatusBadRequest, err)
 		return
 	}
 
 	search := c.Query("search")
 
-	response, err := h.storage.Repository().GetList(context.Background(), models.GetListRequest{
-		Page:   page,
-		Limit:  limit,
-		Search: search,
+	withDeleted, err := includeDeleted(c)
+	if err != nil {
+		handleResponse(c, h.log, "error is while converting include_deleted", http.StatusBadRequest, err)
+		return
+	}
+
+	if format := exportFormat(c); format != "" {
+		h.exportList(c, format, "repositories", []string{"id", "product_id", "branch_id", "count", "created_at", "updated_at"},
+			func(after models.Cursor, limit int) ([][]string, models.Cursor, error) {
+				response, err := h.services.Repository().GetList(c.Request.Context(), models.GetListRequest{
+					After:          &after,
+					Limit:          limit,
+					Search:         search,
+					IncludeDeleted: withDeleted,
+				})
+				if err != nil {
+					return nil, after, err
+				}
+
+				rows := make([][]string, 0, len(response.Repositories))
+				for _, r := range response.Repositories {
+					rows = append(rows, []string{r.ID, r.ProductID, r.BranchID, strconv.Itoa(r.Count), exportTime(r.CreatedAt), exportTime(r.UpdatedAt)})
+					after = models.Cursor{CreatedAt: r.CreatedAt, ID: r.ID}
+				}
+
+				return rows, after, nil
+			})
+		return
+	}
+
+	response, err := h.services.Repository().GetList(c.Request.Context(), models.GetListRequest{
+		Page:           page,
+		Limit:          limit,
+		Search:         search,
+		IncludeDeleted: withDeleted,
 	})
 	if err != nil {
-		handleResponse(c, h.log, "error while getting repository list", http.StatusInternalServerError, err.Error())
+		handleResponse(c, h.log, "error while getting repository list", http.StatusInternalServerError, err)
 		return
 	}
 
@@ -128,6 +154,7 @@ func (h Handler) GetRepositoryList(c *gin.Context) {
 // @Produce      json
 // @Param 		 id path string true "repository_id"
 // @Param 		 repository body models.UpdateRepository false "repository"
+// @Param 		 If-Match header string true "ETag of the entity from GET"
 // @Success      200  {object}  models.Repository
 // @Failure      400  {object}  models.Response
 // @Failure      404  {object}  models.Response
@@ -137,25 +164,71 @@ func (h Handler) UpdateRepository(c *gin.Context) {
 
 	repository := models.UpdateRepository{}
 	if err := c.ShouldBindJSON(&repository); err != nil {
-		handleResponse(c, h.log, "error while reading from body", http.StatusBadRequest, err.Error())
+		handleResponse(c, h.log, "error while reading from body", http.StatusBadRequest, err)
 		return
 	}
 
 	repository.ID = uid
-	if _, err := h.storage.Repository().Update(context.Background(), repository); err != nil {
-		handleResponse(c, h.log, "error while updating repository ", http.StatusInternalServerError, err.Error())
+
+	version, err := ifMatch(c)
+	if err != nil {
+		handleResponse(c, h.log, "error is while reading If-Match header", http.StatusPreconditionRequired, err)
 		return
 	}
+	repository.Version = version
 
-	updatedRepository, err := h.storage.Repository().GetByID(context.Background(), models.PrimaryKey{ID: uid})
+	updatedRepository, err := h.services.Repository().Update(c.Request.Context(), repository)
 	if err != nil {
-		handleResponse(c, h.log, "error while getting by ID", http.StatusInternalServerError, err.Error())
+		handleResponse(c, h.log, "error while updating repository ", http.StatusInternalServerError, err)
 		return
 	}
 
+	setETag(c, updatedRepository.Version)
+
 	handleResponse(c, h.log, "", http.StatusOK, updatedRepository)
 }
 
+// PatchRepository godoc
+// @Router       /repository/{id} [PATCH]
+// @Summary      Patch repository
+// @Description  change only the given fields of repository, null clears a nullable field
+// @Tags         repository
+// @Accept       json
+// @Produce      json
+// @Param 		 id path string true "repository_id"
+// @Param 		 repository body models.PatchRepository false "repository"
+// @Param 		 If-Match header string true "ETag of the entity from GET"
+// @Success      200  {object}  models.Repository
+// @Failure      400  {object}  models.Response
+// @Failure      404  {object}  models.Response
+// @Failure      500  {object}  models.Response
+func (h Handler) PatchRepository(c *gin.Context) {
+	repository := models.PatchRepository{}
+	if err := bindPatch(c, &repository); err != nil {
+		handleResponse(c, h.log, "error is while reading body", http.StatusBadRequest, err)
+		return
+	}
+
+	repository.ID = c.Param("id")
+
+	version, err := ifMatch(c)
+	if err != nil {
+		handleResponse(c, h.log, "error is while reading If-Match header", http.StatusPreconditionRequired, err)
+		return
+	}
+	repository.Version = version
+
+	resp, err := h.services.Repository().Patch(c.Request.Context(), repository)
+	if err != nil {
+		handleResponse(c, h.log, "error is while patching repository", http.StatusInternalServerError, err)
+		return
+	}
+
+	setETag(c, resp.Version)
+
+	handleResponse(c, h.log, "", http.StatusOK, resp)
+}
+
 // DeleteRepository godoc
 // @Router       /repository/{id} [DELETE]
 // @Summary      Delete repository
@@ -171,10 +244,34 @@ func (h Handler) UpdateRepository(c *gin.Context) {
 func (h Handler) DeleteRepository(c *gin.Context) {
 	uid := c.Param("id")
 
-	if err := h.storage.Repository().Delete(context.Background(), uid); err != nil {
-		handleResponse(c, h.log, "error while deleting repository ", http.StatusInternalServerError, err.Error())
+	if err := h.services.Repository().Delete(c.Request.Context(), uid); err != nil {
+		handleResponse(c, h.log, "error while deleting repository ", http.StatusInternalServerError, err)
 		return
 	}
 
 	handleResponse(c, h.log, "", http.StatusOK, "repository deleted")
+}
+
+// RestoreRepository godoc
+// @Router       /repository/{id}/restore [POST]
+// @Summary      Restore repository
+// @Description  bring back soft deleted repository
+// @Tags         repository
+// @Accept       json
+// @Produce      json
+// @Param 		 id path string true "repository_id"
+// @Success      200  {object}  models.Repository
+// @Failure      400  {object}  models.Response
+// @Failure      404  {object}  models.Response
+// @Failure      500  {object}  models.Response
+func (h Handler) RestoreRepository(c *gin.Context) {
+	repository, err := h.services.Repository().Restore(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		handleResponse(c, h.log, "error is while restoring repository", http.StatusInternalServerError, err)
+		return
+	}
+
+	setETag(c, repository.Version)
+
+	handleResponse(c, h.log, "", http.StatusOK, repository)
 }
\ No newline at end of file
@@ -1,7 +1,6 @@
 package handler
 
 import (
-	"context"
 	"net/http"
 	"strconv"
 	"market/api/models"
@@ -25,21 +24,13 @@ func (h Handler) CreateRepositoryTransaction(c *gin.Context) {
 	rtransaction := models.CreateRepositoryTransaction{}
 
 	if err := c.ShouldBindJSON(&rtransaction); err != nil {
-		handleResponse(c, h.log, "error while reading body", http.StatusBadRequest, err.Error())
+		handleResponse(c, h.log, "error while reading body", http.StatusBadRequest, err)
 		return
 	}
 
-	id, err := h.storage.RTransaction().Create(context.Background(), rtransaction)
+	createdRTransaction, err := h.services.RepositoryTransaction().Create(c.Request.Context(), rtransaction)
 	if err != nil {
-		handleResponse(c, h.log, "error while creating repository transaction", http.StatusInternalServerError, err.Error())
-		return
-	}
-
-	createdRTransaction, err := h.storage.RTransaction().GetByID(context.Background(), models.PrimaryKey{
-		ID: id,
-	})
-	if err != nil {
-		handleResponse(c, h.log, "error while getting by ID", http.StatusInternalServerError, err.Error())
+		handleResponse(c, h.log, "error while creating repository transaction", http.StatusInternalServerError, err)
 		return
 	}
 
@@ -61,12 +52,14 @@ func (h Handler) CreateRepositoryTransaction(c *gin.Context) {
 func (h Handler) GetRepositoryTransaction(c *gin.Context) {
 	uid := c.Param("id")
 
-	repository, err := h.storage.RTransaction().GetByID(context.Background(), models.PrimaryKey{ID: uid})
+	repository, err := h.services.RepositoryTransaction().Get(c.Request.Context(), uid)
 	if err != nil {
-		handleResponse(c, h.log, "error while getting repository transaction by ID", http.StatusInternalServerError, err.Error())
+		handleResponse(c, h.log, "error while getting repository transaction by ID", http.StatusInternalServerError, err)
 		return
 	}
 
+	setETag(c, repository.Version)
+
 	handleResponse(c, h.log, "", http.StatusOK, repository)
 }
 
@@ -80,6 +73,8 @@ func (h Handler) GetRepositoryTransaction(c *gin.Context) {
 // @Param 		 page query string false "page"
 // @Param 		 limit query string false "limit"
 // @Param 		 search query string false "search"
+// @Param 		 include_deleted query bool false "list soft deleted rows too"
+// @Param 		 format query string false "csv or xlsx to download all rows as a file"
 // @Success      200  {object}  models.RepositoryTransactionsResponse
 // @Failure      400  {object}  models.Response
 // @Failure      404  {object}  models.Response
@@ -93,26 +88,59 @@ func (h Handler) GetRepositoryTransactionList(c *gin.Context) {
 	pageStr := c.DefaultQuery("page", "1")
 	page, err = strconv.Atoi(pageStr)
 	if err != nil {
-		handleResponse(c, h.log, "error while converting page", http.StatusBadRequest, err.Error())
+		handleResponse(c, h.log, "error while converting page", http.StatusBadRequest, err)
 		return
 	}
 
 	limitStr := c.DefaultQuery("limit", "10")
 	limit, err = strconv.Atoi(limitStr)
 	if err != nil {
-		handleResponse(c, h.log, "error while converting limit", http.StatusBadRequest, err.Error())
+		handleResponse(c, h.log, "error while converting limit", http.StatusBadRequest, err)
 		return
 	}
 
 	search := c.Query("search")
 
-	response, err := h.storage.RTransaction().GetList(context.Background(), models.GetListRequest{
-		Page:   page,
-		Limit:  limit,
-		Search: search,
+	withDeleted, err := includeDeleted(c)
+	if err != nil {
+		handleResponse(c, h.log, "error is while converting include_deleted", http.StatusBadRequest, err)
+		return
+	}
+
+	if format := exportFormat(c); format != "" {
+		h.exportList(c, format, "repository_transactions",
+			[]string{"id", "staff_id", "product_id", "repository_transaction_type", "price", "quantity", "created_at", "updated_at"},
+			func(after models.Cursor, limit int) ([][]string, models.Cursor, error) {
+				response, err := h.services.RepositoryTransaction().GetList(c.Request.Context(), models.GetListRequest{
+					After:          &after,
+					Limit:          limit,
+					Search:         search,
+					IncludeDeleted: withDeleted,
+				})
+				if err != nil {
+					return nil, after, err
+				}
+
+				rows := make([][]string, 0, len(response.RepositoryTransactions))
+				for _, r := range response.RepositoryTransactions {
+					rows = append(rows, []string{r.ID, r.StaffID, r.ProductID, r.RepositoryTransactionType,
+						strconv.Itoa(r.Price), strconv.Itoa(r.Quantity), exportTime(r.CreatedAt), exportTime(r.UpdatedAt)})
+					after = models.Cursor{CreatedAt: r.CreatedAt, ID: r.ID}
+				}
+
+				return rows, after, nil
+			})
+		return
+	}
+
+	response, err := h.services.RepositoryTransaction().GetList(c.Request.Context(), models.GetListRequest{
+		Page:           page,
+		Limit:          limit,
+		Search:         search,
+		IncludeDeleted: withDeleted,
 	})
 	if err != nil {
-		handleResponse(c, h.log, "error while getting repository list", http.StatusInternalServerError, err.Error())
+		handleResponse(c, h.log, "error while getting repository list", http.StatusInternalServerError, err)
 		return
 	}
 
@@ -128,6 +156,7 @@ func (h Handler) GetRepositoryTransactionList(c *gin.Context) {
 // @Produce      json
 // @Param 		 id path string true "rtransaction_id"
 // @Param 		 rtransaction body models.UpdateRepositoryTransaction false "rtransaction"
+// @Param 		 If-Match header string true "ETag of the entity from GET"
 // @Success      200  {object}  models.Repository
 // @Failure      400  {object}  models.Response
 // @Failure      404  {object}  models.Response
@@ -137,25 +166,71 @@ func (h Handler) UpdateRepositoryTransaction(c *gin.Context) {
 
 	rTransaction := models.UpdateRepositoryTransaction{}
 	if err := c.ShouldBindJSON(&rTransaction); err != nil {
-		handleResponse(c, h.log, "error while reading from body", http.StatusBadRequest, err.Error())
+		handleResponse(c, h.log, "error while reading from body", http.StatusBadRequest, err)
 		return
 	}
 
 	rTransaction.ID = uid
-	if _, err := h.storage.RTransaction().Update(context.Background(), rTransaction); err != nil {
-		handleResponse(c, h.log, "error while updating repository transaction ", http.StatusInternalServerError, err.Error())
+
+	version, err := ifMatch(c)
+	if err != nil {
+		handleResponse(c, h.log, "error is while reading If-Match header", http.StatusPreconditionRequired, err)
 		return
 	}
+	rTransaction.Version = version
 
-	updatedRTransaction, err := h.storage.RTransaction().GetByID(context.Background(), models.PrimaryKey{ID: uid})
+	updatedRTransaction, err := h.services.RepositoryTransaction().Update(c.Request.Context(), rTransaction)
 	if err != nil {
-		handleResponse(c, h.log, "error while getting by ID", http.StatusInternalServerError, err.Error())
+		handleResponse(c, h.log, "error while updating repository transaction ", http.StatusInternalServerError, err)
 		return
 	}
 
+	setETag(c, updatedRTransaction.Version)
+
 	handleResponse(c, h.log, "", http.StatusOK, updatedRTransaction)
 }
 
+// PatchRepositoryTransaction godoc
+// @Router       /rtransaction/{id} [PATCH]
+// @Summary      Patch repository transaction
+// @Description  change only the given fields of repository transaction, null clears a nullable field
+// @Tags         rtransaction
+// @Accept       json
+// @Produce      json
+// @Param 		 id path string true "repository_transaction_id"
+// @Param 		 rtransaction body models.PatchRepositoryTransaction false "rtransaction"
+// @Param 		 If-Match header string true "ETag of the entity from GET"
+// @Success      200  {object}  models.RepositoryTransaction
+// @Failure      400  {object}  models.Response
+// @Failure      404  {object}  models.Response
+// @Failure      500  {object}  models.Response
+func (h Handler) PatchRepositoryTransaction(c *gin.Context) {
+	rtransaction := models.PatchRepositoryTransaction{}
+	if err := bindPatch(c, &rtransaction); err != nil {
+		handleResponse(c, h.log, "error is while reading body", http.StatusBadRequest, err)
+		return
+	}
+
+	rtransaction.ID = c.Param("id")
+
+	version, err := ifMatch(c)
+	if err != nil {
+		handleResponse(c, h.log, "error is while reading If-Match header", http.StatusPreconditionRequired, err)
+		return
+	}
+	rtransaction.Version = version
+
+	resp, err := h.services.RepositoryTransaction().Patch(c.Request.Context(), rtransaction)
+	if err != nil {
+		handleResponse(c, h.log, "error is while patching repository transaction", http.StatusInternalServerError, err)
+		return
+	}
+
+	setETag(c, resp.Version)
+
+	handleResponse(c, h.log, "", http.StatusOK, resp)
+}
+
 // DeleteRepositoryTransaction godoc
 // @Router       /rtransaction/{id} [DELETE]
 // @Summary      Delete rtransaction
@@ -171,10 +246,34 @@ func (h Handler) UpdateRepositoryTransaction(c *gin.Context) {
 func (h Handler) DeleteRepositoryTransaction(c *gin.Context) {
 	uid := c.Param("id")
 
-	if err := h.storage.RTransaction().Delete(context.Background(), uid); err != nil {
-		handleResponse(c, h.log, "error while deleting repository transaction ", http.StatusInternalServerError, err.Error())
+	if err := h.services.RepositoryTransaction().Delete(c.Request.Context(), uid); err != nil {
+		handleResponse(c, h.log, "error while deleting repository transaction ", http.StatusInternalServerError, err)
 		return
 	}
 
 	handleResponse(c, h.log, "", http.StatusOK, "repository transaction deleted")
+}
+
+// RestoreRepositoryTransaction godoc
+// @Router       /rtransaction/{id}/restore [POST]
+// @Summary      Restore repository transaction
+// @Description  bring back soft deleted repository transaction
+// @Tags         rtransaction
+// @Accept       json
+// @Produce      json
+// @Param 		 id path string true "rtransaction_id"
+// @Success      200  {object}  models.RepositoryTransaction
+// @Failure      400  {object}  models.Response
+// @Failure      404  {object}  models.Response
+// @Failure      500  {object}  models.Response
+func (h Handler) RestoreRepositoryTransaction(c *gin.Context) {
+	repositoryTransaction, err := h.services.RepositoryTransaction().Restore(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		handleResponse(c, h.log, "error is while restoring repository transaction", http.StatusInternalServerError, err)
+		return
+	}
+
+	setETag(c, repositoryTransaction.Version)
+
+	handleResponse(c, h.log, "", http.StatusOK, repositoryTransaction)
 }
\ No newline at end of file
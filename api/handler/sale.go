@@ -1,9 +1,9 @@
 package handler
 
 import (
-	"context"
 	"fmt"
 	"market/api/models"
+	"market/pkg/receipt"
 	"net/http"
 	"strconv"
 
@@ -13,35 +13,31 @@ import (
 // CreateSale godoc
 // @Router       /sale [POST]
 // @Summary      Create a new sale
-// @Description  create a new sale
+// @Description  create a new sale, a sale with cashier is made in the cashier's open shift
 // @Tags         sale
 // @Accept       json
 // @Produce      json
 // @Param 		 sale body models.CreateSale false "sale"
+// @Param 		 Idempotency-Key header string false "retries with the same key get the first response"
 // @Success      200  {object}  models.Sale
 // @Failure      400  {object}  models.Response
 // @Failure      404  {object}  models.Response
+// @Failure      409  {object}  models.Response
 // @Failure      500  {object}  models.Response
 func (h Handler) CreateSale(c *gin.Context) {
 	sale := models.CreateSale{}
 	if err := c.ShouldBindJSON(&sale); err != nil {
-		handleResponse(c, h.log, "error is while reading from body", http.StatusBadRequest, err.Error())
+		handleResponse(c, h.log, "error is while reading from body", http.StatusBadRequest, err)
 		return
 	}
 
-	id, err := h.storage.Sale().Create(context.Background(), sale)
+	createdSale, err := h.services.Sale().Create(c.Request.Context(), sale)
 	if err != nil {
-		handleResponse(c, h.log, "error is while creating sale", http.StatusInternalServerError, err.Error())
+		handleResponse(c, h.log, "error is while creating sale", http.StatusInternalServerError, err)
 		return
 	}
 
-	createdBranch, err := h.storage.Sale().GetByID(context.Background(), id)
-	if err != nil {
-		handleResponse(c, h.log, "error is while getting by id", http.StatusInternalServerError, err.Error())
-		return
-	}
-
-	handleResponse(c, h.log, "", http.StatusCreated, createdBranch)
+	handleResponse(c, h.log, "", http.StatusCreated, createdSale)
 }
 
 // GetSale godoc
@@ -59,12 +55,14 @@ func (h Handler) CreateSale(c *gin.Context) {
 func (h Handler) GetSale(c *gin.Context) {
 	uid := c.Param("id")
 
-	sale, err := h.storage.Sale().GetByID(context.Background(), uid)
+	sale, err := h.services.Sale().Get(c.Request.Context(), uid)
 	if err != nil {
-		handleResponse(c, h.log, "error is while getting by id", http.StatusInternalServerError, err.Error())
+		handleResponse(c, h.log, "error is while getting by id", http.StatusInternalServerError, err)
 		return
 	}
 
+	setETag(c, sale.Version)
+
 	handleResponse(c, h.log, "", http.StatusOK, sale)
 }
 
@@ -78,6 +76,8 @@ func (h Handler) GetSale(c *gin.Context) {
 // @Param 		 page query string false "page"
 // @Param 		 limit query string false "limit"
 // @Param 		 search query string false "search"
+// @Param 		 include_deleted query bool false "list soft deleted rows too"
+// @Param 		 format query string false "csv or xlsx to download all rows as a file"
 // @Success      200  {object}  models.Sale
 // @Failure      400  {object}  models.Response
 // @Failure      404  {object}  models.Response
@@ -92,26 +92,59 @@ func (h Handler) GetSaleList(c *gin.Context) {
 	pageStr := c.DefaultQuery("page", "1")
 	page, err = strconv.Atoi(pageStr)
 	if err != nil {
-		handleResponse(c, h.log, "error is while converting page ", http.StatusBadRequest, err.Error())
+		handleResponse(c, h.log, "error is while converting page ", http.StatusBadRequest, err)
 		return
 	}
 
 	limitStr := c.DefaultQuery("limit", "10")
 	limit, err = strconv.Atoi(limitStr)
 	if err != nil {
-		handleResponse(c, h.log, "error is while converting limit", http.StatusBadRequest, err.Error())
+		handleResponse(c, h.log, "error is while converting limit", http.StatusBadRequest, err)
 		return
 	}
 
 	search = c.Query("search")
 
-	sales, err := h.storage.Sale().GetList(context.Background(), models.GetListRequest{
-		Page:   page,
-		Limit:  limit,
-		Search: search,
+	withDeleted, err := includeDeleted(c)
+	if err != nil {
+		handleResponse(c, h.log, "error is while converting include_deleted", http.StatusBadRequest, err)
+		return
+	}
+
+	if format := exportFormat(c); format != "" {
+		h.exportList(c, format, "sales",
+			[]string{"id", "branch_id", "shop_assistant_id", "cashier_id", "customer_id", "client_name", "payment_type", "price", "status", "created_at", "updated_at"},
+			func(after models.Cursor, limit int) ([][]string, models.Cursor, error) {
+				sales, err := h.services.Sale().GetList(c.Request.Context(), models.GetListRequest{
+					After:          &after,
+					Limit:          limit,
+					Search:         search,
+					IncludeDeleted: withDeleted,
+				})
+				if err != nil {
+					return nil, after, err
+				}
+
+				rows := make([][]string, 0, len(sales.Sales))
+				for _, s := range sales.Sales {
+					rows = append(rows, []string{s.ID, s.BranchID, s.ShopAssistantID, s.CashierID, s.CustomerID, s.ClientName,
+						s.PaymentType, strconv.FormatFloat(float64(s.Price), 'f', 2, 32), s.Status, exportTime(s.CreatedAt), exportTime(s.UpdatedAt)})
+					after = models.Cursor{CreatedAt: s.CreatedAt, ID: s.ID}
+				}
+
+				return rows, after, nil
+			})
+		return
+	}
+
+	sales, err := h.services.Sale().GetList(c.Request.Context(), models.GetListRequest{
+		Page:           page,
+		Limit:          limit,
+		Search:         search,
+		IncludeDeleted: withDeleted,
 	})
 	if err != nil {
-		handleResponse(c, h.log, "Error is while getting Sale list: ", http.StatusInternalServerError, err.Error())
+		handleResponse(c, h.log, "Error is while getting Sale list: ", http.StatusInternalServerError, err)
 		return 
 	}
 
@@ -127,78 +160,84 @@ func (h Handler) GetSaleList(c *gin.Context) {
 // @Produce      json
 // @Param        id path string true "Sale ID"
 // @Param        sale body models.UpdateSale true "Sale object"
+// @Param        If-Match header string true "ETag of the entity from GET"
 // @Success      200 {object} models.Response
 // @Failure      400 {object} models.Response
 // @Failure      404 {object} models.Response
 // @Failure      500 {object} models.Response
 func (h Handler) UpdateSale(c *gin.Context) {
-    uid := c.Param("id")
-    sale := models.UpdateSale{}
+	uid := c.Param("id")
+	sale := models.UpdateSale{}
 
-    if uid == "" {
-        handleResponse(c, h.log, "error is while reading body", http.StatusBadRequest, "sale ID is empty")
-        return
-    }
+	if uid == "" {
+		handleResponse(c, h.log, "error is while reading body", http.StatusBadRequest, "sale ID is empty")
+		return
+	}
 
-    if err := c.ShouldBindJSON(&sale); err != nil {
-        handleResponse(c, h.log, "error is while reading body", http.StatusBadRequest, err.Error())
-        return
-    }
+	if err := c.ShouldBindJSON(&sale); err != nil {
+		handleResponse(c, h.log, "error is while reading body", http.StatusBadRequest, err)
+		return
+	}
+
+	sale.ID = uid
 
-	status, err := h.storage.Sale().GetByID(context.Background(), uid)
+	version, err := ifMatch(c)
 	if err != nil {
-		handleResponse(c, h.log, "error is while getting by id", http.StatusInternalServerError, err.Error())
+		handleResponse(c, h.log, "error is while reading If-Match header", http.StatusPreconditionRequired, err)
 		return
 	}
+	sale.Version = version
 
-	if status.Status != "in_process" {
-		handleResponse(c, h.log, "sale status is not 'in_process', cannot update", http.StatusBadRequest, "")
+	updatedSale, err := h.services.Sale().Update(c.Request.Context(), sale)
+	if err != nil {
+		handleResponse(c, h.log, "error is while updating sale", http.StatusInternalServerError, err)
 		return
 	}
 
-    sale.ID = uid
+	setETag(c, updatedSale.Version)
 
-    baskets, err := h.storage.Basket().GetList(context.Background(), models.GetListRequest{
-		Page: 1,
-		Limit: 100,  
-		Search: uid,
-    })
-    if err != nil {
-        handleResponse(c, h.log, "error is while getting baskets by sale ID", http.StatusInternalServerError, err.Error())
-        return
-    }
+	handleResponse(c, h.log, "", http.StatusOK, updatedSale)
+}
 
-    totalPrice := 0
-    for _, basket := range baskets.Baskets {
-        totalPrice += basket.Price
-    }
+// PatchSale godoc
+// @Router       /sale/{id} [PATCH]
+// @Summary      Patch sale
+// @Description  change only the given fields of sale, null clears a nullable field
+// @Tags         sale
+// @Accept       json
+// @Produce      json
+// @Param 		 id path string true "sale_id"
+// @Param 		 sale body models.PatchSale false "sale"
+// @Param 		 If-Match header string true "ETag of the entity from GET"
+// @Success      200  {object}  models.Sale
+// @Failure      400  {object}  models.Response
+// @Failure      404  {object}  models.Response
+// @Failure      500  {object}  models.Response
+func (h Handler) PatchSale(c *gin.Context) {
+	sale := models.PatchSale{}
+	if err := bindPatch(c, &sale); err != nil {
+		handleResponse(c, h.log, "error is while reading body", http.StatusBadRequest, err)
+		return
+	}
 
-	fmt.Println(totalPrice)
+	sale.ID = c.Param("id")
 
-	count, err := h.storage.Repository().ProductByID(context.Background(), baskets.Baskets[0].ProductID)
+	version, err := ifMatch(c)
 	if err != nil {
-		return 
+		handleResponse(c, h.log, "error is while reading If-Match header", http.StatusPreconditionRequired, err)
+		return
 	}
+	sale.Version = version
 
-	if count - baskets.Baskets[0].Quantity < 0 {
+	resp, err := h.services.Sale().Patch(c.Request.Context(), sale)
+	if err != nil {
+		handleResponse(c, h.log, "error is while patching sale", http.StatusInternalServerError, err)
 		return
 	}
 
-    sale.Price = float32(totalPrice)
-
-    id, err := h.storage.Sale().Update(context.Background(), sale)
-    if err != nil {
-        handleResponse(c, h.log, "error is while updating sale", http.StatusInternalServerError, err.Error())
-        return
-    }
-
-    updatedSale, err := h.storage.Sale().GetByID(context.Background(), id)
-    if err != nil {
-        handleResponse(c, h.log, "error is while getting by ID", http.StatusInternalServerError, err.Error())
-        return
-    }
+	setETag(c, resp.Version)
 
-    handleResponse(c, h.log, "", http.StatusOK, updatedSale)
+	handleResponse(c, h.log, "", http.StatusOK, resp)
 }
 
 // DeleteSale godoc
@@ -215,10 +254,75 @@ func (h Handler) UpdateSale(c *gin.Context) {
 // @Failure      500  {object}  models.Response
 func (h Handler) DeleteSale(c *gin.Context) {
 	uid := c.Param("id")
-	if err := h.storage.Sale().Delete(context.Background(), uid); err != nil {
-		handleResponse(c, h.log, "error is while deleting", http.StatusInternalServerError, err.Error())
+	if err := h.services.Sale().Delete(c.Request.Context(), uid); err != nil {
+		handleResponse(c, h.log, "error is while deleting", http.StatusInternalServerError, err)
+		return
+	}
+
+	handleResponse(c, h.log, "", http.StatusOK, "sale deleted!")
+}
+
+// RestoreSale godoc
+// @Router       /sale/{id}/restore [POST]
+// @Summary      Restore sale
+// @Description  bring back soft deleted sale
+// @Tags         sale
+// @Accept       json
+// @Produce      json
+// @Param 		 id path string true "sale_id"
+// @Success      200  {object}  models.Sale
+// @Failure      400  {object}  models.Response
+// @Failure      404  {object}  models.Response
+// @Failure      500  {object}  models.Response
+func (h Handler) RestoreSale(c *gin.Context) {
+	sale, err := h.services.Sale().Restore(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		handleResponse(c, h.log, "error is while restoring sale", http.StatusInternalServerError, err)
 		return
 	}
 
-	handleResponse(c, "", http.StatusOK, "sale deleted!")
+	setETag(c, sale.Version)
+
+	handleResponse(c, h.log, "", http.StatusOK, sale)
+}
+
+// GetSaleReceipt godoc
+// @Router       /sale/{id}/receipt [GET]
+// @Summary      Get sale receipt
+// @Description  get receipt of completed sale as text, html or pdf
+// @Tags         sale
+// @Produce      plain
+// @Produce      html
+// @Produce      application/pdf
+// @Param 		 id path string true "sale_id"
+// @Param 		 format query string false "text, html or pdf"
+// @Success      200  {string}  string
+// @Failure      400  {object}  models.Response
+// @Failure      404  {object}  models.Response
+// @Failure      500  {object}  models.Response
+func (h Handler) GetSaleReceipt(c *gin.Context) {
+	uid := c.Param("id")
+
+	r, err := h.services.Sale().Receipt(c.Request.Context(), uid)
+	if err != nil {
+		handleResponse(c, h.log, "error is while getting receipt", http.StatusInternalServerError, err)
+		return
+	}
+
+	switch c.DefaultQuery("format", "text") {
+	case "text":
+		c.String(http.StatusOK, receipt.Text(r))
+	case "html":
+		page, err := receipt.HTML(r)
+		if err != nil {
+			handleResponse(c, h.log, "error is while rendering receipt", http.StatusInternalServerError, err)
+			return
+		}
+		c.Data(http.StatusOK, "text/html; charset=utf-8", page)
+	case "pdf":
+		c.Header("Content-Disposition", fmt.Sprintf(`inline; filename="receipt-%s.pdf"`, uid))
+		c.Data(http.StatusOK, "application/pdf", receipt.PDF(r))
+	default:
+		handleResponse(c, h.log, "unknown receipt format", http.StatusBadRequest, "format should be text, html or pdf")
+	}
 }
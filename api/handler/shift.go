@@ -0,0 +1,180 @@
+package handler
+
+import (
+	"market/api/models"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OpenShift godoc
+// @Router       /shift [POST]
+// @Summary      Open a new shift
+// @Description  open a new cash register shift for cashier
+// @Tags         shift
+// @Accept       json
+// @Produce      json
+// @Param 		 shift body models.OpenShift false "shift"
+// @Success      200  {object}  models.Shift
+// @Failure      400  {object}  models.Response
+// @Failure      404  {object}  models.Response
+// @Failure      500  {object}  models.Response
+func (h Handler) OpenShift(c *gin.Context) {
+	shift := models.OpenShift{}
+	if err := c.ShouldBindJSON(&shift); err != nil {
+		handleResponse(c, h.log, "error is while reading body", http.StatusBadRequest, err)
+		return
+	}
+
+	resp, err := h.services.Shift().Open(c.Request.Context(), shift)
+	if err != nil {
+		handleResponse(c, h.log, "error is while opening shift", http.StatusInternalServerError, err)
+		return
+	}
+
+	handleResponse(c, h.log, "", http.StatusCreated, resp)
+}
+
+// CloseShift godoc
+// @Router       /shift/{id}/close [POST]
+// @Summary      Close shift
+// @Description  close shift with counted cash and get z-report
+// @Tags         shift
+// @Accept       json
+// @Produce      json
+// @Param 		 id path string true "shift_id"
+// @Param 		 shift body models.CloseShift false "shift"
+// @Success      200  {object}  models.ZReport
+// @Failure      400  {object}  models.Response
+// @Failure      404  {object}  models.Response
+// @Failure      409  {object}  models.Response
+// @Failure      500  {object}  models.Response
+func (h Handler) CloseShift(c *gin.Context) {
+	shift := models.CloseShift{}
+	if err := c.ShouldBindJSON(&shift); err != nil {
+		handleResponse(c, h.log, "error is while reading body", http.StatusBadRequest, err)
+		return
+	}
+
+	shift.ID = c.Param("id")
+
+	report, err := h.services.Shift().Close(c.Request.Context(), shift)
+	if err != nil {
+		handleResponse(c, h.log, "error is while closing shift", http.StatusInternalServerError, err)
+		return
+	}
+
+	handleResponse(c, h.log, "", http.StatusOK, report)
+}
+
+// GetShift godoc
+// @Router       /shift/{id} [GET]
+// @Summary      Get shift by id
+// @Description  get shift by id
+// @Tags         shift
+// @Accept       json
+// @Produce      json
+// @Param 		 id path string true "shift_id"
+// @Success      200  {object}  models.Shift
+// @Failure      400  {object}  models.Response
+// @Failure      404  {object}  models.Response
+// @Failure      500  {object}  models.Response
+func (h Handler) GetShift(c *gin.Context) {
+	shift, err := h.services.Shift().Get(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		handleResponse(c, h.log, "error is while getting shift by id", http.StatusInternalServerError, err)
+		return
+	}
+
+	handleResponse(c, h.log, "", http.StatusOK, shift)
+}
+
+// GetShiftList godoc
+// @Router       /shifts [GET]
+// @Summary      Get shift list
+// @Description  get shift list
+// @Tags         shift
+// @Accept       json
+// @Produce      json
+// @Param 		 page query string false "page"
+// @Param 		 limit query string false "limit"
+// @Param 		 cashier_id query string false "cashier_id"
+// @Param 		 format query string false "csv or xlsx to download all rows as a file"
+// @Success      200  {object}  models.ShiftsResponse
+// @Failure      400  {object}  models.Response
+// @Failure      404  {object}  models.Response
+// @Failure      500  {object}  models.Response
+func (h Handler) GetShiftList(c *gin.Context) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil {
+		handleResponse(c, h.log, "error is while converting page", http.StatusBadRequest, err)
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil {
+		handleResponse(c, h.log, "error is while converting limit", http.StatusBadRequest, err)
+		return
+	}
+
+	if format := exportFormat(c); format != "" {
+		h.exportList(c, format, "shifts",
+			[]string{"id", "branch_id", "cashier_id", "status", "opening_cash", "expected_cash", "counted_cash", "opened_at", "closed_at"},
+			func(after models.Cursor, limit int) ([][]string, models.Cursor, error) {
+				shifts, err := h.services.Shift().GetList(c.Request.Context(), models.GetListRequest{
+					After:  &after,
+					Limit:  limit,
+					Search: c.Query("cashier_id"),
+				})
+				if err != nil {
+					return nil, after, err
+				}
+
+				rows := make([][]string, 0, len(shifts.Shifts))
+				for _, s := range shifts.Shifts {
+					rows = append(rows, []string{s.ID, s.BranchID, s.CashierID, s.Status,
+						strconv.FormatFloat(s.OpeningCash, 'f', 2, 64), strconv.FormatFloat(s.ExpectedCash, 'f', 2, 64),
+						strconv.FormatFloat(s.CountedCash, 'f', 2, 64), exportTime(s.OpenedAt), exportTime(s.ClosedAt)})
+					after = models.Cursor{CreatedAt: s.CreatedAt, ID: s.ID}
+				}
+
+				return rows, after, nil
+			})
+		return
+	}
+
+	shifts, err := h.services.Shift().GetList(c.Request.Context(), models.GetListRequest{
+		Page:   page,
+		Limit:  limit,
+		Search: c.Query("cashier_id"),
+	})
+	if err != nil {
+		handleResponse(c, h.log, "error is while getting shift list", http.StatusInternalServerError, err)
+		return
+	}
+
+	handleResponse(c, h.log, "", http.StatusOK, shifts)
+}
+
+// GetShiftZReport godoc
+// @Router       /shift/{id}/zreport [GET]
+// @Summary      Get z-report of shift
+// @Description  get card/cash totals and cancellations of sales made in the shift
+// @Tags         shift
+// @Accept       json
+// @Produce      json
+// @Param 		 id path string true "shift_id"
+// @Success      200  {object}  models.ZReport
+// @Failure      400  {object}  models.Response
+// @Failure      404  {object}  models.Response
+// @Failure      500  {object}  models.Response
+func (h Handler) GetShiftZReport(c *gin.Context) {
+	report, err := h.services.Shift().ZReport(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		handleResponse(c, h.log, "error is while getting z-report", http.StatusInternalServerError, err)
+		return
+	}
+
+	handleResponse(c, h.log, "", http.StatusOK, report)
+}
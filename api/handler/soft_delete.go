@@ -0,0 +1,13 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// includeDeleted reads the include_deleted query of list endpoints, soft deleted
+// rows are listed only when it is true.
+func includeDeleted(c *gin.Context) (bool, error) {
+	return strconv.ParseBool(c.DefaultQuery("include_deleted", "false"))
+}
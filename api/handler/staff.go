@@ -1,11 +1,9 @@
 package handler
 
 import (
-	"context"
 	"net/http"
 	"strconv"
 	"market/api/models"
-	"market/pkg/check"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -27,24 +25,17 @@ func (h Handler) CreateStaff(c *gin.Context) {
 	staff := models.CreateStaff{}
 
 	if err := c.ShouldBindJSON(&staff); err != nil {
-		handleResponse(c, h.log, "error while reading body", http.StatusBadRequest, err.Error())
+		handleResponse(c, h.log, "error while reading body", http.StatusBadRequest, err)
 		return
 	}
 
-	id, err := h.storage.Staff().Create(context.Background(), staff)
-
-	if err != nil {
-		handleResponse(c, h.log, "error while creating staff ", http.StatusInternalServerError, err.Error())
-		return
-	}
-
-	createdStaffTarif, err := h.storage.Staff().StaffByID(context.Background(), models.PrimaryKey{ID: id})
+	createdStaff, err := h.services.Staff().Create(c.Request.Context(), staff)
 	if err != nil {
-		handleResponse(c, h.log, "error while getting by ID", http.StatusInternalServerError, err.Error())
+		handleResponse(c, h.log, "error while creating staff ", http.StatusInternalServerError, err)
 		return
 	}
 
-	handleResponse(c, h.log, "", http.StatusCreated, createdStaffTarif)
+	handleResponse(c, h.log, "", http.StatusCreated, createdStaff)
 }
 
 // GetStaff godoc
@@ -62,12 +53,14 @@ func (h Handler) CreateStaff(c *gin.Context) {
 func (h Handler) GetStaff(c *gin.Context) {
 	uid := c.Param("id")
 
-	staffTarif, err := h.storage.Staff().StaffByID(context.Background(), models.PrimaryKey{ID: uid})
+	staffTarif, err := h.services.Staff().Get(c.Request.Context(), uid)
 	if err != nil {
-		handleResponse(c, h.log, "error while getting staff  by ID", http.StatusInternalServerError, err.Error())
+		handleResponse(c, h.log, "error while getting staff  by ID", http.StatusInternalServerError, err)
 		return
 	}
 
+	setETag(c, staffTarif.Version)
+
 	handleResponse(c, h.log, "", http.StatusOK, staffTarif)
 }
 
@@ -81,6 +74,8 @@ func (h Handler) GetStaff(c *gin.Context) {
 // @Param 		 page query string false "page"
 // @Param 		 limit query string false "limit"
 // @Param 		 search query string false "search"
+// @Param 		 include_deleted query bool false "list soft deleted rows too"
+// @Param 		 format query string false "csv or xlsx to download all rows as a file"
 // @Success      200  {object}  models.StaffsResponse
 // @Failure      400  {object}  models.Response
 // @Failure      404  {object}  models.Response
@@ -94,26 +89,60 @@ func (h Handler) GetStaffList(c *gin.Context) {
 	pageStr := c.DefaultQuery("page", "1")
 	page, err = strconv.Atoi(pageStr)
 	if err != nil {
-		handleResponse(c, h.log, "error while converting page", http.StatusBadRequest, err.Error())
+		handleResponse(c, h.log, "error while converting page", http.StatusBadRequest, err)
 		return
 	}
 
 	limitStr := c.DefaultQuery("limit", "10")
 	limit, err = strconv.Atoi(limitStr)
 	if err != nil {
-		handleResponse(c, h.log, "error while converting limit", http.StatusBadRequest, err.Error())
+		handleResponse(c, h.log, "error while converting limit", http.StatusBadRequest, err)
 		return
 	}
 
 	search := c.Query("search")
 
-	response, err := h.storage.Staff().GetStaffTList(context.Background(), models.GetListRequest{
-		Page:   page,
-		Limit:  limit,
-		Search: search,
+	withDeleted, err := includeDeleted(c)
+	if err != nil {
+		handleResponse(c, h.log, "error is while converting include_deleted", http.StatusBadRequest, err)
+		return
+	}
+
+	if format := exportFormat(c); format != "" {
+		h.exportList(c, format, "staffs",
+			[]string{"id", "branch_id", "tariff_id", "staff_type", "name", "balance", "age", "birth_date", "login", "created_at", "updated_at"},
+			func(after models.Cursor, limit int) ([][]string, models.Cursor, error) {
+				response, err := h.services.Staff().GetList(c.Request.Context(), models.GetListRequest{
+					After:          &after,
+					Limit:          limit,
+					Search:         search,
+					IncludeDeleted: withDeleted,
+				})
+				if err != nil {
+					return nil, after, err
+				}
+
+				rows := make([][]string, 0, len(response.Staffs))
+				for _, s := range response.Staffs {
+					rows = append(rows, []string{s.ID, s.BranchID, s.TariffID, s.StaffType, s.Name,
+						strconv.FormatUint(uint64(s.Balance), 10), strconv.FormatUint(uint64(s.Age), 10),
+						s.BirthDate.Format("2006-01-02"), s.Login, exportTime(s.CreatedAt), exportTime(s.UpdatedAt)})
+					after = models.Cursor{CreatedAt: s.CreatedAt, ID: s.ID}
+				}
+
+				return rows, after, nil
+			})
+		return
+	}
+
+	response, err := h.services.Staff().GetList(c.Request.Context(), models.GetListRequest{
+		Page:           page,
+		Limit:          limit,
+		Search:         search,
+		IncludeDeleted: withDeleted,
 	})
 	if err != nil {
-		handleResponse(c, h.log, "error while getting staff list", http.StatusInternalServerError, err.Error())
+		handleResponse(c, h.log, "error while getting staff list", http.StatusInternalServerError, err)
 		return
 	}
 
@@ -129,6 +158,7 @@ func (h Handler) GetStaffList(c *gin.Context) {
 // @Produce      json
 // @Param 		 id path string true "staff_id"
 // @Param 		 staff body models.UpdateStaff false "staff"
+// @Param 		 If-Match header string true "ETag of the entity from GET"
 // @Success      200  {object}  models.Staff
 // @Failure      400  {object}  models.Response
 // @Failure      404  {object}  models.Response
@@ -138,25 +168,71 @@ func (h Handler) UpdateStaff(c *gin.Context) {
 
 	staff := models.UpdateStaff{}
 	if err := c.ShouldBindJSON(&staff); err != nil {
-		handleResponse(c, h.log, "error while reading from body", http.StatusBadRequest, err.Error())
+		handleResponse(c, h.log, "error while reading from body", http.StatusBadRequest, err)
 		return
 	}
 
 	staff.ID = uid
-	if _, err := h.storage.Staff().UpdateStaff(context.Background(), staff); err != nil {
-		handleResponse(c, h.log, "error while updating staff ", http.StatusInternalServerError, err.Error())
+
+	version, err := ifMatch(c)
+	if err != nil {
+		handleResponse(c, h.log, "error is while reading If-Match header", http.StatusPreconditionRequired, err)
 		return
 	}
+	staff.Version = version
 
-	updatedStaff, err := h.storage.Staff().StaffByID(context.Background(), models.PrimaryKey{ID: uid})
+	updatedStaff, err := h.services.Staff().Update(c.Request.Context(), staff)
 	if err != nil {
-		handleResponse(c, h.log, "error while getting by ID", http.StatusInternalServerError, err.Error())
+		handleResponse(c, h.log, "error while updating staff ", http.StatusInternalServerError, err)
 		return
 	}
 
+	setETag(c, updatedStaff.Version)
+
 	handleResponse(c, h.log, "", http.StatusOK, updatedStaff)
 }
 
+// PatchStaff godoc
+// @Router       /staff/{id} [PATCH]
+// @Summary      Patch staff
+// @Description  change only the given fields of staff, null clears a nullable field
+// @Tags         staff
+// @Accept       json
+// @Produce      json
+// @Param 		 id path string true "staff_id"
+// @Param 		 staff body models.PatchStaff false "staff"
+// @Param 		 If-Match header string true "ETag of the entity from GET"
+// @Success      200  {object}  models.Staff
+// @Failure      400  {object}  models.Response
+// @Failure      404  {object}  models.Response
+// @Failure      500  {object}  models.Response
+func (h Handler) PatchStaff(c *gin.Context) {
+	staff := models.PatchStaff{}
+	if err := bindPatch(c, &staff); err != nil {
+		handleResponse(c, h.log, "error is while reading body", http.StatusBadRequest, err)
+		return
+	}
+
+	staff.ID = c.Param("id")
+
+	version, err := ifMatch(c)
+	if err != nil {
+		handleResponse(c, h.log, "error is while reading If-Match header", http.StatusPreconditionRequired, err)
+		return
+	}
+	staff.Version = version
+
+	resp, err := h.services.Staff().Patch(c.Request.Context(), staff)
+	if err != nil {
+		handleResponse(c, h.log, "error is while patching staff", http.StatusInternalServerError, err)
+		return
+	}
+
+	setETag(c, resp.Version)
+
+	handleResponse(c, h.log, "", http.StatusOK, resp)
+}
+
 // DeleteStaff godoc
 // @Router       /staff/{id} [DELETE]
 // @Summary      Delete staff
@@ -172,14 +248,38 @@ func (h Handler) UpdateStaff(c *gin.Context) {
 func (h Handler) DeleteStaff(c *gin.Context) {
 	uid := c.Param("id")
 
-	if err := h.storage.Staff().DeleteStaff(context.Background(), uid); err != nil {
-		handleResponse(c, h.log, "error while deleting staff ", http.StatusInternalServerError, err.Error())
+	if err := h.services.Staff().Delete(c.Request.Context(), uid); err != nil {
+		handleResponse(c, h.log, "error while deleting staff ", http.StatusInternalServerError, err)
 		return
 	}
 
 	handleResponse(c, h.log, "", http.StatusOK, "staff deleted")
 }
 
+// RestoreStaff godoc
+// @Router       /staff/{id}/restore [POST]
+// @Summary      Restore staff
+// @Description  bring back soft deleted staff
+// @Tags         staff
+// @Accept       json
+// @Produce      json
+// @Param 		 id path string true "staff_id"
+// @Success      200  {object}  models.Staff
+// @Failure      400  {object}  models.Response
+// @Failure      404  {object}  models.Response
+// @Failure      500  {object}  models.Response
+func (h Handler) RestoreStaff(c *gin.Context) {
+	staff, err := h.services.Staff().Restore(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		handleResponse(c, h.log, "error is while restoring staff", http.StatusInternalServerError, err)
+		return
+	}
+
+	setETag(c, staff.Version)
+
+	handleResponse(c, h.log, "", http.StatusOK, staff)
+}
+
 // UpdateStaffPassword godoc
 // @Router       /staff/{id} [PATCH]
 // @Summary      Update staff password
@@ -197,38 +297,80 @@ func (h Handler) UpdateStaffPassword(c *gin.Context) {
 	updateStaffPassword := models.UpdateStaffPassword{}
 
 	if err := c.ShouldBindJSON(&updateStaffPassword); err != nil {
-		handleResponse(c, h.log, "error while reading body", http.StatusBadRequest, err.Error())
+		handleResponse(c, h.log, "error while reading body", http.StatusBadRequest, err)
 		return
 	}
 
 	uid, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		handleResponse(c, "error while parsing uuid", http.StatusBadRequest, err.Error())
+		handleResponse(c, h.log, "error while parsing uuid", http.StatusBadRequest, err)
 		return
 	}
 
 	updateStaffPassword.ID = uid.String()
 
-	oldPassword, err := h.storage.Staff().GetPassword(context.Background(), updateStaffPassword.ID)
-	if err != nil {
-		handleResponse(c, h.log, "error while getting password by id", http.StatusInternalServerError, err.Error())
+	if err = h.services.Staff().UpdatePassword(c.Request.Context(), updateStaffPassword); err != nil {
+		handleResponse(c, h.log, "error while updating staff password by id", http.StatusInternalServerError, err)
 		return
 	}
 
-	if oldPassword != updateStaffPassword.OldPassword {
-		handleResponse(c, h.log, "old password is not correct", http.StatusBadRequest, "old password is not correct")
+	handleResponse(c, h.log, "", http.StatusOK, "password successfully updated")
+}
+// AdjustStaffBalance godoc
+// @Router       /staff/{id}/balance [POST]
+// @Summary      Pay bonus or take penalty
+// @Description  topup or withdraw staff balance with a bonus transaction
+// @Tags         staff
+// @Accept       json
+// @Produce      json
+// @Param 		 id path string true "staff_id"
+// @Param        adjustment body models.StaffBalanceAdjustment true "adjustment"
+// @Success      200  {object}  models.StaffBalanceResponse
+// @Failure      400  {object}  models.Response
+// @Failure      404  {object}  models.Response
+// @Failure      500  {object}  models.Response
+func (h Handler) AdjustStaffBalance(c *gin.Context) {
+	request := models.StaffBalanceAdjustment{}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		handleResponse(c, h.log, "error while reading body", http.StatusBadRequest, err)
 		return
 	}
 
-	if err = check.ValidatePassword(updateStaffPassword.NewPassword); err != nil {
-		handleResponse(c, h.log, "new password is weak", http.StatusBadRequest, err.Error())
+	request.StaffID = c.Param("id")
+
+	resp, err := h.services.Staff().AdjustBalance(c.Request.Context(), request)
+	if err != nil {
+		handleResponse(c, h.log, "error while adjusting staff balance", http.StatusInternalServerError, err)
 		return
 	}
 
-	if err = h.storage.Staff().UpdatePassword(context.Background(), updateStaffPassword); err != nil {
-		handleResponse(c, h.log, "error while updating staff password by id", http.StatusInternalServerError, err.Error())
+	handleResponse(c, h.log, "", http.StatusOK, resp)
+}
+
+// GetStaffStats godoc
+// @Router       /staff/{id}/stats [GET]
+// @Summary      Get staff performance
+// @Description  get sales, revenue, commissions and cancellation rate of staff
+// @Tags         staff
+// @Accept       json
+// @Produce      json
+// @Param 		 id path string true "staff_id"
+// @Param 		 from query string false "from date, 2006-01-02"
+// @Param 		 to query string false "to date, 2006-01-02"
+// @Success      200  {object}  models.StaffStats
+// @Failure      400  {object}  models.Response
+// @Failure      404  {object}  models.Response
+// @Failure      500  {object}  models.Response
+func (h Handler) GetStaffStats(c *gin.Context) {
+	stats, err := h.services.Staff().Stats(c.Request.Context(), c.Param("id"), models.ReportRequest{
+		From: c.Query("from"),
+		To:   c.Query("to"),
+	})
+	if err != nil {
+		handleResponse(c, h.log, "error while getting staff stats", http.StatusInternalServerError, err)
 		return
 	}
 
-	handleResponse(c, h.log, "", http.StatusOK, "password successfully updated")
-}
\ No newline at end of file
+	handleResponse(c, h.log, "", http.StatusOK, stats)
+}
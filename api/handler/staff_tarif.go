@@ -1,7 +1,6 @@
 package handler
 
 import (
-	"context"
 	"net/http"
 	"strconv"
 	"market/api/models"
@@ -25,19 +24,13 @@ func (h Handler) CreateStaffTariff(c *gin.Context) {
 	staffTariff := models.CreateStaffTarif{}
 
 	if err := c.ShouldBindJSON(&staffTariff); err != nil {
-		handleResponse(c, h.log, "error while reading body", http.StatusBadRequest, err.Error())
+		handleResponse(c, h.log, "error while reading body", http.StatusBadRequest, err)
 		return
 	}
 
-	id, err := h.storage.StaffTariff().Create(context.Background(), staffTariff)
+	createdStaffTariff, err := h.services.StaffTarif().Create(c.Request.Context(), staffTariff)
 	if err != nil {
-		handleResponse(c, h.log, "error while creating staff tariff", http.StatusInternalServerError, err.Error())
-		return
-	}
-
-	createdStaffTariff, err := h.storage.StaffTariff().GetStaffTariffByID(context.Background(), models.PrimaryKey{ID: id})
-	if err != nil {
-		handleResponse(c, h.log, "error while getting by ID", http.StatusInternalServerError, err.Error())
+		handleResponse(c, h.log, "error while creating staff tariff", http.StatusInternalServerError, err)
 		return
 	}
 
@@ -59,12 +52,14 @@ func (h Handler) CreateStaffTariff(c *gin.Context) {
 func (h Handler) GetStaffTariff(c *gin.Context) {
 	uid := c.Param("id")
 
-	staffTariff, err := h.storage.StaffTariff().GetStaffTariffByID(context.Background(), models.PrimaryKey{ID: uid})
+	staffTariff, err := h.services.StaffTarif().Get(c.Request.Context(), uid)
 	if err != nil {
-		handleResponse(c, h.log, "error while getting staff tariff by ID", http.StatusInternalServerError, err.Error())
+		handleResponse(c, h.log, "error while getting staff tariff by ID", http.StatusInternalServerError, err)
 		return
 	}
 
+	setETag(c, staffTariff.Version)
+
 	handleResponse(c, h.log, "", http.StatusOK, staffTariff)
 }
 
@@ -78,6 +73,8 @@ func (h Handler) GetStaffTariff(c *gin.Context) {
 // @Param 		 page query string false "page"
 // @Param 		 limit query string false "limit"
 // @Param 		 search query string false "search"
+// @Param 		 include_deleted query bool false "list soft deleted rows too"
+// @Param 		 format query string false "csv or xlsx to download all rows as a file"
 // @Success      200  {object}  models.StaffTarifResponse
 // @Failure      400  {object}  models.Response
 // @Failure      404  {object}  models.Response
@@ -91,26 +88,59 @@ func (h Handler) GetStaffTariffList(c *gin.Context) {
 	pageStr := c.DefaultQuery("page", "1")
 	page, err = strconv.Atoi(pageStr)
 	if err != nil {
-		handleResponse(c, h.log, "error while converting page", http.StatusBadRequest, err.Error())
+		handleResponse(c, h.log, "error while converting page", http.StatusBadRequest, err)
 		return
 	}
 
 	limitStr := c.DefaultQuery("limit", "10")
 	limit, err = strconv.Atoi(limitStr)
 	if err != nil {
-		handleResponse(c, h.log, "error while converting limit", http.StatusBadRequest, err.Error())
+		handleResponse(c, h.log, "error while converting limit", http.StatusBadRequest, err)
 		return
 	}
 
 	search := c.Query("search")
 
-	response, err := h.storage.StaffTariff().GetStaffTariffList(context.Background(), models.GetListRequest{
-		Page:   page,
-		Limit:  limit,
-		Search: search,
+	withDeleted, err := includeDeleted(c)
+	if err != nil {
+		handleResponse(c, h.log, "error is while converting include_deleted", http.StatusBadRequest, err)
+		return
+	}
+
+	if format := exportFormat(c); format != "" {
+		h.exportList(c, format, "staff_tarifs",
+			[]string{"id", "name", "tarif_type", "amount_for_cash", "amount_for_card", "created_at", "updated_at"},
+			func(after models.Cursor, limit int) ([][]string, models.Cursor, error) {
+				response, err := h.services.StaffTarif().GetList(c.Request.Context(), models.GetListRequest{
+					After:          &after,
+					Limit:          limit,
+					Search:         search,
+					IncludeDeleted: withDeleted,
+				})
+				if err != nil {
+					return nil, after, err
+				}
+
+				rows := make([][]string, 0, len(response.StaffTarifs))
+				for _, t := range response.StaffTarifs {
+					rows = append(rows, []string{t.ID, t.Name, t.TarifType, strconv.Itoa(t.AmountForCash),
+						strconv.Itoa(t.AmountForCard), exportTime(t.CreatedAt), exportTime(t.UpdatedAt)})
+					after = models.Cursor{CreatedAt: t.CreatedAt, ID: t.ID}
+				}
+
+				return rows, after, nil
+			})
+		return
+	}
+
+	response, err := h.services.StaffTarif().GetList(c.Request.Context(), models.GetListRequest{
+		Page:           page,
+		Limit:          limit,
+		Search:         search,
+		IncludeDeleted: withDeleted,
 	})
 	if err != nil {
-		handleResponse(c, h.log, "error while getting staff tariff list", http.StatusInternalServerError, err.Error())
+		handleResponse(c, h.log, "error while getting staff tariff list", http.StatusInternalServerError, err)
 		return
 	}
 
@@ -126,6 +156,7 @@ func (h Handler) GetStaffTariffList(c *gin.Context) {
 // @Produce      json
 // @Param 		 id path string true "stafftarif_id"
 // @Param 		 staff-tariff body models.UpdateStaffTarif false "staff-tariff"
+// @Param 		 If-Match header string true "ETag of the entity from GET"
 // @Success      200  {object}  models.StaffTarif
 // @Failure      400  {object}  models.Response
 // @Failure      404  {object}  models.Response
@@ -135,25 +166,71 @@ func (h Handler) UpdateStaffTariff(c *gin.Context) {
 
 	sTariff := models.UpdateStaffTarif{}
 	if err := c.ShouldBindJSON(&sTariff); err != nil {
-		handleResponse(c, h.log, "error while reading from body", http.StatusBadRequest, err.Error())
+		handleResponse(c, h.log, "error while reading from body", http.StatusBadRequest, err)
 		return
 	}
 
 	sTariff.ID = uid
-	if _, err := h.storage.StaffTariff().UpdateStaffTariff(context.Background(), sTariff); err != nil {
-		handleResponse(c, h.log, "error while updating staff tariff", http.StatusInternalServerError, err.Error())
+
+	version, err := ifMatch(c)
+	if err != nil {
+		handleResponse(c, h.log, "error is while reading If-Match header", http.StatusPreconditionRequired, err)
 		return
 	}
+	sTariff.Version = version
 
-	updatedStaffTariff, err := h.storage.StaffTariff().GetStaffTariffByID(context.Background(), models.PrimaryKey{ID: uid})
+	updatedStaffTariff, err := h.services.StaffTarif().Update(c.Request.Context(), sTariff)
 	if err != nil {
-		handleResponse(c, h.log, "error while getting by ID", http.StatusInternalServerError, err.Error())
+		handleResponse(c, h.log, "error while updating staff tariff", http.StatusInternalServerError, err)
 		return
 	}
 
+	setETag(c, updatedStaffTariff.Version)
+
 	handleResponse(c, h.log, "", http.StatusOK, updatedStaffTariff)
 }
 
+// PatchStaffTariff godoc
+// @Router       /stafftarif/{id} [PATCH]
+// @Summary      Patch staff tariff
+// @Description  change only the given fields of staff tariff, null clears a nullable field
+// @Tags         staff-tariff
+// @Accept       json
+// @Produce      json
+// @Param 		 id path string true "staff_tariff_id"
+// @Param 		 staff-tariff body models.PatchStaffTarif false "staff-tariff"
+// @Param 		 If-Match header string true "ETag of the entity from GET"
+// @Success      200  {object}  models.StaffTarif
+// @Failure      400  {object}  models.Response
+// @Failure      404  {object}  models.Response
+// @Failure      500  {object}  models.Response
+func (h Handler) PatchStaffTariff(c *gin.Context) {
+	staffTarif := models.PatchStaffTarif{}
+	if err := bindPatch(c, &staffTarif); err != nil {
+		handleResponse(c, h.log, "error is while reading body", http.StatusBadRequest, err)
+		return
+	}
+
+	staffTarif.ID = c.Param("id")
+
+	version, err := ifMatch(c)
+	if err != nil {
+		handleResponse(c, h.log, "error is while reading If-Match header", http.StatusPreconditionRequired, err)
+		return
+	}
+	staffTarif.Version = version
+
+	resp, err := h.services.StaffTarif().Patch(c.Request.Context(), staffTarif)
+	if err != nil {
+		handleResponse(c, h.log, "error is while patching staff tariff", http.StatusInternalServerError, err)
+		return
+	}
+
+	setETag(c, resp.Version)
+
+	handleResponse(c, h.log, "", http.StatusOK, resp)
+}
+
 // DeleteStaffTariff godoc
 // @Router       /stafftarif/{id} [DELETE]
 // @Summary      Delete staff tariff
@@ -169,10 +246,34 @@ func (h Handler) UpdateStaffTariff(c *gin.Context) {
 func (h Handler) DeleteStaffTariff(c *gin.Context) {
 	uid := c.Param("id")
 
-	if err := h.storage.StaffTariff().DeleteStaffTariff(context.Background(), uid); err != nil {
-		handleResponse(c, h.log, "error while deleting staff tariff", http.StatusInternalServerError, err.Error())
+	if err := h.services.StaffTarif().Delete(c.Request.Context(), uid); err != nil {
+		handleResponse(c, h.log, "error while deleting staff tariff", http.StatusInternalServerError, err)
 		return
 	}
 
 	handleResponse(c, h.log, "", http.StatusOK, "staff tariff deleted")
+}
+
+// RestoreStaffTariff godoc
+// @Router       /stafftarif/{id}/restore [POST]
+// @Summary      Restore staff tariff
+// @Description  bring back soft deleted staff tariff
+// @Tags         staff-tariff
+// @Accept       json
+// @Produce      json
+// @Param 		 id path string true "stafftarif_id"
+// @Success      200  {object}  models.StaffTarif
+// @Failure      400  {object}  models.Response
+// @Failure      404  {object}  models.Response
+// @Failure      500  {object}  models.Response
+func (h Handler) RestoreStaffTariff(c *gin.Context) {
+	staffTarif, err := h.services.StaffTarif().Restore(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		handleResponse(c, h.log, "error is while restoring staff tariff", http.StatusInternalServerError, err)
+		return
+	}
+
+	setETag(c, staffTarif.Version)
+
+	handleResponse(c, h.log, "", http.StatusOK, staffTarif)
 }
\ No newline at end of file
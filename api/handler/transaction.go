@@ -1,7 +1,6 @@
 package handler
 
 import (
-	"context"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"math"
@@ -18,6 +17,7 @@ import (
 // @Accept       json
 // @Produce      json
 // @Param 		 transaction body models.CreateTransaction false "sale"
+// @Param 		 Idempotency-Key header string false "retries with the same key get the first response"
 // @Success      200  {object}  models.Transaction
 // @Failure      400  {object}  models.Response
 // @Failure      404  {object}  models.Response
@@ -25,19 +25,13 @@ import (
 func (h Handler) CreateTransaction(c *gin.Context) {
 	trans := models.CreateTransaction{}
 	if err := c.ShouldBindJSON(&trans); err != nil {
-		handleResponse(c, h.log, "error is while reading body", http.StatusBadRequest, err.Error())
+		handleResponse(c, h.log, "error is while reading body", http.StatusBadRequest, err)
 		return
 	}
 
-	id, err := h.storage.Transaction().Create(context.Background(), trans)
+	createdTrans, err := h.services.Transaction().Create(c.Request.Context(), trans)
 	if err != nil {
-		handleResponse(c, h.log, "error is while creating", http.StatusInternalServerError, err.Error())
-		return
-	}
-
-	createdTrans, err := h.storage.Transaction().GetByID(context.Background(), id)
-	if err != nil {
-		handleResponse(c, h.log, "error is while getting by id", http.StatusInternalServerError, err.Error())
+		handleResponse(c, h.log, "error is while creating", http.StatusInternalServerError, err)
 		return
 	}
 
@@ -59,12 +53,14 @@ func (h Handler) CreateTransaction(c *gin.Context) {
 func (h Handler) GetTransaction(c *gin.Context) {
 	uid := c.Param("id")
 
-	trans, err := h.storage.Transaction().GetByID(context.Background(), uid)
+	trans, err := h.services.Transaction().Get(c.Request.Context(), uid)
 	if err != nil {
-		handleResponse(c, h.log, "error is while getting by id", http.StatusInternalServerError, err.Error())
+		handleResponse(c, h.log, "error is while getting by id", http.StatusInternalServerError, err)
 		return
 	}
 
+	setETag(c, trans.Version)
+
 	handleResponse(c, h.log, "", http.StatusOK, trans)
 }
 
@@ -79,6 +75,8 @@ func (h Handler) GetTransaction(c *gin.Context) {
 // @Param		 limit query string false "limit"
 // @Param		 from-amount query string false "from-amount"
 // @Param		 to-amount query string false "to-amount"
+// @Param 		 include_deleted query bool false "list soft deleted rows too"
+// @Param 		 format query string false "csv or xlsx to download all rows as a file"
 // @Success      200  {object}  models.TransactionResponse
 // @Failure      400  {object}  models.Response
 // @Failure      404  {object}  models.Response
@@ -94,40 +92,74 @@ func (h Handler) GetTransactionList(c *gin.Context) {
 	pageStr := c.DefaultQuery("page", "1")
 	page, err = strconv.Atoi(pageStr)
 	if err != nil {
-		handleResponse(c, h.log, "error is while converting page", http.StatusBadRequest, err.Error())
+		handleResponse(c, h.log, "error is while converting page", http.StatusBadRequest, err)
 		return
 	}
 
 	limitStr := c.DefaultQuery("limit", "10")
 	limit, err = strconv.Atoi(limitStr)
 	if err != nil {
-		handleResponse(c, h.log, "error is while converting limit", http.StatusBadRequest, err.Error())
+		handleResponse(c, h.log, "error is while converting limit", http.StatusBadRequest, err)
 		return
 	}
 
 	fromAmountStr := c.DefaultQuery("from-amount", "0")
 	fromAmount, err = strconv.ParseFloat(fromAmountStr, 64)
 	if err != nil {
-		handleResponse(c, h.log, "error is while converting from amount", http.StatusBadRequest, err.Error())
+		handleResponse(c, h.log, "error is while converting from amount", http.StatusBadRequest, err)
 		return
 	}
 
 	toAmountStr := c.DefaultQuery("to-amount", fmt.Sprintf("%f", math.MaxFloat64))
 	toAmount, err = strconv.ParseFloat(toAmountStr, 64)
 	if err != nil {
-		handleResponse(c, h.log, "error is while converting to amount", http.StatusBadRequest, err.Error())
+		handleResponse(c, h.log, "error is while converting to amount", http.StatusBadRequest, err)
+		return
+	}
+
+	withDeleted, err := includeDeleted(c)
+	if err != nil {
+		handleResponse(c, h.log, "error is while converting include_deleted", http.StatusBadRequest, err)
+		return
+	}
+
+	if format := exportFormat(c); format != "" {
+		h.exportList(c, format, "transactions",
+			[]string{"id", "sale_id", "staff_id", "transaction_type", "source_type", "amount", "description", "created_at", "updated_at"},
+			func(after models.Cursor, limit int) ([][]string, models.Cursor, error) {
+				transactions, err := h.services.Transaction().GetList(c.Request.Context(), models.TransactionGetListRequest{
+					After:          &after,
+					Limit:          limit,
+					FromAmount:     fromAmount,
+					ToAmount:       toAmount,
+					IncludeDeleted: withDeleted,
+				})
+				if err != nil {
+					return nil, after, err
+				}
+
+				rows := make([][]string, 0, len(transactions.Transactions))
+				for _, t := range transactions.Transactions {
+					rows = append(rows, []string{t.ID, t.SaleID, t.StaffID, t.TransactionType, t.SourceType,
+						strconv.FormatFloat(t.Amount, 'f', 2, 64), t.Description, exportTime(t.CreatedAt), exportTime(t.UpdatedAt)})
+					after = models.Cursor{CreatedAt: t.CreatedAt, ID: t.ID}
+				}
+
+				return rows, after, nil
+			})
 		return
 	}
 
-	transactions, err := h.storage.Transaction().GetList(context.Background(), models.TransactionGetListRequest{
-		Page:       page,
-		Limit:      limit,
-		FromAmount: fromAmount,
-		ToAmount:   toAmount,
+	transactions, err := h.services.Transaction().GetList(c.Request.Context(), models.TransactionGetListRequest{
+		Page:           page,
+		Limit:          limit,
+		FromAmount:     fromAmount,
+		ToAmount:       toAmount,
+		IncludeDeleted: withDeleted,
 	})
 
 	if err != nil {
-		handleResponse(c, h.log, "error is while getting list", http.StatusInternalServerError, err.Error())
+		handleResponse(c, h.log, "error is while getting list", http.StatusInternalServerError, err)
 		return
 	}
 
@@ -143,6 +175,7 @@ func (h Handler) GetTransactionList(c *gin.Context) {
 // @Produce      json
 // @Param 		 id path string true "transaction_id"
 // @Param 		 transaction body models.UpdateTransaction false "sale"
+// @Param 		 If-Match header string true "ETag of the entity from GET"
 // @Success      200  {object}  models.Transaction
 // @Failure      400  {object}  models.Response
 // @Failure      404  {object}  models.Response
@@ -152,27 +185,71 @@ func (h Handler) UpdateTransaction(c *gin.Context) {
 
 	trans := models.UpdateTransaction{}
 	if err := c.ShouldBindJSON(&trans); err != nil {
-		handleResponse(c, h.log, "error is while reading body", http.StatusBadRequest, err.Error())
+		handleResponse(c, h.log, "error is while reading body", http.StatusBadRequest, err)
 		return
 	}
 
 	trans.ID = uid
 
-	id, err := h.storage.Transaction().Update(context.Background(), trans)
+	version, err := ifMatch(c)
 	if err != nil {
-		handleResponse(c, h.log, "error is while updating trans", http.StatusInternalServerError, err.Error())
+		handleResponse(c, h.log, "error is while reading If-Match header", http.StatusPreconditionRequired, err)
 		return
 	}
+	trans.Version = version
 
-	updatedTrans, err := h.storage.Transaction().GetByID(context.Background(), id)
+	updatedTrans, err := h.services.Transaction().Update(c.Request.Context(), trans)
 	if err != nil {
-		handleResponse(c, h.log, "error is while getting by id", http.StatusInternalServerError, err.Error())
+		handleResponse(c, h.log, "error is while updating trans", http.StatusInternalServerError, err)
 		return
 	}
 
+	setETag(c, updatedTrans.Version)
+
 	handleResponse(c, h.log, "", http.StatusOK, updatedTrans)
 }
 
+// PatchTransaction godoc
+// @Router       /transaction/{id} [PATCH]
+// @Summary      Patch transaction
+// @Description  change only the given fields of transaction, null clears a nullable field
+// @Tags         transaction
+// @Accept       json
+// @Produce      json
+// @Param 		 id path string true "transaction_id"
+// @Param 		 transaction body models.PatchTransaction false "transaction"
+// @Param 		 If-Match header string true "ETag of the entity from GET"
+// @Success      200  {object}  models.Transaction
+// @Failure      400  {object}  models.Response
+// @Failure      404  {object}  models.Response
+// @Failure      500  {object}  models.Response
+func (h Handler) PatchTransaction(c *gin.Context) {
+	transaction := models.PatchTransaction{}
+	if err := bindPatch(c, &transaction); err != nil {
+		handleResponse(c, h.log, "error is while reading body", http.StatusBadRequest, err)
+		return
+	}
+
+	transaction.ID = c.Param("id")
+
+	version, err := ifMatch(c)
+	if err != nil {
+		handleResponse(c, h.log, "error is while reading If-Match header", http.StatusPreconditionRequired, err)
+		return
+	}
+	transaction.Version = version
+
+	resp, err := h.services.Transaction().Patch(c.Request.Context(), transaction)
+	if err != nil {
+		handleResponse(c, h.log, "error is while patching transaction", http.StatusInternalServerError, err)
+		return
+	}
+
+	setETag(c, resp.Version)
+
+	handleResponse(c, h.log, "", http.StatusOK, resp)
+}
+
 // DeleteTransaction godoc
 // @Router       /transaction/{id} [DELETE]
 // @Summary      Delete transaction
@@ -188,10 +265,34 @@ func (h Handler) UpdateTransaction(c *gin.Context) {
 func (h Handler) DeleteTransaction(c *gin.Context) {
 	uid := c.Param("id")
 
-	if err := h.storage.Transaction().Delete(context.Background(), uid); err != nil {
-		handleResponse(c, h.log, "error is while deleting", http.StatusInternalServerError, err.Error())
+	if err := h.services.Transaction().Delete(c.Request.Context(), uid); err != nil {
+		handleResponse(c, h.log, "error is while deleting", http.StatusInternalServerError, err)
 		return
 	}
 
 	handleResponse(c, h.log, "", http.StatusOK, "transaction deleted!")
 }
+
+// RestoreTransaction godoc
+// @Router       /transaction/{id}/restore [POST]
+// @Summary      Restore transaction
+// @Description  bring back soft deleted transaction
+// @Tags         transaction
+// @Accept       json
+// @Produce      json
+// @Param 		 id path string true "transaction_id"
+// @Success      200  {object}  models.Transaction
+// @Failure      400  {object}  models.Response
+// @Failure      404  {object}  models.Response
+// @Failure      500  {object}  models.Response
+func (h Handler) RestoreTransaction(c *gin.Context) {
+	transaction, err := h.services.Transaction().Restore(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		handleResponse(c, h.log, "error is while restoring transaction", http.StatusInternalServerError, err)
+		return
+	}
+
+	setETag(c, transaction.Version)
+
+	handleResponse(c, h.log, "", http.StatusOK, transaction)
+}
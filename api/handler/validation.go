@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"fmt"
+	"market/api/models"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+// enums are the values of postgres enum types, fields are checked with `binding:"enum=<name>"`.
+var enums = map[string][]string{
+	"payment_type":                {"card", "cash", "points"},
+	"sale_status":                 {"in_process", "success", "cancel"},
+	"transaction_type":            {"withdraw", "topup"},
+	"source_type":                 {"bonus", "sales", "payout"},
+	"tarif_type":                  {"percent", "fixed"},
+	"staff_type":                  {"shop_assistant", "cashier"},
+	"repository_transaction_type": {"minus", "plus"},
+}
+
+var registerOnce sync.Once
+
+// registerValidations adds custom rules to the validator which gin uses for binding,
+// and makes field errors use json names of the fields.
+func registerValidations() {
+	registerOnce.Do(func() {
+		v, ok := binding.Validator.Engine().(*validator.Validate)
+		if !ok {
+			return
+		}
+
+		v.RegisterTagNameFunc(func(field reflect.StructField) string {
+			name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+			if name == "-" || name == "" {
+				return field.Name
+			}
+			return name
+		})
+
+		// uuid replaces the built in rule, which accepts only lower case ids while postgres accepts both
+		_ = v.RegisterValidation("uuid", func(fl validator.FieldLevel) bool {
+			value := fl.Field().String()
+			if len(value) != 36 {
+				return false
+			}
+			_, err := uuid.Parse(value)
+			return err == nil
+		})
+
+		_ = v.RegisterValidation("enum", func(fl validator.FieldLevel) bool {
+			for _, value := range enums[fl.Param()] {
+				if fl.Field().String() == value {
+					return true
+				}
+			}
+			return false
+		})
+	})
+}
+
+func fieldErrors(validationErrors validator.ValidationErrors) []models.FieldError {
+	fields := make([]models.FieldError, 0, len(validationErrors))
+
+	for _, fe := range validationErrors {
+		fields = append(fields, models.FieldError{
+			Field:   fe.Field(),
+			Rule:    fe.Tag(),
+			Message: fieldMessage(fe),
+		})
+	}
+
+	return fields
+}
+
+func fieldMessage(fe validator.FieldError) string {
+	unit := ""
+	if fe.Kind() == reflect.String {
+		unit = " characters"
+	}
+
+	switch fe.Tag() {
+	case "required":
+		return fmt.Sprintf("%s is required", fe.Field())
+	case "uuid":
+		return fmt.Sprintf("%s should be a valid uuid", fe.Field())
+	case "enum":
+		return fmt.Sprintf("%s should be one of: %s", fe.Field(), strings.Join(enums[fe.Param()], ", "))
+	case "datetime":
+		return fmt.Sprintf("%s should be in %s format", fe.Field(), fe.Param())
+	case "min":
+		return fmt.Sprintf("%s should be at least %s%s", fe.Field(), fe.Param(), unit)
+	case "max":
+		return fmt.Sprintf("%s should be at most %s%s", fe.Field(), fe.Param(), unit)
+	case "gt":
+		return fmt.Sprintf("%s should be greater than %s", fe.Field(), fe.Param())
+	case "gte":
+		return fmt.Sprintf("%s should be %s or greater", fe.Field(), fe.Param())
+	}
+
+	return fmt.Sprintf("%s is invalid (%s)", fe.Field(), fe.Tag())
+}
@@ -0,0 +1,263 @@
+package handler
+
+import (
+	"market/api/models"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateWebhook godoc
+// @Router       /webhook [POST]
+// @Summary      Create a new webhook
+// @Description  subscribe url to sale.completed, sale.cancelled, stock.changed or staff.balance_changed events.
+// @Description  Events are posted as models.WebhookPayload with X-Webhook-Signature header, which is
+// @Description  sha256=<hex HMAC-SHA256 of "<X-Webhook-Timestamp>.<body>" with the secret>.
+// @Tags         webhook
+// @Accept       json
+// @Produce      json
+// @Param 		 webhook body models.CreateWebhook false "webhook"
+// @Success      201  {object}  models.Webhook
+// @Failure      400  {object}  models.Response
+// @Failure      422  {object}  models.Response
+// @Failure      500  {object}  models.Response
+func (h Handler) CreateWebhook(c *gin.Context) {
+	webhook := models.CreateWebhook{}
+	if err := c.ShouldBindJSON(&webhook); err != nil {
+		handleResponse(c, h.log, "error is while reading body", http.StatusBadRequest, err)
+		return
+	}
+
+	createdWebhook, err := h.services.Webhook().Create(c.Request.Context(), webhook)
+	if err != nil {
+		handleResponse(c, h.log, "error is while creating webhook", http.StatusInternalServerError, err)
+		return
+	}
+
+	handleResponse(c, h.log, "", http.StatusCreated, createdWebhook)
+}
+
+// GetWebhook godoc
+// @Router       /webhook/{id} [GET]
+// @Summary      Get webhook by id
+// @Description  get webhook by id, its secret is not returned
+// @Tags         webhook
+// @Accept       json
+// @Produce      json
+// @Param 		 id path string true "webhook_id"
+// @Success      200  {object}  models.Webhook
+// @Failure      400  {object}  models.Response
+// @Failure      404  {object}  models.Response
+// @Failure      500  {object}  models.Response
+func (h Handler) GetWebhook(c *gin.Context) {
+	webhook, err := h.services.Webhook().Get(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		handleResponse(c, h.log, "error is while getting webhook by id", http.StatusInternalServerError, err)
+		return
+	}
+
+	setETag(c, webhook.Version)
+
+	handleResponse(c, h.log, "", http.StatusOK, webhook)
+}
+
+// GetWebhookList godoc
+// @Router       /webhooks [GET]
+// @Summary      Get webhook list
+// @Description  get webhook list
+// @Tags         webhook
+// @Accept       json
+// @Produce      json
+// @Param 		 page query string false "page"
+// @Param 		 limit query string false "limit"
+// @Param 		 search query string false "search by url"
+// @Param 		 include_deleted query bool false "list soft deleted rows too"
+// @Success      200  {object}  models.WebhooksResponse
+// @Failure      400  {object}  models.Response
+// @Failure      404  {object}  models.Response
+// @Failure      500  {object}  models.Response
+func (h Handler) GetWebhookList(c *gin.Context) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil {
+		handleResponse(c, h.log, "error is while converting page", http.StatusBadRequest, err)
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil {
+		handleResponse(c, h.log, "error is while converting limit", http.StatusBadRequest, err)
+		return
+	}
+
+	withDeleted, err := includeDeleted(c)
+	if err != nil {
+		handleResponse(c, h.log, "error is while converting include_deleted", http.StatusBadRequest, err)
+		return
+	}
+
+	webhooks, err := h.services.Webhook().GetList(c.Request.Context(), models.GetListRequest{
+		Page:           page,
+		Limit:          limit,
+		Search:         c.Query("search"),
+		IncludeDeleted: withDeleted,
+	})
+	if err != nil {
+		handleResponse(c, h.log, "error is while getting webhook list", http.StatusInternalServerError, err)
+		return
+	}
+
+	handleResponse(c, h.log, "", http.StatusOK, webhooks)
+}
+
+// UpdateWebhook godoc
+// @Router       /webhook/{id} [PUT]
+// @Summary      Update webhook
+// @Description  update webhook, empty secret keeps the current one
+// @Tags         webhook
+// @Accept       json
+// @Produce      json
+// @Param 		 id path string true "webhook_id"
+// @Param 		 webhook body models.UpdateWebhook false "webhook"
+// @Param 		 If-Match header string true "ETag of the entity from GET"
+// @Success      200  {object}  models.Webhook
+// @Failure      400  {object}  models.Response
+// @Failure      404  {object}  models.Response
+// @Failure      409  {object}  models.Response
+// @Failure      428  {object}  models.Response
+// @Failure      500  {object}  models.Response
+func (h Handler) UpdateWebhook(c *gin.Context) {
+	webhook := models.UpdateWebhook{}
+	if err := c.ShouldBindJSON(&webhook); err != nil {
+		handleResponse(c, h.log, "error is while reading body", http.StatusBadRequest, err)
+		return
+	}
+
+	webhook.ID = c.Param("id")
+
+	version, err := ifMatch(c)
+	if err != nil {
+		handleResponse(c, h.log, "error is while reading If-Match header", http.StatusPreconditionRequired, err)
+		return
+	}
+	webhook.Version = version
+
+	updatedWebhook, err := h.services.Webhook().Update(c.Request.Context(), webhook)
+	if err != nil {
+		handleResponse(c, h.log, "error is while updating webhook", http.StatusInternalServerError, err)
+		return
+	}
+
+	setETag(c, updatedWebhook.Version)
+
+	handleResponse(c, h.log, "", http.StatusOK, updatedWebhook)
+}
+
+// DeleteWebhook godoc
+// @Router       /webhook/{id} [DELETE]
+// @Summary      Delete webhook
+// @Description  delete webhook, its pending deliveries are not sent
+// @Tags         webhook
+// @Accept       json
+// @Produce      json
+// @Param 		 id path string true "webhook_id"
+// @Success      200  {object}  models.Response
+// @Failure      400  {object}  models.Response
+// @Failure      404  {object}  models.Response
+// @Failure      500  {object}  models.Response
+func (h Handler) DeleteWebhook(c *gin.Context) {
+	if err := h.services.Webhook().Delete(c.Request.Context(), c.Param("id")); err != nil {
+		handleResponse(c, h.log, "error is while deleting webhook", http.StatusInternalServerError, err)
+		return
+	}
+
+	handleResponse(c, h.log, "", http.StatusOK, "webhook deleted!")
+}
+
+// RestoreWebhook godoc
+// @Router       /webhook/{id}/restore [POST]
+// @Summary      Restore webhook
+// @Description  bring back soft deleted webhook
+// @Tags         webhook
+// @Accept       json
+// @Produce      json
+// @Param 		 id path string true "webhook_id"
+// @Success      200  {object}  models.Webhook
+// @Failure      400  {object}  models.Response
+// @Failure      404  {object}  models.Response
+// @Failure      500  {object}  models.Response
+func (h Handler) RestoreWebhook(c *gin.Context) {
+	webhook, err := h.services.Webhook().Restore(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		handleResponse(c, h.log, "error is while restoring webhook", http.StatusInternalServerError, err)
+		return
+	}
+
+	setETag(c, webhook.Version)
+
+	handleResponse(c, h.log, "", http.StatusOK, webhook)
+}
+
+// TestWebhook godoc
+// @Router       /webhook/{id}/test [POST]
+// @Summary      Test webhook
+// @Description  send webhook.ping event to the webhook right away and return how the delivery went
+// @Tags         webhook
+// @Accept       json
+// @Produce      json
+// @Param 		 id path string true "webhook_id"
+// @Success      200  {object}  models.WebhookDelivery
+// @Failure      400  {object}  models.Response
+// @Failure      404  {object}  models.Response
+// @Failure      500  {object}  models.Response
+func (h Handler) TestWebhook(c *gin.Context) {
+	delivery, err := h.services.Webhook().Test(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		handleResponse(c, h.log, "error is while testing webhook", http.StatusInternalServerError, err)
+		return
+	}
+
+	handleResponse(c, h.log, "", http.StatusOK, delivery)
+}
+
+// GetWebhookDeliveries godoc
+// @Router       /webhook/{id}/deliveries [GET]
+// @Summary      Get delivery log of webhook
+// @Description  get events sent to the webhook and how they went, newest first
+// @Tags         webhook
+// @Accept       json
+// @Produce      json
+// @Param 		 id path string true "webhook_id"
+// @Param 		 page query string false "page"
+// @Param 		 limit query string false "limit"
+// @Param 		 status query string false "pending, delivered or failed"
+// @Success      200  {object}  models.WebhookDeliveriesResponse
+// @Failure      400  {object}  models.Response
+// @Failure      404  {object}  models.Response
+// @Failure      500  {object}  models.Response
+func (h Handler) GetWebhookDeliveries(c *gin.Context) {
+	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil {
+		handleResponse(c, h.log, "error is while converting page", http.StatusBadRequest, err)
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil {
+		handleResponse(c, h.log, "error is while converting limit", http.StatusBadRequest, err)
+		return
+	}
+
+	deliveries, err := h.services.Webhook().Deliveries(c.Request.Context(), models.WebhookDeliveryListRequest{
+		Page:      page,
+		Limit:     limit,
+		WebhookID: c.Param("id"),
+		Status:    c.Query("status"),
+	})
+	if err != nil {
+		handleResponse(c, h.log, "error is while getting webhook deliveries", http.StatusInternalServerError, err)
+		return
+	}
+
+	handleResponse(c, h.log, "", http.StatusOK, deliveries)
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Actions of audit log records.
+const (
+	AuditCreate  = "create"
+	AuditUpdate  = "update"
+	AuditDelete  = "delete"
+	AuditRestore = "restore"
+	AuditPurge   = "purge"
+)
+
+// AuditLog is one change of an entity. Before is empty for creates and restores,
+// After is empty for deletes. ActorID is empty when the request had no X-Staff-ID header
+// and for changes made by background jobs, like purges.
+type AuditLog struct {
+	ID        string          `json:"id"`
+	ActorID   string          `json:"actor_id"`
+	Action    string          `json:"action"`
+	Entity    string          `json:"entity"`
+	EntityID  string          `json:"entity_id"`
+	Before    json.RawMessage `json:"before" swaggertype:"object"`
+	After     json.RawMessage `json:"after" swaggertype:"object"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+type CreateAuditLog struct {
+	ActorID  string
+	Action   string
+	Entity   string
+	EntityID string
+	Before   json.RawMessage
+	After    json.RawMessage
+}
+
+// AuditListRequest filters audit log by the given fields, empty fields match everything.
+type AuditListRequest struct {
+	Page     int
+	Limit    int
+	Entity   string
+	EntityID string
+	ActorID  string
+}
+
+type AuditLogsResponse struct {
+	AuditLogs []AuditLog `json:"audit_logs"`
+	Count     int        `json:"count"`
+}
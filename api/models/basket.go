@@ -7,23 +7,36 @@ type Basket struct {
 	ProductID  string     `json:"product_id"`
 	Quantity   int        `json:"quantity"`
 	Price      int        `json:"price"`
+	Version    int        `json:"version"`
 	CreatedAt  string  	  `json:"created_at"`
 	UpdatedAt  string	  `json:"updated_at"`
+	DeletedAt  int        `json:"deleted_at,omitempty"`
 }
 
 type CreateBasket struct {
-	SaleID     string     `json:"sale_id"`
-	ProductID  string     `json:"product_id"`
-	Quantity   int        `json:"quantity"`
+	SaleID     string     `json:"sale_id" binding:"required,uuid"`
+	ProductID  string     `json:"product_id" binding:"required,uuid"`
+	Quantity   int        `json:"quantity" binding:"gt=0"`
 	Price      int        `json:"-"`
 }
 
 type UpdateBasket struct {
 	ID         string     `json:"id"`
-	SaleID     string     `json:"sale_id"`
-	ProductID  string     `json:"product_id"`
-	Quantity   int        `json:"quantity"`
-	Price      int        `json:"price"`
+	SaleID     string     `json:"sale_id" binding:"required,uuid"`
+	ProductID  string     `json:"product_id" binding:"required,uuid"`
+	Quantity   int        `json:"quantity" binding:"gt=0"`
+	Price      int        `json:"price" binding:"gte=0"`
+	Version    int        `json:"-"`
+}
+
+// PatchBasket changes only the given fields, null clears a nullable field.
+type PatchBasket struct {
+	ID        string  `json:"-"`
+	SaleID    *string `json:"sale_id" binding:"uuid"`
+	ProductID *string `json:"product_id" binding:"uuid"`
+	Quantity  *int    `json:"quantity" binding:"gt=0"`
+	Price     *int    `json:"price" binding:"gte=0"`
+	Version   int     `json:"-"`
 }
 
 type BasketsResponse struct {
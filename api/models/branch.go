@@ -8,19 +8,30 @@ type Branch struct {
 	ID        string    `json:"id"`
 	Name      string    `json:"name"`
 	Address   string    `json:"address"`
+	Version   int       `json:"version"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+	DeletedAt int       `json:"deleted_at,omitempty"`
 }
 
 type CreateBranch struct {
-	Name    string `json:"name"`
-	Address string `json:"address"`
+	Name    string `json:"name" binding:"required,max=30"`
+	Address string `json:"address" binding:"max=100"`
 }
 
 type UpdateBranch struct {
 	ID        string    `json:"-"`
-	Name      string    `json:"name"`
-	Address   string    `json:"address"`
+	Name      string    `json:"name" binding:"required,max=30"`
+	Address   string    `json:"address" binding:"max=100"`
+	Version   int       `json:"-"`
+}
+
+// PatchBranch changes only the given fields, null clears a nullable field.
+type PatchBranch struct {
+	ID      string  `json:"-"`
+	Name    *string `json:"name" binding:"min=1,max=30"`
+	Address *string `json:"address" binding:"omitempty,max=100"`
+	Version int     `json:"-"`
 }
 
 type BranchResponse struct {
@@ -6,19 +6,30 @@ type Category struct {
 	ID        string    `json:"id"`
 	Name      string    `json:"name"`
 	ParentID  string    `json:"parent_id"`
+	Version   int       `json:"version"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+	DeletedAt int       `json:"deleted_at,omitempty"`
 }
 
 type CreateCategory struct {
-	Name     string `json:"name"`
-	ParentID string `json:"parent_id"`
+	Name     string `json:"name" binding:"required,max=30"`
+	ParentID string `json:"parent_id" binding:"omitempty,uuid"`
 }
 
 type UpdateCategory struct {
 	ID        string    `json:"-"`
-	Name      string    `json:"name"`
-	ParentID  string    `json:"parent_id"`
+	Name      string    `json:"name" binding:"required,max=30"`
+	ParentID  string    `json:"parent_id" binding:"omitempty,uuid"`
+	Version   int       `json:"-"`
+}
+
+// PatchCategory changes only the given fields, null clears a nullable field.
+type PatchCategory struct {
+	ID       string  `json:"-"`
+	Name     *string `json:"name" binding:"min=1,max=30"`
+	ParentID *string `json:"parent_id" binding:"omitempty,uuid"`
+	Version  int     `json:"-"`
 }
 
 type CategoryResponse struct {
@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 type PrimaryKey struct {
 	ID string `json:"id"`
 }
@@ -8,4 +10,19 @@ type GetListRequest struct {
 	Page   int
 	Limit  int
 	Search string
-}
\ No newline at end of file
+
+	// IncludeDeleted lists soft deleted rows too, they have deleted_at set.
+	IncludeDeleted bool
+
+	// After switches the list to keyset paging, see Cursor. Page is not used then.
+	After *Cursor
+}
+
+// Cursor is where a list read with keyset paging goes on. Such lists are ordered newest
+// first by created_at and id, and a page starts right after the row of the cursor, or
+// at the newest row when the cursor is zero. Unlike offsets it does not skip or repeat
+// rows which are added or deleted while the pages are read.
+type Cursor struct {
+	CreatedAt time.Time
+	ID        string
+}
@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+type Customer struct {
+	ID        string    `json:"id"`
+	Phone     string    `json:"phone"`
+	Name      string    `json:"name"`
+	BranchID  string    `json:"branch_id"`
+	Points    int       `json:"points"`
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	DeletedAt int       `json:"deleted_at,omitempty"`
+}
+
+type CreateCustomer struct {
+	Phone    string `json:"phone" binding:"required,max=20"`
+	Name     string `json:"name" binding:"max=30"`
+	BranchID string `json:"branch_id" binding:"required,uuid"`
+}
+
+type UpdateCustomer struct {
+	ID      string `json:"-"`
+	Phone   string `json:"phone" binding:"required,max=20"`
+	Name    string `json:"name" binding:"max=30"`
+	Version int    `json:"-"`
+}
+
+// PatchCustomer changes only the given fields, null clears a nullable field.
+type PatchCustomer struct {
+	ID      string  `json:"-"`
+	Phone   *string `json:"phone" binding:"min=1,max=20"`
+	Name    *string `json:"name" binding:"omitempty,max=30"`
+	Version int     `json:"-"`
+}
+
+type CustomersResponse struct {
+	Customers []Customer `json:"customers"`
+	Count     int        `json:"count"`
+}
+
+type CustomerSale struct {
+	Sale    Sale     `json:"sale"`
+	Baskets []Basket `json:"baskets"`
+}
+
+type CustomerHistory struct {
+	Customer Customer       `json:"customer"`
+	Sales    []CustomerSale `json:"sales"`
+	Count    int            `json:"count"`
+}
@@ -0,0 +1,60 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Types of domain events.
+const (
+	EventSaleCompleted       = "sale.completed"
+	EventSaleCancelled       = "sale.cancelled"
+	EventStockChanged        = "stock.changed"
+	EventStaffBalanceChanged = "staff.balance_changed"
+)
+
+// Event is a domain event from the outbox, Payload is the json of one of the event payloads below.
+// AggregateID is the id of the sale, repository or staff the event is about, DeliveredTo
+// are the receivers which got the event already.
+type Event struct {
+	ID          string          `json:"id"`
+	Type        string          `json:"type"`
+	AggregateID string          `json:"aggregate_id"`
+	Payload     json.RawMessage `json:"payload" swaggertype:"object"`
+	Attempts    int             `json:"-"`
+	DeliveredTo []string        `json:"-"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+type CreateEvent struct {
+	Type        string
+	AggregateID string
+	Payload     json.RawMessage
+}
+
+// SaleEvent is the payload of sale.completed and sale.cancelled.
+type SaleEvent struct {
+	SaleID      string  `json:"sale_id"`
+	BranchID    string  `json:"branch_id"`
+	CashierID   string  `json:"cashier_id"`
+	CustomerID  string  `json:"customer_id"`
+	PaymentType string  `json:"payment_type"`
+	Price       float32 `json:"price"`
+	Status      string  `json:"status"`
+}
+
+// StockChangedEvent is the payload of stock.changed.
+type StockChangedEvent struct {
+	RepositoryID string `json:"repository_id"`
+	ProductID    string `json:"product_id"`
+	BranchID     string `json:"branch_id"`
+	CountBefore  int    `json:"count_before"`
+	CountAfter   int    `json:"count_after"`
+}
+
+// StaffBalanceChangedEvent is the payload of staff.balance_changed.
+type StaffBalanceChangedEvent struct {
+	StaffID       string `json:"staff_id"`
+	BalanceBefore int    `json:"balance_before"`
+	BalanceAfter  int    `json:"balance_after"`
+}
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// IdempotentResponse is the stored response of the first request with Idempotency-Key.
+// StatusCode is 0 while the first request is still being handled, since ReservedAt.
+type IdempotentResponse struct {
+	Key         string
+	Endpoint    string
+	RequestHash string
+	StatusCode  int
+	ContentType string
+	Body        []byte
+	ReservedAt  time.Time
+	CreatedAt   time.Time
+}
@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+type Payout struct {
+	ID         string       `json:"id"`
+	BranchID   string       `json:"branch_id"`
+	PeriodFrom string       `json:"period_from"`
+	PeriodTo   string       `json:"period_to"`
+	Total      float64      `json:"total"`
+	Items      []PayoutItem `json:"items"`
+	CreatedAt  time.Time    `json:"created_at"`
+}
+
+type PayoutItem struct {
+	ID            string  `json:"id"`
+	PayoutID      string  `json:"payout_id"`
+	StaffID       string  `json:"staff_id"`
+	StaffName     string  `json:"staff_name"`
+	Earned        float64 `json:"earned"`
+	BalanceBefore int     `json:"balance_before"`
+	Paid          int     `json:"paid"`
+	TransactionID string  `json:"transaction_id"`
+}
+
+type CreatePayout struct {
+	BranchID   string `json:"branch_id" binding:"required,uuid"`
+	PeriodFrom string `json:"period_from" binding:"required,datetime=2006-01-02"`
+	PeriodTo   string `json:"period_to" binding:"required,datetime=2006-01-02"`
+}
+
+type PayoutsResponse struct {
+	Payouts []Payout `json:"payouts"`
+	Count   int      `json:"count"`
+}
+
+// PayrollStatement is what one staff member earned and got paid in a payout run.
+type PayrollStatement struct {
+	Payout       Payout        `json:"payout"`
+	Item         PayoutItem    `json:"item"`
+	Transactions []Transaction `json:"transactions"`
+}
@@ -6,22 +6,35 @@ type Product struct {
 	Price      int       `json:"price"`
 	Barcode    int       `json:"barcode"`
 	CategoryID string    `json:"category_id"`
+	Version    int       `json:"version"`
 	CreatedAt  string    `json:"created_at"`
 	UpdatedAt  string	 `json:"updated_at"`
+	DeletedAt  int       `json:"deleted_at,omitempty"`
 }
 
 type CreateProduct struct {
-	Name       string `json:"name"`
-	Price      int    `json:"price"`
-	Barcode    int    `json:"barcode"`
-	CategoryID string `json:"category_id"`
+	Name       string `json:"name" binding:"required,max=30"`
+	Price      int    `json:"price" binding:"gt=0"`
+	Barcode    int    `json:"barcode" binding:"gt=0"`
+	CategoryID string `json:"category_id" binding:"required,uuid"`
 }
 
 type UpdateProduct struct {
 	ID         string    `json:"-"`
-	Name       string    `json:"name"`
-	Price      int       `json:"price"`
-	CategoryID string    `json:"category_id"`
+	Name       string    `json:"name" binding:"required,max=30"`
+	Price      int       `json:"price" binding:"gt=0"`
+	CategoryID string    `json:"category_id" binding:"required,uuid"`
+	Version    int       `json:"-"`
+}
+
+// PatchProduct changes only the given fields, null clears a nullable field.
+type PatchProduct struct {
+	ID         string  `json:"-"`
+	Name       *string `json:"name" binding:"min=1,max=30"`
+	Price      *int    `json:"price" binding:"gt=0"`
+	Barcode    *int    `json:"barcode" binding:"gt=0"`
+	CategoryID *string `json:"category_id" binding:"uuid"`
+	Version    int     `json:"-"`
 }
 
 type ProductResponse struct {
@@ -34,4 +47,38 @@ type ProductGetListRequest struct {
 	Limit   int    `json:"limit"`
 	Name    string `json:"name"`
 	Barcode int    `json:"barcode"`
+
+	IncludeDeleted bool `json:"include_deleted"`
+
+	// After switches the list to keyset paging, see Cursor. Page is not used then.
+	After *Cursor `json:"-"`
+}
+
+// ImportProduct is a product read from the import file, ID and the ids of its stocks are
+// set when it is inserted.
+type ImportProduct struct {
+	ID         string        `json:"id"`
+	Row        int           `json:"row"`
+	Name       string        `json:"name"`
+	Price      int           `json:"price"`
+	Barcode    int           `json:"barcode"`
+	CategoryID string        `json:"category_id"`
+	Stocks     []ImportStock `json:"stocks"`
+}
+
+type ImportStock struct {
+	ID       string `json:"id"`
+	BranchID string `json:"branch_id"`
+	Count    int    `json:"count"`
+}
+
+type ProductImportError struct {
+	Row     int    `json:"row"`
+	Column  string `json:"column"`
+	Message string `json:"message"`
+}
+
+type ProductImportResult struct {
+	Imported int                  `json:"imported"`
+	Errors   []ProductImportError `json:"errors"`
 }
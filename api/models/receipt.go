@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+type Receipt struct {
+	SaleID        string        `json:"sale_id"`
+	BranchName    string        `json:"branch_name"`
+	BranchAddress string        `json:"branch_address"`
+	CashierName   string        `json:"cashier_name"`
+	ClientName    string        `json:"client_name"`
+	PaymentType   string        `json:"payment_type"`
+	Status        string        `json:"status"`
+	Lines         []ReceiptLine `json:"lines"`
+	Total         float64       `json:"total"`
+	CreatedAt     time.Time     `json:"created_at"`
+}
+
+type ReceiptLine struct {
+	ProductName string `json:"product_name"`
+	Quantity    int    `json:"quantity"`
+	UnitPrice   int    `json:"unit_price"`
+	Price       int    `json:"price"`
+}
@@ -0,0 +1,54 @@
+package models
+
+type ReportRequest struct {
+	BranchID string `json:"branch_id"`
+	From     string `json:"from"`
+	To       string `json:"to"`
+	Group    string `json:"group"`
+	OrderBy  string `json:"order_by"`
+	Limit    int    `json:"limit"`
+}
+
+type RevenueReport struct {
+	Period     string  `json:"period"`
+	BranchID   string  `json:"branch_id"`
+	BranchName string  `json:"branch_name"`
+	SalesCount int     `json:"sales_count"`
+	Revenue    float64 `json:"revenue"`
+}
+
+type ProductReport struct {
+	ProductID string  `json:"product_id"`
+	Name      string  `json:"name"`
+	Quantity  int     `json:"quantity"`
+	Revenue   float64 `json:"revenue"`
+}
+
+type StaffSalesReport struct {
+	StaffID    string  `json:"staff_id"`
+	Name       string  `json:"name"`
+	Role       string  `json:"role"`
+	SalesCount int     `json:"sales_count"`
+	Total      float64 `json:"total"`
+}
+
+type PaymentTypeReport struct {
+	PaymentType string  `json:"payment_type"`
+	SalesCount  int     `json:"sales_count"`
+	Total       float64 `json:"total"`
+}
+
+type StaffStats struct {
+	StaffID            string  `json:"staff_id"`
+	Name               string  `json:"name"`
+	From               string  `json:"from"`
+	To                 string  `json:"to"`
+	SalesAsAssistant   int     `json:"sales_as_shop_assistant"`
+	SalesAsCashier     int     `json:"sales_as_cashier"`
+	Revenue            float64 `json:"revenue"`
+	Commissions        float64 `json:"commissions"`
+	CancelledSales     int     `json:"cancelled_sales"`
+	CancellationRate   float64 `json:"cancellation_rate"`
+	AverageBasketSize  float64 `json:"average_basket_size"`
+	AverageSaleRevenue float64 `json:"average_sale_revenue"`
+}
@@ -7,21 +7,33 @@ type Repository struct {
 	ProductID  string    `json:"product_id"`
 	BranchID   string    `json:"branch_id"`
 	Count      int       `json:"count"`
+	Version    int       `json:"version"`
 	CreatedAt  time.Time `json:"created_at"`
 	UpdatedAt  time.Time `json:"updated_at"`
+	DeletedAt  int       `json:"deleted_at,omitempty"`
 }
 
 type CreateRepository struct {
-	ProductID string `json:"paroduct_id"`
-	BranchID  string `json:"branch_id"`
-	Count     int    `json:"count"`
+	ProductID string `json:"paroduct_id" binding:"required,uuid"`
+	BranchID  string `json:"branch_id" binding:"required,uuid"`
+	Count     int    `json:"count" binding:"gte=0"`
 }
 
 type UpdateRepository struct {
 	ID        string `json:"id"`
-	ProductID string `json:"paroduct_id"`
-	BranchID  string `json:"branch_id"`
-	Count     int    `json:"count"`
+	ProductID string `json:"paroduct_id" binding:"required,uuid"`
+	BranchID  string `json:"branch_id" binding:"required,uuid"`
+	Count     int    `json:"count" binding:"gte=0"`
+	Version   int    `json:"-"`
+}
+
+// PatchRepository changes only the given fields, null clears a nullable field.
+type PatchRepository struct {
+	ID        string  `json:"-"`
+	ProductID *string `json:"product_id" binding:"uuid"`
+	BranchID  *string `json:"branch_id" binding:"uuid"`
+	Count     *int    `json:"count" binding:"gte=0"`
+	Version   int     `json:"-"`
 }
 
 type RepositoriesResponse struct {
@@ -9,25 +9,39 @@ type RepositoryTransaction struct {
 	RepositoryTransactionType string     `json:"repository_transaction_type"`
 	Price 					  int        `json:"price"`
 	Quantity 				  int        `json:"quantity"`
+	Version				  int        `json:"version"`
 	CreatedAt				  time.Time  `json:"created_at"`
 	UpdatedAt				  time.Time  `json:"updated_at"`
+	DeletedAt				  int        `json:"deleted_at,omitempty"`
 }
 
 type CreateRepositoryTransaction struct {
-	StaffID  				  string     `json:"staff_id"`
-	ProductID 				  string     `json:"product_id"`
-	RepositoryTransactionType string     `json:"repository_transaction_type"`
-	Price 					  int        `json:"price"`
-	Quantity 				  int        `json:"quantity"`
+	StaffID  				  string     `json:"staff_id" binding:"required,uuid"`
+	ProductID 				  string     `json:"product_id" binding:"required,uuid"`
+	RepositoryTransactionType string     `json:"repository_transaction_type" binding:"required,enum=repository_transaction_type"`
+	Price 					  int        `json:"price" binding:"gte=0"`
+	Quantity 				  int        `json:"quantity" binding:"gt=0"`
 }
 
 type UpdateRepositoryTransaction struct {
 	ID 						  string     `json:"id"`
-	StaffID  				  string     `json:"staff_id"`
-	ProductID 				  string     `json:"product_id"`
-	RepositoryTransactionType string     `json:"repository_transaction_type"`
-	Price 					  int        `json:"price"`
-	Quantity 				  int        `json:"quantity"`
+	StaffID  				  string     `json:"staff_id" binding:"required,uuid"`
+	ProductID 				  string     `json:"product_id" binding:"required,uuid"`
+	RepositoryTransactionType string     `json:"repository_transaction_type" binding:"required,enum=repository_transaction_type"`
+	Price 					  int        `json:"price" binding:"gte=0"`
+	Quantity 				  int        `json:"quantity" binding:"gt=0"`
+	Version 				  int        `json:"-"`
+}
+
+// PatchRepositoryTransaction changes only the given fields, null clears a nullable field.
+type PatchRepositoryTransaction struct {
+	ID                        string  `json:"-"`
+	StaffID                   *string `json:"staff_id" binding:"uuid"`
+	ProductID                 *string `json:"product_id" binding:"uuid"`
+	RepositoryTransactionType *string `json:"repository_transaction_type" binding:"enum=repository_transaction_type"`
+	Price                     *int    `json:"price" binding:"gte=0"`
+	Quantity                  *int    `json:"quantity" binding:"gt=0"`
+	Version                   int     `json:"-"`
 }
 
 type RepositoryTransactionsResponse struct {
@@ -3,5 +3,13 @@ package models
 type Response struct {
 	StatusCode  int
 	Description string
+	Code        string
 	Data        interface{}
 }
+
+// FieldError is returned in Response.Data for every invalid field of request body.
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
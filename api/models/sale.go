@@ -11,25 +11,48 @@ type Sale struct {
 	Price           float32   `json:"price"`
 	Status          string    `json:"status"`
 	ClientName      string    `json:"client_name"`
+	CustomerID      string    `json:"customer_id"`
+	ShiftID         string    `json:"shift_id"`
+	Version         int       `json:"version"`
 	CreatedAt       time.Time `json:"created_at"`
 	UpdatedAt       time.Time `json:"updated_at"`
+	DeletedAt       int       `json:"deleted_at,omitempty"`
 }
 
 type CreateSale struct {
-	BranchID        string  `json:"branch_id"`
-	ShopAssistantID string  `json:"shop_assistant_id"`
-	CashierID       string  `json:"cashier_id"`
-	PaymentType     string  `json:"payment_type"`
-	ClientName      string  `json:"client_name"`
+	BranchID        string  `json:"branch_id" binding:"required,uuid"`
+	ShopAssistantID string  `json:"shop_assistant_id" binding:"omitempty,uuid"`
+	CashierID       string  `json:"cashier_id" binding:"omitempty,uuid"`
+	PaymentType     string  `json:"payment_type" binding:"omitempty,enum=payment_type"`
+	ClientName      string  `json:"client_name" binding:"max=30"`
+	CustomerID      string  `json:"customer_id" binding:"omitempty,uuid"`
+	ShiftID         string  `json:"-"`
 }
 
 type UpdateSale struct {
 	ID              string    `json:"-"`
-	ShopAssistantID string    `json:"shop_assistant_id"`
-	CashierID       string    `json:"cashier_id"`
-	PaymentType     string    `json:"payment_type"`
+	ShopAssistantID string    `json:"shop_assistant_id" binding:"omitempty,uuid"`
+	CashierID       string    `json:"cashier_id" binding:"omitempty,uuid"`
+	PaymentType     string    `json:"payment_type" binding:"omitempty,enum=payment_type"`
 	Price           float32   `json:"-"`
-	Status          string    `json:"status"`
+	Status          string    `json:"status" binding:"required,enum=sale_status"`
+	ShiftID         string    `json:"-"`
+	Version         int       `json:"-"`
+}
+
+// PatchSale changes only the given fields of in process sale, null clears a nullable field.
+// Price and status are changed only by checkout.
+type PatchSale struct {
+	ID              string   `json:"-"`
+	ShopAssistantID *string  `json:"shop_assistant_id" binding:"omitempty,uuid"`
+	CashierID       *string  `json:"cashier_id" binding:"omitempty,uuid"`
+	PaymentType     *string  `json:"payment_type" binding:"enum=payment_type"`
+	ClientName      *string  `json:"client_name" binding:"omitempty,max=30"`
+	CustomerID      *string  `json:"customer_id" binding:"omitempty,uuid"`
+	Price           *float32 `json:"-"`
+	Status          *string  `json:"-"`
+	ShiftID         *string  `json:"-"`
+	Version         int      `json:"-"`
 }
 
 type SaleResponse struct {
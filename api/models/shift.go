@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+type Shift struct {
+	ID           string    `json:"id"`
+	BranchID     string    `json:"branch_id"`
+	CashierID    string    `json:"cashier_id"`
+	Status       string    `json:"status"`
+	OpeningCash  float64   `json:"opening_cash"`
+	ExpectedCash float64   `json:"expected_cash"`
+	CountedCash  float64   `json:"counted_cash"`
+	OpenedAt     time.Time `json:"opened_at"`
+	ClosedAt     time.Time `json:"closed_at"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+type OpenShift struct {
+	BranchID    string  `json:"branch_id" binding:"required,uuid"`
+	CashierID   string  `json:"cashier_id" binding:"required,uuid"`
+	OpeningCash float64 `json:"opening_cash" binding:"gte=0"`
+}
+
+type CloseShift struct {
+	ID           string  `json:"-"`
+	CountedCash  float64 `json:"counted_cash" binding:"gte=0"`
+	ExpectedCash float64 `json:"-"`
+}
+
+type ShiftsResponse struct {
+	Shifts []Shift `json:"shifts"`
+	Count  int     `json:"count"`
+}
+
+// ZReport is the end of shift summary of sales made by the cashier
+// between opening and closing of the shift.
+type ZReport struct {
+	ShiftID        string    `json:"shift_id"`
+	BranchID       string    `json:"branch_id"`
+	CashierID      string    `json:"cashier_id"`
+	OpenedAt       time.Time `json:"opened_at"`
+	ClosedAt       time.Time `json:"closed_at"`
+	SalesCount     int       `json:"sales_count"`
+	CashTotal      float64   `json:"cash_total"`
+	CardTotal      float64   `json:"card_total"`
+	Total          float64   `json:"total"`
+	CancelledCount int       `json:"cancelled_count"`
+	CancelledTotal float64   `json:"cancelled_total"`
+	OpeningCash    float64   `json:"opening_cash"`
+	ExpectedCash   float64   `json:"expected_cash"`
+	CountedCash    float64   `json:"counted_cash"`
+	Difference     float64   `json:"difference"`
+}
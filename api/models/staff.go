@@ -13,29 +13,46 @@ type Staff struct {
 	BirthDate  time.Time `json:"birth_date"`
 	Login      string    `json:"login"`
 	Password   string    `json:"password"`
+	Version    int       `json:"version"`
 	CreatedAt  time.Time `json:"created_at"`
 	UpdatedAt  time.Time `json:"updated_at"`
+	DeletedAt  int       `json:"deleted_at,omitempty"`
 }
 
 type CreateStaff struct {
-	BranchID   string `json:"branch_id"`
-	TariffID   string `json:"tariff_id"`
-	StaffType  string `json:"staff_type"`
-	Name       string `json:"name"`
+	BranchID   string `json:"branch_id" binding:"required,uuid"`
+	TariffID   string `json:"tariff_id" binding:"required,uuid"`
+	StaffType  string `json:"staff_type" binding:"required,enum=staff_type"`
+	Name       string `json:"name" binding:"required,max=30"`
 	Balance    uint   `json:"balance"`
-	BirthDate  string `json:"birth_date"`
-	Login      string `json:"login"`
-	Password   string `json:"password"`
+	BirthDate  string `json:"birth_date" binding:"omitempty,datetime=2006-01-02"`
+	Login      string `json:"login" binding:"required,max=15"`
+	Password   string `json:"password" binding:"required,min=6,max=100"`
 }
 
 type UpdateStaff struct {
 	ID         string `json:"id"`
-	BranchID   string `json:"branch_id"`
-	TariffID   string `json:"tariff_id"`
-	StaffType  string `json:"staff_type"`
-	Name       string `json:"name"`
+	BranchID   string `json:"branch_id" binding:"required,uuid"`
+	TariffID   string `json:"tariff_id" binding:"required,uuid"`
+	StaffType  string `json:"staff_type" binding:"required,enum=staff_type"`
+	Name       string `json:"name" binding:"required,max=30"`
 	Balance    uint   `json:"balance"`
-	Login      string `json:"login"`
+	Login      string `json:"login" binding:"required,max=15"`
+	Version    int    `json:"-"`
+}
+
+// PatchStaff changes only the given fields of staff, null clears a nullable field.
+// Balance is changed only by balance adjustments.
+type PatchStaff struct {
+	ID        string  `json:"-"`
+	BranchID  *string `json:"branch_id" binding:"uuid"`
+	TariffID  *string `json:"tariff_id" binding:"uuid"`
+	StaffType *string `json:"staff_type" binding:"enum=staff_type"`
+	Name      *string `json:"name" binding:"min=1,max=30"`
+	BirthDate *string `json:"birth_date" binding:"omitempty,datetime=2006-01-02"`
+	Login     *string `json:"login" binding:"min=1,max=15"`
+	Balance   *uint   `json:"-"`
+	Version   int     `json:"-"`
 }
 
 type StaffsResponse struct {
@@ -45,6 +62,17 @@ type StaffsResponse struct {
 
 type UpdateStaffPassword struct {
 	ID          string `json:"id"`
-	NewPassword string `json:"new_password"`
-	OldPassword string `json:"old_password"`
-}
\ No newline at end of file
+	NewPassword string `json:"new_password" binding:"required,min=6,max=100"`
+	OldPassword string `json:"old_password" binding:"required"`
+}
+type StaffBalanceAdjustment struct {
+	StaffID         string  `json:"-"`
+	TransactionType string  `json:"transaction_type" binding:"required,enum=transaction_type"`
+	Amount          float64 `json:"amount" binding:"gt=0"`
+	Description     string  `json:"description" binding:"required"`
+}
+
+type StaffBalanceResponse struct {
+	Staff       Staff       `json:"staff"`
+	Transaction Transaction `json:"transaction"`
+}
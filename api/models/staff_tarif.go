@@ -8,23 +8,36 @@ type StaffTarif struct {
 	TarifType     string    `json:"tarif_type"`
 	AmountForCash int       `json:"amount_for_cash"`
 	AmountForCard int       `json:"amount_for_carsd"`
+	Version       int       `json:"version"`
 	CreatedAt     time.Time `json:"created_at"`
 	UpdatedAt     time.Time `json:"updated_at"`
+	DeletedAt     int       `json:"deleted_at,omitempty"`
 }
 
 type CreateStaffTarif struct {
-	Name          string `json:"name"`
-	TarifType     string `json:"tarif_type"`
-	AmountForCash int    `json:"amount_for_cash"`
-	AmountForCard int    `json:"amount_for_card"`
+	Name          string `json:"name" binding:"required,max=30"`
+	TarifType     string `json:"tarif_type" binding:"required,enum=tarif_type"`
+	AmountForCash int    `json:"amount_for_cash" binding:"gte=0"`
+	AmountForCard int    `json:"amount_for_card" binding:"gte=0"`
 }
 
 type UpdateStaffTarif struct {
 	ID            string `json:"id"`
-	Name          string `json:"name"`
-	TarifType     string `json:"tarif_type"`
-	AmountForCash int    `json:"amount_for_cash"`
-	AmountForCard int    `json:"amount_for_carsd"`
+	Name          string `json:"name" binding:"required,max=30"`
+	TarifType     string `json:"tarif_type" binding:"required,enum=tarif_type"`
+	AmountForCash int    `json:"amount_for_cash" binding:"gte=0"`
+	AmountForCard int    `json:"amount_for_carsd" binding:"gte=0"`
+	Version       int    `json:"-"`
+}
+
+// PatchStaffTarif changes only the given fields, null clears a nullable field.
+type PatchStaffTarif struct {
+	ID            string  `json:"-"`
+	Name          *string `json:"name" binding:"min=1,max=30"`
+	TarifType     *string `json:"tarif_type" binding:"enum=tarif_type"`
+	AmountForCash *int    `json:"amount_for_cash" binding:"gte=0"`
+	AmountForCard *int    `json:"amount_for_card" binding:"gte=0"`
+	Version       int     `json:"-"`
 }
 
 type StaffTarifResponse struct {
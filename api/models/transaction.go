@@ -12,27 +12,42 @@ type Transaction struct {
 	SourceType      string    `json:"source_type"`
 	Amount          float64   `json:"amount"`
 	Description     string    `json:"description"`
+	Version         int       `json:"version"`
 	CreatedAt       time.Time `json:"created_at"`
 	UpdatedAt       time.Time `json:"updated_at"`
+	DeletedAt       int       `json:"deleted_at,omitempty"`
 }
 
 type CreateTransaction struct {
-	SaleID          string  `json:"sale_id"`
-	StaffID         string  `json:"staff_id"`
-	TransactionType string  `json:"transaction_type"`
-	SourceType      string  `json:"source_type"`
-	Amount          float64 `json:"amount"`
+	SaleID          string  `json:"sale_id" binding:"omitempty,uuid"`
+	StaffID         string  `json:"staff_id" binding:"required,uuid"`
+	TransactionType string  `json:"transaction_type" binding:"required,enum=transaction_type"`
+	SourceType      string  `json:"source_type" binding:"required,enum=source_type"`
+	Amount          float64 `json:"amount" binding:"gt=0"`
 	Description     string  `json:"description"`
 }
 
 type UpdateTransaction struct {
 	ID              string    `json:"-"`
-	SaleID          string    `json:"sale_id"`
-	StaffID         string    `json:"staff_id"`
-	TransactionType string    `json:"transaction_type"`
-	SourceType      string    `json:"source_type"`
-	Amount          float64   `json:"amount"`
+	SaleID          string    `json:"sale_id" binding:"omitempty,uuid"`
+	StaffID         string    `json:"staff_id" binding:"required,uuid"`
+	TransactionType string    `json:"transaction_type" binding:"required,enum=transaction_type"`
+	SourceType      string    `json:"source_type" binding:"required,enum=source_type"`
+	Amount          float64   `json:"amount" binding:"gt=0"`
 	Description     string    `json:"description"`
+	Version         int       `json:"-"`
+}
+
+// PatchTransaction changes only the given fields, null clears a nullable field.
+type PatchTransaction struct {
+	ID              string   `json:"-"`
+	SaleID          *string  `json:"sale_id" binding:"omitempty,uuid"`
+	StaffID         *string  `json:"staff_id" binding:"uuid"`
+	TransactionType *string  `json:"transaction_type" binding:"enum=transaction_type"`
+	SourceType      *string  `json:"source_type" binding:"enum=source_type"`
+	Amount          *float64 `json:"amount" binding:"gt=0"`
+	Description     *string  `json:"description"`
+	Version         int      `json:"-"`
 }
 
 type TransactionResponse struct {
@@ -45,4 +60,9 @@ type TransactionGetListRequest struct {
 	Limit      int     `json:"limit"`
 	FromAmount float64 `json:"from_amount"`
 	ToAmount   float64 `json:"to_amount"`
+
+	IncludeDeleted bool `json:"include_deleted"`
+
+	// After switches the list to keyset paging, see Cursor. Page is not used then.
+	After *Cursor `json:"-"`
 }
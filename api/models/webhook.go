@@ -0,0 +1,110 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// WebhookPing is the type of the test event sent to check that a webhook works.
+const WebhookPing = "webhook.ping"
+
+// Statuses of webhook deliveries.
+const (
+	WebhookPending   = "pending"
+	WebhookDelivered = "delivered"
+	WebhookFailed    = "failed"
+)
+
+// Webhook is a subscription of an outside system to domain events. Secret signs the
+// requests and is never returned.
+type Webhook struct {
+	ID         string    `json:"id"`
+	URL        string    `json:"url"`
+	Secret     string    `json:"-"`
+	EventTypes []string  `json:"event_types"`
+	Active     bool      `json:"active"`
+	Version    int       `json:"version"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+	DeletedAt  int       `json:"deleted_at,omitempty"`
+}
+
+type CreateWebhook struct {
+	URL        string   `json:"url" binding:"required,url,max=500"`
+	Secret     string   `json:"secret" binding:"required,min=16,max=100"`
+	EventTypes []string `json:"event_types" binding:"required,min=1"`
+}
+
+// UpdateWebhook replaces the webhook, empty secret keeps the current one.
+type UpdateWebhook struct {
+	ID         string   `json:"-"`
+	URL        string   `json:"url" binding:"required,url,max=500"`
+	Secret     string   `json:"secret" binding:"omitempty,min=16,max=100"`
+	EventTypes []string `json:"event_types" binding:"required,min=1"`
+	Active     bool     `json:"active"`
+	Version    int      `json:"-"`
+}
+
+type WebhooksResponse struct {
+	Webhooks []Webhook `json:"webhooks"`
+	Count    int       `json:"count"`
+}
+
+// WebhookPayload is the body posted to webhooks, Data is the payload of the event.
+type WebhookPayload struct {
+	EventID     string          `json:"event_id"`
+	EventType   string          `json:"event_type"`
+	AggregateID string          `json:"aggregate_id"`
+	Data        json.RawMessage `json:"data" swaggertype:"object"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+// WebhookDelivery is one event sent to one webhook. Payload is the exact body which is
+// posted, so that retries are signed and sent the same way.
+type WebhookDelivery struct {
+	ID            string          `json:"id"`
+	WebhookID     string          `json:"webhook_id"`
+	EventID       string          `json:"event_id"`
+	EventType     string          `json:"event_type"`
+	Payload       json.RawMessage `json:"payload" swaggertype:"object"`
+	Status        string          `json:"status"`
+	Attempts      int             `json:"attempts"`
+	ResponseCode  int             `json:"response_code"`
+	LastError     string          `json:"last_error"`
+	NextAttemptAt time.Time       `json:"next_attempt_at"`
+	CreatedAt     time.Time       `json:"created_at"`
+	DeliveredAt   time.Time       `json:"delivered_at"`
+}
+
+// CreateWebhookDelivery is a delivery to queue, it is due once Delay has passed by the
+// db clock or right away if Delay is zero.
+type CreateWebhookDelivery struct {
+	WebhookID string
+	EventID   string
+	EventType string
+	Payload   json.RawMessage
+	Delay     time.Duration
+}
+
+// WebhookAttempt is the result of sending a delivery. When the delivery stays pending
+// it is due again once Backoff has passed by the db clock.
+type WebhookAttempt struct {
+	ID           string
+	Status       string
+	ResponseCode int
+	Error        string
+	Backoff      time.Duration
+}
+
+// WebhookDeliveryListRequest filters deliveries by the given fields, empty fields match everything.
+type WebhookDeliveryListRequest struct {
+	Page      int
+	Limit     int
+	WebhookID string
+	Status    string
+}
+
+type WebhookDeliveriesResponse struct {
+	Deliveries []WebhookDelivery `json:"deliveries"`
+	Count      int               `json:"count"`
+}
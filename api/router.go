@@ -11,7 +11,7 @@ import (
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
 
-// New ...
+// New builds the router of the api, serving it is up to the caller.
 // @title           Swagger Example API
 // @version         1.0
 // @description     This is a sample server celler server.
@@ -20,67 +20,133 @@ func New(services service.IServiceManager, log logger.ILogger) *gin.Engine {
 
 	r := gin.New()
 
+	r.Use(h.Instrument(), h.Actor())
+
+	r.GET("/healthz", h.Healthz)
+	r.GET("/readyz", h.Readyz)
+	r.GET("/metrics", h.GetMetrics)
+
 	r.POST("/category", h.CreateCategory)
 	r.GET("/category/:id", h.GetCategory)
 	r.GET("/categories", h.GetCategoryList)
 	r.PUT("/category/:id", h.UpdateCategory)
+	r.PATCH("/category/:id", h.PatchCategory)
 	r.DELETE("/category/:id", h.DeleteCategory)
+	r.POST("/category/:id/restore", h.RestoreCategory)
 
 	r.POST("/product", h.CreateProduct)
 	r.GET("/product/:id", h.GetProduct)
 	r.GET("/products", h.GetProductList)
 	r.PUT("/product/:id", h.UpdateProduct)
+	r.PATCH("/product/:id", h.PatchProduct)
 	r.DELETE("/product/:id", h.DeleteProduct)
+	r.POST("/product/:id/restore", h.RestoreProduct)
+	r.POST("/products/import", h.ImportProducts)
 
 	r.POST("/branch", h.CreateBranch)
 	r.GET("/branch/:id", h.GetBranch)
 	r.GET("/branches", h.GetBranchList)
 	r.PUT("/branch/:id", h.UpdateBranch)
+	r.PATCH("/branch/:id", h.PatchBranch)
 	r.DELETE("/branch/:id", h.DeleteBranch)
+	r.POST("/branch/:id/restore", h.RestoreBranch)
 
 	r.POST("/repository", h.CreateRepository)
 	r.GET("/repository/:id", h.GetRepository)
 	r.GET("/repositories", h.GetRepositoryList)
 	r.PUT("/repository/:id", h.UpdateRepository)
+	r.PATCH("/repository/:id", h.PatchRepository)
 	r.DELETE("/repository/:id", h.DeleteRepository)
+	r.POST("/repository/:id/restore", h.RestoreRepository)
 
-	r.POST("/sale", h.CreateSale)
+	r.POST("/sale", h.Idempotent(), h.CreateSale)
 	r.GET("/sale/:id", h.GetSale)
 	r.GET("/sales", h.GetSaleList)
 	r.PUT("/sale/:id", h.UpdateSale)
+	r.PATCH("/sale/:id", h.PatchSale)
 	r.DELETE("/sale/:id", h.DeleteSale)
+	r.POST("/sale/:id/restore", h.RestoreSale)
+	r.GET("/sale/:id/receipt", h.GetSaleReceipt)
 
-	r.POST("/basket", h.CreateBasket)
+	r.POST("/basket", h.Idempotent(), h.CreateBasket)
 	r.GET("/basket/:id", h.GetBasket)
 	r.GET("/baskets", h.GetBasketList)
 	r.PUT("/basket/:id", h.UpdateBasket)
+	r.PATCH("/basket/:id", h.PatchBasket)
 	r.DELETE("/basket/:id", h.DeleteBasket)
+	r.POST("/basket/:id/restore", h.RestoreBasket)
 
 	r.POST("/stafftarif", h.CreateStaffTariff)
 	r.GET("/stafftarif/:id", h.GetStaffTariff)
 	r.GET("/stafftarifs", h.GetStaffTariffList)
 	r.PUT("/stafftarif/:id", h.UpdateStaffTariff)
+	r.PATCH("/stafftarif/:id", h.PatchStaffTariff)
 	r.DELETE("/stafftarif/:id", h.DeleteStaffTariff)
+	r.POST("/stafftarif/:id/restore", h.RestoreStaffTariff)
 
 	r.POST("/staff", h.CreateStaff)
 	r.GET("/staff/:id", h.GetStaff)
 	r.GET("/staffs", h.GetStaffList)
 	r.PUT("/staff/:id", h.UpdateStaff)
+	r.PATCH("/staff/:id", h.PatchStaff)
 	r.DELETE("/staff/:id", h.DeleteStaff)
+	r.POST("/staff/:id/restore", h.RestoreStaff)
+	r.POST("/staff/:id/balance", h.AdjustStaffBalance)
+	r.GET("/staff/:id/stats", h.GetStaffStats)
 
-	r.POST("/transaction", h.CreateTransaction)
+	r.POST("/transaction", h.Idempotent(), h.CreateTransaction)
 	r.GET("/transaction/:id", h.GetTransaction)
 	r.GET("/transactions", h.GetTransactionList)
 	r.PUT("/transaction/:id", h.UpdateTransaction)
+	r.PATCH("/transaction/:id", h.PatchTransaction)
 	r.DELETE("/transaction/:id", h.DeleteTransaction)
+	r.POST("/transaction/:id/restore", h.RestoreTransaction)
 
 	r.POST("/rtransaction", h.CreateRepositoryTransaction)
 	r.GET("/rtransaction/:id", h.GetRepositoryTransaction)
 	r.GET("/rtransactions", h.GetRepositoryTransactionList)
 	r.PUT("/rtransaction/:id", h.UpdateRepositoryTransaction)
+	r.PATCH("/rtransaction/:id", h.PatchRepositoryTransaction)
 	r.DELETE("/rtransaction/:id", h.DeleteRepositoryTransaction)
+	r.POST("/rtransaction/:id/restore", h.RestoreRepositoryTransaction)
+
+	r.POST("/shift", h.OpenShift)
+	r.GET("/shift/:id", h.GetShift)
+	r.GET("/shifts", h.GetShiftList)
+	r.POST("/shift/:id/close", h.CloseShift)
+	r.GET("/shift/:id/zreport", h.GetShiftZReport)
+
+	r.POST("/customer", h.CreateCustomer)
+	r.GET("/customer/:id", h.GetCustomer)
+	r.GET("/customers", h.GetCustomerList)
+	r.PUT("/customer/:id", h.UpdateCustomer)
+	r.PATCH("/customer/:id", h.PatchCustomer)
+	r.DELETE("/customer/:id", h.DeleteCustomer)
+	r.POST("/customer/:id/restore", h.RestoreCustomer)
+	r.GET("/customer/:id/history", h.GetCustomerHistory)
+
+	r.POST("/payout", h.CreatePayout)
+	r.GET("/payout/:id", h.GetPayout)
+	r.GET("/payouts", h.GetPayoutList)
+	r.GET("/payout/:id/statement/:staff_id", h.GetPayrollStatement)
+
+	r.GET("/reports/revenue", h.GetRevenueReport)
+	r.GET("/reports/top-products", h.GetTopProductsReport)
+	r.GET("/reports/staff", h.GetStaffSalesReport)
+	r.GET("/reports/payment-types", h.GetPaymentTypesReport)
+
+	r.GET("/audit", h.GetAuditList)
+
+	r.POST("/webhook", h.CreateWebhook)
+	r.GET("/webhook/:id", h.GetWebhook)
+	r.GET("/webhooks", h.GetWebhookList)
+	r.PUT("/webhook/:id", h.UpdateWebhook)
+	r.DELETE("/webhook/:id", h.DeleteWebhook)
+	r.POST("/webhook/:id/restore", h.RestoreWebhook)
+	r.POST("/webhook/:id/test", h.TestWebhook)
+	r.GET("/webhook/:id/deliveries", h.GetWebhookDeliveries)
 
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
-	r.Run(":8080")
+
 	return r
 }
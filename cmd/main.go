@@ -7,7 +7,14 @@ import (
 	"market/config"
 	"market/pkg/logger"
 	"market/service"
+	"market/storage"
+	"market/storage/memory"
 	"market/storage/postgres"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
 )
 
 func main() {
@@ -16,17 +23,121 @@ func main() {
 
 	log := logger.New(cfg.ServiceName)
 
-	store, err := postgres.New(context.Background(), cfg, log)
-	if err != nil {
-		log.Error("error while connecting to db: %v", logger.Error(err))
+	// market migrate up | down N | force VERSION | version manages the postgres schema
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(cfg, log, os.Args[2:]); err != nil {
+			fmt.Printf("error while migrating: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	var store storage.IStorage
+	switch cfg.StorageType {
+	case "memory":
+		store = memory.New(log)
+	case "postgres":
+		var err error
+		store, err = postgres.New(context.Background(), cfg, log)
+		if err != nil {
+			log.Error("error while connecting to db: %v", logger.Error(err))
+			os.Exit(1)
+		}
+	default:
+		fmt.Printf("unknown storage %q, it should be postgres or memory\n", cfg.StorageType)
+		os.Exit(1)
 	}
 	defer store.Close()
 
 	services := service.New(store, log)
 
-	server := api.New(services, log)
+	if pg, ok := store.(*postgres.Store); ok {
+		pg.RegisterMetrics(services.Metrics().Registry())
+	}
+
+	// market rebuild-summaries [from] [to] recalculates daily summaries of historical sales
+	if len(os.Args) > 1 && os.Args[1] == "rebuild-summaries" {
+		from, to := "", ""
+		if len(os.Args) > 2 {
+			from = os.Args[2]
+		}
+		if len(os.Args) > 3 {
+			to = os.Args[3]
+		}
+
+		if err := services.Summary().Rebuild(context.Background(), from, to); err != nil {
+			fmt.Printf("error while rebuilding summaries: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Background jobs keep running while requests in flight are drained, so that
+	// what those requests change is still summarized and dispatched.
+	workersCtx, stopWorkers := context.WithCancel(context.Background())
+	workers := sync.WaitGroup{}
+	for _, run := range []func(context.Context){
+		services.Summary().Run,
+		services.Idempotency().Run,
+		services.Purge().Run,
+		services.Events().Run,
+		services.Webhook().Run,
+	} {
+		workers.Add(1)
+		go func(run func(context.Context)) {
+			defer workers.Done()
+			run(workersCtx)
+		}(run)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	err := serve(ctx, cfg, services, log)
+	if err != nil {
+		log.Error("error while running server", logger.Error(err))
+	}
+
+	// the pgx pool is closed by the deferred store.Close only after the jobs stopped using it
+	stopWorkers()
+	workers.Wait()
 
-	if err := server.Run("localhost:8080"); err != nil {
-		fmt.Printf("error while running server: %v\n", err)
+	if err != nil {
+		store.Close()
+		os.Exit(1)
 	}
 }
+
+// serve runs the api until ctx is done, then stops accepting connections and waits
+// at most cfg.ShutdownTimeout for requests in flight to finish.
+func serve(ctx context.Context, cfg config.Config, services service.IServiceManager, log logger.ILogger) error {
+	server := &http.Server{
+		Addr:         cfg.HTTPAddress,
+		Handler:      api.New(services, log),
+		ReadTimeout:  cfg.HTTPReadTimeout,
+		WriteTimeout: cfg.HTTPWriteTimeout,
+	}
+
+	serverErr := make(chan error, 1)
+	go func() {
+		log.Info("server is listening", logger.String("address", cfg.HTTPAddress))
+		serverErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serverErr:
+		return err
+	case <-ctx.Done():
+	}
+
+	log.Info("server is shutting down, draining requests in flight")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("requests in flight were cut off: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"market/config"
+	"market/pkg/logger"
+	"market/storage/postgres"
+	"strconv"
+)
+
+const migrateUsage = `usage: market migrate up | down N | force VERSION | version`
+
+// runMigrate runs market migrate subcommands against the postgres database from config.
+func runMigrate(cfg config.Config, log logger.ILogger, args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	m, err := postgres.NewMigrator(cfg, log)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	switch {
+	case args[0] == "up" && len(args) == 1:
+		return m.Up()
+	case args[0] == "down" && len(args) == 2:
+		steps, err := strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("down needs the number of migrations to roll back: %v", err)
+		}
+		return m.Down(steps)
+	case args[0] == "force" && len(args) == 2:
+		version, err := strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("force needs a migration version: %v", err)
+		}
+		return m.Force(version)
+	case args[0] == "version" && len(args) == 1:
+		version, dirty, err := m.Version()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("version: %d, dirty: %v\n", version, dirty)
+		return nil
+	}
+
+	return errors.New(migrateUsage)
+}
@@ -5,6 +5,7 @@ import (
 	"github.com/joho/godotenv"
 	"github.com/spf13/cast"
 	"os"
+	"time"
 )
 
 type Config struct {
@@ -14,6 +15,18 @@ type Config struct {
 	PostgresPassword string
 	PostgresDB       string
 
+	// StorageType is postgres or memory, memory keeps nothing after restart
+	// and is meant for tests and demos.
+	StorageType string
+
+	// HTTPAddress is where the api listens. Reading a request and writing its response
+	// may take at most HTTPReadTimeout and HTTPWriteTimeout, and on SIGTERM requests
+	// in flight get ShutdownTimeout to finish.
+	HTTPAddress      string
+	HTTPReadTimeout  time.Duration
+	HTTPWriteTimeout time.Duration
+	ShutdownTimeout  time.Duration
+
 	ServiceName string
 	LoggerLevel string
 }
@@ -30,6 +43,13 @@ func Load() Config {
 	cfg.PostgresPassword = cast.ToString(getOrReturnDefault("POSTGRES_PASSWORD", "your password"))
 	cfg.PostgresDB = cast.ToString(getOrReturnDefault("POSTGRES_DB", "your database"))
 
+	cfg.StorageType = cast.ToString(getOrReturnDefault("STORAGE", "postgres"))
+
+	cfg.HTTPAddress = cast.ToString(getOrReturnDefault("HTTP_ADDRESS", ":8080"))
+	cfg.HTTPReadTimeout = cast.ToDuration(getOrReturnDefault("HTTP_READ_TIMEOUT", "10s"))
+	cfg.HTTPWriteTimeout = cast.ToDuration(getOrReturnDefault("HTTP_WRITE_TIMEOUT", "30s"))
+	cfg.ShutdownTimeout = cast.ToDuration(getOrReturnDefault("SHUTDOWN_TIMEOUT", "30s"))
+
 	cfg.ServiceName = cast.ToString(getOrReturnDefault("SERVICE_NAME", "store"))
 	cfg.LoggerLevel = cast.ToString(getOrReturnDefault("LOGGER_LEVEL", "debug"))
 
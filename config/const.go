@@ -7,4 +7,59 @@ var SignKey = []byte("asd@#lskd2!aw32k34242WSASdsk32")
 const (
 	AccessExpireTime  = time.Minute * 20
 	RefreshExpireTime = time.Hour * 24
-)
\ No newline at end of file
+)
+
+// LoyaltyPercent is the part of successful sale price which customer gets back as points.
+const LoyaltyPercent = 1
+
+// SummaryCatchUpInterval is how often finished sales which were missed by
+// the summary job are looked up and added to the daily summaries.
+const SummaryCatchUpInterval = time.Minute
+
+// IdempotencyKeyTTL is how long the response of a request with Idempotency-Key
+// is replayed for retries, IdempotencyPurgeInterval is how often older keys are removed.
+// IdempotencyLease is how long a key stays reserved by a request which has not finished,
+// after it a retry handles the request again.
+const (
+	IdempotencyKeyTTL        = time.Hour * 24
+	IdempotencyPurgeInterval = time.Hour
+	IdempotencyLease         = time.Minute
+)
+
+// SoftDeleteRetention is how long soft deleted rows can be restored, PurgeInterval
+// is how often rows deleted longer ago are removed for good.
+const (
+	SoftDeleteRetention = time.Hour * 24 * 30
+	PurgeInterval       = time.Hour * 24
+)
+
+// OutboxPollInterval is how often pending domain events are dispatched, OutboxBatchSize
+// of them at a time, and a claimed batch is not claimed by others for OutboxClaimLease.
+// A failed event is dispatched again after OutboxBackoffBase, doubling every attempt up
+// to OutboxBackoffMax, and it is not dispatched again after OutboxMaxAttempts.
+// Dispatched events are kept for OutboxRetention and removed every OutboxCleanupInterval.
+const (
+	OutboxPollInterval    = time.Second
+	OutboxBatchSize       = 100
+	OutboxClaimLease      = time.Minute
+	OutboxMaxAttempts     = 10
+	OutboxBackoffBase     = time.Second * 5
+	OutboxBackoffMax      = time.Hour
+	OutboxRetention       = time.Hour * 24 * 7
+	OutboxCleanupInterval = time.Hour
+)
+
+// WebhookPollInterval is how often due webhook deliveries are sent, WebhookBatchSize of them
+// at a time, each waiting at most WebhookTimeout for the answer. A claimed batch is not
+// claimed by others for WebhookClaimLease. A failed delivery is sent again after
+// WebhookBackoffBase, doubling every attempt up to WebhookBackoffMax, and it is given
+// up after WebhookMaxAttempts.
+const (
+	WebhookPollInterval = time.Second
+	WebhookBatchSize    = 50
+	WebhookTimeout      = time.Second * 10
+	WebhookClaimLease   = WebhookTimeout * WebhookBatchSize * 2
+	WebhookMaxAttempts  = 8
+	WebhookBackoffBase  = time.Second * 30
+	WebhookBackoffMax   = time.Hour * 6
+)
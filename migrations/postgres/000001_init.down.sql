@@ -0,0 +1,18 @@
+DROP TABLE IF EXISTS repository_transactions;
+DROP TABLE IF EXISTS transactions;
+DROP TABLE IF EXISTS staffs;
+DROP TABLE IF EXISTS staff_tarifs;
+DROP TABLE IF EXISTS baskets;
+DROP TABLE IF EXISTS sales;
+DROP TABLE IF EXISTS repositories;
+DROP TABLE IF EXISTS branches;
+DROP TABLE IF EXISTS products;
+DROP TABLE IF EXISTS categories;
+
+DROP TYPE IF EXISTS repostitory_transaction_type_enum;
+DROP TYPE IF EXISTS staff_type_enum;
+DROP TYPE IF EXISTS tarif_type_enum;
+DROP TYPE IF EXISTS source_type_enum;
+DROP TYPE IF EXISTS transaction_type_enum;
+DROP TYPE IF EXISTS status_enum;
+DROP TYPE IF EXISTS payment_type_enum;
@@ -121,4 +121,4 @@ create table repository_transactions(
     created_at TIMESTAMP DEFAULT NOW(),
     updated_at TIMESTAMP,
     deleted_at INTEGER DEFAULT 0
-);
\ No newline at end of file
+);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS shifts;
+
+DROP TYPE IF EXISTS shift_status_enum;
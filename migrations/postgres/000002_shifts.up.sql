@@ -0,0 +1,18 @@
+CREATE TYPE shift_status_enum AS ENUM ('open', 'closed');
+
+CREATE TABLE shifts (
+    id UUID PRIMARY KEY NOT NULL,
+    branch_id UUID REFERENCES branches(id),
+    cashier_id UUID REFERENCES staffs(id),
+    status shift_status_enum DEFAULT 'open',
+    opening_cash numeric DEFAULT 0,
+    expected_cash numeric DEFAULT 0,
+    counted_cash numeric DEFAULT 0,
+    opened_at TIMESTAMP DEFAULT NOW(),
+    closed_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP,
+    deleted_at INTEGER DEFAULT 0
+);
+
+CREATE UNIQUE INDEX shifts_one_open_per_cashier ON shifts (cashier_id) WHERE status = 'open' AND deleted_at = 0;
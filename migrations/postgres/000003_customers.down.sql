@@ -0,0 +1,10 @@
+ALTER TABLE sales DROP COLUMN IF EXISTS customer_id;
+
+DROP TABLE IF EXISTS customers;
+
+-- enum values can not be dropped, the type is created again without 'points'.
+-- It fails while there are sales paid with points.
+ALTER TYPE payment_type_enum RENAME TO payment_type_enum_old;
+CREATE TYPE payment_type_enum AS ENUM ('card', 'cash');
+ALTER TABLE sales ALTER COLUMN payment_type TYPE payment_type_enum USING payment_type::text::payment_type_enum;
+DROP TYPE payment_type_enum_old;
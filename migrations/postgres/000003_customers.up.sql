@@ -0,0 +1,14 @@
+ALTER TYPE payment_type_enum ADD VALUE 'points';
+
+CREATE TABLE customers (
+    id UUID PRIMARY KEY NOT NULL,
+    phone VARCHAR(20) UNIQUE NOT NULL,
+    name VARCHAR(30),
+    branch_id UUID REFERENCES branches(id),
+    points INT DEFAULT 0 CHECK (points >= 0),
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP,
+    deleted_at INTEGER DEFAULT 0
+);
+
+ALTER TABLE sales ADD COLUMN customer_id UUID REFERENCES customers(id);
@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS payout_items;
+DROP TABLE IF EXISTS payouts;
+
+-- enum values can not be dropped, the type is created again without 'payout'.
+-- It fails while there are payout transactions.
+ALTER TYPE source_type_enum RENAME TO source_type_enum_old;
+CREATE TYPE source_type_enum AS ENUM ('bonus', 'sales');
+ALTER TABLE transactions ALTER COLUMN source_type TYPE source_type_enum USING source_type::text::source_type_enum;
+DROP TYPE source_type_enum_old;
@@ -0,0 +1,23 @@
+ALTER TYPE source_type_enum ADD VALUE 'payout';
+
+CREATE TABLE payouts (
+    id UUID PRIMARY KEY NOT NULL,
+    branch_id UUID REFERENCES branches(id),
+    period_from DATE NOT NULL,
+    period_to DATE NOT NULL,
+    total numeric DEFAULT 0,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP,
+    deleted_at INTEGER DEFAULT 0
+);
+
+CREATE TABLE payout_items (
+    id UUID PRIMARY KEY NOT NULL,
+    payout_id UUID REFERENCES payouts(id),
+    staff_id UUID REFERENCES staffs(id),
+    earned numeric DEFAULT 0,
+    balance_before INT DEFAULT 0,
+    paid INT DEFAULT 0,
+    transaction_id UUID REFERENCES transactions(id),
+    created_at TIMESTAMP DEFAULT NOW()
+);
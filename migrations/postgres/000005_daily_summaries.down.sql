@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS sales_not_summarized;
+
+DROP TABLE IF EXISTS daily_product_summaries;
+DROP TABLE IF EXISTS daily_branch_summaries;
+
+ALTER TABLE sales DROP COLUMN IF EXISTS summarized;
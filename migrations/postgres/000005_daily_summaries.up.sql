@@ -0,0 +1,23 @@
+ALTER TABLE sales ADD COLUMN summarized BOOLEAN DEFAULT false;
+
+CREATE TABLE daily_branch_summaries (
+    day DATE NOT NULL,
+    branch_id UUID NOT NULL REFERENCES branches(id),
+    payment_type VARCHAR(20) NOT NULL DEFAULT '',
+    sales_count INT DEFAULT 0,
+    revenue numeric DEFAULT 0,
+    cancelled_count INT DEFAULT 0,
+    cancelled_total numeric DEFAULT 0,
+    PRIMARY KEY (day, branch_id, payment_type)
+);
+
+CREATE TABLE daily_product_summaries (
+    day DATE NOT NULL,
+    branch_id UUID NOT NULL REFERENCES branches(id),
+    product_id UUID NOT NULL REFERENCES products(id),
+    quantity INT DEFAULT 0,
+    revenue numeric DEFAULT 0,
+    PRIMARY KEY (day, branch_id, product_id)
+);
+
+CREATE INDEX sales_not_summarized ON sales (created_at) WHERE NOT summarized;
@@ -0,0 +1,11 @@
+ALTER TABLE categories DROP COLUMN IF EXISTS version;
+ALTER TABLE products DROP COLUMN IF EXISTS version;
+ALTER TABLE branches DROP COLUMN IF EXISTS version;
+ALTER TABLE repositories DROP COLUMN IF EXISTS version;
+ALTER TABLE customers DROP COLUMN IF EXISTS version;
+ALTER TABLE sales DROP COLUMN IF EXISTS version;
+ALTER TABLE baskets DROP COLUMN IF EXISTS version;
+ALTER TABLE staff_tarifs DROP COLUMN IF EXISTS version;
+ALTER TABLE staffs DROP COLUMN IF EXISTS version;
+ALTER TABLE transactions DROP COLUMN IF EXISTS version;
+ALTER TABLE repository_transactions DROP COLUMN IF EXISTS version;
@@ -0,0 +1,11 @@
+ALTER TABLE categories ADD COLUMN version INT NOT NULL DEFAULT 1;
+ALTER TABLE products ADD COLUMN version INT NOT NULL DEFAULT 1;
+ALTER TABLE branches ADD COLUMN version INT NOT NULL DEFAULT 1;
+ALTER TABLE repositories ADD COLUMN version INT NOT NULL DEFAULT 1;
+ALTER TABLE customers ADD COLUMN version INT NOT NULL DEFAULT 1;
+ALTER TABLE sales ADD COLUMN version INT NOT NULL DEFAULT 1;
+ALTER TABLE baskets ADD COLUMN version INT NOT NULL DEFAULT 1;
+ALTER TABLE staff_tarifs ADD COLUMN version INT NOT NULL DEFAULT 1;
+ALTER TABLE staffs ADD COLUMN version INT NOT NULL DEFAULT 1;
+ALTER TABLE transactions ADD COLUMN version INT NOT NULL DEFAULT 1;
+ALTER TABLE repository_transactions ADD COLUMN version INT NOT NULL DEFAULT 1;
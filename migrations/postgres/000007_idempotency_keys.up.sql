@@ -0,0 +1,11 @@
+CREATE TABLE idempotency_keys (
+    key VARCHAR(255) NOT NULL,
+    endpoint VARCHAR(100) NOT NULL,
+    request_hash VARCHAR(64) NOT NULL,
+    status_code INT,
+    response BYTEA,
+    created_at TIMESTAMP DEFAULT NOW(),
+    PRIMARY KEY (key, endpoint)
+);
+
+CREATE INDEX idempotency_keys_created_at ON idempotency_keys (created_at);
@@ -0,0 +1,13 @@
+CREATE TABLE audit_logs (
+    id UUID PRIMARY KEY NOT NULL,
+    actor_id UUID,
+    action VARCHAR(20) NOT NULL,
+    entity VARCHAR(50) NOT NULL,
+    entity_id UUID NOT NULL,
+    before JSONB,
+    after JSONB,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX audit_logs_entity ON audit_logs (entity, entity_id, created_at);
+CREATE INDEX audit_logs_actor ON audit_logs (actor_id, created_at);
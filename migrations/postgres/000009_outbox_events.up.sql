@@ -0,0 +1,14 @@
+CREATE TABLE outbox_events (
+    id UUID PRIMARY KEY NOT NULL,
+    seq BIGSERIAL NOT NULL,
+    event_type VARCHAR(50) NOT NULL,
+    aggregate_id UUID NOT NULL,
+    payload JSONB NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    created_at TIMESTAMP DEFAULT NOW(),
+    dispatched_at TIMESTAMP
+);
+
+CREATE INDEX outbox_events_pending ON outbox_events (seq) WHERE dispatched_at IS NULL;
+CREATE INDEX outbox_events_dispatched_at ON outbox_events (dispatched_at);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS sales_shift_id;
+ALTER TABLE sales DROP COLUMN IF EXISTS shift_id;
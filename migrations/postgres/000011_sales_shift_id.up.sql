@@ -0,0 +1,9 @@
+ALTER TABLE sales ADD COLUMN shift_id UUID REFERENCES shifts (id);
+
+-- sales made before the column existed belong to the shift their cashier had open then
+UPDATE sales s SET shift_id = sh.id
+    FROM shifts sh
+    WHERE sh.cashier_id::text = s.cashier_id AND sh.branch_id = s.branch_id AND sh.deleted_at = 0
+        AND s.created_at >= sh.opened_at AND s.created_at <= COALESCE(sh.closed_at, NOW());
+
+CREATE INDEX sales_shift_id ON sales (shift_id);
@@ -0,0 +1,4 @@
+-- reserved_at is when the request which handles the key took it, a key which is still
+-- in progress a lease after it is given to the next retry.
+ALTER TABLE idempotency_keys ADD COLUMN reserved_at TIMESTAMP NOT NULL DEFAULT NOW();
+ALTER TABLE idempotency_keys ADD COLUMN content_type VARCHAR(100);
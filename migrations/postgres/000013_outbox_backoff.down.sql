@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS outbox_events_due;
+CREATE INDEX outbox_events_pending ON outbox_events (seq) WHERE dispatched_at IS NULL;
+
+ALTER TABLE outbox_events DROP COLUMN IF EXISTS next_attempt_at;
@@ -0,0 +1,6 @@
+-- a failed event is dispatched again at next_attempt_at, and a claimed one is hidden from
+-- other dispatchers until then
+ALTER TABLE outbox_events ADD COLUMN next_attempt_at TIMESTAMP NOT NULL DEFAULT NOW();
+
+DROP INDEX IF EXISTS outbox_events_pending;
+CREATE INDEX outbox_events_due ON outbox_events (next_attempt_at) WHERE dispatched_at IS NULL;
@@ -0,0 +1,2 @@
+-- receivers which got the event already, a failed event is delivered again only to the rest
+ALTER TABLE outbox_events ADD COLUMN delivered_to TEXT[] NOT NULL DEFAULT '{}';
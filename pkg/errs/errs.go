@@ -0,0 +1,90 @@
+package errs
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Code is a stable machine readable error code which is returned to clients in models.Response.
+type Code string
+
+const (
+	CodeNotFound             Code = "not_found"
+	CodeConflict             Code = "conflict"
+	CodeValidation           Code = "validation_failed"
+	CodeInsufficientStock    Code = "insufficient_stock"
+	CodeForbidden            Code = "forbidden"
+	CodePreconditionRequired Code = "precondition_required"
+)
+
+// Error is a domain error produced by storage and service layers.
+type Error struct {
+	Code    Code
+	Message string
+	Err     error
+}
+
+// Sentinels to check error kind with errors.Is, they match any Error with the same code.
+var (
+	ErrNotFound          = &Error{Code: CodeNotFound}
+	ErrConflict          = &Error{Code: CodeConflict}
+	ErrValidation        = &Error{Code: CodeValidation}
+	ErrInsufficientStock = &Error{Code: CodeInsufficientStock}
+	ErrForbidden         = &Error{Code: CodeForbidden}
+)
+
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return string(e.Code)
+	}
+
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Message == "" && t.Code == e.Code
+}
+
+func NotFound(format string, args ...interface{}) error {
+	return &Error{Code: CodeNotFound, Message: fmt.Sprintf(format, args...)}
+}
+
+func Conflict(format string, args ...interface{}) error {
+	return &Error{Code: CodeConflict, Message: fmt.Sprintf(format, args...)}
+}
+
+func Validation(format string, args ...interface{}) error {
+	return &Error{Code: CodeValidation, Message: fmt.Sprintf(format, args...)}
+}
+
+func InsufficientStock(format string, args ...interface{}) error {
+	return &Error{Code: CodeInsufficientStock, Message: fmt.Sprintf(format, args...)}
+}
+
+func Forbidden(format string, args ...interface{}) error {
+	return &Error{Code: CodeForbidden, Message: fmt.Sprintf(format, args...)}
+}
+
+func PreconditionRequired(format string, args ...interface{}) error {
+	return &Error{Code: CodePreconditionRequired, Message: fmt.Sprintf(format, args...)}
+}
+
+// Wrap keeps err as the cause of a domain error with the given code and message.
+func Wrap(code Code, err error, message string) error {
+	return &Error{Code: code, Message: message, Err: err}
+}
+
+// CodeOf returns the code of the first domain error in err chain, or empty code.
+func CodeOf(err error) Code {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Code
+	}
+
+	return ""
+}
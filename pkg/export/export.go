@@ -0,0 +1,62 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+)
+
+const (
+	CSV  = "csv"
+	XLSX = "xlsx"
+)
+
+// Writer writes table rows one by one to the underlying stream.
+type Writer interface {
+	Write(row []string) error
+	Close() error
+}
+
+// New returns a Writer for the format, sheet is used as the worksheet name of xlsx files.
+func New(format string, w io.Writer, sheet string) (Writer, error) {
+	switch format {
+	case CSV:
+		return &csvWriter{w: csv.NewWriter(w)}, nil
+	case XLSX:
+		return newXLSXWriter(w, sheet)
+	}
+
+	return nil, fmt.Errorf("unknown export format %q", format)
+}
+
+func ContentType(format string) string {
+	if format == XLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+
+	return "text/csv"
+}
+
+type csvWriter struct {
+	w *csv.Writer
+	n int
+}
+
+func (c *csvWriter) Write(row []string) error {
+	if err := c.w.Write(row); err != nil {
+		return err
+	}
+
+	// flush from time to time so rows go to the client while reading the next page
+	c.n++
+	if c.n%100 == 0 {
+		c.w.Flush()
+	}
+
+	return c.w.Error()
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
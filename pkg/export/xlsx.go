@@ -0,0 +1,141 @@
+package export
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	contentTypesXML = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`</Types>`
+
+	relsXML = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`
+
+	workbookRelsXML = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`</Relationships>`
+
+	workbookXML = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="%s" sheetId="1" r:id="rId1"/></sheets></workbook>`
+
+	sheetHeaderXML = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`
+
+	sheetFooterXML = `</sheetData></worksheet>`
+)
+
+// xlsxWriter writes a single sheet workbook. Rows are streamed straight into
+// the zip entry of the sheet, so the whole table is never kept in memory.
+type xlsxWriter struct {
+	zip   *zip.Writer
+	sheet io.Writer
+}
+
+func newXLSXWriter(w io.Writer, sheet string) (*xlsxWriter, error) {
+	z := zip.NewWriter(w)
+
+	files := []struct{ name, body string }{
+		{"[Content_Types].xml", contentTypesXML},
+		{"_rels/.rels", relsXML},
+		{"xl/_rels/workbook.xml.rels", workbookRelsXML},
+		{"xl/workbook.xml", strings.Replace(workbookXML, "%s", escape(sheetName(sheet)), 1)},
+	}
+
+	for _, file := range files {
+		f, err := z.Create(file.name)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err = io.WriteString(f, file.body); err != nil {
+			return nil, err
+		}
+	}
+
+	s, err := z.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = io.WriteString(s, sheetHeaderXML); err != nil {
+		return nil, err
+	}
+
+	return &xlsxWriter{zip: z, sheet: s}, nil
+}
+
+func (x *xlsxWriter) Write(row []string) error {
+	b := strings.Builder{}
+	b.WriteString("<row>")
+	for _, value := range row {
+		if isNumber(value) {
+			b.WriteString("<c><v>" + value + "</v></c>")
+			continue
+		}
+
+		b.WriteString(`<c t="inlineStr"><is><t xml:space="preserve">` + escape(value) + "</t></is></c>")
+	}
+	b.WriteString("</row>")
+
+	_, err := io.WriteString(x.sheet, b.String())
+	return err
+}
+
+func (x *xlsxWriter) Close() error {
+	if _, err := io.WriteString(x.sheet, sheetFooterXML); err != nil {
+		return err
+	}
+
+	return x.zip.Close()
+}
+
+// isNumber reports whether value can be written as a numeric cell, long digit
+// strings are kept as text so excel does not round them.
+func isNumber(value string) bool {
+	if value == "" || len(value) > 15 || strings.Trim(value, "0123456789.-") != "" {
+		return false
+	}
+
+	_, err := strconv.ParseFloat(value, 64)
+	return err == nil
+}
+
+func escape(s string) string {
+	b := strings.Builder{}
+	xml.EscapeText(&b, []byte(s))
+
+	return b.String()
+}
+
+// sheetName strips characters excel does not allow in sheet names.
+func sheetName(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return -1
+		}
+		return r
+	}, s)
+
+	if s == "" {
+		s = "Sheet1"
+	}
+
+	if r := []rune(s); len(r) > 31 {
+		s = string(r[:31])
+	}
+
+	return s
+}
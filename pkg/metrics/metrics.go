@@ -0,0 +1,265 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ContentType is the content type of the Prometheus text format which Registry writes.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefaultBuckets are the upper bounds of latency histograms in seconds.
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+type metric interface {
+	write(w *bufio.Writer)
+}
+
+// Registry keeps metrics and writes them in the Prometheus text format. Registering
+// the same name twice or using a wrong number of label values panics, both are bugs.
+type Registry struct {
+	mu      sync.Mutex
+	names   map[string]bool
+	metrics []metric
+}
+
+func NewRegistry() *Registry {
+	return &Registry{names: map[string]bool{}}
+}
+
+func (r *Registry) register(name string, m metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.names[name] {
+		panic("metrics: " + name + " is already registered")
+	}
+
+	r.names[name] = true
+	r.metrics = append(r.metrics, m)
+}
+
+// Counter registers a counter with the given label names.
+func (r *Registry) Counter(name, help string, labels ...string) *Counter {
+	c := &Counter{
+		desc:   desc{name: name, help: help, kind: "counter", labels: labels},
+		values: map[string]*counterValue{},
+	}
+	r.register(name, c)
+
+	return c
+}
+
+// Histogram registers a histogram with the given bucket upper bounds and label names.
+func (r *Registry) Histogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	h := &Histogram{
+		desc:    desc{name: name, help: help, kind: "histogram", labels: labels},
+		buckets: append([]float64{}, buckets...),
+		values:  map[string]*histogramValue{},
+	}
+	sort.Float64s(h.buckets)
+	r.register(name, h)
+
+	return h
+}
+
+// GaugeFunc registers a gauge whose value is read by value on every scrape.
+func (r *Registry) GaugeFunc(name, help string, value func() float64) {
+	r.register(name, &funcMetric{desc: desc{name: name, help: help, kind: "gauge"}, value: value})
+}
+
+// CounterFunc registers a counter whose value is read by value on every scrape,
+// for totals which are counted somewhere else.
+func (r *Registry) CounterFunc(name, help string, value func() float64) {
+	r.register(name, &funcMetric{desc: desc{name: name, help: help, kind: "counter"}, value: value})
+}
+
+// Write writes all metrics in the order they were registered.
+func (r *Registry) Write(w io.Writer) error {
+	r.mu.Lock()
+	metrics := append([]metric{}, r.metrics...)
+	r.mu.Unlock()
+
+	buf := bufio.NewWriter(w)
+	for _, m := range metrics {
+		m.write(buf)
+	}
+
+	return buf.Flush()
+}
+
+type desc struct {
+	name   string
+	help   string
+	kind   string
+	labels []string
+}
+
+func (d desc) writeHeader(w *bufio.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n", d.name, strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(d.help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", d.name, d.kind)
+}
+
+func (d desc) key(labelValues []string) string {
+	if len(labelValues) != len(d.labels) {
+		panic(fmt.Sprintf("metrics: %s has %d labels, got %d values", d.name, len(d.labels), len(labelValues)))
+	}
+
+	return strings.Join(labelValues, "\xff")
+}
+
+// writeSample writes one line of the metric, extra is a label added after the labels
+// of the metric, like le of histogram buckets.
+func writeSample(w *bufio.Writer, name string, labels, values []string, extra string, value float64) {
+	w.WriteString(name)
+
+	pairs := make([]string, 0, len(labels)+1)
+	for i, label := range labels {
+		pairs = append(pairs, label+`="`+escapeLabel(values[i])+`"`)
+	}
+	if extra != "" {
+		pairs = append(pairs, extra)
+	}
+
+	if len(pairs) > 0 {
+		w.WriteString("{" + strings.Join(pairs, ",") + "}")
+	}
+
+	w.WriteString(" " + formatValue(value) + "\n")
+}
+
+func escapeLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+func formatValue(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	}
+
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// sortedKeys returns the keys of values in order, so that scrapes list samples the same way.
+func sortedKeys[T any](values map[string]T) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// Counter is a value which only goes up, one per combination of label values.
+type Counter struct {
+	desc
+	mu     sync.Mutex
+	values map[string]*counterValue
+}
+
+type counterValue struct {
+	labelValues []string
+	value       float64
+}
+
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add adds value, which should not be negative, to the counter of the label values.
+func (c *Counter) Add(value float64, labelValues ...string) {
+	key := c.key(labelValues)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	v, ok := c.values[key]
+	if !ok {
+		v = &counterValue{labelValues: append([]string{}, labelValues...)}
+		c.values[key] = v
+	}
+	v.value += value
+}
+
+func (c *Counter) write(w *bufio.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.writeHeader(w)
+	for _, key := range sortedKeys(c.values) {
+		v := c.values[key]
+		writeSample(w, c.name, c.labels, v.labelValues, "", v.value)
+	}
+}
+
+// Histogram counts observed values in buckets, one set of buckets per combination of label values.
+type Histogram struct {
+	desc
+	buckets []float64
+	mu      sync.Mutex
+	values  map[string]*histogramValue
+}
+
+type histogramValue struct {
+	labelValues []string
+	counts      []uint64
+	count       uint64
+	sum         float64
+}
+
+func (h *Histogram) Observe(value float64, labelValues ...string) {
+	key := h.key(labelValues)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	v, ok := h.values[key]
+	if !ok {
+		v = &histogramValue{labelValues: append([]string{}, labelValues...), counts: make([]uint64, len(h.buckets))}
+		h.values[key] = v
+	}
+
+	for i, bound := range h.buckets {
+		if value <= bound {
+			v.counts[i]++
+		}
+	}
+	v.count++
+	v.sum += value
+}
+
+func (h *Histogram) write(w *bufio.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.writeHeader(w)
+	for _, key := range sortedKeys(h.values) {
+		v := h.values[key]
+		for i, bound := range h.buckets {
+			writeSample(w, h.name+"_bucket", h.labels, v.labelValues, `le="`+formatValue(bound)+`"`, float64(v.counts[i]))
+		}
+		writeSample(w, h.name+"_bucket", h.labels, v.labelValues, `le="+Inf"`, float64(v.count))
+		writeSample(w, h.name+"_sum", h.labels, v.labelValues, "", v.sum)
+		writeSample(w, h.name+"_count", h.labels, v.labelValues, "", float64(v.count))
+	}
+}
+
+type funcMetric struct {
+	desc
+	value func() float64
+}
+
+func (f *funcMetric) write(w *bufio.Writer) {
+	f.writeHeader(w)
+	writeSample(w, f.name, nil, nil, "", f.value())
+}
@@ -0,0 +1,123 @@
+package metrics
+
+import (
+	"bytes"
+	"math"
+	"testing"
+)
+
+func write(t *testing.T, registry *Registry) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := registry.Write(&buf); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	return buf.String()
+}
+
+func TestCounterEscapesHelpAndLabelValues(t *testing.T) {
+	registry := NewRegistry()
+	counter := registry.Counter("requests_total", "Requests by path,\nback\\slash in help.", "path", "status")
+
+	counter.Inc(`/a"b`, "200")
+	counter.Add(2, "/c\\d\ne", "500")
+
+	want := `# HELP requests_total Requests by path,\nback\\slash in help.
+# TYPE requests_total counter
+requests_total{path="/a\"b",status="200"} 1
+requests_total{path="/c\\d\ne",status="500"} 2
+`
+	if got := write(t, registry); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestSamplesAreInTheSameOrderOnEveryScrape(t *testing.T) {
+	registry := NewRegistry()
+	counter := registry.Counter("sales_total", "Sales.", "status", "payment_type")
+	registry.GaugeFunc("goroutines", "Goroutines.", func() float64 { return 3 })
+
+	// labels keep the order they were registered in, samples are sorted by their values
+	for _, values := range [][]string{{"completed", "card"}, {"cancelled", "cash"}, {"completed", "cash"}} {
+		counter.Inc(values...)
+	}
+
+	want := `# HELP sales_total Sales.
+# TYPE sales_total counter
+sales_total{status="cancelled",payment_type="cash"} 1
+sales_total{status="completed",payment_type="card"} 1
+sales_total{status="completed",payment_type="cash"} 1
+# HELP goroutines Goroutines.
+# TYPE goroutines gauge
+goroutines 3
+`
+	for i := 0; i < 3; i++ {
+		if got := write(t, registry); got != want {
+			t.Fatalf("scrape %d got\n%s\nwant\n%s", i, got, want)
+		}
+	}
+}
+
+func TestHistogramBucketsAreCumulative(t *testing.T) {
+	registry := NewRegistry()
+	histogram := registry.Histogram("duration_seconds", "Durations.", []float64{1, 0.1, 0.5}, "route")
+
+	for _, value := range []float64{0.05, 0.1, 0.3, 2} {
+		histogram.Observe(value, "/sale")
+	}
+
+	want := `# HELP duration_seconds Durations.
+# TYPE duration_seconds histogram
+duration_seconds_bucket{route="/sale",le="0.1"} 2
+duration_seconds_bucket{route="/sale",le="0.5"} 3
+duration_seconds_bucket{route="/sale",le="1"} 3
+duration_seconds_bucket{route="/sale",le="+Inf"} 4
+duration_seconds_sum{route="/sale"} 2.45
+duration_seconds_count{route="/sale"} 4
+`
+	if got := write(t, registry); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestFormatValue(t *testing.T) {
+	for _, tt := range []struct {
+		value float64
+		want  string
+	}{
+		{0, "0"},
+		{1500, "1500"},
+		{0.25, "0.25"},
+		{1e21, "1e+21"},
+		{math.Inf(1), "+Inf"},
+		{math.Inf(-1), "-Inf"},
+		{math.NaN(), "NaN"},
+	} {
+		if got := formatValue(tt.value); got != tt.want {
+			t.Errorf("formatValue(%v) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestMisuseIsABug(t *testing.T) {
+	for name, misuse := range map[string]func(*Registry){
+		"same name twice": func(r *Registry) {
+			r.Counter("total", "Total.")
+			r.GaugeFunc("total", "Total.", func() float64 { return 0 })
+		},
+		"wrong number of label values": func(r *Registry) {
+			r.Counter("total", "Total.", "status").Inc("ok", "extra")
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("did not panic")
+				}
+			}()
+			misuse(NewRegistry())
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package receipt
+
+import (
+	"bytes"
+	"html/template"
+	"market/api/models"
+)
+
+var htmlTemplate = template.Must(template.New("receipt").Funcs(template.FuncMap{
+	"short": shortID,
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Receipt {{short .SaleID}}</title>
+<style>
+body { font-family: monospace; width: 80mm; margin: 0 auto; }
+h1 { font-size: 16px; text-align: center; margin: 4px 0; }
+p.address { text-align: center; margin: 0 0 8px 0; }
+table { width: 100%; border-collapse: collapse; }
+td.num { text-align: right; }
+tr.total td { border-top: 1px dashed #000; font-weight: bold; }
+p.cancelled { text-align: center; font-weight: bold; }
+</style>
+</head>
+<body>
+<h1>{{.BranchName}}</h1>
+<p class="address">{{.BranchAddress}}</p>
+<table>
+<tr><td>Sale</td><td class="num">{{short .SaleID}}</td></tr>
+<tr><td>Date</td><td class="num">{{.CreatedAt.Format "2006-01-02 15:04"}}</td></tr>
+<tr><td>Cashier</td><td class="num">{{.CashierName}}</td></tr>
+{{- if .ClientName}}
+<tr><td>Client</td><td class="num">{{.ClientName}}</td></tr>
+{{- end}}
+</table>
+<hr>
+<table>
+{{- range .Lines}}
+<tr><td colspan="2">{{.ProductName}}</td></tr>
+<tr><td>&nbsp;&nbsp;{{.Quantity}} x {{.UnitPrice}}</td><td class="num">{{.Price}}</td></tr>
+{{- end}}
+<tr class="total"><td>TOTAL</td><td class="num">{{printf "%.2f" .Total}}</td></tr>
+<tr><td>Payment</td><td class="num">{{.PaymentType}}</td></tr>
+</table>
+{{- if eq .Status "cancel"}}
+<p class="cancelled">*** CANCELLED ***</p>
+{{- end}}
+<hr>
+<p style="text-align: center">Thank you for your purchase!</p>
+</body>
+</html>
+`))
+
+// HTML renders receipt as a standalone html page.
+func HTML(r models.Receipt) ([]byte, error) {
+	buf := bytes.Buffer{}
+	if err := htmlTemplate.Execute(&buf, r); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
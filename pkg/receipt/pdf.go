@@ -0,0 +1,111 @@
+package receipt
+
+import (
+	"bytes"
+	"fmt"
+	"market/api/models"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	pdfFontSize   = 8
+	pdfLineHeight = 10
+	pdfMargin     = 12
+	// 80mm paper in points
+	pdfPageWidth = 226
+)
+
+// PDF renders the text receipt onto a single 80mm wide page using the
+// built-in Courier font, so no external fonts or libraries are needed.
+func PDF(r models.Receipt) []byte {
+	lines := strings.Split(strings.TrimRight(Text(r), "\n"), "\n")
+	height := 2*pdfMargin + len(lines)*pdfLineHeight
+
+	content := bytes.Buffer{}
+	fmt.Fprintf(&content, "BT\n/F1 %d Tf\n%d TL\n%d %d Td\n", pdfFontSize, pdfLineHeight, pdfMargin, height-pdfMargin-pdfFontSize)
+	for _, line := range lines {
+		fmt.Fprintf(&content, "(%s) Tj T*\n", pdfString(line))
+	}
+	content.WriteString("ET\n")
+
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 4 0 R >> >> /Contents 5 0 R >>", pdfPageWidth, height),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()),
+	}
+
+	buf := bytes.Buffer{}
+	buf.WriteString("%PDF-1.4\n")
+
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+
+	return buf.Bytes()
+}
+
+// winAnsi has the characters which WinAnsiEncoding puts in 0x80-0x9f, where it
+// differs from latin-1. Latin-1 characters above 0x9f have the same code in both.
+var winAnsi = map[rune]byte{
+	'€': 0x80, '‚': 0x82, 'ƒ': 0x83, '„': 0x84, '…': 0x85, '†': 0x86, '‡': 0x87,
+	'ˆ': 0x88, '‰': 0x89, 'Š': 0x8a, '‹': 0x8b, 'Œ': 0x8c, 'Ž': 0x8e,
+	'‘': 0x91, '’': 0x92, '“': 0x93, '”': 0x94, '•': 0x95, '–': 0x96, '—': 0x97,
+	'˜': 0x98, '™': 0x99, 'š': 0x9a, '›': 0x9b, 'œ': 0x9c, 'ž': 0x9e, 'Ÿ': 0x9f,
+	// apostrophes of the uzbek latin letters o‘ and g‘
+	'ʻ': 0x91, 'ʼ': 0x92,
+}
+
+// cyrillic is the latin spelling of the uzbek and russian cyrillic letters, the
+// standard fonts have no cyrillic so names are transliterated on pdf receipts.
+var cyrillic = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "yo", 'ж': "j",
+	'з': "z", 'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o",
+	'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "x", 'ц': "ts",
+	'ч': "ch", 'ш': "sh", 'щ': "sh", 'ъ': "’", 'ы': "i", 'ь': "", 'э': "e", 'ю': "yu",
+	'я': "ya", 'ў': "o‘", 'қ': "q", 'ғ': "g‘", 'ҳ': "h",
+}
+
+// pdfString escapes s for a pdf literal string in WinAnsiEncoding. The standard
+// fonts can show only the WinAnsi characters, so cyrillic is transliterated and
+// anything else is replaced with '?'. Showing other scripts needs an embedded
+// unicode font.
+func pdfString(s string) string {
+	b := strings.Builder{}
+	for _, r := range s {
+		if latin, ok := cyrillic[unicode.ToLower(r)]; ok {
+			if r != unicode.ToLower(r) && latin != "" {
+				first, size := utf8.DecodeRuneInString(latin)
+				latin = string(unicode.ToUpper(first)) + latin[size:]
+			}
+			b.WriteString(pdfString(latin))
+			continue
+		}
+
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case winAnsi[r] != 0:
+			b.WriteByte(winAnsi[r])
+		case r < 32 || (r >= 0x7f && r < 0xa0) || r > 0xff:
+			b.WriteByte('?')
+		default:
+			b.WriteByte(byte(r))
+		}
+	}
+
+	return b.String()
+}
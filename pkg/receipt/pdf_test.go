@@ -0,0 +1,25 @@
+package receipt
+
+import "testing"
+
+func TestPDFString(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"Choy (qora)", `Choy \(qora\)`},
+		{"Qahva 50%", "Qahva 50%"},
+		{"Café", "Caf\xe9"},
+		{"Oʻzbekiston", "O\x91zbekiston"},
+		{"Ўзбекистон", "O\x91zbekiston"},
+		{"Шоколад", "Shokolad"},
+		{"Ғишт – 5€", "G\x91isht \x96 5\x80"},
+		{"\u0085 茶", "? ?"},
+	}
+
+	for _, tt := range tests {
+		if got := pdfString(tt.in); got != tt.want {
+			t.Errorf("pdfString(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
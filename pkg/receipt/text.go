@@ -0,0 +1,112 @@
+package receipt
+
+import (
+	"fmt"
+	"market/api/models"
+	"strings"
+)
+
+// Width is the number of characters that fit into one line of 80mm thermal paper.
+const Width = 42
+
+// Text renders receipt as plain text for thermal printers.
+func Text(r models.Receipt) string {
+	b := strings.Builder{}
+
+	b.WriteString(center(r.BranchName) + "\n")
+	if r.BranchAddress != "" {
+		for _, line := range wrap(r.BranchAddress, Width) {
+			b.WriteString(center(line) + "\n")
+		}
+	}
+	b.WriteString(separator())
+
+	b.WriteString(pair("Sale:", shortID(r.SaleID)))
+	b.WriteString(pair("Date:", r.CreatedAt.Format("2006-01-02 15:04")))
+	b.WriteString(pair("Cashier:", r.CashierName))
+	if r.ClientName != "" {
+		b.WriteString(pair("Client:", r.ClientName))
+	}
+	b.WriteString(separator())
+
+	for _, line := range r.Lines {
+		b.WriteString(truncate(line.ProductName, Width) + "\n")
+		b.WriteString(pair(fmt.Sprintf("  %d x %d", line.Quantity, line.UnitPrice), fmt.Sprint(line.Price)))
+	}
+	b.WriteString(separator())
+
+	b.WriteString(pair("TOTAL", fmt.Sprintf("%.2f", r.Total)))
+	b.WriteString(pair("Payment:", r.PaymentType))
+	if r.Status == "cancel" {
+		b.WriteString(center("*** CANCELLED ***") + "\n")
+	}
+	b.WriteString(separator())
+	b.WriteString(center("Thank you for your purchase!") + "\n")
+
+	return b.String()
+}
+
+func separator() string {
+	return strings.Repeat("-", Width) + "\n"
+}
+
+func pair(left, right string) string {
+	space := Width - len([]rune(left)) - len([]rune(right))
+	if space < 1 {
+		left = truncate(left, Width-len([]rune(right))-1)
+		space = 1
+	}
+
+	return left + strings.Repeat(" ", space) + right + "\n"
+}
+
+func center(s string) string {
+	s = truncate(s, Width)
+	pad := (Width - len([]rune(s))) / 2
+
+	return strings.Repeat(" ", pad) + s
+}
+
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if n < 0 {
+		n = 0
+	}
+	if len(r) <= n {
+		return s
+	}
+
+	return string(r[:n])
+}
+
+func wrap(s string, n int) []string {
+	var (
+		lines   []string
+		current string
+	)
+
+	for _, word := range strings.Fields(s) {
+		switch {
+		case current == "":
+			current = word
+		case len([]rune(current))+1+len([]rune(word)) <= n:
+			current += " " + word
+		default:
+			lines = append(lines, current)
+			current = word
+		}
+	}
+	if current != "" {
+		lines = append(lines, current)
+	}
+
+	return lines
+}
+
+func shortID(id string) string {
+	if len(id) > 8 {
+		return strings.ToUpper(id[:8])
+	}
+
+	return strings.ToUpper(id)
+}
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"market/api/models"
+	"market/pkg/logger"
+	"market/storage"
+)
+
+// Entities of audit log records.
+const (
+	auditCategory              = "category"
+	auditProduct               = "product"
+	auditBranch                = "branch"
+	auditRepository            = "repository"
+	auditSale                  = "sale"
+	auditBasket                = "basket"
+	auditStaffTariff           = "staff_tariff"
+	auditStaff                 = "staff"
+	auditTransaction           = "transaction"
+	auditRepositoryTransaction = "repository_transaction"
+	auditCustomer              = "customer"
+	auditWebhook               = "webhook"
+	auditShift                 = "shift"
+	auditPayout                = "payout"
+	auditPurge                 = "purge"
+)
+
+type actorKey struct{}
+
+// WithActor returns ctx with the id of the staff member who makes the request,
+// changes made with it are recorded in the audit log under that staff member.
+func WithActor(ctx context.Context, staffID string) context.Context {
+	return context.WithValue(ctx, actorKey{}, staffID)
+}
+
+func actorFrom(ctx context.Context) string {
+	staffID, _ := ctx.Value(actorKey{}).(string)
+	return staffID
+}
+
+// audited runs change and records it in the audit log, both in one transaction, and returns
+// the entity after the change. id is the entity id, it is empty for creates, and change returns
+// the id of the changed entity. get reads the entity before updates and deletes, and after
+// every change except deletes.
+func audited[T any](ctx context.Context, store storage.IStorage, log logger.ILogger, action, entity, id string,
+	get func(context.Context, storage.IStorage, string) (T, error), change func(storage.IStorage) (string, error)) (T, error) {
+	return auditedWith(ctx, store, log, action, entity, id, get, change, nil)
+}
+
+// auditedWith is audited which also calls changed in the transaction after the change is
+// recorded, it gets the entity before and after the change, nil for creates and deletes.
+func auditedWith[T any](ctx context.Context, store storage.IStorage, log logger.ILogger, action, entity, id string,
+	get func(context.Context, storage.IStorage, string) (T, error), change func(storage.IStorage) (string, error),
+	changed func(store storage.IStorage, before, after *T) error) (T, error) {
+	var after T
+
+	err := store.WithTx(ctx, func(store storage.IStorage) error {
+		var (
+			before       *T
+			beforeRecord interface{}
+		)
+		if action == models.AuditUpdate || action == models.AuditDelete {
+			value, err := get(ctx, store, id)
+			if err != nil {
+				log.Error("error in service layer while getting "+entity+" before change", logger.Error(err))
+				return err
+			}
+			before, beforeRecord = &value, value
+		}
+
+		changedID, err := change(store)
+		if err != nil {
+			return err
+		}
+
+		if action == models.AuditDelete {
+			if err = recordAudit(ctx, store, log, action, entity, changedID, beforeRecord, nil); err != nil || changed == nil {
+				return err
+			}
+			return changed(store, before, nil)
+		}
+
+		if after, err = get(ctx, store, changedID); err != nil {
+			log.Error("error in service layer while getting "+entity+" after change", logger.Error(err))
+			return err
+		}
+
+		if err = recordAudit(ctx, store, log, action, entity, changedID, beforeRecord, after); err != nil || changed == nil {
+			return err
+		}
+		return changed(store, before, &after)
+	})
+
+	return after, err
+}
+
+// recordAudit writes the change of the entity made in store to the audit log,
+// nil before or after is kept empty.
+func recordAudit(ctx context.Context, store storage.IStorage, log logger.ILogger, action, entity, entityID string, before, after interface{}) error {
+	record := models.CreateAuditLog{
+		ActorID:  actorFrom(ctx),
+		Action:   action,
+		Entity:   entity,
+		EntityID: entityID,
+	}
+
+	var err error
+	if before != nil {
+		if record.Before, err = json.Marshal(before); err != nil {
+			return err
+		}
+	}
+
+	if after != nil {
+		if record.After, err = json.Marshal(after); err != nil {
+			return err
+		}
+	}
+
+	if err = store.Audit().Create(ctx, record); err != nil {
+		log.Error("error in service layer while recording audit log", logger.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+type auditService struct {
+	storage storage.IStorage
+	log     logger.ILogger
+}
+
+func NewAuditService(storage storage.IStorage, log logger.ILogger) auditService {
+	return auditService{
+		storage: storage,
+		log:     log,
+	}
+}
+
+func (a auditService) GetList(ctx context.Context, request models.AuditListRequest) (models.AuditLogsResponse, error) {
+	auditLogs, err := a.storage.Audit().GetList(ctx, request)
+	if err != nil {
+		a.log.Error("error in service layer while getting audit log", logger.Error(err))
+		return models.AuditLogsResponse{}, err
+	}
+
+	return auditLogs, nil
+}
@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"market/api/models"
+	"market/pkg/errs"
 	"market/pkg/logger"
 	"market/storage"
 )
@@ -19,68 +20,83 @@ func NewBasketService(storage storage.IStorage, log  logger.ILogger) basketServi
 	}
 }
 
-func (b basketService) Create(ctx context.Context, createBasket models.CreateBasket) (models.Basket, error) {
+func getBasket(ctx context.Context, store storage.IStorage, id string) (models.Basket, error) {
+	return store.Basket().GetByID(ctx, models.PrimaryKey{ID: id})
+}
 
-	count, err := b.storage.Repository().ProductByID(context.Background(), createBasket.ProductID)
+func (b basketService) Create(ctx context.Context, createBasket models.CreateBasket) (models.Basket, error) {
+	var id string
 
+	// Qoldiqni tekshirish va savatni yozish bitta tranzaksiyada bajariladi
 
-	// Agar olinmoqchi bulgan product omborda bulmasa habar berish
+	err := b.storage.WithTx(ctx, func(store storage.IStorage) error {
+		count, err := store.Repository().ProductByID(ctx, createBasket.ProductID)
+		if err != nil {
+			b.log.Error("Error in service layer while getting product count for Basket", logger.Error(err))
+			return err
+		}
 
-	if count < createBasket.Quantity {
-		b.log.Error("We don't have enough product", logger.Error(err))
-		return models.Basket{}, err
-	}
+		// Agar olinmoqchi bulgan product omborda bulmasa habar berish
 
-	product, err := b.storage.Product().GetByID(context.Background(), createBasket.ProductID)
-	if err != nil {
-		b.log.Error("Error in service layer while getting product ByID for Basket", logger.Error(err))
-		return models.Basket{}, err
-	}
+		if count < createBasket.Quantity {
+			return errs.InsufficientStock("we don't have enough product")
+		}
 
-	totalPrice := product.Price * createBasket.Quantity
+		product, err := store.Product().GetByID(ctx, createBasket.ProductID)
+		if err != nil {
+			b.log.Error("Error in service layer while getting product ByID for Basket", logger.Error(err))
+			return err
+		}
 
-	baskets, err := b.storage.Basket().GetList(context.Background(), models.GetListRequest{
-		Page:   1,
-		Limit:  100,
-		Search: createBasket.SaleID,
-	})
-	if err != nil {
-		b.log.Error("Error in service layer while getting baskets by SaleID for create Basket", logger.Error(err))
-		return models.Basket{}, err
-	}
+		totalPrice := product.Price * createBasket.Quantity
 
-	// Agar yaratilmoqchi bo'lgan basketdan bazada mavjud bo'lsa uning sonini o'zgartirish
+		baskets, err := store.Basket().GetList(ctx, models.GetListRequest{
+			Page:   1,
+			Limit:  100,
+			Search: createBasket.SaleID,
+		})
+		if err != nil {
+			b.log.Error("Error in service layer while getting baskets by SaleID for create Basket", logger.Error(err))
+			return err
+		}
 
-	for _, basket := range baskets.Baskets {
-		if basket.ProductID == createBasket.ProductID {
-			if count < basket.Quantity + createBasket.Quantity {
-				b.log.Error("We don't have enough product")
-				return models.Basket{}, nil
-			}
-			updateBasket := models.UpdateBasket{
-				ID:        basket.ID,
-				SaleID:    basket.SaleID,
-				ProductID: basket.ProductID,
-				Quantity:  basket.Quantity + createBasket.Quantity,
-				Price:     basket.Price + totalPrice,
+		// Agar yaratilmoqchi bo'lgan basketdan bazada mavjud bo'lsa uning sonini o'zgartirish
+
+		for _, basket := range baskets.Baskets {
+			if basket.ProductID == createBasket.ProductID {
+				if count < basket.Quantity+createBasket.Quantity {
+					return errs.InsufficientStock("we don't have enough product")
+				}
+				updateBasket := models.UpdateBasket{
+					ID:        basket.ID,
+					SaleID:    basket.SaleID,
+					ProductID: basket.ProductID,
+					Quantity:  basket.Quantity + createBasket.Quantity,
+					Price:     basket.Price + totalPrice,
+					Version:   basket.Version,
+				}
+				if id, err = store.Basket().Update(ctx, updateBasket); err != nil {
+					b.log.Error("Error in service layer when adding baskets", logger.Error(err))
+					return err
+				}
+				return b.recordChange(ctx, store, models.AuditUpdate, id, basket)
 			}
-			if _, err := b.storage.Basket().Update(context.Background(), updateBasket); err != nil {
-				b.log.Error("Error in service layer when adding baskets", logger.Error(err))
-				return models.Basket{}, err
-			}
-			return models.Basket{}, err
 		}
-	}
 
-	createBasket.Price = totalPrice
+		createBasket.Price = totalPrice
 
-	id, err :=  b.storage.Basket().Create(context.Background(), createBasket)
+		if id, err = store.Basket().Create(ctx, createBasket); err != nil {
+			b.log.Error("Error in service layer when creating basket", logger.Error(err))
+			return err
+		}
+
+		return b.recordChange(ctx, store, models.AuditCreate, id, nil)
+	})
 	if err != nil {
-		b.log.Error("Error in service layer when creating basket", logger.Error(err))
 		return models.Basket{}, err
 	}
 
-	createdBasket, err := b.storage.Basket().GetByID(context.Background(), models.PrimaryKey{
+	createdBasket, err := b.storage.Basket().GetByID(ctx, models.PrimaryKey{
 		ID: id,
 	})
 	if err != nil {
@@ -89,7 +105,6 @@ func (b basketService) Create(ctx context.Context, createBasket models.CreateBas
 	}
 
 	return createdBasket, nil
-
 }
 
 func (b basketService) Get(ctx context.Context, id string) (models.Basket, error) {
@@ -115,23 +130,62 @@ func (b basketService) GetList(ctx context.Context, request models.GetListReques
 }
 
 func (b basketService) Update(ctx context.Context, basket models.UpdateBasket) (models.Basket, error) {
-	id, err := b.storage.Basket().Update(ctx, basket)
+	updatedBasket, err := audited(ctx, b.storage, b.log, models.AuditUpdate, auditBasket, basket.ID, getBasket,
+		func(store storage.IStorage) (string, error) {
+			return store.Basket().Update(ctx, basket)
+		})
 	if err != nil {
 		b.log.Error("error in service layer while updating", logger.Error(err))
 		return models.Basket{}, err
 	}
 
-	updatedBasket, err := b.storage.Basket().GetByID(context.Background(), models.PrimaryKey{ID: id})
+	return updatedBasket, nil
+}
+
+func (b basketService) Delete(ctx context.Context, key models.PrimaryKey) error {
+	_, err := audited(ctx, b.storage, b.log, models.AuditDelete, auditBasket, key.ID, getBasket,
+		func(store storage.IStorage) (string, error) {
+			return key.ID, store.Basket().Delete(ctx, key)
+		})
+
+	return err
+}
+
+// Patch changes only the given fields of basket and returns it.
+func (b basketService) Patch(ctx context.Context, patchBasket models.PatchBasket) (models.Basket, error) {
+	basket, err := audited(ctx, b.storage, b.log, models.AuditUpdate, auditBasket, patchBasket.ID, getBasket,
+		func(store storage.IStorage) (string, error) {
+			return store.Basket().Patch(ctx, patchBasket)
+		})
 	if err != nil {
-		b.log.Error("error in service layer while getting basket by id", logger.Error(err))
+		b.log.Error("error in service layer while patching basket", logger.Error(err))
 		return models.Basket{}, err
 	}
 
-	return updatedBasket, nil
+	return basket, nil
 }
 
-func (b basketService) Delete(ctx context.Context, key models.PrimaryKey) error {
-	err := b.storage.Basket().Delete(ctx, key)
+// Restore brings back the soft deleted basket and returns it.
+func (b basketService) Restore(ctx context.Context, id string) (models.Basket, error) {
+	basket, err := audited(ctx, b.storage, b.log, models.AuditRestore, auditBasket, id, getBasket,
+		func(store storage.IStorage) (string, error) {
+			return id, store.Basket().Restore(ctx, id)
+		})
+	if err != nil {
+		b.log.Error("error in service layer while restoring basket", logger.Error(err))
+		return models.Basket{}, err
+	}
 
-	return err
+	return basket, nil
+}
+
+// recordChange writes the basket change made in store to the audit log, before is nil for creates.
+func (b basketService) recordChange(ctx context.Context, store storage.IStorage, action, id string, before interface{}) error {
+	after, err := getBasket(ctx, store, id)
+	if err != nil {
+		b.log.Error("Error in service layer when getting basket by id for audit log", logger.Error(err))
+		return err
+	}
+
+	return recordAudit(ctx, store, b.log, action, auditBasket, id, before, after)
 }
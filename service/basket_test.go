@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"market/api/models"
+	"market/pkg/errs"
+	"testing"
+)
+
+func TestBasketCreate(t *testing.T) {
+	ctx := context.Background()
+	services, _ := newTestServices(t)
+	shop := newTestShop(t, services, 1500, 10)
+
+	sale, err := services.Sale().Create(ctx, models.CreateSale{BranchID: shop.branch.ID})
+	if err != nil {
+		t.Fatalf("create sale: %v", err)
+	}
+
+	// adding a product which is in the basket already adds to its quantity
+	steps := []struct {
+		quantity     int
+		wantQuantity int
+		wantPrice    int
+		code         errs.Code
+	}{
+		{quantity: 2, wantQuantity: 2, wantPrice: 3000},
+		{quantity: 3, wantQuantity: 5, wantPrice: 7500},
+		{quantity: 6, code: errs.CodeInsufficientStock},
+		{quantity: 11, code: errs.CodeInsufficientStock},
+		{quantity: 5, wantQuantity: 10, wantPrice: 15000},
+	}
+
+	for i, step := range steps {
+		basket, err := services.Basket().Create(ctx, models.CreateBasket{SaleID: sale.ID, ProductID: shop.product.ID, Quantity: step.quantity})
+		if step.code != "" {
+			if got := errs.CodeOf(err); got != step.code {
+				t.Fatalf("step %d: got error %v with code %q, want code %q", i, err, got, step.code)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("step %d: create basket: %v", i, err)
+		}
+
+		if basket.SaleID != sale.ID || basket.ProductID != shop.product.ID ||
+			basket.Quantity != step.wantQuantity || basket.Price != step.wantPrice {
+			t.Errorf("step %d: got %d pieces for %d, want %d for %d", i, basket.Quantity, basket.Price, step.wantQuantity, step.wantPrice)
+		}
+	}
+
+	baskets, err := services.Basket().GetList(ctx, models.GetListRequest{Page: 1, Limit: 10, Search: sale.ID})
+	if err != nil {
+		t.Fatalf("basket list: %v", err)
+	}
+	if baskets.Count != 1 {
+		t.Errorf("sale has %d baskets, want 1", baskets.Count)
+	}
+
+	// baskets do not reserve stock, it is taken at checkout
+	if got := shop.stock(t, services); got != 10 {
+		t.Errorf("repository has %d left, want 10", got)
+	}
+}
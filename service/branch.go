@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"market/api/models"
+	"market/pkg/logger"
+	"market/storage"
+)
+
+type branchService struct {
+	storage storage.IStorage
+	log     logger.ILogger
+}
+
+func NewBranchService(storage storage.IStorage, log logger.ILogger) branchService {
+	return branchService{
+		storage: storage,
+		log:     log,
+	}
+}
+
+func getBranch(ctx context.Context, store storage.IStorage, id string) (models.Branch, error) {
+	return store.Branch().GetByID(ctx, id)
+}
+
+func (b branchService) Create(ctx context.Context, createBranch models.CreateBranch) (models.Branch, error) {
+	branch, err := audited(ctx, b.storage, b.log, models.AuditCreate, auditBranch, "", getBranch,
+		func(store storage.IStorage) (string, error) {
+			return store.Branch().Create(ctx, createBranch)
+		})
+	if err != nil {
+		b.log.Error("error in service layer while creating branch", logger.Error(err))
+		return models.Branch{}, err
+	}
+
+	return branch, nil
+}
+
+func (b branchService) Get(ctx context.Context, id string) (models.Branch, error) {
+	branch, err := getBranch(ctx, b.storage, id)
+	if err != nil {
+		b.log.Error("error in service layer while getting branch by id", logger.Error(err))
+		return models.Branch{}, err
+	}
+
+	return branch, nil
+}
+
+func (b branchService) GetList(ctx context.Context, request models.GetListRequest) (models.BranchResponse, error) {
+	branches, err := b.storage.Branch().GetList(ctx, request)
+	if err != nil {
+		b.log.Error("error in service layer while getting branch list", logger.Error(err))
+		return models.BranchResponse{}, err
+	}
+
+	return branches, nil
+}
+
+func (b branchService) Update(ctx context.Context, updateBranch models.UpdateBranch) (models.Branch, error) {
+	branch, err := audited(ctx, b.storage, b.log, models.AuditUpdate, auditBranch, updateBranch.ID, getBranch,
+		func(store storage.IStorage) (string, error) {
+			return store.Branch().Update(ctx, updateBranch)
+		})
+	if err != nil {
+		b.log.Error("error in service layer while updating branch", logger.Error(err))
+		return models.Branch{}, err
+	}
+
+	return branch, nil
+}
+
+// Patch changes only the given fields of branch and returns it.
+func (b branchService) Patch(ctx context.Context, patchBranch models.PatchBranch) (models.Branch, error) {
+	branch, err := audited(ctx, b.storage, b.log, models.AuditUpdate, auditBranch, patchBranch.ID, getBranch,
+		func(store storage.IStorage) (string, error) {
+			return store.Branch().Patch(ctx, patchBranch)
+		})
+	if err != nil {
+		b.log.Error("error in service layer while patching branch", logger.Error(err))
+		return models.Branch{}, err
+	}
+
+	return branch, nil
+}
+
+func (b branchService) Delete(ctx context.Context, id string) error {
+	if _, err := audited(ctx, b.storage, b.log, models.AuditDelete, auditBranch, id, getBranch,
+		func(store storage.IStorage) (string, error) {
+			return id, store.Branch().Delete(ctx, id)
+		}); err != nil {
+		b.log.Error("error in service layer while deleting branch", logger.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// Restore brings back the soft deleted branch and returns it.
+func (b branchService) Restore(ctx context.Context, id string) (models.Branch, error) {
+	branch, err := audited(ctx, b.storage, b.log, models.AuditRestore, auditBranch, id, getBranch,
+		func(store storage.IStorage) (string, error) {
+			return id, store.Branch().Restore(ctx, id)
+		})
+	if err != nil {
+		b.log.Error("error in service layer while restoring branch", logger.Error(err))
+		return models.Branch{}, err
+	}
+
+	return branch, nil
+}
@@ -20,22 +20,94 @@ func NewCategoryService(storage storage.IStorage, log logger.ILogger) categorySe
 	}
 }
 
+func getCategory(ctx context.Context, store storage.IStorage, id string) (models.Category, error) {
+	return store.Category().GetByID(ctx, models.PrimaryKey{ID: id})
+}
+
 func (c categoryService) Create(ctx context.Context, createCategory models.CreateCategory) (models.Category, error) {
 	c.log.Info("category create service layer", logger.Any("category", createCategory))
 
-	pKey, err := c.storage.Category().Create(ctx, createCategory)
+	category, err := audited(ctx, c.storage, c.log, models.AuditCreate, auditCategory, "", getCategory,
+		func(store storage.IStorage) (string, error) {
+			return store.Category().Create(ctx, createCategory)
+		})
 	if err != nil {
 		c.log.Error("ERROR in service layer while creating category", logger.Error(err))
 		return models.Category{}, err
 	}
 
-	category, err := c.storage.Category().GetByID(ctx, models.PrimaryKey{
-		ID: pKey,
-	})
+	return category, nil
+}
+
+func (c categoryService) Get(ctx context.Context, id string) (models.Category, error) {
+	category, err := getCategory(ctx, c.storage, id)
+	if err != nil {
+		c.log.Error("error in service layer while getting category by id", logger.Error(err))
+		return models.Category{}, err
+	}
+
+	return category, nil
+}
+
+func (c categoryService) GetList(ctx context.Context, request models.GetListRequest) (models.CategoryResponse, error) {
+	categories, err := c.storage.Category().GetList(ctx, request)
+	if err != nil {
+		c.log.Error("error in service layer while getting category list", logger.Error(err))
+		return models.CategoryResponse{}, err
+	}
+
+	return categories, nil
+}
+
+func (c categoryService) Update(ctx context.Context, updateCategory models.UpdateCategory) (models.Category, error) {
+	category, err := audited(ctx, c.storage, c.log, models.AuditUpdate, auditCategory, updateCategory.ID, getCategory,
+		func(store storage.IStorage) (string, error) {
+			return store.Category().Update(ctx, updateCategory)
+		})
+	if err != nil {
+		c.log.Error("error in service layer while updating category", logger.Error(err))
+		return models.Category{}, err
+	}
+
+	return category, nil
+}
+
+// Patch changes only the given fields of category and returns it.
+func (c categoryService) Patch(ctx context.Context, patchCategory models.PatchCategory) (models.Category, error) {
+	category, err := audited(ctx, c.storage, c.log, models.AuditUpdate, auditCategory, patchCategory.ID, getCategory,
+		func(store storage.IStorage) (string, error) {
+			return store.Category().Patch(ctx, patchCategory)
+		})
+	if err != nil {
+		c.log.Error("error in service layer while patching category", logger.Error(err))
+		return models.Category{}, err
+	}
+
+	return category, nil
+}
+
+func (c categoryService) Delete(ctx context.Context, id string) error {
+	if _, err := audited(ctx, c.storage, c.log, models.AuditDelete, auditCategory, id, getCategory,
+		func(store storage.IStorage) (string, error) {
+			return id, store.Category().Delete(ctx, id)
+		}); err != nil {
+		c.log.Error("error in service layer while deleting category", logger.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// Restore brings back the soft deleted category and returns it.
+func (c categoryService) Restore(ctx context.Context, id string) (models.Category, error) {
+	category, err := audited(ctx, c.storage, c.log, models.AuditRestore, auditCategory, id, getCategory,
+		func(store storage.IStorage) (string, error) {
+			return id, store.Category().Restore(ctx, id)
+		})
 	if err != nil {
-		c.log.Error("ERROR in service layer while getting category", logger.Error(err))
+		c.log.Error("error in service layer while restoring category", logger.Error(err))
 		return models.Category{}, err
 	}
 
 	return category, nil
-}
\ No newline at end of file
+}
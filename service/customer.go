@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"market/api/models"
+	"market/pkg/logger"
+	"market/storage"
+)
+
+type customerService struct {
+	storage storage.IStorage
+	log     logger.ILogger
+}
+
+func NewCustomerService(storage storage.IStorage, log logger.ILogger) customerService {
+	return customerService{
+		storage: storage,
+		log:     log,
+	}
+}
+
+func getCustomer(ctx context.Context, store storage.IStorage, id string) (models.Customer, error) {
+	return store.Customer().GetByID(ctx, id)
+}
+
+func (c customerService) Create(ctx context.Context, createCustomer models.CreateCustomer) (models.Customer, error) {
+	if _, err := c.storage.Branch().GetByID(ctx, createCustomer.BranchID); err != nil {
+		c.log.Error("error in service layer while getting branch for customer", logger.Error(err))
+		return models.Customer{}, err
+	}
+
+	customer, err := audited(ctx, c.storage, c.log, models.AuditCreate, auditCustomer, "", getCustomer,
+		func(store storage.IStorage) (string, error) {
+			return store.Customer().Create(ctx, createCustomer)
+		})
+	if err != nil {
+		c.log.Error("error in service layer while creating customer", logger.Error(err))
+		return models.Customer{}, err
+	}
+
+	return customer, nil
+}
+
+func (c customerService) Get(ctx context.Context, id string) (models.Customer, error) {
+	customer, err := c.storage.Customer().GetByID(ctx, id)
+	if err != nil {
+		c.log.Error("error in service layer while getting customer by id", logger.Error(err))
+		return models.Customer{}, err
+	}
+
+	return customer, nil
+}
+
+func (c customerService) GetList(ctx context.Context, request models.GetListRequest) (models.CustomersResponse, error) {
+	customers, err := c.storage.Customer().GetList(ctx, request)
+	if err != nil {
+		c.log.Error("error in service layer while getting customer list", logger.Error(err))
+		return models.CustomersResponse{}, err
+	}
+
+	return customers, nil
+}
+
+func (c customerService) Update(ctx context.Context, updateCustomer models.UpdateCustomer) (models.Customer, error) {
+	customer, err := audited(ctx, c.storage, c.log, models.AuditUpdate, auditCustomer, updateCustomer.ID, getCustomer,
+		func(store storage.IStorage) (string, error) {
+			return store.Customer().Update(ctx, updateCustomer)
+		})
+	if err != nil {
+		c.log.Error("error in service layer while updating customer", logger.Error(err))
+		return models.Customer{}, err
+	}
+
+	return customer, nil
+}
+
+func (c customerService) Delete(ctx context.Context, id string) error {
+	_, err := audited(ctx, c.storage, c.log, models.AuditDelete, auditCustomer, id, getCustomer,
+		func(store storage.IStorage) (string, error) {
+			return id, store.Customer().Delete(ctx, id)
+		})
+
+	return err
+}
+
+func (c customerService) History(ctx context.Context, id string, request models.GetListRequest) (models.CustomerHistory, error) {
+	customer, err := c.storage.Customer().GetByID(ctx, id)
+	if err != nil {
+		c.log.Error("error in service layer while getting customer by id", logger.Error(err))
+		return models.CustomerHistory{}, err
+	}
+
+	sales, count, err := c.storage.Customer().SaleHistory(ctx, id, request)
+	if err != nil {
+		c.log.Error("error in service layer while getting customer history", logger.Error(err))
+		return models.CustomerHistory{}, err
+	}
+
+	return models.CustomerHistory{
+		Customer: customer,
+		Sales:    sales,
+		Count:    count,
+	}, nil
+}
+
+// Patch changes only the given fields of customer and returns it.
+func (c customerService) Patch(ctx context.Context, patchCustomer models.PatchCustomer) (models.Customer, error) {
+	customer, err := audited(ctx, c.storage, c.log, models.AuditUpdate, auditCustomer, patchCustomer.ID, getCustomer,
+		func(store storage.IStorage) (string, error) {
+			return store.Customer().Patch(ctx, patchCustomer)
+		})
+	if err != nil {
+		c.log.Error("error in service layer while patching customer", logger.Error(err))
+		return models.Customer{}, err
+	}
+
+	return customer, nil
+}
+
+// Restore brings back the soft deleted customer and returns it.
+func (c customerService) Restore(ctx context.Context, id string) (models.Customer, error) {
+	customer, err := audited(ctx, c.storage, c.log, models.AuditRestore, auditCustomer, id, getCustomer,
+		func(store storage.IStorage) (string, error) {
+			return id, store.Customer().Restore(ctx, id)
+		})
+	if err != nil {
+		c.log.Error("error in service layer while restoring customer", logger.Error(err))
+		return models.Customer{}, err
+	}
+
+	return customer, nil
+}
@@ -0,0 +1,274 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"market/api/models"
+	"market/config"
+	"market/pkg/logger"
+	"market/storage"
+	"sync"
+	"time"
+)
+
+// EventHandler handles a domain event in process. Events are delivered at least once,
+// an error makes the event delivered again later to the receivers which have not got it,
+// so handlers should be idempotent by event id.
+type EventHandler func(context.Context, models.Event) error
+
+// EventSink delivers domain events out of the process, like webhooks or a message broker.
+// Like handlers, sinks get every event at least once, Name tells the sink apart from
+// the other receivers of the event.
+type EventSink interface {
+	Name() string
+	Send(context.Context, models.Event) error
+}
+
+// emit writes the event to the outbox with store, services call it with the store of
+// the transaction which makes the change, so the event is saved only with the change.
+func emit(ctx context.Context, store storage.IStorage, eventType, aggregateID string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	return store.Outbox().Add(ctx, models.CreateEvent{
+		Type:        eventType,
+		AggregateID: aggregateID,
+		Payload:     data,
+	})
+}
+
+// emitSaleFinished emits sale.completed or sale.cancelled when checkout finishes the sale.
+func emitSaleFinished(ctx context.Context, store storage.IStorage, sale models.Sale) error {
+	eventType := ""
+	switch sale.Status {
+	case "success":
+		eventType = models.EventSaleCompleted
+	case "cancel":
+		eventType = models.EventSaleCancelled
+	default:
+		return nil
+	}
+
+	return emit(ctx, store, eventType, sale.ID, models.SaleEvent{
+		SaleID:      sale.ID,
+		BranchID:    sale.BranchID,
+		CashierID:   sale.CashierID,
+		CustomerID:  sale.CustomerID,
+		PaymentType: sale.PaymentType,
+		Price:       sale.Price,
+		Status:      sale.Status,
+	})
+}
+
+// emitStockChanged emits stock.changed when the count of the repository changes. before is nil
+// for created or restored repositories and after is nil for deleted ones, their count is 0.
+// The emitted event is added to changes, so that it is counted once the transaction commits.
+func emitStockChanged(ctx context.Context, store storage.IStorage, changes *[]models.StockChangedEvent, before, after *models.Repository) error {
+	event := models.StockChangedEvent{}
+	for _, repository := range []*models.Repository{after, before} {
+		if repository != nil {
+			event.RepositoryID = repository.ID
+			event.ProductID = repository.ProductID
+			event.BranchID = repository.BranchID
+		}
+	}
+
+	if before != nil {
+		event.CountBefore = before.Count
+	}
+	if after != nil {
+		event.CountAfter = after.Count
+	}
+
+	if event.CountBefore == event.CountAfter {
+		return nil
+	}
+
+	if err := emit(ctx, store, models.EventStockChanged, event.RepositoryID, event); err != nil {
+		return err
+	}
+	*changes = append(*changes, event)
+
+	return nil
+}
+
+// emitBalanceChanged emits staff.balance_changed when the balance of the staff member changes.
+func emitBalanceChanged(ctx context.Context, store storage.IStorage, staffID string, before, after int) error {
+	if before == after {
+		return nil
+	}
+
+	return emit(ctx, store, models.EventStaffBalanceChanged, staffID, models.StaffBalanceChangedEvent{
+		StaffID:       staffID,
+		BalanceBefore: before,
+		BalanceAfter:  after,
+	})
+}
+
+// eventSubscriber is a handler subscribed to an event type, name is what the outbox
+// records once the handler has got the event.
+type eventSubscriber struct {
+	name    string
+	handler EventHandler
+}
+
+// eventReceivers are the subscribers and sinks events are dispatched to, they are shared
+// by all copies of eventService.
+type eventReceivers struct {
+	mu          sync.RWMutex
+	subscribers map[string][]eventSubscriber
+	sinks       []EventSink
+}
+
+type eventService struct {
+	storage   storage.IStorage
+	log       logger.ILogger
+	receivers *eventReceivers
+}
+
+func NewEventService(storage storage.IStorage, log logger.ILogger) eventService {
+	return eventService{
+		storage:   storage,
+		log:       log,
+		receivers: &eventReceivers{subscribers: map[string][]eventSubscriber{}},
+	}
+}
+
+// Subscribe registers handler for events of the type, name must be unique among the
+// receivers of the type and stay the same across restarts.
+func (e eventService) Subscribe(eventType, name string, handler EventHandler) {
+	e.receivers.mu.Lock()
+	defer e.receivers.mu.Unlock()
+
+	e.receivers.subscribers[eventType] = append(e.receivers.subscribers[eventType], eventSubscriber{name: name, handler: handler})
+}
+
+// AddSink registers sink for events of every type.
+func (e eventService) AddSink(sink EventSink) {
+	e.receivers.mu.Lock()
+	defer e.receivers.mu.Unlock()
+
+	e.receivers.sinks = append(e.receivers.sinks, sink)
+}
+
+// Run dispatches pending events from the outbox every config.OutboxPollInterval
+// and removes old dispatched events every config.OutboxCleanupInterval until ctx is done.
+func (e eventService) Run(ctx context.Context) {
+	poll := time.NewTicker(config.OutboxPollInterval)
+	defer poll.Stop()
+
+	cleanup := time.NewTicker(config.OutboxCleanupInterval)
+	defer cleanup.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-poll.C:
+			e.dispatch(ctx)
+		case <-cleanup.C:
+			deleted, err := e.storage.Outbox().DeleteDispatched(ctx, config.OutboxRetention)
+			if err != nil {
+				e.log.Error("error in service layer while deleting dispatched events", logger.Error(err))
+				continue
+			}
+			e.log.Info("dispatched events are deleted", logger.Any("count", deleted))
+		}
+	}
+}
+
+// dispatch delivers pending events in batches until none is left or ctx is done.
+func (e eventService) dispatch(ctx context.Context) {
+	for ctx.Err() == nil {
+		events, err := e.storage.Outbox().Claim(ctx, config.OutboxBatchSize, config.OutboxMaxAttempts, config.OutboxClaimLease)
+		if err != nil {
+			e.log.Error("error in service layer while getting pending events", logger.Error(err))
+			return
+		}
+
+		for _, event := range events {
+			if err = e.deliver(ctx, event); err != nil {
+				e.log.Error("error in service layer while delivering event", logger.String("event_id", event.ID),
+					logger.String("type", event.Type), logger.Error(err))
+
+				wait := backoff(event.Attempts+1, config.OutboxBackoffBase, config.OutboxBackoffMax)
+				if err = e.storage.Outbox().MarkFailed(ctx, event.ID, err.Error(), wait); err != nil {
+					e.log.Error("error in service layer while marking event failed", logger.Error(err))
+					return
+				}
+				continue
+			}
+
+			if err = e.storage.Outbox().MarkDispatched(ctx, event.ID); err != nil {
+				e.log.Error("error in service layer while marking event dispatched", logger.Error(err))
+				return
+			}
+		}
+
+		// failed events are not due until their backoff has passed, so they are not claimed again here
+		if len(events) < config.OutboxBatchSize {
+			return
+		}
+	}
+}
+
+// deliver sends the event to its subscribers and to every sink which have not got it yet,
+// each receiver which succeeds is recorded in the outbox, so a failed one does not make the
+// others get the event again. All of them are tried even if some fail, and the errors are
+// returned together.
+func (e eventService) deliver(ctx context.Context, event models.Event) error {
+	e.receivers.mu.RLock()
+	subscribers := e.receivers.subscribers[event.Type]
+	sinks := e.receivers.sinks
+	e.receivers.mu.RUnlock()
+
+	delivered := map[string]bool{}
+	for _, receiver := range event.DeliveredTo {
+		delivered[receiver] = true
+	}
+
+	var errs []error
+	send := func(name string, handler EventHandler) {
+		if delivered[name] {
+			return
+		}
+
+		if err := handler(ctx, event); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			return
+		}
+
+		if err := e.storage.Outbox().MarkDelivered(ctx, event.ID, name); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+
+	for _, subscriber := range subscribers {
+		send(subscriber.name, subscriber.handler)
+	}
+
+	for _, sink := range sinks {
+		send(sink.Name(), sink.Send)
+	}
+
+	return errors.Join(errs...)
+}
+
+// backoff is how long to wait before the next attempt after the given number of failed
+// ones, it doubles every attempt from base up to max.
+func backoff(attempts int, base, max time.Duration) time.Duration {
+	wait := base
+	for i := 1; i < attempts && wait < max; i++ {
+		wait *= 2
+	}
+
+	if wait > max {
+		return max
+	}
+
+	return wait
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"market/api/models"
+	"market/pkg/logger"
+	"testing"
+)
+
+// flakySink fails the first fails events it gets and counts the ones it is sent.
+type flakySink struct {
+	fails int
+	sent  int
+}
+
+func (f *flakySink) Name() string { return "flaky" }
+
+func (f *flakySink) Send(ctx context.Context, event models.Event) error {
+	f.sent++
+	if f.fails > 0 {
+		f.fails--
+		return errors.New("sink is down")
+	}
+
+	return nil
+}
+
+func TestEventIsDeliveredAgainOnlyToFailedReceivers(t *testing.T) {
+	ctx := context.Background()
+	_, store := newTestServices(t)
+	events := NewEventService(store, logger.New("test"))
+
+	handled := 0
+	events.Subscribe(models.EventSaleCompleted, "counter", func(ctx context.Context, event models.Event) error {
+		handled++
+		return nil
+	})
+
+	sink := &flakySink{fails: 1}
+	events.AddSink(sink)
+
+	if err := emit(ctx, store, models.EventSaleCompleted, "sale-1", models.SaleEvent{SaleID: "sale-1"}); err != nil {
+		t.Fatalf("emit: %v", err)
+	}
+
+	// no lease, so the event is due again right away
+	for attempt, wantErr := range []bool{true, false} {
+		claimed, err := store.Outbox().Claim(ctx, 10, 10, 0)
+		if err != nil || len(claimed) != 1 {
+			t.Fatalf("attempt %d: claimed %+v with error %v, want the event", attempt, claimed, err)
+		}
+
+		if err = events.deliver(ctx, claimed[0]); (err != nil) != wantErr {
+			t.Fatalf("attempt %d: deliver returned %v, want error %v", attempt, err, wantErr)
+		}
+	}
+
+	if handled != 1 || sink.sent != 2 {
+		t.Errorf("subscriber got the event %d times and the sink %d, want 1 and 2", handled, sink.sent)
+	}
+}
@@ -0,0 +1,29 @@
+package service
+
+import (
+	"context"
+	"market/pkg/logger"
+	"market/storage"
+)
+
+type healthService struct {
+	storage storage.IStorage
+	log     logger.ILogger
+}
+
+func NewHealthService(storage storage.IStorage, log logger.ILogger) healthService {
+	return healthService{
+		storage: storage,
+		log:     log,
+	}
+}
+
+// Ready reports whether the api can serve requests, that is whether its storage can.
+func (h healthService) Ready(ctx context.Context) error {
+	if err := h.storage.Ping(ctx); err != nil {
+		h.log.Error("error in service layer while checking readiness", logger.Error(err))
+		return err
+	}
+
+	return nil
+}
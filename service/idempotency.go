@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"market/api/models"
+	"market/config"
+	"market/pkg/errs"
+	"market/pkg/logger"
+	"market/storage"
+	"net/http"
+	"time"
+)
+
+// maxIdempotencyKeyLength is the size of idempotency_keys.key column.
+const maxIdempotencyKeyLength = 255
+
+type idempotencyService struct {
+	storage storage.IStorage
+	log     logger.ILogger
+}
+
+func NewIdempotencyService(storage storage.IStorage, log logger.ILogger) idempotencyService {
+	return idempotencyService{
+		storage: storage,
+		log:     log,
+	}
+}
+
+// Begin reserves the key of the request and returns the reservation, which Finish and Release
+// need. If the key was used before for the same endpoint, the stored response is returned with
+// true to be replayed instead of handling the request again. Reusing the key with another body,
+// or while the first request is still handled, is an error. A request which has not finished in
+// config.IdempotencyLease is handled again by its retry.
+func (i idempotencyService) Begin(ctx context.Context, request models.IdempotentResponse) (models.IdempotentResponse, bool, error) {
+	if len(request.Key) > maxIdempotencyKeyLength {
+		return models.IdempotentResponse{}, false, errs.Validation("Idempotency-Key should be at most %d characters", maxIdempotencyKeyLength)
+	}
+
+	stored, reserved, err := i.storage.Idempotency().Reserve(ctx, request, config.IdempotencyKeyTTL, config.IdempotencyLease)
+	if err != nil {
+		i.log.Error("error in service layer while reserving idempotency key", logger.Error(err))
+		return models.IdempotentResponse{}, false, err
+	}
+
+	if reserved {
+		return stored, false, nil
+	}
+
+	if stored.RequestHash != request.RequestHash {
+		return models.IdempotentResponse{}, false, errs.Validation("Idempotency-Key was already used with another request body")
+	}
+
+	if stored.StatusCode == 0 {
+		return models.IdempotentResponse{}, false, errs.Conflict("request with this Idempotency-Key is still in progress, retry later")
+	}
+
+	return stored, true, nil
+}
+
+// Finish stores the response of the request to replay it for retries. Server errors are
+// not stored, the key is released instead so that the retry is handled again.
+func (i idempotencyService) Finish(ctx context.Context, response models.IdempotentResponse) error {
+	if response.StatusCode >= http.StatusInternalServerError {
+		return i.Release(ctx, response)
+	}
+
+	if err := i.storage.Idempotency().Save(ctx, response); err != nil {
+		i.log.Error("error in service layer while saving idempotent response", logger.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// Release frees the key of the request which was not handled to the end, so that the
+// retry is handled again.
+func (i idempotencyService) Release(ctx context.Context, request models.IdempotentResponse) error {
+	if err := i.storage.Idempotency().Release(ctx, request); err != nil {
+		i.log.Error("error in service layer while releasing idempotency key", logger.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// Run removes keys older than config.IdempotencyKeyTTL every config.IdempotencyPurgeInterval until ctx is done.
+func (i idempotencyService) Run(ctx context.Context) {
+	ticker := time.NewTicker(config.IdempotencyPurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deleted, err := i.storage.Idempotency().DeleteExpired(ctx, config.IdempotencyKeyTTL)
+			if err != nil {
+				i.log.Error("error in service layer while deleting expired idempotency keys", logger.Error(err))
+				continue
+			}
+			i.log.Info("expired idempotency keys are deleted", logger.Any("count", deleted))
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"market/api/models"
+	"market/pkg/errs"
+	"net/http"
+	"testing"
+)
+
+func TestIdempotencyReplaysTheStoredResponse(t *testing.T) {
+	ctx := context.Background()
+	services, _ := newTestServices(t)
+	request := models.IdempotentResponse{Key: "key-1", Endpoint: "POST /sale", RequestHash: "hash-1"}
+
+	reservation, replay, err := services.Idempotency().Begin(ctx, request)
+	if err != nil || replay || reservation.ReservedAt.IsZero() {
+		t.Fatalf("begin: replay %v at %s with error %v, want a reservation", replay, reservation.ReservedAt, err)
+	}
+
+	_, _, err = services.Idempotency().Begin(ctx, request)
+	if errs.CodeOf(err) != errs.CodeConflict {
+		t.Errorf("begin while in progress: got error %v, want conflict", err)
+	}
+
+	other := request
+	other.RequestHash = "hash-2"
+	_, _, err = services.Idempotency().Begin(ctx, other)
+	if errs.CodeOf(err) != errs.CodeValidation {
+		t.Errorf("begin with another body: got error %v, want validation", err)
+	}
+
+	// the response is stored only for the reservation which holds the key
+	if err = services.Idempotency().Finish(ctx, models.IdempotentResponse{Key: "key-1", Endpoint: "POST /sale", RequestHash: "hash-1", StatusCode: http.StatusCreated}); errs.CodeOf(err) != errs.CodeConflict {
+		t.Errorf("finish without the reservation: got error %v, want conflict", err)
+	}
+
+	reservation.StatusCode = http.StatusCreated
+	reservation.Body = []byte(`{"id":"1"}`)
+	if err = services.Idempotency().Finish(ctx, reservation); err != nil {
+		t.Fatalf("finish: %v", err)
+	}
+
+	stored, replay, err := services.Idempotency().Begin(ctx, request)
+	if err != nil || !replay || stored.StatusCode != http.StatusCreated || string(stored.Body) != `{"id":"1"}` {
+		t.Errorf("begin after finish: got %+v, replay %v with error %v, want the stored response", stored, replay, err)
+	}
+}
+
+func TestIdempotencyReleasesKeyOnServerError(t *testing.T) {
+	ctx := context.Background()
+	services, _ := newTestServices(t)
+	request := models.IdempotentResponse{Key: "key-1", Endpoint: "POST /sale", RequestHash: "hash-1"}
+
+	reservation, _, err := services.Idempotency().Begin(ctx, request)
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+
+	reservation.StatusCode = http.StatusInternalServerError
+	if err = services.Idempotency().Finish(ctx, reservation); err != nil {
+		t.Fatalf("finish: %v", err)
+	}
+
+	if _, replay, err := services.Idempotency().Begin(ctx, request); err != nil || replay {
+		t.Errorf("begin after server error: replay %v with error %v, want the key reserved again", replay, err)
+	}
+}
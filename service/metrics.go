@@ -0,0 +1,83 @@
+package service
+
+import (
+	"market/api/models"
+	"market/pkg/metrics"
+	"runtime"
+	"strconv"
+	"time"
+)
+
+// metricsService keeps the metrics of the api. Business counters are counted by the services
+// once the transaction of the change commits, so every instance counts the changes it made,
+// each once, and their sum is the total.
+type metricsService struct {
+	registry *metrics.Registry
+
+	requests        *metrics.Counter
+	requestDuration *metrics.Histogram
+	sales           *metrics.Counter
+	salesAmount     *metrics.Counter
+	stockMovements  *metrics.Counter
+	stockUnits      *metrics.Counter
+}
+
+func NewMetricsService() metricsService {
+	registry := metrics.NewRegistry()
+
+	m := metricsService{
+		registry: registry,
+		requests: registry.Counter("http_requests_total",
+			"HTTP requests by route and status code.", "method", "route", "status"),
+		requestDuration: registry.Histogram("http_request_duration_seconds",
+			"Time to serve HTTP requests by route.", metrics.DefaultBuckets, "method", "route"),
+		sales: registry.Counter("market_sales_total",
+			"Finished sales by status (completed or cancelled) and payment type.", "status", "payment_type"),
+		salesAmount: registry.Counter("market_sales_amount_total",
+			"Price of completed sales by payment type.", "payment_type"),
+		stockMovements: registry.Counter("market_stock_movements_total",
+			"Changes of product counts in repositories by direction (in or out).", "direction"),
+		stockUnits: registry.Counter("market_stock_units_total",
+			"Units of products which came in or went out of repositories.", "direction"),
+	}
+
+	registry.GaugeFunc("go_goroutines", "Goroutines that currently exist.",
+		func() float64 { return float64(runtime.NumGoroutine()) })
+
+	return m
+}
+
+// Registry is where other parts of the api, like storage, add their metrics.
+func (m metricsService) Registry() *metrics.Registry {
+	return m.registry
+}
+
+// ObserveRequest counts the request to route, route is the pattern of the path like /sale/:id.
+func (m metricsService) ObserveRequest(method, route string, status int, duration time.Duration) {
+	m.requests.Inc(method, route, strconv.Itoa(status))
+	m.requestDuration.Observe(duration.Seconds(), method, route)
+}
+
+// CountSale counts the sale if checkout finished it.
+func (m metricsService) CountSale(sale models.Sale) {
+	switch sale.Status {
+	case "success":
+		m.sales.Inc("completed", sale.PaymentType)
+		m.salesAmount.Add(float64(sale.Price), sale.PaymentType)
+	case "cancel":
+		m.sales.Inc("cancelled", sale.PaymentType)
+	}
+}
+
+// CountStock counts the changes of product counts in repositories.
+func (m metricsService) CountStock(changes []models.StockChangedEvent) {
+	for _, change := range changes {
+		direction, units := "in", change.CountAfter-change.CountBefore
+		if units < 0 {
+			direction, units = "out", -units
+		}
+
+		m.stockMovements.Inc(direction)
+		m.stockUnits.Add(float64(units), direction)
+	}
+}
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// scrape returns the samples which start with prefix, like market_sales_total{.
+func scrape(t *testing.T, services Service, prefix string) []string {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := services.Metrics().Registry().Write(&buf); err != nil {
+		t.Fatalf("write metrics: %v", err)
+	}
+
+	lines := []string{}
+	for _, line := range strings.Split(buf.String(), "\n") {
+		if strings.HasPrefix(line, prefix) {
+			lines = append(lines, line)
+		}
+	}
+
+	return lines
+}
+
+func TestCommittedCheckoutIsCountedOnce(t *testing.T) {
+	services, store := newTestServices(t)
+	shop := newTestShop(t, services, 1500, 10)
+	customer := newTestCustomer(t, services, store, shop, 0)
+
+	if _, err := checkout(services, newTestSale(t, services, shop, customer.ID, 2), "cash"); err != nil {
+		t.Fatalf("checkout: %v", err)
+	}
+
+	// the checkout which is rolled back is not counted
+	if _, err := checkout(services, newTestSale(t, services, shop, customer.ID, 1), "points"); err == nil {
+		t.Fatal("checkout with points the customer does not have succeeded")
+	}
+
+	want := map[string][]string{
+		"market_sales_total":        {`market_sales_total{status="completed",payment_type="cash"} 1`},
+		"market_sales_amount_total": {`market_sales_amount_total{payment_type="cash"} 3000`},
+		// the repository came in with 10 pieces and 2 went out
+		"market_stock_units_total": {`market_stock_units_total{direction="in"} 10`, `market_stock_units_total{direction="out"} 2`},
+	}
+	for name, lines := range want {
+		got := scrape(t, services, name+"{")
+		if strings.Join(got, "\n") != strings.Join(lines, "\n") {
+			t.Errorf("%s is\n%s\nwant\n%s", name, strings.Join(got, "\n"), strings.Join(lines, "\n"))
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"market/api/models"
+	"market/pkg/errs"
+	"market/pkg/logger"
+	"market/storage"
+	"time"
+)
+
+type payoutService struct {
+	storage storage.IStorage
+	log     logger.ILogger
+}
+
+func NewPayoutService(storage storage.IStorage, log logger.ILogger) payoutService {
+	return payoutService{
+		storage: storage,
+		log:     log,
+	}
+}
+
+func getPayout(ctx context.Context, store storage.IStorage, id string) (models.Payout, error) {
+	return store.Payout().GetByID(ctx, id)
+}
+
+func (p payoutService) Run(ctx context.Context, request models.CreatePayout) (models.Payout, error) {
+	from, err := time.Parse("2006-01-02", request.PeriodFrom)
+	if err != nil {
+		return models.Payout{}, errs.Validation("period_from should be in 2006-01-02 format")
+	}
+
+	to, err := time.Parse("2006-01-02", request.PeriodTo)
+	if err != nil {
+		return models.Payout{}, errs.Validation("period_to should be in 2006-01-02 format")
+	}
+
+	if to.Before(from) {
+		return models.Payout{}, errs.Validation("period_to can not be before period_from")
+	}
+
+	if _, err = p.storage.Branch().GetByID(ctx, request.BranchID); err != nil {
+		p.log.Error("error in service layer while getting branch for payout", logger.Error(err))
+		return models.Payout{}, err
+	}
+
+	// Balanslar o'zgargani haqidagi eventlar to'lov bilan bitta tranzaksiyada yoziladi
+
+	payout, err := auditedWith(ctx, p.storage, p.log, models.AuditCreate, auditPayout, "", getPayout,
+		func(store storage.IStorage) (string, error) {
+			id, err := store.Payout().Run(ctx, request)
+			if err != nil {
+				p.log.Error("error in service layer while running payout", logger.Error(err))
+				return "", err
+			}
+
+			return id, nil
+		}, func(store storage.IStorage, _, after *models.Payout) error {
+			for _, item := range after.Items {
+				if err := emitBalanceChanged(ctx, store, item.StaffID, item.BalanceBefore, item.BalanceBefore-item.Paid); err != nil {
+					p.log.Error("error in service layer while emitting staff balance event", logger.Error(err))
+					return err
+				}
+			}
+
+			return nil
+		})
+	if err != nil {
+		return models.Payout{}, err
+	}
+
+	return payout, nil
+}
+
+func (p payoutService) Get(ctx context.Context, id string) (models.Payout, error) {
+	payout, err := p.storage.Payout().GetByID(ctx, id)
+	if err != nil {
+		p.log.Error("error in service layer while getting payout by id", logger.Error(err))
+		return models.Payout{}, err
+	}
+
+	return payout, nil
+}
+
+func (p payoutService) GetList(ctx context.Context, request models.GetListRequest) (models.PayoutsResponse, error) {
+	payouts, err := p.storage.Payout().GetList(ctx, request)
+	if err != nil {
+		p.log.Error("error in service layer while getting payout list", logger.Error(err))
+		return models.PayoutsResponse{}, err
+	}
+
+	return payouts, nil
+}
+
+func (p payoutService) Statement(ctx context.Context, payoutID, staffID string) (models.PayrollStatement, error) {
+	statement, err := p.storage.Payout().Statement(ctx, payoutID, staffID)
+	if err != nil {
+		p.log.Error("error in service layer while getting payroll statement", logger.Error(err))
+		return models.PayrollStatement{}, err
+	}
+
+	return statement, nil
+}
@@ -0,0 +1,383 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"market/api/models"
+	"market/pkg/errs"
+	"market/pkg/logger"
+	"market/storage"
+	"strconv"
+	"strings"
+)
+
+// lookupLimit is enough to read all categories or branches in one page.
+const lookupLimit = 10000
+
+var ErrInvalidImport = errs.Validation("import file has invalid rows")
+
+type productService struct {
+	storage storage.IStorage
+	log     logger.ILogger
+	metrics metricsService
+}
+
+func NewProductService(storage storage.IStorage, log logger.ILogger, metrics metricsService) productService {
+	return productService{
+		storage: storage,
+		log:     log,
+		metrics: metrics,
+	}
+}
+
+// Import reads products from csv with name, price, barcode and category (name or id)
+// columns. Every other column is a branch name or id with initial stock of the product.
+// Nothing is inserted if any row is invalid, the errors are returned per row with ErrInvalidImport.
+func (p productService) Import(ctx context.Context, file io.Reader) (models.ProductImportResult, error) {
+	result := models.ProductImportResult{Errors: []models.ProductImportError{}}
+
+	reader := csv.NewReader(file)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return result, errs.Wrap(errs.CodeValidation, err, "error while reading csv header: "+err.Error())
+	}
+
+	columns := map[string]int{}
+	for i, column := range header {
+		columns[strings.ToLower(strings.TrimSpace(column))] = i
+	}
+
+	for _, required := range []string{"name", "price", "barcode", "category"} {
+		if _, ok := columns[required]; !ok {
+			return result, errs.Validation("csv should have %s column", required)
+		}
+	}
+
+	categories, err := p.categoryLookup(ctx)
+	if err != nil {
+		return result, err
+	}
+
+	branches, err := p.branchLookup(ctx)
+	if err != nil {
+		return result, err
+	}
+
+	stockColumns := map[int]string{}
+	for i, column := range header {
+		switch strings.ToLower(strings.TrimSpace(column)) {
+		case "name", "price", "barcode", "category":
+			continue
+		}
+
+		branchID, ok := branches[strings.ToLower(strings.TrimSpace(column))]
+		if !ok {
+			result.Errors = append(result.Errors, models.ProductImportError{Row: 1, Column: column, Message: "unknown branch"})
+			continue
+		}
+		stockColumns[i] = branchID
+	}
+
+	var (
+		products = []models.ImportProduct{}
+		names    = map[string]int{}
+		barcodes = map[int]int{}
+		rowError = func(row int, column, message string) {
+			result.Errors = append(result.Errors, models.ProductImportError{Row: row, Column: column, Message: message})
+		}
+	)
+
+	for row := 2; ; row++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			rowError(row, "", err.Error())
+			continue
+		}
+
+		product := models.ImportProduct{Row: row}
+
+		product.Name = strings.TrimSpace(record[columns["name"]])
+		switch {
+		case product.Name == "":
+			rowError(row, "name", "name is required")
+		case len([]rune(product.Name)) > 30:
+			rowError(row, "name", "name should be at most 30 characters")
+		case names[strings.ToLower(product.Name)] != 0:
+			rowError(row, "name", fmt.Sprintf("name is duplicated in row %d", names[strings.ToLower(product.Name)]))
+		default:
+			names[strings.ToLower(product.Name)] = row
+		}
+
+		if product.Price, err = strconv.Atoi(strings.TrimSpace(record[columns["price"]])); err != nil || product.Price <= 0 {
+			rowError(row, "price", "price should be a positive number")
+		}
+
+		product.Barcode, err = strconv.Atoi(strings.TrimSpace(record[columns["barcode"]]))
+		switch {
+		case err != nil || product.Barcode <= 0:
+			rowError(row, "barcode", "barcode should be a positive number")
+		case barcodes[product.Barcode] != 0:
+			rowError(row, "barcode", fmt.Sprintf("barcode is duplicated in row %d", barcodes[product.Barcode]))
+		default:
+			barcodes[product.Barcode] = row
+		}
+
+		category := strings.ToLower(strings.TrimSpace(record[columns["category"]]))
+		if product.CategoryID = categories[category]; product.CategoryID == "" {
+			rowError(row, "category", "unknown category")
+		}
+
+		for i, branchID := range stockColumns {
+			value := strings.TrimSpace(record[i])
+			if value == "" {
+				continue
+			}
+
+			count, err := strconv.Atoi(value)
+			if err != nil || count < 0 {
+				rowError(row, header[i], "stock should be zero or a positive number")
+				continue
+			}
+
+			product.Stocks = append(product.Stocks, models.ImportStock{BranchID: branchID, Count: count})
+		}
+
+		products = append(products, product)
+	}
+
+	if len(products) == 0 && len(result.Errors) == 0 {
+		return result, errs.Validation("csv has no products")
+	}
+
+	if err = p.checkExisting(ctx, products, rowError); err != nil {
+		return result, err
+	}
+
+	if len(result.Errors) > 0 {
+		return result, ErrInvalidImport
+	}
+
+	changes := []models.StockChangedEvent{}
+	if err = p.storage.WithTx(ctx, func(store storage.IStorage) error {
+		if err := store.Product().Import(ctx, products); err != nil {
+			return err
+		}
+
+		return p.imported(ctx, store, &changes, products)
+	}); err != nil {
+		p.log.Error("error in service layer while importing products", logger.Error(err))
+		return result, err
+	}
+
+	p.metrics.CountStock(changes)
+
+	result.Imported = len(products)
+
+	return result, nil
+}
+
+// imported records the imported products and repositories in the audit log and emits
+// stock.changed for the repositories, in the transaction of the import, and adds them to changes.
+func (p productService) imported(ctx context.Context, store storage.IStorage, changes *[]models.StockChangedEvent, products []models.ImportProduct) error {
+	for _, imported := range products {
+		product, err := getProduct(ctx, store, imported.ID)
+		if err != nil {
+			p.log.Error("error in service layer while getting imported product", logger.Error(err))
+			return err
+		}
+
+		if err = recordAudit(ctx, store, p.log, models.AuditCreate, auditProduct, product.ID, nil, product); err != nil {
+			return err
+		}
+
+		for _, stock := range imported.Stocks {
+			repository, err := getRepository(ctx, store, stock.ID)
+			if err != nil {
+				p.log.Error("error in service layer while getting imported repository", logger.Error(err))
+				return err
+			}
+
+			if err = recordAudit(ctx, store, p.log, models.AuditCreate, auditRepository, repository.ID, nil, repository); err != nil {
+				return err
+			}
+
+			if err = emitStockChanged(ctx, store, changes, nil, &repository); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (p productService) checkExisting(ctx context.Context, products []models.ImportProduct, rowError func(int, string, string)) error {
+	var (
+		names    = make([]string, 0, len(products))
+		barcodes = make([]int, 0, len(products))
+	)
+
+	for _, product := range products {
+		names = append(names, product.Name)
+		barcodes = append(barcodes, product.Barcode)
+	}
+
+	existingNames, existingBarcodes, err := p.storage.Product().Existing(ctx, names, barcodes)
+	if err != nil {
+		p.log.Error("error in service layer while checking existing products", logger.Error(err))
+		return err
+	}
+
+	takenNames := map[string]bool{}
+	for _, name := range existingNames {
+		takenNames[name] = true
+	}
+
+	takenBarcodes := map[int]bool{}
+	for _, barcode := range existingBarcodes {
+		takenBarcodes[barcode] = true
+	}
+
+	for _, product := range products {
+		if takenNames[product.Name] {
+			rowError(product.Row, "name", "product with this name already exists")
+		}
+
+		if takenBarcodes[product.Barcode] {
+			rowError(product.Row, "barcode", "product with this barcode already exists")
+		}
+	}
+
+	return nil
+}
+
+// categoryLookup maps lower cased category names and ids to category ids.
+func (p productService) categoryLookup(ctx context.Context) (map[string]string, error) {
+	categories, err := p.storage.Category().GetList(ctx, models.GetListRequest{Page: 1, Limit: lookupLimit})
+	if err != nil {
+		p.log.Error("error in service layer while getting categories for import", logger.Error(err))
+		return nil, err
+	}
+
+	lookup := map[string]string{}
+	for _, category := range categories.Categories {
+		lookup[strings.ToLower(category.Name)] = category.ID
+		lookup[strings.ToLower(category.ID)] = category.ID
+	}
+
+	return lookup, nil
+}
+
+// branchLookup maps lower cased branch names and ids to branch ids.
+func (p productService) branchLookup(ctx context.Context) (map[string]string, error) {
+	branches, err := p.storage.Branch().GetList(ctx, models.GetListRequest{Page: 1, Limit: lookupLimit})
+	if err != nil {
+		p.log.Error("error in service layer while getting branches for import", logger.Error(err))
+		return nil, err
+	}
+
+	lookup := map[string]string{}
+	for _, branch := range branches.Branches {
+		lookup[strings.ToLower(branch.Name)] = branch.ID
+		lookup[strings.ToLower(branch.ID)] = branch.ID
+	}
+
+	return lookup, nil
+}
+
+func getProduct(ctx context.Context, store storage.IStorage, id string) (models.Product, error) {
+	return store.Product().GetByID(ctx, id)
+}
+
+func (p productService) Create(ctx context.Context, createProduct models.CreateProduct) (models.Product, error) {
+	product, err := audited(ctx, p.storage, p.log, models.AuditCreate, auditProduct, "", getProduct,
+		func(store storage.IStorage) (string, error) {
+			return store.Product().Create(ctx, createProduct)
+		})
+	if err != nil {
+		p.log.Error("error in service layer while creating product", logger.Error(err))
+		return models.Product{}, err
+	}
+
+	return product, nil
+}
+
+func (p productService) Get(ctx context.Context, id string) (models.Product, error) {
+	product, err := getProduct(ctx, p.storage, id)
+	if err != nil {
+		p.log.Error("error in service layer while getting product by id", logger.Error(err))
+		return models.Product{}, err
+	}
+
+	return product, nil
+}
+
+func (p productService) GetList(ctx context.Context, request models.ProductGetListRequest) (models.ProductResponse, error) {
+	products, err := p.storage.Product().GetList(ctx, request)
+	if err != nil {
+		p.log.Error("error in service layer while getting product list", logger.Error(err))
+		return models.ProductResponse{}, err
+	}
+
+	return products, nil
+}
+
+func (p productService) Update(ctx context.Context, updateProduct models.UpdateProduct) (models.Product, error) {
+	product, err := audited(ctx, p.storage, p.log, models.AuditUpdate, auditProduct, updateProduct.ID, getProduct,
+		func(store storage.IStorage) (string, error) {
+			return store.Product().Update(ctx, updateProduct)
+		})
+	if err != nil {
+		p.log.Error("error in service layer while updating product", logger.Error(err))
+		return models.Product{}, err
+	}
+
+	return product, nil
+}
+
+// Patch changes only the given fields of product and returns it.
+func (p productService) Patch(ctx context.Context, patchProduct models.PatchProduct) (models.Product, error) {
+	product, err := audited(ctx, p.storage, p.log, models.AuditUpdate, auditProduct, patchProduct.ID, getProduct,
+		func(store storage.IStorage) (string, error) {
+			return store.Product().Patch(ctx, patchProduct)
+		})
+	if err != nil {
+		p.log.Error("error in service layer while patching product", logger.Error(err))
+		return models.Product{}, err
+	}
+
+	return product, nil
+}
+
+func (p productService) Delete(ctx context.Context, id string) error {
+	if _, err := audited(ctx, p.storage, p.log, models.AuditDelete, auditProduct, id, getProduct,
+		func(store storage.IStorage) (string, error) {
+			return id, store.Product().Delete(ctx, id)
+		}); err != nil {
+		p.log.Error("error in service layer while deleting product", logger.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// Restore brings back the soft deleted product and returns it.
+func (p productService) Restore(ctx context.Context, id string) (models.Product, error) {
+	product, err := audited(ctx, p.storage, p.log, models.AuditRestore, auditProduct, id, getProduct,
+		func(store storage.IStorage) (string, error) {
+			return id, store.Product().Restore(ctx, id)
+		})
+	if err != nil {
+		p.log.Error("error in service layer while restoring product", logger.Error(err))
+		return models.Product{}, err
+	}
+
+	return product, nil
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"market/api/models"
+	"market/config"
+	"market/pkg/logger"
+	"market/storage"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type purgeService struct {
+	storage storage.IStorage
+	log     logger.ILogger
+}
+
+func NewPurgeService(storage storage.IStorage, log logger.ILogger) purgeService {
+	return purgeService{
+		storage: storage,
+		log:     log,
+	}
+}
+
+// Purge removes rows which were soft deleted longer than the retention period ago. Each
+// purge is recorded in the audit log under its own id, with the count of removed rows.
+func (p purgeService) Purge(ctx context.Context) (int64, error) {
+	var (
+		purged int64
+		before = time.Now().Add(-config.SoftDeleteRetention)
+	)
+
+	if err := p.storage.WithTx(ctx, func(store storage.IStorage) error {
+		var err error
+		if purged, err = store.Purge().Purge(ctx, before); err != nil {
+			p.log.Error("error in service layer while purging deleted rows", logger.Error(err))
+			return err
+		}
+
+		return recordAudit(ctx, store, p.log, models.AuditPurge, auditPurge, uuid.New().String(), nil, map[string]interface{}{
+			"deleted_before": before,
+			"purged":         purged,
+		})
+	}); err != nil {
+		return 0, err
+	}
+
+	return purged, nil
+}
+
+// Run purges deleted rows every PurgeInterval until ctx is done.
+func (p purgeService) Run(ctx context.Context) {
+	ticker := time.NewTicker(config.PurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			purged, err := p.Purge(ctx)
+			if err != nil {
+				continue
+			}
+			p.log.Info("deleted rows are purged", logger.Any("count", purged))
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"market/api/models"
+	"market/pkg/errs"
+	"market/pkg/logger"
+	"market/storage"
+	"time"
+)
+
+type reportService struct {
+	storage storage.IStorage
+	log     logger.ILogger
+}
+
+func NewReportService(storage storage.IStorage, log logger.ILogger) reportService {
+	return reportService{
+		storage: storage,
+		log:     log,
+	}
+}
+
+func (r reportService) Revenue(ctx context.Context, request models.ReportRequest) ([]models.RevenueReport, error) {
+	request, err := normalizeReportRequest(request)
+	if err != nil {
+		return nil, err
+	}
+
+	switch request.Group {
+	case "":
+		request.Group = "day"
+	case "day", "week", "month":
+	default:
+		return nil, errs.Validation("group should be day, week or month")
+	}
+
+	reports, err := r.storage.Report().Revenue(ctx, request)
+	if err != nil {
+		r.log.Error("error in service layer while getting revenue report", logger.Error(err))
+		return nil, err
+	}
+
+	return reports, nil
+}
+
+func (r reportService) TopProducts(ctx context.Context, request models.ReportRequest) ([]models.ProductReport, error) {
+	request, err := normalizeReportRequest(request)
+	if err != nil {
+		return nil, err
+	}
+
+	switch request.OrderBy {
+	case "":
+		request.OrderBy = "quantity"
+	case "quantity", "revenue":
+	default:
+		return nil, errs.Validation("order_by should be quantity or revenue")
+	}
+
+	if request.Limit <= 0 {
+		request.Limit = 10
+	}
+
+	reports, err := r.storage.Report().TopProducts(ctx, request)
+	if err != nil {
+		r.log.Error("error in service layer while getting top products report", logger.Error(err))
+		return nil, err
+	}
+
+	return reports, nil
+}
+
+func (r reportService) StaffSales(ctx context.Context, request models.ReportRequest) ([]models.StaffSalesReport, error) {
+	request, err := normalizeReportRequest(request)
+	if err != nil {
+		return nil, err
+	}
+
+	reports, err := r.storage.Report().StaffSales(ctx, request)
+	if err != nil {
+		r.log.Error("error in service layer while getting staff sales report", logger.Error(err))
+		return nil, err
+	}
+
+	return reports, nil
+}
+
+func (r reportService) PaymentTypes(ctx context.Context, request models.ReportRequest) ([]models.PaymentTypeReport, error) {
+	request, err := normalizeReportRequest(request)
+	if err != nil {
+		return nil, err
+	}
+
+	reports, err := r.storage.Report().PaymentTypes(ctx, request)
+	if err != nil {
+		r.log.Error("error in service layer while getting payment types report", logger.Error(err))
+		return nil, err
+	}
+
+	return reports, nil
+}
+
+// normalizeReportRequest checks the date range, by default reports are for the last 30 days.
+func normalizeReportRequest(request models.ReportRequest) (models.ReportRequest, error) {
+	const layout = "2006-01-02"
+
+	to := time.Now()
+	if request.To != "" {
+		t, err := time.Parse(layout, request.To)
+		if err != nil {
+			return request, errs.Validation("to should be in 2006-01-02 format")
+		}
+		to = t
+	}
+
+	from := to.AddDate(0, 0, -30)
+	if request.From != "" {
+		f, err := time.Parse(layout, request.From)
+		if err != nil {
+			return request, errs.Validation("from should be in 2006-01-02 format")
+		}
+		from = f
+	}
+
+	if to.Before(from) {
+		return request, errs.Validation("to can not be before from")
+	}
+
+	request.From = from.Format(layout)
+	request.To = to.Format(layout)
+
+	return request, nil
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"market/api/models"
+	"market/pkg/logger"
+	"market/storage"
+)
+
+type repositoryService struct {
+	storage storage.IStorage
+	log     logger.ILogger
+	metrics metricsService
+}
+
+func NewRepositoryService(storage storage.IStorage, log logger.ILogger, metrics metricsService) repositoryService {
+	return repositoryService{
+		storage: storage,
+		log:     log,
+		metrics: metrics,
+	}
+}
+
+func getRepository(ctx context.Context, store storage.IStorage, id string) (models.Repository, error) {
+	return store.Repository().GetByID(ctx, models.PrimaryKey{ID: id})
+}
+
+// stockChanged emits stock.changed in the transaction of the repository change and adds
+// it to changes.
+func stockChanged(ctx context.Context, changes *[]models.StockChangedEvent) func(storage.IStorage, *models.Repository, *models.Repository) error {
+	return func(store storage.IStorage, before, after *models.Repository) error {
+		return emitStockChanged(ctx, store, changes, before, after)
+	}
+}
+
+func (r repositoryService) Create(ctx context.Context, createRepository models.CreateRepository) (models.Repository, error) {
+	changes := []models.StockChangedEvent{}
+	repository, err := auditedWith(ctx, r.storage, r.log, models.AuditCreate, auditRepository, "", getRepository,
+		func(store storage.IStorage) (string, error) {
+			return store.Repository().Create(ctx, createRepository)
+		}, stockChanged(ctx, &changes))
+	if err != nil {
+		r.log.Error("error in service layer while creating repository", logger.Error(err))
+		return models.Repository{}, err
+	}
+
+	r.metrics.CountStock(changes)
+
+	return repository, nil
+}
+
+func (r repositoryService) Get(ctx context.Context, id string) (models.Repository, error) {
+	repository, err := getRepository(ctx, r.storage, id)
+	if err != nil {
+		r.log.Error("error in service layer while getting repository by id", logger.Error(err))
+		return models.Repository{}, err
+	}
+
+	return repository, nil
+}
+
+func (r repositoryService) GetList(ctx context.Context, request models.GetListRequest) (models.RepositoriesResponse, error) {
+	repositories, err := r.storage.Repository().GetList(ctx, request)
+	if err != nil {
+		r.log.Error("error in service layer while getting repository list", logger.Error(err))
+		return models.RepositoriesResponse{}, err
+	}
+
+	return repositories, nil
+}
+
+func (r repositoryService) Update(ctx context.Context, updateRepository models.UpdateRepository) (models.Repository, error) {
+	changes := []models.StockChangedEvent{}
+	repository, err := auditedWith(ctx, r.storage, r.log, models.AuditUpdate, auditRepository, updateRepository.ID, getRepository,
+		func(store storage.IStorage) (string, error) {
+			return store.Repository().Update(ctx, updateRepository)
+		}, stockChanged(ctx, &changes))
+	if err != nil {
+		r.log.Error("error in service layer while updating repository", logger.Error(err))
+		return models.Repository{}, err
+	}
+
+	r.metrics.CountStock(changes)
+
+	return repository, nil
+}
+
+// Patch changes only the given fields of repository and returns it.
+func (r repositoryService) Patch(ctx context.Context, patchRepository models.PatchRepository) (models.Repository, error) {
+	changes := []models.StockChangedEvent{}
+	repository, err := auditedWith(ctx, r.storage, r.log, models.AuditUpdate, auditRepository, patchRepository.ID, getRepository,
+		func(store storage.IStorage) (string, error) {
+			return store.Repository().Patch(ctx, patchRepository)
+		}, stockChanged(ctx, &changes))
+	if err != nil {
+		r.log.Error("error in service layer while patching repository", logger.Error(err))
+		return models.Repository{}, err
+	}
+
+	r.metrics.CountStock(changes)
+
+	return repository, nil
+}
+
+func (r repositoryService) Delete(ctx context.Context, id string) error {
+	changes := []models.StockChangedEvent{}
+	if _, err := auditedWith(ctx, r.storage, r.log, models.AuditDelete, auditRepository, id, getRepository,
+		func(store storage.IStorage) (string, error) {
+			return id, store.Repository().Delete(ctx, id)
+		}, stockChanged(ctx, &changes)); err != nil {
+		r.log.Error("error in service layer while deleting repository", logger.Error(err))
+		return err
+	}
+
+	r.metrics.CountStock(changes)
+
+	return nil
+}
+
+// Restore brings back the soft deleted repository and returns it.
+func (r repositoryService) Restore(ctx context.Context, id string) (models.Repository, error) {
+	changes := []models.StockChangedEvent{}
+	repository, err := auditedWith(ctx, r.storage, r.log, models.AuditRestore, auditRepository, id, getRepository,
+		func(store storage.IStorage) (string, error) {
+			return id, store.Repository().Restore(ctx, id)
+		}, stockChanged(ctx, &changes))
+	if err != nil {
+		r.log.Error("error in service layer while restoring repository", logger.Error(err))
+		return models.Repository{}, err
+	}
+
+	r.metrics.CountStock(changes)
+
+	return repository, nil
+}
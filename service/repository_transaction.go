@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"market/api/models"
+	"market/pkg/logger"
+	"market/storage"
+)
+
+type repositoryTransactionService struct {
+	storage storage.IStorage
+	log     logger.ILogger
+}
+
+func NewRepositoryTransactionService(storage storage.IStorage, log logger.ILogger) repositoryTransactionService {
+	return repositoryTransactionService{
+		storage: storage,
+		log:     log,
+	}
+}
+
+func getRepositoryTransaction(ctx context.Context, store storage.IStorage, id string) (models.RepositoryTransaction, error) {
+	return store.RTransaction().GetByID(ctx, models.PrimaryKey{ID: id})
+}
+
+func (r repositoryTransactionService) Create(ctx context.Context, createRepositoryTransaction models.CreateRepositoryTransaction) (models.RepositoryTransaction, error) {
+	repositoryTransaction, err := audited(ctx, r.storage, r.log, models.AuditCreate, auditRepositoryTransaction, "", getRepositoryTransaction,
+		func(store storage.IStorage) (string, error) {
+			return store.RTransaction().Create(ctx, createRepositoryTransaction)
+		})
+	if err != nil {
+		r.log.Error("error in service layer while creating repository transaction", logger.Error(err))
+		return models.RepositoryTransaction{}, err
+	}
+
+	return repositoryTransaction, nil
+}
+
+func (r repositoryTransactionService) Get(ctx context.Context, id string) (models.RepositoryTransaction, error) {
+	repositoryTransaction, err := getRepositoryTransaction(ctx, r.storage, id)
+	if err != nil {
+		r.log.Error("error in service layer while getting repository transaction by id", logger.Error(err))
+		return models.RepositoryTransaction{}, err
+	}
+
+	return repositoryTransaction, nil
+}
+
+func (r repositoryTransactionService) GetList(ctx context.Context, request models.GetListRequest) (models.RepositoryTransactionsResponse, error) {
+	repositoryTransactions, err := r.storage.RTransaction().GetList(ctx, request)
+	if err != nil {
+		r.log.Error("error in service layer while getting repository transaction list", logger.Error(err))
+		return models.RepositoryTransactionsResponse{}, err
+	}
+
+	return repositoryTransactions, nil
+}
+
+func (r repositoryTransactionService) Update(ctx context.Context, updateRepositoryTransaction models.UpdateRepositoryTransaction) (models.RepositoryTransaction, error) {
+	repositoryTransaction, err := audited(ctx, r.storage, r.log, models.AuditUpdate, auditRepositoryTransaction, updateRepositoryTransaction.ID, getRepositoryTransaction,
+		func(store storage.IStorage) (string, error) {
+			return store.RTransaction().Update(ctx, updateRepositoryTransaction)
+		})
+	if err != nil {
+		r.log.Error("error in service layer while updating repository transaction", logger.Error(err))
+		return models.RepositoryTransaction{}, err
+	}
+
+	return repositoryTransaction, nil
+}
+
+// Patch changes only the given fields of repository transaction and returns it.
+func (r repositoryTransactionService) Patch(ctx context.Context, patchRepositoryTransaction models.PatchRepositoryTransaction) (models.RepositoryTransaction, error) {
+	repositoryTransaction, err := audited(ctx, r.storage, r.log, models.AuditUpdate, auditRepositoryTransaction, patchRepositoryTransaction.ID, getRepositoryTransaction,
+		func(store storage.IStorage) (string, error) {
+			return store.RTransaction().Patch(ctx, patchRepositoryTransaction)
+		})
+	if err != nil {
+		r.log.Error("error in service layer while patching repository transaction", logger.Error(err))
+		return models.RepositoryTransaction{}, err
+	}
+
+	return repositoryTransaction, nil
+}
+
+func (r repositoryTransactionService) Delete(ctx context.Context, id string) error {
+	if _, err := audited(ctx, r.storage, r.log, models.AuditDelete, auditRepositoryTransaction, id, getRepositoryTransaction,
+		func(store storage.IStorage) (string, error) {
+			return id, store.RTransaction().Delete(ctx, id)
+		}); err != nil {
+		r.log.Error("error in service layer while deleting repository transaction", logger.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// Restore brings back the soft deleted repository transaction and returns it.
+func (r repositoryTransactionService) Restore(ctx context.Context, id string) (models.RepositoryTransaction, error) {
+	repositoryTransaction, err := audited(ctx, r.storage, r.log, models.AuditRestore, auditRepositoryTransaction, id, getRepositoryTransaction,
+		func(store storage.IStorage) (string, error) {
+			return id, store.RTransaction().Restore(ctx, id)
+		})
+	if err != nil {
+		r.log.Error("error in service layer while restoring repository transaction", logger.Error(err))
+		return models.RepositoryTransaction{}, err
+	}
+
+	return repositoryTransaction, nil
+}
@@ -1,13 +1,317 @@
 package service
 
-import "market/storage"
+import (
+	"context"
+	"errors"
+	"market/api/models"
+	"market/config"
+	"market/pkg/errs"
+	"market/pkg/logger"
+	"market/storage"
+)
 
 type saleService struct {
 	storage storage.IStorage
+	log     logger.ILogger
+	summary summaryService
+	metrics metricsService
 }
 
-func NewSaleService(storage storage.IStorage) saleService {
+func NewSaleService(storage storage.IStorage, log logger.ILogger, summary summaryService, metrics metricsService) saleService {
 	return saleService{
 		storage: storage,
+		log:     log,
+		summary: summary,
+		metrics: metrics,
 	}
-}
\ No newline at end of file
+}
+
+func getSale(ctx context.Context, store storage.IStorage, id string) (models.Sale, error) {
+	return store.Sale().GetByID(ctx, id)
+}
+
+func (s saleService) Create(ctx context.Context, createSale models.CreateSale) (models.Sale, error) {
+	sale, err := audited(ctx, s.storage, s.log, models.AuditCreate, auditSale, "", getSale,
+		func(store storage.IStorage) (string, error) {
+			shiftID, err := s.shift(ctx, store, createSale.BranchID, createSale.CashierID)
+			if err != nil {
+				return "", err
+			}
+
+			createSale.ShiftID = shiftID
+
+			return store.Sale().Create(ctx, createSale)
+		})
+	if err != nil {
+		s.log.Error("error in service layer while creating sale", logger.Error(err))
+		return models.Sale{}, err
+	}
+
+	return sale, nil
+}
+
+func (s saleService) Receipt(ctx context.Context, id string) (models.Receipt, error) {
+	receipt, err := s.storage.Sale().GetReceipt(ctx, id)
+	if err != nil {
+		s.log.Error("error in service layer while getting receipt", logger.Error(err))
+		return models.Receipt{}, err
+	}
+
+	if receipt.Status == "in_process" {
+		return models.Receipt{}, errs.Conflict("sale is not completed yet")
+	}
+
+	return receipt, nil
+}
+
+func (s saleService) Get(ctx context.Context, id string) (models.Sale, error) {
+	sale, err := getSale(ctx, s.storage, id)
+	if err != nil {
+		s.log.Error("error in service layer while getting sale by id", logger.Error(err))
+		return models.Sale{}, err
+	}
+
+	return sale, nil
+}
+
+func (s saleService) GetList(ctx context.Context, request models.GetListRequest) (models.SaleResponse, error) {
+	sales, err := s.storage.Sale().GetList(ctx, request)
+	if err != nil {
+		s.log.Error("error in service layer while getting sale list", logger.Error(err))
+		return models.SaleResponse{}, err
+	}
+
+	return sales, nil
+}
+
+func (s saleService) Update(ctx context.Context, updateSale models.UpdateSale) (models.Sale, error) {
+	// Sotuv, savat, ombor qoldig'i va ballar bitta tranzaksiyada o'qiladi va o'zgaradi
+
+	changes := []models.StockChangedEvent{}
+	updatedSale, err := auditedWith(ctx, s.storage, s.log, models.AuditUpdate, auditSale, updateSale.ID, getSale,
+		func(store storage.IStorage) (string, error) {
+			sale, err := store.Sale().GetByID(ctx, updateSale.ID)
+			if err != nil {
+				s.log.Error("error in service layer while getting sale by id", logger.Error(err))
+				return "", err
+			}
+
+			if sale.Version != updateSale.Version {
+				return "", errs.Conflict("sale was changed by someone else, get it again and retry")
+			}
+
+			if sale.Status != "in_process" {
+				return "", errs.Conflict("sale status is not 'in_process', cannot update")
+			}
+
+			if updateSale.ShiftID, err = s.shift(ctx, store, sale.BranchID, updateSale.CashierID); err != nil {
+				return "", err
+			}
+
+			baskets, err := store.Basket().GetList(ctx, models.GetListRequest{
+				Page:   1,
+				Limit:  100,
+				Search: updateSale.ID,
+			})
+			if err != nil {
+				s.log.Error("error in service layer while getting baskets by sale id", logger.Error(err))
+				return "", err
+			}
+
+			totalPrice := 0
+			for _, basket := range baskets.Baskets {
+				totalPrice += basket.Price
+			}
+
+			updateSale.Price = float32(totalPrice)
+
+			if updateSale.Status == "success" {
+				for _, basket := range baskets.Baskets {
+					if err = s.takeStock(ctx, store, &changes, sale.BranchID, basket); err != nil {
+						return "", err
+					}
+				}
+			}
+
+			// Ballar bilan to'lanayotgan bo'lsa avval mijoz ballaridan yechib olish
+
+			if updateSale.PaymentType == "points" && updateSale.Status == "success" {
+				if sale.CustomerID == "" {
+					return "", errs.Validation("only customers can pay with points")
+				}
+
+				if err := s.addPoints(ctx, store, sale.CustomerID, -totalPrice); err != nil {
+					s.log.Error("error in service layer while redeeming points", logger.Error(err))
+					return "", err
+				}
+			}
+
+			id, err := store.Sale().Update(ctx, updateSale)
+			if err != nil {
+				s.log.Error("error in service layer while updating sale", logger.Error(err))
+				return "", err
+			}
+
+			if updateSale.Status == "success" && updateSale.PaymentType != "points" && sale.CustomerID != "" {
+				points := totalPrice * config.LoyaltyPercent / 100
+				if err := s.addPoints(ctx, store, sale.CustomerID, points); err != nil {
+					s.log.Error("error in service layer while accruing points", logger.Error(err))
+					return "", err
+				}
+			}
+
+			return id, nil
+		}, func(store storage.IStorage, _, after *models.Sale) error {
+			return emitSaleFinished(ctx, store, *after)
+		})
+	if err != nil {
+		return models.Sale{}, err
+	}
+
+	s.summary.Notify(updatedSale.ID)
+	s.metrics.CountSale(updatedSale)
+	s.metrics.CountStock(changes)
+
+	return updatedSale, nil
+}
+
+// shift returns the id of the shift the cashier has open in the branch, a cashier sells
+// only in an open shift so that the sale is counted in its z-report. Sales without a
+// cashier have no shift. The shift is locked for share until the sale is saved, so it is
+// not closed before its z-report can count the sale.
+func (s saleService) shift(ctx context.Context, store storage.IStorage, branchID, cashierID string) (string, error) {
+	if cashierID == "" {
+		return "", nil
+	}
+
+	shift, err := store.Shift().OpenByCashierForShare(ctx, cashierID)
+	if errors.Is(err, errs.ErrNotFound) {
+		return "", errs.Conflict("cashier has no open shift, open one before selling")
+	}
+	if err != nil {
+		s.log.Error("error in service layer while getting open shift of cashier", logger.Error(err))
+		return "", err
+	}
+
+	if shift.BranchID != branchID {
+		return "", errs.Forbidden("cashier's shift is open in another branch")
+	}
+
+	return shift.ID, nil
+}
+
+// takeStock takes the products of the basket from the repository of the branch. The
+// repository stays locked until the checkout ends, so two checkouts can not both sell
+// the last items. The change of the stock is added to changes.
+func (s saleService) takeStock(ctx context.Context, store storage.IStorage, changes *[]models.StockChangedEvent, branchID string, basket models.Basket) error {
+	repository, err := store.Repository().ForUpdate(ctx, branchID, basket.ProductID)
+	if errors.Is(err, errs.ErrNotFound) {
+		return errs.InsufficientStock("product %s is not in the repository of the branch", basket.ProductID)
+	}
+	if err != nil {
+		s.log.Error("error in service layer while getting repository for checkout", logger.Error(err))
+		return err
+	}
+
+	if repository.Count < basket.Quantity {
+		return errs.InsufficientStock("we don't have enough product")
+	}
+
+	count := repository.Count - basket.Quantity
+	if _, err = auditedWith(ctx, store, s.log, models.AuditUpdate, auditRepository, repository.ID, getRepository,
+		func(store storage.IStorage) (string, error) {
+			return store.Repository().Patch(ctx, models.PatchRepository{
+				ID:      repository.ID,
+				Count:   &count,
+				Version: repository.Version,
+			})
+		}, stockChanged(ctx, changes)); err != nil {
+		s.log.Error("error in service layer while taking products from repository", logger.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// addPoints adds points to the customer in the transaction of store and records the change
+// in the audit log, negative points are taken away.
+func (s saleService) addPoints(ctx context.Context, store storage.IStorage, customerID string, points int) error {
+	if points == 0 {
+		return nil
+	}
+
+	_, err := audited(ctx, store, s.log, models.AuditUpdate, auditCustomer, customerID, getCustomer,
+		func(store storage.IStorage) (string, error) {
+			return customerID, store.Customer().AddPoints(ctx, customerID, points)
+		})
+
+	return err
+}
+
+// Patch changes only the given fields of the sale while it is in process,
+// checkout still goes through Update.
+func (s saleService) Patch(ctx context.Context, patchSale models.PatchSale) (models.Sale, error) {
+	sale, err := s.storage.Sale().GetByID(ctx, patchSale.ID)
+	if err != nil {
+		s.log.Error("error in service layer while getting sale by id", logger.Error(err))
+		return models.Sale{}, err
+	}
+
+	if sale.Status != "in_process" {
+		return models.Sale{}, errs.Conflict("sale status is not 'in_process', cannot update")
+	}
+
+	patchedSale, err := audited(ctx, s.storage, s.log, models.AuditUpdate, auditSale, patchSale.ID, getSale,
+		func(store storage.IStorage) (string, error) {
+			if patchSale.CashierID != nil {
+				shiftID, err := s.shift(ctx, store, sale.BranchID, *patchSale.CashierID)
+				if err != nil {
+					return "", err
+				}
+
+				patchSale.ShiftID = &shiftID
+			}
+
+			return store.Sale().Patch(ctx, patchSale)
+		})
+	if err != nil {
+		s.log.Error("error in service layer while patching sale", logger.Error(err))
+		return models.Sale{}, err
+	}
+
+	return patchedSale, nil
+}
+
+func (s saleService) Delete(ctx context.Context, id string) error {
+	if _, err := audited(ctx, s.storage, s.log, models.AuditDelete, auditSale, id, getSale,
+		func(store storage.IStorage) (string, error) {
+			if err := store.Summary().RemoveSale(ctx, id); err != nil {
+				s.log.Error("error in service layer while removing sale from summaries", logger.Error(err))
+				return "", err
+			}
+
+			return id, store.Sale().Delete(ctx, id)
+		}); err != nil {
+		s.log.Error("error in service layer while deleting sale", logger.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// Restore brings back the soft deleted sale and returns it.
+func (s saleService) Restore(ctx context.Context, id string) (models.Sale, error) {
+	sale, err := audited(ctx, s.storage, s.log, models.AuditRestore, auditSale, id, getSale,
+		func(store storage.IStorage) (string, error) {
+			return id, store.Sale().Restore(ctx, id)
+		})
+	if err != nil {
+		s.log.Error("error in service layer while restoring sale", logger.Error(err))
+		return models.Sale{}, err
+	}
+
+	// o'chirilganda hisobotdan ayrilgan sotuv yana qo'shiladi
+	s.summary.Notify(sale.ID)
+
+	return sale, nil
+}
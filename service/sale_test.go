@@ -0,0 +1,244 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"market/api/models"
+	"market/config"
+	"market/pkg/errs"
+	"market/storage"
+	"testing"
+)
+
+// newTestSale creates a sale of the customer in the shop with quantity pieces in its basket.
+func newTestSale(t *testing.T, services Service, shop testShop, customerID string, quantity int) models.Sale {
+	t.Helper()
+	ctx := context.Background()
+
+	sale, err := services.Sale().Create(ctx, models.CreateSale{BranchID: shop.branch.ID, CustomerID: customerID})
+	if err != nil {
+		t.Fatalf("create sale: %v", err)
+	}
+
+	if _, err = services.Basket().Create(ctx, models.CreateBasket{SaleID: sale.ID, ProductID: shop.product.ID, Quantity: quantity}); err != nil {
+		t.Fatalf("create basket: %v", err)
+	}
+
+	if sale, err = services.Sale().Get(ctx, sale.ID); err != nil {
+		t.Fatalf("get sale: %v", err)
+	}
+
+	return sale
+}
+
+// newTestCustomer creates a customer of the shop who has points.
+func newTestCustomer(t *testing.T, services Service, store storage.IStorage, shop testShop, points int) models.Customer {
+	t.Helper()
+	ctx := context.Background()
+
+	customer, err := services.Customer().Create(ctx, models.CreateCustomer{Phone: "+998901234567", Name: "Aziz", BranchID: shop.branch.ID})
+	if err != nil {
+		t.Fatalf("create customer: %v", err)
+	}
+
+	if err = store.Customer().AddPoints(ctx, customer.ID, points); err != nil {
+		t.Fatalf("add points: %v", err)
+	}
+
+	return customer
+}
+
+func checkout(services Service, sale models.Sale, paymentType string) (models.Sale, error) {
+	return services.Sale().Update(context.Background(), models.UpdateSale{
+		ID:          sale.ID,
+		PaymentType: paymentType,
+		Status:      "success",
+		Version:     sale.Version,
+	})
+}
+
+func customerPoints(t *testing.T, services Service, id string) int {
+	t.Helper()
+
+	customer, err := services.Customer().Get(context.Background(), id)
+	if err != nil {
+		t.Fatalf("get customer: %v", err)
+	}
+
+	return customer.Points
+}
+
+func TestSaleCheckoutWithPoints(t *testing.T) {
+	services, store := newTestServices(t)
+	shop := newTestShop(t, services, 1500, 10)
+	customer := newTestCustomer(t, services, store, shop, 5000)
+	sale := newTestSale(t, services, shop, customer.ID, 2)
+
+	sold, err := checkout(services, sale, "points")
+	if err != nil {
+		t.Fatalf("checkout: %v", err)
+	}
+
+	if sold.Status != "success" || sold.Price != 3000 || sold.Version != sale.Version+1 {
+		t.Errorf("got sale %s for %v with version %d, want success for 3000 with version %d",
+			sold.Status, sold.Price, sold.Version, sale.Version+1)
+	}
+
+	// paying with points does not earn points
+	if got := customerPoints(t, services, customer.ID); got != 2000 {
+		t.Errorf("customer has %d points, want 2000", got)
+	}
+
+	if got := shop.stock(t, services); got != 8 {
+		t.Errorf("repository has %d left, want 8", got)
+	}
+}
+
+func TestSaleCheckoutAccruesPoints(t *testing.T) {
+	services, store := newTestServices(t)
+	shop := newTestShop(t, services, 1500, 10)
+	customer := newTestCustomer(t, services, store, shop, 0)
+	sale := newTestSale(t, services, shop, customer.ID, 2)
+
+	if _, err := checkout(services, sale, "cash"); err != nil {
+		t.Fatalf("checkout: %v", err)
+	}
+
+	if got, want := customerPoints(t, services, customer.ID), 3000*config.LoyaltyPercent/100; got != want {
+		t.Errorf("customer has %d points, want %d", got, want)
+	}
+
+	// the accrual is in the audit log next to the creation of the customer
+	logs, err := services.Audit().GetList(context.Background(), models.AuditListRequest{Page: 1, Limit: 10, Entity: "customer", EntityID: customer.ID})
+	if err != nil {
+		t.Fatalf("audit list: %v", err)
+	}
+	if logs.Count != 2 {
+		t.Errorf("customer has %d audit logs, want the create and the accrual", logs.Count)
+	}
+}
+
+func TestSaleCheckoutRollsBack(t *testing.T) {
+	tests := []struct {
+		name        string
+		points      int
+		takeStock   int
+		paymentType string
+		code        errs.Code
+	}{
+		{name: "not enough points", points: 100, paymentType: "points", code: errs.CodeValidation},
+		{name: "not enough stock", points: 5000, takeStock: 9, paymentType: "points", code: errs.CodeInsufficientStock},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			services, store := newTestServices(t)
+			shop := newTestShop(t, services, 1500, 10)
+			customer := newTestCustomer(t, services, store, shop, tt.points)
+			sale := newTestSale(t, services, shop, customer.ID, 2)
+
+			// stock is sold by another sale after the basket was filled
+			if tt.takeStock > 0 {
+				other := newTestSale(t, services, shop, "", tt.takeStock)
+				if _, err := checkout(services, other, "cash"); err != nil {
+					t.Fatalf("checkout of other sale: %v", err)
+				}
+			}
+			stock := shop.stock(t, services)
+
+			_, err := checkout(services, sale, tt.paymentType)
+			if got := errs.CodeOf(err); got != tt.code {
+				t.Fatalf("got error %v with code %q, want code %q", err, got, tt.code)
+			}
+
+			after, err := services.Sale().Get(ctx, sale.ID)
+			if err != nil {
+				t.Fatalf("get sale: %v", err)
+			}
+			if after.Status != "in_process" || after.Version != sale.Version {
+				t.Errorf("sale is %s with version %d, want in_process with version %d", after.Status, after.Version, sale.Version)
+			}
+
+			if got := shop.stock(t, services); got != stock {
+				t.Errorf("repository has %d left, want %d", got, stock)
+			}
+
+			if got := customerPoints(t, services, customer.ID); got != tt.points {
+				t.Errorf("customer has %d points, want %d", got, tt.points)
+			}
+
+			logs, err := services.Audit().GetList(ctx, models.AuditListRequest{Page: 1, Limit: 10, Entity: auditSale, EntityID: sale.ID})
+			if err != nil {
+				t.Fatalf("audit log: %v", err)
+			}
+			for _, log := range logs.AuditLogs {
+				if log.Action != models.AuditCreate {
+					t.Errorf("failed checkout left %s audit record", log.Action)
+				}
+			}
+		})
+	}
+}
+
+func TestSaleDeleteAndRestore(t *testing.T) {
+	ctx := context.Background()
+	services, _ := newTestServices(t)
+	shop := newTestShop(t, services, 1500, 10)
+	sale := newTestSale(t, services, shop, "", 1)
+
+	if err := services.Sale().Delete(ctx, sale.ID); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+
+	if _, err := services.Sale().Get(ctx, sale.ID); !errors.Is(err, errs.ErrNotFound) {
+		t.Errorf("deleted sale: got error %v, want not found", err)
+	}
+
+	sales, err := services.Sale().GetList(ctx, models.GetListRequest{Page: 1, Limit: 10})
+	if err != nil {
+		t.Fatalf("sale list: %v", err)
+	}
+	if sales.Count != 0 {
+		t.Errorf("sale list has %d sales, want the deleted one left out", sales.Count)
+	}
+
+	sales, err = services.Sale().GetList(ctx, models.GetListRequest{Page: 1, Limit: 10, IncludeDeleted: true})
+	if err != nil {
+		t.Fatalf("sale list with deleted: %v", err)
+	}
+	if sales.Count != 1 || sales.Sales[0].DeletedAt == 0 {
+		t.Errorf("sale list with deleted has %d sales, want the deleted one with deleted_at", sales.Count)
+	}
+
+	restored, err := services.Sale().Restore(ctx, sale.ID)
+	if err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	if restored.ID != sale.ID || restored.DeletedAt != 0 {
+		t.Errorf("restored sale %s has deleted_at %d, want %s with 0", restored.ID, restored.DeletedAt, sale.ID)
+	}
+
+	if _, err = services.Sale().Get(ctx, sale.ID); err != nil {
+		t.Errorf("restored sale: %v", err)
+	}
+
+	if _, err = services.Sale().Restore(ctx, sale.ID); !errors.Is(err, errs.ErrNotFound) {
+		t.Errorf("restoring a sale which is not deleted: got error %v, want not found", err)
+	}
+
+	logs, err := services.Audit().GetList(ctx, models.AuditListRequest{Page: 1, Limit: 10, Entity: auditSale, EntityID: sale.ID})
+	if err != nil {
+		t.Fatalf("audit log: %v", err)
+	}
+
+	actions := map[string]bool{}
+	for _, log := range logs.AuditLogs {
+		actions[log.Action] = true
+	}
+	for _, action := range []string{models.AuditCreate, models.AuditDelete, models.AuditRestore} {
+		if !actions[action] {
+			t.Errorf("audit log has no %s record of the sale", action)
+		}
+	}
+}
@@ -8,18 +8,79 @@ import (
 type IServiceManager interface {
 	Basket() basketService
 	Category() categoryService
-
+	Shift() shiftService
+	Sale() saleService
+	Customer() customerService
+	Staff() staffService
+	Payout() payoutService
+	Report() reportService
+	Product() productService
+	Summary() summaryService
+	Branch() branchService
+	Repository() repositoryService
+	RepositoryTransaction() repositoryTransactionService
+	StaffTarif() staffTarifService
+	Transaction() transactionService
+	Idempotency() idempotencyService
+	Purge() purgeService
+	Audit() auditService
+	Events() eventService
+	Webhook() webhookService
+	Health() healthService
+	Metrics() metricsService
 }
 
 type Service struct {
 	basketService basketService
 	categoryService categoryService
+	shiftService shiftService
+	saleService saleService
+	customerService customerService
+	staffService staffService
+	payoutService payoutService
+	reportService reportService
+	productService productService
+	summaryService summaryService
+	branchService branchService
+	repositoryService repositoryService
+	repositoryTransactionService repositoryTransactionService
+	staffTarifService staffTarifService
+	transactionService transactionService
+	idempotencyService idempotencyService
+	purgeService purgeService
+	auditService auditService
+	eventService eventService
+	webhookService webhookService
+	healthService healthService
+	metricsService metricsService
 }
 
 func New(storage storage.IStorage,  log logger.ILogger) Service {
 	services := Service{}
 
 	services.basketService = NewBasketService(storage, log)
+	services.categoryService = NewCategoryService(storage, log)
+	services.shiftService = NewShiftService(storage, log)
+	services.metricsService = NewMetricsService()
+	services.summaryService = NewSummaryService(storage, log)
+	services.saleService = NewSaleService(storage, log, services.summaryService, services.metricsService)
+	services.customerService = NewCustomerService(storage, log)
+	services.staffService = NewStaffService(storage, log)
+	services.payoutService = NewPayoutService(storage, log)
+	services.reportService = NewReportService(storage, log)
+	services.productService = NewProductService(storage, log, services.metricsService)
+	services.branchService = NewBranchService(storage, log)
+	services.repositoryService = NewRepositoryService(storage, log, services.metricsService)
+	services.repositoryTransactionService = NewRepositoryTransactionService(storage, log)
+	services.staffTarifService = NewStaffTarifService(storage, log)
+	services.transactionService = NewTransactionService(storage, log)
+	services.idempotencyService = NewIdempotencyService(storage, log)
+	services.purgeService = NewPurgeService(storage, log)
+	services.auditService = NewAuditService(storage, log)
+	services.eventService = NewEventService(storage, log)
+	services.webhookService = NewWebhookService(storage, log)
+	services.eventService.AddSink(services.webhookService)
+	services.healthService = NewHealthService(storage, log)
 
 	return  services
 }
@@ -30,4 +91,84 @@ func (s Service) Basket() basketService {
 
 func (s Service) Category() categoryService {
 	return s.categoryService
-}
\ No newline at end of file
+}
+
+func (s Service) Shift() shiftService {
+	return s.shiftService
+}
+
+func (s Service) Sale() saleService {
+	return s.saleService
+}
+
+func (s Service) Customer() customerService {
+	return s.customerService
+}
+
+func (s Service) Staff() staffService {
+	return s.staffService
+}
+
+func (s Service) Payout() payoutService {
+	return s.payoutService
+}
+
+func (s Service) Report() reportService {
+	return s.reportService
+}
+
+func (s Service) Product() productService {
+	return s.productService
+}
+
+func (s Service) Summary() summaryService {
+	return s.summaryService
+}
+
+func (s Service) Branch() branchService {
+	return s.branchService
+}
+
+func (s Service) Repository() repositoryService {
+	return s.repositoryService
+}
+
+func (s Service) RepositoryTransaction() repositoryTransactionService {
+	return s.repositoryTransactionService
+}
+
+func (s Service) StaffTarif() staffTarifService {
+	return s.staffTarifService
+}
+
+func (s Service) Transaction() transactionService {
+	return s.transactionService
+}
+
+func (s Service) Idempotency() idempotencyService {
+	return s.idempotencyService
+}
+
+func (s Service) Purge() purgeService {
+	return s.purgeService
+}
+
+func (s Service) Audit() auditService {
+	return s.auditService
+}
+
+func (s Service) Events() eventService {
+	return s.eventService
+}
+
+func (s Service) Webhook() webhookService {
+	return s.webhookService
+}
+
+func (s Service) Health() healthService {
+	return s.healthService
+}
+
+func (s Service) Metrics() metricsService {
+	return s.metricsService
+}
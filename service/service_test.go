@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"market/api/models"
+	"market/pkg/errs"
+	"market/pkg/logger"
+	"market/storage"
+	"market/storage/memory"
+	"testing"
+)
+
+// testShop is a branch with one product in its repository.
+type testShop struct {
+	branch     models.Branch
+	product    models.Product
+	repository models.Repository
+}
+
+func newTestServices(t *testing.T) (Service, storage.IStorage) {
+	t.Helper()
+
+	log := logger.New("test")
+	store := memory.New(log)
+
+	return New(store, log), store
+}
+
+// newTestShop creates a branch which has count pieces of a product priced price.
+func newTestShop(t *testing.T, services Service, price, count int) testShop {
+	t.Helper()
+	ctx := context.Background()
+
+	branch, err := services.Branch().Create(ctx, models.CreateBranch{Name: "Chilonzor", Address: "Bunyodkor 1"})
+	if err != nil {
+		t.Fatalf("create branch: %v", err)
+	}
+
+	category, err := services.Category().Create(ctx, models.CreateCategory{Name: "Ichimliklar"})
+	if err != nil {
+		t.Fatalf("create category: %v", err)
+	}
+
+	product, err := services.Product().Create(ctx, models.CreateProduct{Name: "Choy", Price: price, Barcode: 4780001, CategoryID: category.ID})
+	if err != nil {
+		t.Fatalf("create product: %v", err)
+	}
+
+	repository, err := services.Repository().Create(ctx, models.CreateRepository{ProductID: product.ID, BranchID: branch.ID, Count: count})
+	if err != nil {
+		t.Fatalf("create repository: %v", err)
+	}
+
+	return testShop{branch: branch, product: product, repository: repository}
+}
+
+// stock returns how many pieces of the product are left in the shop.
+func (s testShop) stock(t *testing.T, services Service) int {
+	t.Helper()
+
+	repository, err := services.Repository().Get(context.Background(), s.repository.ID)
+	if err != nil {
+		t.Fatalf("get repository: %v", err)
+	}
+
+	return repository.Count
+}
+
+func TestWithTxRollsBackOnError(t *testing.T) {
+	ctx := context.Background()
+	services, store := newTestServices(t)
+	errBoom := errors.New("boom")
+
+	var branchID string
+	_, err := auditedWith(ctx, store, services.Branch().log, models.AuditCreate, auditBranch, "", getBranch,
+		func(store storage.IStorage) (string, error) {
+			id, err := store.Branch().Create(ctx, models.CreateBranch{Name: "Yunusobod"})
+			branchID = id
+			return id, err
+		}, func(storage.IStorage, *models.Branch, *models.Branch) error {
+			return errBoom
+		})
+	if !errors.Is(err, errBoom) {
+		t.Fatalf("got error %v, want %v", err, errBoom)
+	}
+
+	if _, err = services.Branch().Get(ctx, branchID); !errors.Is(err, errs.ErrNotFound) {
+		t.Errorf("branch of rolled back transaction: got error %v, want not found", err)
+	}
+
+	logs, err := services.Audit().GetList(ctx, models.AuditListRequest{Page: 1, Limit: 10, Entity: auditBranch})
+	if err != nil {
+		t.Fatalf("audit log: %v", err)
+	}
+	if logs.Count != 0 {
+		t.Errorf("rolled back transaction left %d audit records", logs.Count)
+	}
+}
+
+func TestWithTxCommitsNestedTransaction(t *testing.T) {
+	ctx := context.Background()
+	services, store := newTestServices(t)
+
+	var branchID string
+	if err := store.WithTx(ctx, func(store storage.IStorage) error {
+		branch, err := audited(ctx, store, services.Branch().log, models.AuditCreate, auditBranch, "", getBranch,
+			func(store storage.IStorage) (string, error) {
+				return store.Branch().Create(ctx, models.CreateBranch{Name: "Yunusobod"})
+			})
+		branchID = branch.ID
+		return err
+	}); err != nil {
+		t.Fatalf("with tx: %v", err)
+	}
+
+	if _, err := services.Branch().Get(ctx, branchID); err != nil {
+		t.Errorf("branch of committed transaction: %v", err)
+	}
+}
@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"market/api/models"
+	"market/pkg/errs"
+	"market/pkg/logger"
+	"market/storage"
+)
+
+type shiftService struct {
+	storage storage.IStorage
+	log     logger.ILogger
+}
+
+func NewShiftService(storage storage.IStorage, log logger.ILogger) shiftService {
+	return shiftService{
+		storage: storage,
+		log:     log,
+	}
+}
+
+func getShift(ctx context.Context, store storage.IStorage, id string) (models.Shift, error) {
+	return store.Shift().GetByID(ctx, id)
+}
+
+func (s shiftService) Open(ctx context.Context, openShift models.OpenShift) (models.Shift, error) {
+	cashier, err := s.storage.Staff().StaffByID(ctx, models.PrimaryKey{ID: openShift.CashierID})
+	if err != nil {
+		s.log.Error("error in service layer while getting cashier for shift", logger.Error(err))
+		return models.Shift{}, err
+	}
+
+	if cashier.StaffType != "cashier" {
+		return models.Shift{}, errs.Forbidden("only cashiers can open a shift")
+	}
+
+	if cashier.BranchID != openShift.BranchID {
+		return models.Shift{}, errs.Forbidden("cashier does not work in this branch")
+	}
+
+	if openShift.OpeningCash < 0 {
+		return models.Shift{}, errs.Validation("opening cash can not be negative")
+	}
+
+	// Kassirda ochiq smena bo'lsa yangisini ochishga ruxsat bermaslik
+
+	shift, err := audited(ctx, s.storage, s.log, models.AuditCreate, auditShift, "", getShift,
+		func(store storage.IStorage) (string, error) {
+			if _, err := store.Shift().GetOpenByCashier(ctx, openShift.CashierID); err == nil {
+				return "", errs.Conflict("cashier already has an open shift")
+			} else if !errors.Is(err, errs.ErrNotFound) {
+				s.log.Error("error in service layer while checking open shift", logger.Error(err))
+				return "", err
+			}
+
+			id, err := store.Shift().Open(ctx, openShift)
+			if err != nil {
+				s.log.Error("error in service layer while opening shift", logger.Error(err))
+				return "", err
+			}
+
+			return id, nil
+		})
+	if err != nil {
+		return models.Shift{}, err
+	}
+
+	return shift, nil
+}
+
+func (s shiftService) Close(ctx context.Context, closeShift models.CloseShift) (models.ZReport, error) {
+	if _, err := audited(ctx, s.storage, s.log, models.AuditUpdate, auditShift, closeShift.ID, getShift,
+		func(store storage.IStorage) (string, error) {
+			// the lock waits for the checkouts in the shift, and keeps new ones out until the z-report is taken
+			shift, err := store.Shift().ForUpdate(ctx, closeShift.ID)
+			if err != nil {
+				s.log.Error("error in service layer while locking shift", logger.Error(err))
+				return "", err
+			}
+
+			if shift.Status != "open" {
+				return "", errs.Conflict("shift is already closed")
+			}
+
+			report, err := store.Shift().ZReport(ctx, shift)
+			if err != nil {
+				s.log.Error("error in service layer while calculating z-report", logger.Error(err))
+				return "", err
+			}
+
+			closeShift.ExpectedCash = report.ExpectedCash
+
+			if _, err = store.Shift().Close(ctx, closeShift); err != nil {
+				s.log.Error("error in service layer while closing shift", logger.Error(err))
+				return "", err
+			}
+
+			return closeShift.ID, nil
+		}); err != nil {
+		return models.ZReport{}, err
+	}
+
+	return s.ZReport(ctx, closeShift.ID)
+}
+
+func (s shiftService) Get(ctx context.Context, id string) (models.Shift, error) {
+	shift, err := s.storage.Shift().GetByID(ctx, id)
+	if err != nil {
+		s.log.Error("error in service layer while getting shift by id", logger.Error(err))
+		return models.Shift{}, err
+	}
+
+	return shift, nil
+}
+
+func (s shiftService) GetList(ctx context.Context, request models.GetListRequest) (models.ShiftsResponse, error) {
+	shifts, err := s.storage.Shift().GetList(ctx, request)
+	if err != nil {
+		s.log.Error("error in service layer while getting shift list", logger.Error(err))
+		return models.ShiftsResponse{}, err
+	}
+
+	return shifts, nil
+}
+
+func (s shiftService) ZReport(ctx context.Context, id string) (models.ZReport, error) {
+	shift, err := s.storage.Shift().GetByID(ctx, id)
+	if err != nil {
+		s.log.Error("error in service layer while getting shift by id", logger.Error(err))
+		return models.ZReport{}, err
+	}
+
+	report, err := s.storage.Shift().ZReport(ctx, shift)
+	if err != nil {
+		s.log.Error("error in service layer while calculating z-report", logger.Error(err))
+		return models.ZReport{}, err
+	}
+
+	return report, nil
+}
@@ -0,0 +1,226 @@
+package service
+
+import (
+	"context"
+	"market/api/models"
+	"market/pkg/check"
+	"market/pkg/errs"
+	"market/pkg/logger"
+	"market/storage"
+	"math"
+	"strings"
+)
+
+type staffService struct {
+	storage storage.IStorage
+	log     logger.ILogger
+}
+
+func NewStaffService(storage storage.IStorage, log logger.ILogger) staffService {
+	return staffService{
+		storage: storage,
+		log:     log,
+	}
+}
+
+// AdjustBalance pays a bonus to staff (topup) or takes a penalty (withdraw).
+func (s staffService) AdjustBalance(ctx context.Context, request models.StaffBalanceAdjustment) (models.StaffBalanceResponse, error) {
+	if request.TransactionType != "topup" && request.TransactionType != "withdraw" {
+		return models.StaffBalanceResponse{}, errs.Validation("transaction type should be topup or withdraw")
+	}
+
+	if request.Amount <= 0 || request.Amount != math.Trunc(request.Amount) {
+		return models.StaffBalanceResponse{}, errs.Validation("amount should be a positive whole number")
+	}
+
+	if strings.TrimSpace(request.Description) == "" {
+		return models.StaffBalanceResponse{}, errs.Validation("description is required")
+	}
+
+	staff, err := s.storage.Staff().StaffByID(ctx, models.PrimaryKey{ID: request.StaffID})
+	if err != nil {
+		s.log.Error("error in service layer while getting staff by id", logger.Error(err))
+		return models.StaffBalanceResponse{}, err
+	}
+
+	// Balans manfiy bo'lib qolishiga yo'l qo'ymaslik
+
+	if request.TransactionType == "withdraw" && uint(request.Amount) > staff.Balance {
+		return models.StaffBalanceResponse{}, errs.Validation("staff balance is not enough")
+	}
+
+	var id string
+	staff, err = auditedWith(ctx, s.storage, s.log, models.AuditUpdate, auditStaff, request.StaffID, getStaff,
+		func(store storage.IStorage) (string, error) {
+			var err error
+			id, err = store.Staff().AdjustBalance(ctx, request)
+			return request.StaffID, err
+		}, balanceChanged(ctx))
+	if err != nil {
+		s.log.Error("error in service layer while adjusting staff balance", logger.Error(err))
+		return models.StaffBalanceResponse{}, err
+	}
+
+	transaction, err := s.storage.Transaction().GetByID(ctx, id)
+	if err != nil {
+		s.log.Error("error in service layer while getting transaction by id", logger.Error(err))
+		return models.StaffBalanceResponse{}, err
+	}
+
+	return models.StaffBalanceResponse{
+		Staff:       staff,
+		Transaction: transaction,
+	}, nil
+}
+
+func (s staffService) Stats(ctx context.Context, id string, request models.ReportRequest) (models.StaffStats, error) {
+	request, err := normalizeReportRequest(request)
+	if err != nil {
+		return models.StaffStats{}, err
+	}
+
+	staff, err := s.storage.Staff().StaffByID(ctx, models.PrimaryKey{ID: id})
+	if err != nil {
+		s.log.Error("error in service layer while getting staff by id", logger.Error(err))
+		return models.StaffStats{}, err
+	}
+
+	stats, err := s.storage.Report().StaffStats(ctx, id, request)
+	if err != nil {
+		s.log.Error("error in service layer while getting staff stats", logger.Error(err))
+		return models.StaffStats{}, err
+	}
+
+	stats.Name = staff.Name
+
+	return stats, nil
+}
+
+func getStaff(ctx context.Context, store storage.IStorage, id string) (models.Staff, error) {
+	return store.Staff().StaffByID(ctx, models.PrimaryKey{ID: id})
+}
+
+// balanceChanged emits staff.balance_changed in the transaction of the staff change.
+func balanceChanged(ctx context.Context) func(storage.IStorage, *models.Staff, *models.Staff) error {
+	return func(store storage.IStorage, before, after *models.Staff) error {
+		return emitBalanceChanged(ctx, store, after.ID, int(before.Balance), int(after.Balance))
+	}
+}
+
+func (s staffService) Create(ctx context.Context, createStaff models.CreateStaff) (models.Staff, error) {
+	staff, err := audited(ctx, s.storage, s.log, models.AuditCreate, auditStaff, "", getStaff,
+		func(store storage.IStorage) (string, error) {
+			return store.Staff().Create(ctx, createStaff)
+		})
+	if err != nil {
+		s.log.Error("error in service layer while creating staff", logger.Error(err))
+		return models.Staff{}, err
+	}
+
+	return staff, nil
+}
+
+func (s staffService) Get(ctx context.Context, id string) (models.Staff, error) {
+	staff, err := getStaff(ctx, s.storage, id)
+	if err != nil {
+		s.log.Error("error in service layer while getting staff by id", logger.Error(err))
+		return models.Staff{}, err
+	}
+
+	return staff, nil
+}
+
+func (s staffService) GetList(ctx context.Context, request models.GetListRequest) (models.StaffsResponse, error) {
+	staffs, err := s.storage.Staff().GetStaffTList(ctx, request)
+	if err != nil {
+		s.log.Error("error in service layer while getting staff list", logger.Error(err))
+		return models.StaffsResponse{}, err
+	}
+
+	return staffs, nil
+}
+
+// UpdatePassword changes the password of staff when the old password is right and the new one is strong enough.
+func (s staffService) UpdatePassword(ctx context.Context, updatePassword models.UpdateStaffPassword) error {
+	oldPassword, err := s.storage.Staff().GetPassword(ctx, updatePassword.ID)
+	if err != nil {
+		s.log.Error("error in service layer while getting staff password", logger.Error(err))
+		return err
+	}
+
+	if oldPassword != updatePassword.OldPassword {
+		return errs.Validation("old password is not correct")
+	}
+
+	if err = check.ValidatePassword(updatePassword.NewPassword); err != nil {
+		return errs.Wrap(errs.CodeValidation, err, err.Error())
+	}
+
+	// staff read by id has no password, so the audit log records only that it was changed
+	if _, err = audited(ctx, s.storage, s.log, models.AuditUpdate, auditStaff, updatePassword.ID, getStaff,
+		func(store storage.IStorage) (string, error) {
+			if err := store.Staff().UpdatePassword(ctx, updatePassword); err != nil {
+				s.log.Error("error in service layer while updating staff password", logger.Error(err))
+				return "", err
+			}
+
+			return updatePassword.ID, nil
+		}); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s staffService) Update(ctx context.Context, updateStaff models.UpdateStaff) (models.Staff, error) {
+	staff, err := auditedWith(ctx, s.storage, s.log, models.AuditUpdate, auditStaff, updateStaff.ID, getStaff,
+		func(store storage.IStorage) (string, error) {
+			return store.Staff().UpdateStaff(ctx, updateStaff)
+		}, balanceChanged(ctx))
+	if err != nil {
+		s.log.Error("error in service layer while updating staff", logger.Error(err))
+		return models.Staff{}, err
+	}
+
+	return staff, nil
+}
+
+// Patch changes only the given fields of staff and returns it.
+func (s staffService) Patch(ctx context.Context, patchStaff models.PatchStaff) (models.Staff, error) {
+	staff, err := auditedWith(ctx, s.storage, s.log, models.AuditUpdate, auditStaff, patchStaff.ID, getStaff,
+		func(store storage.IStorage) (string, error) {
+			return store.Staff().PatchStaff(ctx, patchStaff)
+		}, balanceChanged(ctx))
+	if err != nil {
+		s.log.Error("error in service layer while patching staff", logger.Error(err))
+		return models.Staff{}, err
+	}
+
+	return staff, nil
+}
+
+func (s staffService) Delete(ctx context.Context, id string) error {
+	if _, err := audited(ctx, s.storage, s.log, models.AuditDelete, auditStaff, id, getStaff,
+		func(store storage.IStorage) (string, error) {
+			return id, store.Staff().DeleteStaff(ctx, id)
+		}); err != nil {
+		s.log.Error("error in service layer while deleting staff", logger.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// Restore brings back the soft deleted staff and returns it.
+func (s staffService) Restore(ctx context.Context, id string) (models.Staff, error) {
+	staff, err := audited(ctx, s.storage, s.log, models.AuditRestore, auditStaff, id, getStaff,
+		func(store storage.IStorage) (string, error) {
+			return id, store.Staff().RestoreStaff(ctx, id)
+		})
+	if err != nil {
+		s.log.Error("error in service layer while restoring staff", logger.Error(err))
+		return models.Staff{}, err
+	}
+
+	return staff, nil
+}
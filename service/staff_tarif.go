@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"market/api/models"
+	"market/pkg/logger"
+	"market/storage"
+)
+
+type staffTarifService struct {
+	storage storage.IStorage
+	log     logger.ILogger
+}
+
+func NewStaffTarifService(storage storage.IStorage, log logger.ILogger) staffTarifService {
+	return staffTarifService{
+		storage: storage,
+		log:     log,
+	}
+}
+
+func getStaffTarif(ctx context.Context, store storage.IStorage, id string) (models.StaffTarif, error) {
+	return store.StaffTariff().GetStaffTariffByID(ctx, models.PrimaryKey{ID: id})
+}
+
+func (s staffTarifService) Create(ctx context.Context, createStaffTarif models.CreateStaffTarif) (models.StaffTarif, error) {
+	staffTarif, err := audited(ctx, s.storage, s.log, models.AuditCreate, auditStaffTariff, "", getStaffTarif,
+		func(store storage.IStorage) (string, error) {
+			return store.StaffTariff().Create(ctx, createStaffTarif)
+		})
+	if err != nil {
+		s.log.Error("error in service layer while creating staff tarif", logger.Error(err))
+		return models.StaffTarif{}, err
+	}
+
+	return staffTarif, nil
+}
+
+func (s staffTarifService) Get(ctx context.Context, id string) (models.StaffTarif, error) {
+	staffTarif, err := getStaffTarif(ctx, s.storage, id)
+	if err != nil {
+		s.log.Error("error in service layer while getting staff tarif by id", logger.Error(err))
+		return models.StaffTarif{}, err
+	}
+
+	return staffTarif, nil
+}
+
+func (s staffTarifService) GetList(ctx context.Context, request models.GetListRequest) (models.StaffTarifResponse, error) {
+	staffTarifs, err := s.storage.StaffTariff().GetStaffTariffList(ctx, request)
+	if err != nil {
+		s.log.Error("error in service layer while getting staff tarif list", logger.Error(err))
+		return models.StaffTarifResponse{}, err
+	}
+
+	return staffTarifs, nil
+}
+
+func (s staffTarifService) Update(ctx context.Context, updateStaffTarif models.UpdateStaffTarif) (models.StaffTarif, error) {
+	staffTarif, err := audited(ctx, s.storage, s.log, models.AuditUpdate, auditStaffTariff, updateStaffTarif.ID, getStaffTarif,
+		func(store storage.IStorage) (string, error) {
+			return store.StaffTariff().UpdateStaffTariff(ctx, updateStaffTarif)
+		})
+	if err != nil {
+		s.log.Error("error in service layer while updating staff tarif", logger.Error(err))
+		return models.StaffTarif{}, err
+	}
+
+	return staffTarif, nil
+}
+
+// Patch changes only the given fields of staff tarif and returns it.
+func (s staffTarifService) Patch(ctx context.Context, patchStaffTarif models.PatchStaffTarif) (models.StaffTarif, error) {
+	staffTarif, err := audited(ctx, s.storage, s.log, models.AuditUpdate, auditStaffTariff, patchStaffTarif.ID, getStaffTarif,
+		func(store storage.IStorage) (string, error) {
+			return store.StaffTariff().PatchStaffTariff(ctx, patchStaffTarif)
+		})
+	if err != nil {
+		s.log.Error("error in service layer while patching staff tarif", logger.Error(err))
+		return models.StaffTarif{}, err
+	}
+
+	return staffTarif, nil
+}
+
+func (s staffTarifService) Delete(ctx context.Context, id string) error {
+	if _, err := audited(ctx, s.storage, s.log, models.AuditDelete, auditStaffTariff, id, getStaffTarif,
+		func(store storage.IStorage) (string, error) {
+			return id, store.StaffTariff().DeleteStaffTariff(ctx, id)
+		}); err != nil {
+		s.log.Error("error in service layer while deleting staff tarif", logger.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// Restore brings back the soft deleted staff tarif and returns it.
+func (s staffTarifService) Restore(ctx context.Context, id string) (models.StaffTarif, error) {
+	staffTarif, err := audited(ctx, s.storage, s.log, models.AuditRestore, auditStaffTariff, id, getStaffTarif,
+		func(store storage.IStorage) (string, error) {
+			return id, store.StaffTariff().RestoreStaffTariff(ctx, id)
+		})
+	if err != nil {
+		s.log.Error("error in service layer while restoring staff tarif", logger.Error(err))
+		return models.StaffTarif{}, err
+	}
+
+	return staffTarif, nil
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"market/api/models"
+	"market/config"
+	"market/pkg/logger"
+	"market/storage"
+	"time"
+)
+
+// summaryBatch is how many missed sales are summarized in one catch up.
+const summaryBatch = 500
+
+type summaryService struct {
+	storage storage.IStorage
+	log     logger.ILogger
+	queue   chan string
+}
+
+func NewSummaryService(storage storage.IStorage, log logger.ILogger) summaryService {
+	return summaryService{
+		storage: storage,
+		log:     log,
+		queue:   make(chan string, summaryBatch),
+	}
+}
+
+// Notify queues finished sale to be added to the daily summaries. It never blocks,
+// if the queue is full the sale is picked up by the next catch up.
+func (s summaryService) Notify(saleID string) {
+	select {
+	case s.queue <- saleID:
+	default:
+		s.log.Warning("summary queue is full, sale is left for catch up", logger.String("sale_id", saleID))
+	}
+}
+
+// Run keeps daily summaries up to date until ctx is done. Notified sales are applied
+// one by one, and finished sales which were missed (queue overflow, restart) are
+// looked up every config.SummaryCatchUpInterval.
+func (s summaryService) Run(ctx context.Context) {
+	ticker := time.NewTicker(config.SummaryCatchUpInterval)
+	defer ticker.Stop()
+
+	s.catchUp(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case id := <-s.queue:
+			if err := s.storage.Summary().ApplySale(ctx, id); err != nil {
+				s.log.Error("error in service layer while summarizing sale", logger.Error(err))
+			}
+		case <-ticker.C:
+			s.catchUp(ctx)
+		}
+	}
+}
+
+func (s summaryService) catchUp(ctx context.Context) {
+	for {
+		ids, err := s.storage.Summary().Pending(ctx, summaryBatch)
+		if err != nil {
+			s.log.Error("error in service layer while getting not summarized sales", logger.Error(err))
+			return
+		}
+
+		for _, id := range ids {
+			if err = s.storage.Summary().ApplySale(ctx, id); err != nil {
+				s.log.Error("error in service layer while summarizing sale", logger.Error(err))
+				return
+			}
+		}
+
+		if len(ids) < summaryBatch {
+			return
+		}
+	}
+}
+
+// Rebuild recalculates daily summaries between from and to dates (2006-01-02, both inclusive)
+// from historical sales.
+func (s summaryService) Rebuild(ctx context.Context, from, to string) error {
+	request, err := normalizeReportRequest(models.ReportRequest{From: from, To: to})
+	if err != nil {
+		return err
+	}
+
+	if err = s.storage.Summary().Rebuild(ctx, request.From, request.To); err != nil {
+		s.log.Error("error in service layer while rebuilding summaries", logger.Error(err))
+		return err
+	}
+
+	return nil
+}
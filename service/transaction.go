@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"market/api/models"
+	"market/pkg/logger"
+	"market/storage"
+)
+
+type transactionService struct {
+	storage storage.IStorage
+	log     logger.ILogger
+}
+
+func NewTransactionService(storage storage.IStorage, log logger.ILogger) transactionService {
+	return transactionService{
+		storage: storage,
+		log:     log,
+	}
+}
+
+func getTransaction(ctx context.Context, store storage.IStorage, id string) (models.Transaction, error) {
+	return store.Transaction().GetByID(ctx, id)
+}
+
+func (t transactionService) Create(ctx context.Context, createTransaction models.CreateTransaction) (models.Transaction, error) {
+	transaction, err := audited(ctx, t.storage, t.log, models.AuditCreate, auditTransaction, "", getTransaction,
+		func(store storage.IStorage) (string, error) {
+			return store.Transaction().Create(ctx, createTransaction)
+		})
+	if err != nil {
+		t.log.Error("error in service layer while creating transaction", logger.Error(err))
+		return models.Transaction{}, err
+	}
+
+	return transaction, nil
+}
+
+func (t transactionService) Get(ctx context.Context, id string) (models.Transaction, error) {
+	transaction, err := getTransaction(ctx, t.storage, id)
+	if err != nil {
+		t.log.Error("error in service layer while getting transaction by id", logger.Error(err))
+		return models.Transaction{}, err
+	}
+
+	return transaction, nil
+}
+
+func (t transactionService) GetList(ctx context.Context, request models.TransactionGetListRequest) (models.TransactionResponse, error) {
+	transactions, err := t.storage.Transaction().GetList(ctx, request)
+	if err != nil {
+		t.log.Error("error in service layer while getting transaction list", logger.Error(err))
+		return models.TransactionResponse{}, err
+	}
+
+	return transactions, nil
+}
+
+func (t transactionService) Update(ctx context.Context, updateTransaction models.UpdateTransaction) (models.Transaction, error) {
+	transaction, err := audited(ctx, t.storage, t.log, models.AuditUpdate, auditTransaction, updateTransaction.ID, getTransaction,
+		func(store storage.IStorage) (string, error) {
+			return store.Transaction().Update(ctx, updateTransaction)
+		})
+	if err != nil {
+		t.log.Error("error in service layer while updating transaction", logger.Error(err))
+		return models.Transaction{}, err
+	}
+
+	return transaction, nil
+}
+
+// Patch changes only the given fields of transaction and returns it.
+func (t transactionService) Patch(ctx context.Context, patchTransaction models.PatchTransaction) (models.Transaction, error) {
+	transaction, err := audited(ctx, t.storage, t.log, models.AuditUpdate, auditTransaction, patchTransaction.ID, getTransaction,
+		func(store storage.IStorage) (string, error) {
+			return store.Transaction().Patch(ctx, patchTransaction)
+		})
+	if err != nil {
+		t.log.Error("error in service layer while patching transaction", logger.Error(err))
+		return models.Transaction{}, err
+	}
+
+	return transaction, nil
+}
+
+func (t transactionService) Delete(ctx context.Context, id string) error {
+	if _, err := audited(ctx, t.storage, t.log, models.AuditDelete, auditTransaction, id, getTransaction,
+		func(store storage.IStorage) (string, error) {
+			return id, store.Transaction().Delete(ctx, id)
+		}); err != nil {
+		t.log.Error("error in service layer while deleting transaction", logger.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// Restore brings back the soft deleted transaction and returns it.
+func (t transactionService) Restore(ctx context.Context, id string) (models.Transaction, error) {
+	transaction, err := audited(ctx, t.storage, t.log, models.AuditRestore, auditTransaction, id, getTransaction,
+		func(store storage.IStorage) (string, error) {
+			return id, store.Transaction().Restore(ctx, id)
+		})
+	if err != nil {
+		t.log.Error("error in service layer while restoring transaction", logger.Error(err))
+		return models.Transaction{}, err
+	}
+
+	return transaction, nil
+}
@@ -0,0 +1,370 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"market/api/models"
+	"market/config"
+	"market/pkg/errs"
+	"market/pkg/logger"
+	"market/storage"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// webhookEventTypes are the domain events webhooks can subscribe to.
+var webhookEventTypes = map[string]bool{
+	models.EventSaleCompleted:       true,
+	models.EventSaleCancelled:       true,
+	models.EventStockChanged:        true,
+	models.EventStaffBalanceChanged: true,
+}
+
+// webhookService keeps webhook subscriptions and sends them the events they subscribed to.
+// As an EventSink it only queues deliveries, Run sends them and retries the failed ones.
+//
+// Every request is a POST of models.WebhookPayload with the headers:
+//
+//	X-Webhook-Delivery   id of the delivery, the same for all attempts of it
+//	X-Webhook-Event      event type
+//	X-Webhook-Timestamp  unix time of the attempt
+//	X-Webhook-Signature  sha256=<hex HMAC-SHA256 of "<timestamp>.<body>" with the webhook secret>
+//
+// Receivers should check the signature and the timestamp, and ignore deliveries they have
+// already handled, because a delivery is sent again until it gets a 2xx answer.
+type webhookService struct {
+	storage storage.IStorage
+	log     logger.ILogger
+	client  *http.Client
+}
+
+func NewWebhookService(storage storage.IStorage, log logger.ILogger) webhookService {
+	return webhookService{
+		storage: storage,
+		log:     log,
+		client:  &http.Client{Timeout: config.WebhookTimeout},
+	}
+}
+
+func getWebhook(ctx context.Context, store storage.IStorage, id string) (models.Webhook, error) {
+	return store.Webhook().GetByID(ctx, id)
+}
+
+func validateEventTypes(eventTypes []string) error {
+	for _, eventType := range eventTypes {
+		if !webhookEventTypes[eventType] {
+			return errs.Validation("unknown event type %q", eventType)
+		}
+	}
+
+	return nil
+}
+
+func (w webhookService) Create(ctx context.Context, createWebhook models.CreateWebhook) (models.Webhook, error) {
+	if err := validateEventTypes(createWebhook.EventTypes); err != nil {
+		return models.Webhook{}, err
+	}
+
+	webhook, err := audited(ctx, w.storage, w.log, models.AuditCreate, auditWebhook, "", getWebhook,
+		func(store storage.IStorage) (string, error) {
+			return store.Webhook().Create(ctx, createWebhook)
+		})
+	if err != nil {
+		w.log.Error("error in service layer while creating webhook", logger.Error(err))
+		return models.Webhook{}, err
+	}
+
+	return webhook, nil
+}
+
+func (w webhookService) Get(ctx context.Context, id string) (models.Webhook, error) {
+	webhook, err := w.storage.Webhook().GetByID(ctx, id)
+	if err != nil {
+		w.log.Error("error in service layer while getting webhook by id", logger.Error(err))
+		return models.Webhook{}, err
+	}
+
+	return webhook, nil
+}
+
+func (w webhookService) GetList(ctx context.Context, request models.GetListRequest) (models.WebhooksResponse, error) {
+	webhooks, err := w.storage.Webhook().GetList(ctx, request)
+	if err != nil {
+		w.log.Error("error in service layer while getting webhook list", logger.Error(err))
+		return models.WebhooksResponse{}, err
+	}
+
+	return webhooks, nil
+}
+
+func (w webhookService) Update(ctx context.Context, updateWebhook models.UpdateWebhook) (models.Webhook, error) {
+	if err := validateEventTypes(updateWebhook.EventTypes); err != nil {
+		return models.Webhook{}, err
+	}
+
+	webhook, err := audited(ctx, w.storage, w.log, models.AuditUpdate, auditWebhook, updateWebhook.ID, getWebhook,
+		func(store storage.IStorage) (string, error) {
+			return store.Webhook().Update(ctx, updateWebhook)
+		})
+	if err != nil {
+		w.log.Error("error in service layer while updating webhook", logger.Error(err))
+		return models.Webhook{}, err
+	}
+
+	return webhook, nil
+}
+
+func (w webhookService) Delete(ctx context.Context, id string) error {
+	if _, err := audited(ctx, w.storage, w.log, models.AuditDelete, auditWebhook, id, getWebhook,
+		func(store storage.IStorage) (string, error) {
+			return id, store.Webhook().Delete(ctx, id)
+		}); err != nil {
+		w.log.Error("error in service layer while deleting webhook", logger.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// Restore brings back the soft deleted webhook and returns it.
+func (w webhookService) Restore(ctx context.Context, id string) (models.Webhook, error) {
+	webhook, err := audited(ctx, w.storage, w.log, models.AuditRestore, auditWebhook, id, getWebhook,
+		func(store storage.IStorage) (string, error) {
+			return id, store.Webhook().Restore(ctx, id)
+		})
+	if err != nil {
+		w.log.Error("error in service layer while restoring webhook", logger.Error(err))
+		return models.Webhook{}, err
+	}
+
+	return webhook, nil
+}
+
+func (w webhookService) Deliveries(ctx context.Context, request models.WebhookDeliveryListRequest) (models.WebhookDeliveriesResponse, error) {
+	deliveries, err := w.storage.Webhook().Deliveries(ctx, request)
+	if err != nil {
+		w.log.Error("error in service layer while getting webhook deliveries", logger.Error(err))
+		return models.WebhookDeliveriesResponse{}, err
+	}
+
+	return deliveries, nil
+}
+
+// Test sends webhook.ping event to the webhook right away and returns its delivery.
+// If the webhook does not answer with 2xx the ping is retried like other deliveries.
+// The ping is queued as claimed, so Run does not send it too while it is sent here.
+func (w webhookService) Test(ctx context.Context, id string) (models.WebhookDelivery, error) {
+	webhook, err := w.storage.Webhook().GetByID(ctx, id)
+	if err != nil {
+		w.log.Error("error in service layer while getting webhook by id", logger.Error(err))
+		return models.WebhookDelivery{}, err
+	}
+
+	data, err := json.Marshal(map[string]string{"webhook_id": webhook.ID})
+	if err != nil {
+		return models.WebhookDelivery{}, err
+	}
+
+	deliveryID, err := w.queue(ctx, webhook, models.Event{
+		ID:          uuid.New().String(),
+		Type:        models.WebhookPing,
+		AggregateID: webhook.ID,
+		Payload:     data,
+		CreatedAt:   time.Now(),
+	}, config.WebhookClaimLease)
+	if err != nil {
+		return models.WebhookDelivery{}, err
+	}
+
+	delivery, err := w.storage.Webhook().DeliveryByID(ctx, deliveryID)
+	if err != nil {
+		w.log.Error("error in service layer while getting webhook delivery", logger.Error(err))
+		return models.WebhookDelivery{}, err
+	}
+
+	if err = w.attempt(ctx, delivery); err != nil {
+		return models.WebhookDelivery{}, err
+	}
+
+	return w.storage.Webhook().DeliveryByID(ctx, deliveryID)
+}
+
+// Name is the name of the webhooks in errors of event dispatching.
+func (w webhookService) Name() string {
+	return "webhooks"
+}
+
+// Send queues the event for every active webhook subscribed to its type. An event which
+// is dispatched again is not queued twice for the same webhook.
+func (w webhookService) Send(ctx context.Context, event models.Event) error {
+	webhooks, err := w.storage.Webhook().Subscribed(ctx, event.Type)
+	if err != nil {
+		w.log.Error("error in service layer while getting subscribed webhooks", logger.Error(err))
+		return err
+	}
+
+	for _, webhook := range webhooks {
+		if _, err = w.queue(ctx, webhook, event, 0); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// queue adds delivery of the event to the webhook, due after delay or right away if
+// it is zero, and returns its id.
+func (w webhookService) queue(ctx context.Context, webhook models.Webhook, event models.Event, delay time.Duration) (string, error) {
+	payload, err := json.Marshal(models.WebhookPayload{
+		EventID:     event.ID,
+		EventType:   event.Type,
+		AggregateID: event.AggregateID,
+		Data:        event.Payload,
+		CreatedAt:   event.CreatedAt,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	id, err := w.storage.Webhook().AddDelivery(ctx, models.CreateWebhookDelivery{
+		WebhookID: webhook.ID,
+		EventID:   event.ID,
+		EventType: event.Type,
+		Payload:   payload,
+		Delay:     delay,
+	})
+	if err != nil {
+		w.log.Error("error in service layer while queueing webhook delivery", logger.Error(err))
+		return "", err
+	}
+
+	return id, nil
+}
+
+// Run sends due deliveries every config.WebhookPollInterval until ctx is done.
+func (w webhookService) Run(ctx context.Context) {
+	ticker := time.NewTicker(config.WebhookPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.deliverDue(ctx)
+		}
+	}
+}
+
+// deliverDue sends due deliveries in batches until none is left or ctx is done.
+func (w webhookService) deliverDue(ctx context.Context) {
+	for ctx.Err() == nil {
+		deliveries, err := w.storage.Webhook().ClaimDue(ctx, config.WebhookBatchSize, config.WebhookClaimLease)
+		if err != nil {
+			w.log.Error("error in service layer while getting due webhook deliveries", logger.Error(err))
+			return
+		}
+
+		for _, delivery := range deliveries {
+			if err = w.attempt(ctx, delivery); err != nil {
+				return
+			}
+		}
+
+		if len(deliveries) < config.WebhookBatchSize {
+			return
+		}
+	}
+}
+
+// attempt sends the delivery once and records how it went. A failed delivery is
+// sent again later with backoff until it runs out of attempts.
+func (w webhookService) attempt(ctx context.Context, delivery models.WebhookDelivery) error {
+	result := models.WebhookAttempt{ID: delivery.ID}
+
+	webhook, err := w.storage.Webhook().GetByID(ctx, delivery.WebhookID)
+	switch {
+	case errors.Is(err, errs.ErrNotFound):
+		result.Status, result.Error = models.WebhookFailed, "webhook is deleted"
+	case err != nil:
+		w.log.Error("error in service layer while getting webhook by id", logger.Error(err))
+		return err
+	case !webhook.Active:
+		result.Status, result.Error = models.WebhookFailed, "webhook is not active"
+	default:
+		result.ResponseCode, err = w.post(ctx, webhook, delivery)
+		if err == nil {
+			result.Status = models.WebhookDelivered
+			break
+		}
+
+		result.Error = err.Error()
+		if attempts := delivery.Attempts + 1; attempts < config.WebhookMaxAttempts {
+			result.Status, result.Backoff = models.WebhookPending,
+				backoff(attempts, config.WebhookBackoffBase, config.WebhookBackoffMax)
+		} else {
+			result.Status = models.WebhookFailed
+		}
+
+		w.log.Warning("webhook delivery failed", logger.String("delivery_id", delivery.ID),
+			logger.String("url", webhook.URL), logger.Error(err))
+	}
+
+	if err = w.storage.Webhook().RecordAttempt(ctx, result); err != nil {
+		w.log.Error("error in service layer while recording webhook delivery attempt", logger.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// post sends the signed payload of the delivery to the webhook and returns the status code
+// of the answer, anything but 2xx is an error.
+func (w webhookService) post(ctx context.Context, webhook models.Webhook, delivery models.WebhookDelivery) (int, error) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("X-Webhook-Delivery", delivery.ID)
+	request.Header.Set("X-Webhook-Event", delivery.EventType)
+	request.Header.Set("X-Webhook-Timestamp", timestamp)
+	request.Header.Set("X-Webhook-Signature", signWebhook(webhook.Secret, timestamp, delivery.Payload))
+
+	response, err := w.client.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+
+	// a little of the answer is kept in the delivery log to see why the webhook failed
+	body, _ := io.ReadAll(io.LimitReader(response.Body, 512))
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return response.StatusCode, fmt.Errorf("webhook answered with status %d: %s", response.StatusCode, body)
+	}
+
+	return response.StatusCode, nil
+}
+
+// signWebhook returns X-Webhook-Signature of the body sent at timestamp.
+func signWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,236 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"market/api/models"
+	"market/config"
+	"market/pkg/logger"
+	"market/storage/memory"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const testWebhookSecret = "0123456789abcdef"
+
+// webhookReceiver is a webhook endpoint which answers with the given status codes in
+// turn, 200 once they run out, and keeps the requests it got. onFirst is called while
+// the first request is handled.
+type webhookReceiver struct {
+	mu       sync.Mutex
+	statuses []int
+	requests []receivedWebhook
+	onFirst  func()
+}
+
+type receivedWebhook struct {
+	header http.Header
+	body   []byte
+}
+
+func (r *webhookReceiver) ServeHTTP(w http.ResponseWriter, request *http.Request) {
+	body, _ := io.ReadAll(request.Body)
+
+	r.mu.Lock()
+	r.requests = append(r.requests, receivedWebhook{header: request.Header.Clone(), body: body})
+	onFirst := r.onFirst
+	r.onFirst = nil
+	r.mu.Unlock()
+
+	if onFirst != nil {
+		onFirst()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	status := http.StatusOK
+	if len(r.statuses) > 0 {
+		status, r.statuses = r.statuses[0], r.statuses[1:]
+	}
+	w.WriteHeader(status)
+}
+
+func (r *webhookReceiver) received() []receivedWebhook {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]receivedWebhook(nil), r.requests...)
+}
+
+func newTestWebhook(t *testing.T, statuses ...int) (webhookService, models.Webhook, *webhookReceiver) {
+	t.Helper()
+
+	receiver := &webhookReceiver{statuses: statuses}
+	server := httptest.NewServer(receiver)
+	t.Cleanup(server.Close)
+
+	log := logger.New("test")
+	service := NewWebhookService(memory.New(log), log)
+
+	webhook, err := service.Create(context.Background(), models.CreateWebhook{
+		URL:        server.URL,
+		Secret:     testWebhookSecret,
+		EventTypes: []string{models.EventSaleCompleted},
+	})
+	if err != nil {
+		t.Fatalf("create webhook: %v", err)
+	}
+
+	return service, webhook, receiver
+}
+
+func TestWebhookDeliveryIsSignedAndRetried(t *testing.T) {
+	ctx := context.Background()
+	service, webhook, receiver := newTestWebhook(t, http.StatusInternalServerError, http.StatusBadGateway)
+
+	if err := service.Send(ctx, models.Event{
+		ID:          uuid.New().String(),
+		Type:        models.EventSaleCompleted,
+		AggregateID: uuid.New().String(),
+		Payload:     []byte(`{"price":100}`),
+		CreatedAt:   time.Now(),
+	}); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+
+	service.deliverDue(ctx)
+
+	deliveries, err := service.Deliveries(ctx, models.WebhookDeliveryListRequest{Page: 1, Limit: 10, WebhookID: webhook.ID})
+	if err != nil {
+		t.Fatalf("deliveries: %v", err)
+	}
+	if deliveries.Count != 1 {
+		t.Fatalf("got %d deliveries, want 1", deliveries.Count)
+	}
+
+	delivery := deliveries.Deliveries[0]
+	if delivery.Status != models.WebhookPending || delivery.Attempts != 1 || delivery.ResponseCode != http.StatusInternalServerError {
+		t.Fatalf("after 5xx got status %s, attempts %d, code %d, want pending, 1, 500",
+			delivery.Status, delivery.Attempts, delivery.ResponseCode)
+	}
+	if delivery.LastError == "" {
+		t.Error("failed attempt has no error in the delivery log")
+	}
+	if wait := time.Until(delivery.NextAttemptAt); wait < config.WebhookBackoffBase-time.Second || wait > config.WebhookBackoffBase {
+		t.Errorf("next attempt in %s, want about %s", wait, config.WebhookBackoffBase)
+	}
+
+	// the delivery is not due before its backoff has passed
+	service.deliverDue(ctx)
+	if got := len(receiver.received()); got != 1 {
+		t.Fatalf("webhook got %d requests before backoff passed, want 1", got)
+	}
+
+	for attempt := 2; attempt <= 3; attempt++ {
+		if err = service.attempt(ctx, delivery); err != nil {
+			t.Fatalf("attempt %d: %v", attempt, err)
+		}
+		if delivery, err = service.storage.Webhook().DeliveryByID(ctx, delivery.ID); err != nil {
+			t.Fatalf("delivery by id: %v", err)
+		}
+	}
+
+	if delivery.Status != models.WebhookDelivered || delivery.Attempts != 3 || delivery.ResponseCode != http.StatusOK {
+		t.Fatalf("after 2xx got status %s, attempts %d, code %d, want delivered, 3, 200",
+			delivery.Status, delivery.Attempts, delivery.ResponseCode)
+	}
+	if delivery.DeliveredAt.IsZero() {
+		t.Error("delivered delivery has no delivered_at")
+	}
+
+	requests := receiver.received()
+	if len(requests) != 3 {
+		t.Fatalf("webhook got %d requests, want 3", len(requests))
+	}
+
+	for i, request := range requests {
+		if got := request.header.Get("X-Webhook-Delivery"); got != delivery.ID {
+			t.Errorf("request %d: X-Webhook-Delivery is %q, want %q", i, got, delivery.ID)
+		}
+		if got := request.header.Get("X-Webhook-Event"); got != models.EventSaleCompleted {
+			t.Errorf("request %d: X-Webhook-Event is %q, want %q", i, got, models.EventSaleCompleted)
+		}
+
+		mac := hmac.New(sha256.New, []byte(testWebhookSecret))
+		mac.Write([]byte(request.header.Get("X-Webhook-Timestamp") + "."))
+		mac.Write(request.body)
+		if want := "sha256=" + hex.EncodeToString(mac.Sum(nil)); request.header.Get("X-Webhook-Signature") != want {
+			t.Errorf("request %d: X-Webhook-Signature is %q, want %q", i, request.header.Get("X-Webhook-Signature"), want)
+		}
+	}
+}
+
+func TestWebhookGivesUpAfterMaxAttempts(t *testing.T) {
+	ctx := context.Background()
+
+	statuses := make([]int, config.WebhookMaxAttempts)
+	for i := range statuses {
+		statuses[i] = http.StatusServiceUnavailable
+	}
+	service, webhook, _ := newTestWebhook(t, statuses...)
+
+	delivery, err := service.Test(ctx, webhook.ID)
+	if err != nil {
+		t.Fatalf("test: %v", err)
+	}
+
+	for delivery.Status == models.WebhookPending {
+		if err = service.attempt(ctx, delivery); err != nil {
+			t.Fatalf("attempt: %v", err)
+		}
+		if delivery, err = service.storage.Webhook().DeliveryByID(ctx, delivery.ID); err != nil {
+			t.Fatalf("delivery by id: %v", err)
+		}
+	}
+
+	if delivery.Status != models.WebhookFailed || delivery.Attempts != config.WebhookMaxAttempts {
+		t.Fatalf("got status %s after %d attempts, want failed after %d", delivery.Status, delivery.Attempts, config.WebhookMaxAttempts)
+	}
+}
+
+func TestWebhookTestIsSentOnce(t *testing.T) {
+	ctx := context.Background()
+	service, webhook, receiver := newTestWebhook(t, http.StatusInternalServerError)
+
+	// Run polls while the ping is being sent by Test
+	receiver.onFirst = func() { service.deliverDue(ctx) }
+
+	delivery, err := service.Test(ctx, webhook.ID)
+	if err != nil {
+		t.Fatalf("test: %v", err)
+	}
+	if delivery.EventType != models.WebhookPing || delivery.Attempts != 1 {
+		t.Fatalf("got %s delivery with %d attempts, want %s with 1", delivery.EventType, delivery.Attempts, models.WebhookPing)
+	}
+
+	if got := len(receiver.received()); got != 1 {
+		t.Fatalf("ping was sent %d times, want 1", got)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{attempts: 1, want: time.Second * 30},
+		{attempts: 2, want: time.Minute},
+		{attempts: 4, want: time.Minute * 4},
+		{attempts: 20, want: time.Hour * 6},
+	}
+
+	for _, tt := range tests {
+		if got := backoff(tt.attempts, time.Second*30, time.Hour*6); got != tt.want {
+			t.Errorf("backoff(%d) = %s, want %s", tt.attempts, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package memory
+
+import (
+	"context"
+	"market/api/models"
+	"market/pkg/logger"
+	"market/storage"
+
+	"github.com/google/uuid"
+)
+
+type auditRepo struct {
+	db  *data
+	log logger.ILogger
+}
+
+func newAuditRepo(db *data, log logger.ILogger) storage.IAuditStorage {
+	return auditRepo{
+		db:  db,
+		log: log,
+	}
+}
+
+func (a auditRepo) Create(ctx context.Context, record models.CreateAuditLog) error {
+	defer a.db.lock()()
+
+	id := uuid.New().String()
+	a.db.auditLogs.insert(id, models.AuditLog{
+		ID:        id,
+		ActorID:   record.ActorID,
+		Action:    record.Action,
+		Entity:    record.Entity,
+		EntityID:  record.EntityID,
+		Before:    record.Before,
+		After:     record.After,
+		CreatedAt: now(),
+	})
+
+	return nil
+}
+
+// GetList returns audit log newest first.
+func (a auditRepo) GetList(ctx context.Context, request models.AuditListRequest) (models.AuditLogsResponse, error) {
+	defer a.db.lock()()
+
+	auditLogs := reversed(a.db.auditLogs.list(func(auditLog models.AuditLog) bool {
+		return (request.Entity == "" || auditLog.Entity == request.Entity) &&
+			(request.EntityID == "" || auditLog.EntityID == request.EntityID) &&
+			(request.ActorID == "" || auditLog.ActorID == request.ActorID)
+	}))
+
+	return models.AuditLogsResponse{
+		AuditLogs: page(auditLogs, request.Page, request.Limit),
+		Count:     len(auditLogs),
+	}, nil
+}
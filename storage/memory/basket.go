@@ -0,0 +1,143 @@
+package memory
+
+import (
+	"context"
+	"market/api/models"
+	"market/pkg/logger"
+	"market/storage"
+
+	"github.com/google/uuid"
+)
+
+type basketRepo struct {
+	db  *data
+	log logger.ILogger
+}
+
+func newBasketRepo(db *data, log logger.ILogger) storage.IBasketRepo {
+	return basketRepo{
+		db:  db,
+		log: log,
+	}
+}
+
+func (b basketRepo) Create(ctx context.Context, basket models.CreateBasket) (string, error) {
+	defer b.db.lock()()
+
+	if err := b.check(&basket.SaleID, &basket.ProductID); err != nil {
+		return "", err
+	}
+
+	id := uuid.New().String()
+	b.db.baskets.insert(id, models.Basket{
+		ID:        id,
+		SaleID:    basket.SaleID,
+		ProductID: basket.ProductID,
+		Quantity:  basket.Quantity,
+		Price:     basket.Price,
+		Version:   1,
+		CreatedAt: now().Format(timestampLayout),
+	})
+
+	return id, nil
+}
+
+func (b basketRepo) GetByID(ctx context.Context, key models.PrimaryKey) (models.Basket, error) {
+	defer b.db.lock()()
+
+	row, err := b.db.baskets.get(key.ID)
+	if err != nil {
+		return models.Basket{}, err
+	}
+
+	return row.value, nil
+}
+
+// GetList searches baskets by sale id, newest first.
+func (b basketRepo) GetList(ctx context.Context, request models.GetListRequest) (models.BasketsResponse, error) {
+	defer b.db.lock()()
+
+	baskets := reversed(b.db.baskets.listIncluding(func(basket models.Basket) bool {
+		return request.Search == "" || basket.SaleID == request.Search
+	}, request.IncludeDeleted))
+
+	return models.BasketsResponse{
+		Baskets: pageAfter(baskets, request.After, request.Page, request.Limit, func(basket models.Basket) models.Cursor {
+			return textCursor(basket.CreatedAt, basket.ID)
+		}),
+		Count: len(baskets),
+	}, nil
+}
+
+func (b basketRepo) Update(ctx context.Context, basket models.UpdateBasket) (string, error) {
+	return b.Patch(ctx, models.PatchBasket{
+		ID:        basket.ID,
+		SaleID:    &basket.SaleID,
+		ProductID: &basket.ProductID,
+		Quantity:  &basket.Quantity,
+		Price:     &basket.Price,
+		Version:   basket.Version,
+	})
+}
+
+func (b basketRepo) Patch(ctx context.Context, basket models.PatchBasket) (string, error) {
+	defer b.db.lock()()
+
+	row, err := b.db.baskets.get(basket.ID)
+	if err != nil {
+		return "", err
+	}
+
+	if err = checkVersion(row.value.Version, basket.Version); err != nil {
+		return "", err
+	}
+
+	if err = b.check(basket.SaleID, basket.ProductID); err != nil {
+		return "", err
+	}
+
+	set(&row.value.SaleID, basket.SaleID)
+	set(&row.value.ProductID, basket.ProductID)
+	set(&row.value.Quantity, basket.Quantity)
+	set(&row.value.Price, basket.Price)
+	row.value.Version++
+	row.value.UpdatedAt = now().Format(timestampLayout)
+
+	return basket.ID, nil
+}
+
+func (b basketRepo) Delete(ctx context.Context, key models.PrimaryKey) error {
+	defer b.db.lock()()
+
+	b.db.baskets.delete(key.ID)
+
+	return nil
+}
+
+func (b basketRepo) Restore(ctx context.Context, id string) error {
+	defer b.db.lock()()
+
+	row, err := b.db.baskets.restore(id)
+	if err != nil {
+		return err
+	}
+
+	row.value.Version++
+	row.value.UpdatedAt = now().Format(timestampLayout)
+
+	return nil
+}
+
+func (b basketRepo) check(saleID, productID *string) error {
+	if saleID != nil {
+		if err := foreignKey(b.db.sales, "sale_id", *saleID); err != nil {
+			return err
+		}
+	}
+
+	if productID != nil {
+		return foreignKey(b.db.products, "product_id", *productID)
+	}
+
+	return nil
+}
@@ -0,0 +1,128 @@
+package memory
+
+import (
+	"context"
+	"market/api/models"
+	"market/pkg/errs"
+	"market/pkg/logger"
+	"market/storage"
+
+	"github.com/google/uuid"
+)
+
+type branchRepo struct {
+	db  *data
+	log logger.ILogger
+}
+
+func newBranchRepo(db *data, log logger.ILogger) storage.IBranchStorage {
+	return branchRepo{
+		db:  db,
+		log: log,
+	}
+}
+
+func (b branchRepo) Create(ctx context.Context, branch models.CreateBranch) (string, error) {
+	defer b.db.lock()()
+
+	id := uuid.New().String()
+	b.db.branches.insert(id, models.Branch{
+		ID:        id,
+		Name:      branch.Name,
+		Address:   branch.Address,
+		Version:   1,
+		CreatedAt: now(),
+	})
+
+	return id, nil
+}
+
+func (b branchRepo) GetByID(ctx context.Context, id string) (models.Branch, error) {
+	defer b.db.lock()()
+
+	row, err := b.db.branches.get(id)
+	if err != nil {
+		return models.Branch{}, err
+	}
+
+	return row.value, nil
+}
+
+func (b branchRepo) GetList(ctx context.Context, request models.GetListRequest) (models.BranchResponse, error) {
+	defer b.db.lock()()
+
+	branches := b.db.branches.listIncluding(func(branch models.Branch) bool {
+		return contains(branch.Name, request.Search)
+	}, request.IncludeDeleted)
+
+	return models.BranchResponse{
+		Branches: pageAfter(branches, request.After, request.Page, request.Limit, func(branch models.Branch) models.Cursor {
+			return models.Cursor{CreatedAt: branch.CreatedAt, ID: branch.ID}
+		}),
+		Count: len(branches),
+	}, nil
+}
+
+func (b branchRepo) Update(ctx context.Context, branch models.UpdateBranch) (string, error) {
+	return b.Patch(ctx, models.PatchBranch{
+		ID:      branch.ID,
+		Name:    &branch.Name,
+		Address: &branch.Address,
+		Version: branch.Version,
+	})
+}
+
+func (b branchRepo) Patch(ctx context.Context, branch models.PatchBranch) (string, error) {
+	defer b.db.lock()()
+
+	row, err := b.db.branches.get(branch.ID)
+	if err != nil {
+		return "", err
+	}
+
+	if err = checkVersion(row.value.Version, branch.Version); err != nil {
+		return "", err
+	}
+
+	set(&row.value.Name, branch.Name)
+	set(&row.value.Address, branch.Address)
+	row.value.Version++
+	row.value.UpdatedAt = now()
+
+	return branch.ID, nil
+}
+
+// Delete soft deletes branch which has no stock and no sales in process.
+func (b branchRepo) Delete(ctx context.Context, id string) error {
+	defer b.db.lock()()
+
+	if b.db.repositories.exists(func(repository models.Repository) bool {
+		return repository.BranchID == id && repository.Count > 0 && repository.DeletedAt == 0
+	}) {
+		return errs.Conflict("branch has products in stock, it can not be deleted")
+	}
+
+	if b.db.sales.exists(func(sale models.Sale) bool {
+		return sale.BranchID == id && sale.Status == "in_process" && sale.DeletedAt == 0
+	}) {
+		return errs.Conflict("branch has sales in process, it can not be deleted")
+	}
+
+	b.db.branches.delete(id)
+
+	return nil
+}
+
+func (b branchRepo) Restore(ctx context.Context, id string) error {
+	defer b.db.lock()()
+
+	row, err := b.db.branches.restore(id)
+	if err != nil {
+		return err
+	}
+
+	row.value.Version++
+	row.value.UpdatedAt = now()
+
+	return nil
+}
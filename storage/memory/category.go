@@ -0,0 +1,124 @@
+package memory
+
+import (
+	"context"
+	"market/api/models"
+	"market/pkg/logger"
+	"market/storage"
+
+	"github.com/google/uuid"
+)
+
+type categoryRepo struct {
+	db  *data
+	log logger.ILogger
+}
+
+func newCategoryRepo(db *data, log logger.ILogger) storage.ICategory {
+	return categoryRepo{
+		db:  db,
+		log: log,
+	}
+}
+
+func (c categoryRepo) Create(ctx context.Context, category models.CreateCategory) (string, error) {
+	defer c.db.lock()()
+
+	if err := foreignKey(c.db.categories, "parent_id", category.ParentID); err != nil {
+		return "", err
+	}
+
+	id := uuid.New().String()
+	c.db.categories.insert(id, models.Category{
+		ID:        id,
+		Name:      category.Name,
+		ParentID:  category.ParentID,
+		Version:   1,
+		CreatedAt: now(),
+	})
+
+	return id, nil
+}
+
+func (c categoryRepo) GetByID(ctx context.Context, key models.PrimaryKey) (models.Category, error) {
+	defer c.db.lock()()
+
+	row, err := c.db.categories.get(key.ID)
+	if err != nil {
+		return models.Category{}, err
+	}
+
+	return row.value, nil
+}
+
+func (c categoryRepo) GetList(ctx context.Context, request models.GetListRequest) (models.CategoryResponse, error) {
+	defer c.db.lock()()
+
+	categories := c.db.categories.listIncluding(func(category models.Category) bool {
+		return contains(category.Name, request.Search)
+	}, request.IncludeDeleted)
+
+	return models.CategoryResponse{
+		Categories: pageAfter(categories, request.After, request.Page, request.Limit, func(category models.Category) models.Cursor {
+			return models.Cursor{CreatedAt: category.CreatedAt, ID: category.ID}
+		}),
+		Count: len(categories),
+	}, nil
+}
+
+func (c categoryRepo) Update(ctx context.Context, category models.UpdateCategory) (string, error) {
+	return c.Patch(ctx, models.PatchCategory{
+		ID:       category.ID,
+		Name:     &category.Name,
+		ParentID: &category.ParentID,
+		Version:  category.Version,
+	})
+}
+
+func (c categoryRepo) Patch(ctx context.Context, category models.PatchCategory) (string, error) {
+	defer c.db.lock()()
+
+	row, err := c.db.categories.get(category.ID)
+	if err != nil {
+		return "", err
+	}
+
+	if err = checkVersion(row.value.Version, category.Version); err != nil {
+		return "", err
+	}
+
+	if category.ParentID != nil {
+		if err = foreignKey(c.db.categories, "parent_id", *category.ParentID); err != nil {
+			return "", err
+		}
+	}
+
+	set(&row.value.Name, category.Name)
+	set(&row.value.ParentID, category.ParentID)
+	row.value.Version++
+	row.value.UpdatedAt = now()
+
+	return category.ID, nil
+}
+
+func (c categoryRepo) Delete(ctx context.Context, id string) error {
+	defer c.db.lock()()
+
+	c.db.categories.delete(id)
+
+	return nil
+}
+
+func (c categoryRepo) Restore(ctx context.Context, id string) error {
+	defer c.db.lock()()
+
+	row, err := c.db.categories.restore(id)
+	if err != nil {
+		return err
+	}
+
+	row.value.Version++
+	row.value.UpdatedAt = now()
+
+	return nil
+}
@@ -0,0 +1,173 @@
+package memory
+
+import (
+	"context"
+	"market/api/models"
+	"market/pkg/errs"
+	"market/pkg/logger"
+	"market/storage"
+
+	"github.com/google/uuid"
+)
+
+type customerRepo struct {
+	db  *data
+	log logger.ILogger
+}
+
+func newCustomerRepo(db *data, log logger.ILogger) storage.ICustomerStorage {
+	return customerRepo{
+		db:  db,
+		log: log,
+	}
+}
+
+func (c customerRepo) Create(ctx context.Context, customer models.CreateCustomer) (string, error) {
+	defer c.db.lock()()
+
+	if err := foreignKey(c.db.branches, "branch_id", customer.BranchID); err != nil {
+		return "", err
+	}
+
+	if c.phoneTaken("", customer.Phone) {
+		return "", errs.Conflict("Key (phone)=(%s) already exists.", customer.Phone)
+	}
+
+	id := uuid.New().String()
+	c.db.customers.insert(id, models.Customer{
+		ID:        id,
+		Phone:     customer.Phone,
+		Name:      customer.Name,
+		BranchID:  customer.BranchID,
+		Version:   1,
+		CreatedAt: now(),
+	})
+
+	return id, nil
+}
+
+func (c customerRepo) GetByID(ctx context.Context, id string) (models.Customer, error) {
+	defer c.db.lock()()
+
+	row, err := c.db.customers.get(id)
+	if err != nil {
+		return models.Customer{}, err
+	}
+
+	return row.value, nil
+}
+
+// GetList searches customers by phone or name, newest first.
+func (c customerRepo) GetList(ctx context.Context, request models.GetListRequest) (models.CustomersResponse, error) {
+	defer c.db.lock()()
+
+	customers := reversed(c.db.customers.listIncluding(func(customer models.Customer) bool {
+		return contains(customer.Phone, request.Search) || contains(customer.Name, request.Search)
+	}, request.IncludeDeleted))
+
+	return models.CustomersResponse{
+		Customers: pageAfter(customers, request.After, request.Page, request.Limit, func(customer models.Customer) models.Cursor {
+			return models.Cursor{CreatedAt: customer.CreatedAt, ID: customer.ID}
+		}),
+		Count: len(customers),
+	}, nil
+}
+
+func (c customerRepo) Update(ctx context.Context, customer models.UpdateCustomer) (string, error) {
+	return c.Patch(ctx, models.PatchCustomer{
+		ID:      customer.ID,
+		Phone:   &customer.Phone,
+		Name:    &customer.Name,
+		Version: customer.Version,
+	})
+}
+
+func (c customerRepo) Patch(ctx context.Context, customer models.PatchCustomer) (string, error) {
+	defer c.db.lock()()
+
+	row, err := c.db.customers.get(customer.ID)
+	if err != nil {
+		return "", err
+	}
+
+	if err = checkVersion(row.value.Version, customer.Version); err != nil {
+		return "", err
+	}
+
+	if customer.Phone != nil && c.phoneTaken(customer.ID, *customer.Phone) {
+		return "", errs.Conflict("Key (phone)=(%s) already exists.", *customer.Phone)
+	}
+
+	set(&row.value.Phone, customer.Phone)
+	set(&row.value.Name, customer.Name)
+	row.value.Version++
+	row.value.UpdatedAt = now()
+
+	return customer.ID, nil
+}
+
+func (c customerRepo) Delete(ctx context.Context, id string) error {
+	defer c.db.lock()()
+
+	c.db.customers.delete(id)
+
+	return nil
+}
+
+func (c customerRepo) Restore(ctx context.Context, id string) error {
+	defer c.db.lock()()
+
+	row, err := c.db.customers.restore(id)
+	if err != nil {
+		return err
+	}
+
+	row.value.Version++
+	row.value.UpdatedAt = now()
+
+	return nil
+}
+
+// AddPoints adds points to the customer balance, negative points are withdrawn
+// only if the customer has enough of them.
+func (c customerRepo) AddPoints(ctx context.Context, id string, points int) error {
+	defer c.db.lock()()
+
+	row, err := c.db.customers.get(id)
+	if err != nil || row.value.Points+points < 0 {
+		return errs.Validation("customer does not have enough points")
+	}
+
+	row.value.Points += points
+	row.value.Version++
+	row.value.UpdatedAt = now()
+
+	return nil
+}
+
+// SaleHistory returns a page of the customer's sales, newest first, with their baskets.
+func (c customerRepo) SaleHistory(ctx context.Context, id string, request models.GetListRequest) ([]models.CustomerSale, int, error) {
+	defer c.db.lock()()
+
+	sales := reversed(c.db.sales.list(func(sale models.Sale) bool {
+		return sale.CustomerID == id
+	}))
+
+	history := []models.CustomerSale{}
+	for _, sale := range page(sales, request.Page, request.Limit) {
+		history = append(history, models.CustomerSale{
+			Sale: sale,
+			Baskets: c.db.baskets.list(func(basket models.Basket) bool {
+				return basket.SaleID == sale.ID
+			}),
+		})
+	}
+
+	return history, len(sales), nil
+}
+
+func (c customerRepo) phoneTaken(id, phone string) bool {
+	return c.db.customers.exists(func(customer models.Customer) bool {
+		return customer.ID != id && customer.Phone == phone
+	})
+}
@@ -0,0 +1,107 @@
+package memory
+
+import (
+	"context"
+	"market/api/models"
+	"market/pkg/errs"
+	"market/pkg/logger"
+	"market/storage"
+	"time"
+)
+
+type idempotencyRepo struct {
+	db  *data
+	log logger.ILogger
+}
+
+func newIdempotencyRepo(db *data, log logger.ILogger) storage.IIdempotencyStorage {
+	return idempotencyRepo{
+		db:  db,
+		log: log,
+	}
+}
+
+// Reserve stores the key before the request is handled and returns it with the time it
+// was reserved at. If the key is already stored and not older than ttl, the stored row is
+// returned with false. A key still in progress which was reserved longer than lease ago is
+// reserved again, only by the same request.
+func (i idempotencyRepo) Reserve(ctx context.Context, request models.IdempotentResponse, ttl, lease time.Duration) (models.IdempotentResponse, bool, error) {
+	defer i.db.lock()()
+
+	id := idempotencyKey(request.Key, request.Endpoint)
+	row, ok := i.db.idempotencyKeys.rows[id]
+	if ok && !row.value.CreatedAt.Before(now().Add(-ttl)) {
+		if row.value.StatusCode != 0 || row.value.RequestHash != request.RequestHash ||
+			!row.value.ReservedAt.Before(now().Add(-lease)) {
+			return row.value, false, nil
+		}
+
+		row.value.ReservedAt = now()
+
+		return row.value, true, nil
+	}
+
+	request.StatusCode = 0
+	request.ContentType = ""
+	request.Body = nil
+	request.CreatedAt = now()
+	request.ReservedAt = request.CreatedAt
+	i.db.idempotencyKeys.insert(id, request)
+
+	return request, true, nil
+}
+
+// Save stores the response of the request which reserved the key. If the key was
+// reserved again by a retry after the lease of response ran out, it is a conflict.
+func (i idempotencyRepo) Save(ctx context.Context, response models.IdempotentResponse) error {
+	defer i.db.lock()()
+
+	row, ok := i.db.idempotencyKeys.rows[idempotencyKey(response.Key, response.Endpoint)]
+	if !ok || !reservedBy(row.value, response) {
+		return errs.Conflict("Idempotency-Key %s is reserved by another request", response.Key)
+	}
+
+	row.value.StatusCode = response.StatusCode
+	row.value.ContentType = response.ContentType
+	row.value.Body = response.Body
+
+	return nil
+}
+
+// Release removes the key, so that the request can be retried with it. A key which was
+// reserved again by a retry is left to it.
+func (i idempotencyRepo) Release(ctx context.Context, request models.IdempotentResponse) error {
+	defer i.db.lock()()
+
+	id := idempotencyKey(request.Key, request.Endpoint)
+	if row, ok := i.db.idempotencyKeys.rows[id]; ok && reservedBy(row.value, request) {
+		delete(i.db.idempotencyKeys.rows, id)
+	}
+
+	return nil
+}
+
+// DeleteExpired removes the keys older than ttl.
+func (i idempotencyRepo) DeleteExpired(ctx context.Context, ttl time.Duration) (int64, error) {
+	defer i.db.lock()()
+
+	var deleted int64
+	for id, row := range i.db.idempotencyKeys.rows {
+		if row.value.CreatedAt.Before(now().Add(-ttl)) {
+			delete(i.db.idempotencyKeys.rows, id)
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
+
+// reservedBy reports whether the stored key is still reserved by request.
+func reservedBy(stored, request models.IdempotentResponse) bool {
+	return stored.ReservedAt.Equal(request.ReservedAt) && stored.RequestHash == request.RequestHash
+}
+
+// idempotencyKey is the id of the row, keys are unique per endpoint.
+func idempotencyKey(key, endpoint string) string {
+	return endpoint + " " + key
+}
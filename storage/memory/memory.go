@@ -0,0 +1,472 @@
+package memory
+
+import (
+	"context"
+	"market/api/models"
+	"market/pkg/errs"
+	"market/pkg/logger"
+	"market/storage"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// record is a row of a table, deleted rows are kept like soft deleted rows in postgres.
+// deletedAt is the unix time of deletion, 0 for rows which are not deleted.
+type record[T any] struct {
+	value     T
+	seq       int
+	deletedAt int
+}
+
+func (r *record[T]) deleted() bool {
+	return r.deletedAt != 0
+}
+
+// table keeps rows by id in insertion order. deletedAt returns the DeletedAt field
+// of the model, so that listed rows show when they were deleted.
+type table[T any] struct {
+	rows      map[string]*record[T]
+	seq       int
+	deletedAt func(*T) *int
+}
+
+func newTable[T any]() *table[T] {
+	return &table[T]{rows: map[string]*record[T]{}}
+}
+
+// newSoftTable returns table of a model which has DeletedAt field.
+func newSoftTable[T any](deletedAt func(*T) *int) *table[T] {
+	return &table[T]{rows: map[string]*record[T]{}, deletedAt: deletedAt}
+}
+
+func (t *table[T]) setDeletedAt(row *record[T], deletedAt int) {
+	row.deletedAt = deletedAt
+	if t.deletedAt != nil {
+		*t.deletedAt(&row.value) = deletedAt
+	}
+}
+
+func (t *table[T]) insert(id string, value T) {
+	t.seq++
+	t.rows[id] = &record[T]{value: value, seq: t.seq}
+}
+
+// get returns a row which is not deleted.
+func (t *table[T]) get(id string) (*record[T], error) {
+	row, ok := t.rows[id]
+	if !ok || row.deleted() {
+		return nil, errs.NotFound("record not found")
+	}
+
+	return row, nil
+}
+
+// delete marks the row as deleted, deleting a missing or deleted row does nothing as in postgres repos.
+func (t *table[T]) delete(id string) {
+	if row, ok := t.rows[id]; ok && !row.deleted() {
+		t.setDeletedAt(row, int(now().Unix()))
+	}
+}
+
+// restore clears deletion of the deleted row.
+func (t *table[T]) restore(id string) (*record[T], error) {
+	row, ok := t.rows[id]
+	if !ok || !row.deleted() {
+		return nil, errs.NotFound("deleted record not found")
+	}
+
+	t.setDeletedAt(row, 0)
+
+	return row, nil
+}
+
+// purge removes rows deleted before the given unix time which are not referenced, and returns their number.
+func (t *table[T]) purge(before int, referenced func(id string) bool) int64 {
+	var purged int64
+	for id, row := range t.rows {
+		if row.deleted() && row.deletedAt < before && (referenced == nil || !referenced(id)) {
+			delete(t.rows, id)
+			purged++
+		}
+	}
+
+	return purged
+}
+
+// list returns rows which are not deleted and match, oldest first.
+func (t *table[T]) list(match func(T) bool) []T {
+	return t.listIncluding(match, false)
+}
+
+// listIncluding returns rows which match, oldest first. Deleted rows are returned only with includeDeleted.
+func (t *table[T]) listIncluding(match func(T) bool, includeDeleted bool) []T {
+	rows := make([]*record[T], 0, len(t.rows))
+	for _, row := range t.rows {
+		if (includeDeleted || !row.deleted()) && (match == nil || match(row.value)) {
+			rows = append(rows, row)
+		}
+	}
+
+	sort.Slice(rows, func(i, j int) bool { return rows[i].seq < rows[j].seq })
+
+	values := make([]T, 0, len(rows))
+	for _, row := range rows {
+		values = append(values, row.value)
+	}
+
+	return values
+}
+
+// exists reports whether any row, deleted or not, matches, the same way unique constraints work.
+func (t *table[T]) exists(match func(T) bool) bool {
+	for _, row := range t.rows {
+		if match(row.value) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (t *table[T]) clone() *table[T] {
+	c := &table[T]{rows: make(map[string]*record[T], len(t.rows)), seq: t.seq, deletedAt: t.deletedAt}
+	for id, row := range t.rows {
+		copied := *row
+		c.rows[id] = &copied
+	}
+
+	return c
+}
+
+// foreignKey checks that the referenced row exists, soft deleted rows are still referenced
+// as in postgres. Empty id is NULL and references nothing.
+func foreignKey[T any](t *table[T], column, id string) error {
+	if id == "" {
+		return nil
+	}
+
+	if _, ok := t.rows[id]; !ok {
+		return errs.Validation("Key (%s)=(%s) is not present in table.", column, id)
+	}
+
+	return nil
+}
+
+// checkVersion rejects updates based on an old version of the row.
+func checkVersion(current, expected int) error {
+	if current != expected {
+		return errs.Conflict("record was changed by someone else, get it again and retry")
+	}
+
+	return nil
+}
+
+// page returns the items of the requested page, page and limit start from 1.
+func page[T any](items []T, pageNumber, limit int) []T {
+	if limit <= 0 {
+		return []T{}
+	}
+
+	offset := (pageNumber - 1) * limit
+	if offset < 0 {
+		offset = 0
+	}
+	if offset >= len(items) {
+		return []T{}
+	}
+
+	end := offset + limit
+	if end > len(items) {
+		end = len(items)
+	}
+
+	return items[offset:end]
+}
+
+// pageAfter returns the requested page of items, by keyset after the cursor as postgres
+// repos do when after is set, see models.Cursor, and by page otherwise. cursor returns
+// the created_at and id of an item.
+func pageAfter[T any](items []T, after *models.Cursor, pageNumber, limit int, cursor func(T) models.Cursor) []T {
+	if after == nil {
+		return page(items, pageNumber, limit)
+	}
+
+	older := func(a, b models.Cursor) bool {
+		return a.CreatedAt.Before(b.CreatedAt) || a.CreatedAt.Equal(b.CreatedAt) && a.ID < b.ID
+	}
+
+	rest := make([]T, 0, len(items))
+	for _, item := range items {
+		if after.ID == "" || older(cursor(item), *after) {
+			rest = append(rest, item)
+		}
+	}
+
+	sort.SliceStable(rest, func(i, j int) bool { return older(cursor(rest[j]), cursor(rest[i])) })
+
+	return page(rest, 1, limit)
+}
+
+// textCursor is the cursor of models which keep created_at as text.
+func textCursor(createdAt, id string) models.Cursor {
+	t, _ := time.ParseInLocation(timestampLayout, createdAt, time.Local)
+	return models.Cursor{CreatedAt: t, ID: id}
+}
+
+// reversed returns items newest first, for lists which postgres orders by created_at DESC.
+func reversed[T any](items []T) []T {
+	for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+		items[i], items[j] = items[j], items[i]
+	}
+
+	return items
+}
+
+// set changes field when the patch has value for it.
+func set[T any](field *T, value *T) {
+	if value != nil {
+		*field = *value
+	}
+}
+
+func contains(value, search string) bool {
+	return strings.Contains(strings.ToLower(value), strings.ToLower(search))
+}
+
+// timestampLayout is how postgres prints timestamps, for models which keep them as text.
+const timestampLayout = "2006-01-02 15:04:05.999999"
+
+// now is the timestamp of created_at and updated_at columns.
+func now() time.Time {
+	return time.Now()
+}
+
+// data is the whole database. Every repo call holds mu, and every call made outside
+// of WithTx also holds txMu for reading, so that WithTx can run alone.
+type data struct {
+	mu   sync.Mutex
+	txMu sync.RWMutex
+
+	categories             *table[models.Category]
+	products               *table[models.Product]
+	branches               *table[models.Branch]
+	repositories           *table[models.Repository]
+	sales                  *table[models.Sale]
+	baskets                *table[models.Basket]
+	staffTarifs            *table[models.StaffTarif]
+	staffs                 *table[models.Staff]
+	transactions           *table[models.Transaction]
+	repositoryTransactions *table[models.RepositoryTransaction]
+	shifts                 *table[models.Shift]
+	customers              *table[models.Customer]
+	payouts                *table[models.Payout]
+	idempotencyKeys        *table[models.IdempotentResponse]
+	auditLogs              *table[models.AuditLog]
+	outboxEvents           *table[outboxEvent]
+	webhooks               *table[models.Webhook]
+	webhookDeliveries      *table[models.WebhookDelivery]
+}
+
+func newData() *data {
+	return &data{
+		categories:             newSoftTable(func(row *models.Category) *int { return &row.DeletedAt }),
+		products:               newSoftTable(func(row *models.Product) *int { return &row.DeletedAt }),
+		branches:               newSoftTable(func(row *models.Branch) *int { return &row.DeletedAt }),
+		repositories:           newSoftTable(func(row *models.Repository) *int { return &row.DeletedAt }),
+		sales:                  newSoftTable(func(row *models.Sale) *int { return &row.DeletedAt }),
+		baskets:                newSoftTable(func(row *models.Basket) *int { return &row.DeletedAt }),
+		staffTarifs:            newSoftTable(func(row *models.StaffTarif) *int { return &row.DeletedAt }),
+		staffs:                 newSoftTable(func(row *models.Staff) *int { return &row.DeletedAt }),
+		transactions:           newSoftTable(func(row *models.Transaction) *int { return &row.DeletedAt }),
+		repositoryTransactions: newSoftTable(func(row *models.RepositoryTransaction) *int { return &row.DeletedAt }),
+		shifts:                 newTable[models.Shift](),
+		customers:              newSoftTable(func(row *models.Customer) *int { return &row.DeletedAt }),
+		payouts:                newTable[models.Payout](),
+		idempotencyKeys:        newTable[models.IdempotentResponse](),
+		auditLogs:              newTable[models.AuditLog](),
+		outboxEvents:           newTable[outboxEvent](),
+		webhooks:               newSoftTable(func(row *models.Webhook) *int { return &row.DeletedAt }),
+		webhookDeliveries:      newTable[models.WebhookDelivery](),
+	}
+}
+
+func (d *data) lock() func() {
+	d.txMu.RLock()
+	d.mu.Lock()
+
+	return func() {
+		d.mu.Unlock()
+		d.txMu.RUnlock()
+	}
+}
+
+// clone copies all tables, it is called while the caller holds txMu.
+func (d *data) clone() *data {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return &data{
+		categories:             d.categories.clone(),
+		products:               d.products.clone(),
+		branches:               d.branches.clone(),
+		repositories:           d.repositories.clone(),
+		sales:                  d.sales.clone(),
+		baskets:                d.baskets.clone(),
+		staffTarifs:            d.staffTarifs.clone(),
+		staffs:                 d.staffs.clone(),
+		transactions:           d.transactions.clone(),
+		repositoryTransactions: d.repositoryTransactions.clone(),
+		shifts:                 d.shifts.clone(),
+		customers:              d.customers.clone(),
+		payouts:                d.payouts.clone(),
+		idempotencyKeys:        d.idempotencyKeys.clone(),
+		auditLogs:              d.auditLogs.clone(),
+		outboxEvents:           d.outboxEvents.clone(),
+		webhooks:               d.webhooks.clone(),
+		webhookDeliveries:      d.webhookDeliveries.clone(),
+	}
+}
+
+// commit replaces all tables with the tables of the finished transaction.
+func (d *data) commit(tx *data) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.categories = tx.categories
+	d.products = tx.products
+	d.branches = tx.branches
+	d.repositories = tx.repositories
+	d.sales = tx.sales
+	d.baskets = tx.baskets
+	d.staffTarifs = tx.staffTarifs
+	d.staffs = tx.staffs
+	d.transactions = tx.transactions
+	d.repositoryTransactions = tx.repositoryTransactions
+	d.shifts = tx.shifts
+	d.customers = tx.customers
+	d.payouts = tx.payouts
+	d.idempotencyKeys = tx.idempotencyKeys
+	d.auditLogs = tx.auditLogs
+	d.outboxEvents = tx.outboxEvents
+	d.webhooks = tx.webhooks
+	d.webhookDeliveries = tx.webhookDeliveries
+}
+
+// Store keeps all data in memory, it is used in tests and demo mode instead of postgres.
+// Nothing is saved when the process exits.
+type Store struct {
+	db  *data
+	log logger.ILogger
+}
+
+func New(log logger.ILogger) storage.IStorage {
+	return &Store{
+		db:  newData(),
+		log: log,
+	}
+}
+
+func (s *Store) Close() {}
+
+func (s *Store) Ping(ctx context.Context) error {
+	return nil
+}
+
+// WithTx runs fn with the store on a copy of the data, which replaces the data if fn returns nil.
+// Transactions run one at a time and other calls wait for them, so fn should use only
+// the store it gets.
+func (s *Store) WithTx(ctx context.Context, fn func(storage.IStorage) error) error {
+	s.db.txMu.Lock()
+	defer s.db.txMu.Unlock()
+
+	tx := s.db.clone()
+	if err := fn(&Store{db: tx, log: s.log}); err != nil {
+		return err
+	}
+
+	s.db.commit(tx)
+
+	return nil
+}
+
+func (s *Store) StaffTariff() storage.IStaffTariffRepo {
+	return newStaffTarifRepo(s.db, s.log)
+}
+
+func (s *Store) Category() storage.ICategory {
+	return newCategoryRepo(s.db, s.log)
+}
+
+func (s *Store) Product() storage.IProducts {
+	return newProductRepo(s.db, s.log)
+}
+
+func (s *Store) Branch() storage.IBranchStorage {
+	return newBranchRepo(s.db, s.log)
+}
+
+func (s *Store) Sale() storage.ISaleStorage {
+	return newSaleRepo(s.db, s.log)
+}
+
+func (s *Store) Transaction() storage.ITransactionStorage {
+	return newTransactionRepo(s.db, s.log)
+}
+
+func (s *Store) Staff() storage.IStaffRepo {
+	return newStaffRepo(s.db, s.log)
+}
+
+func (s *Store) Repository() storage.IRepositoryRepo {
+	return newRepositoryRepo(s.db, s.log)
+}
+
+func (s *Store) Basket() storage.IBasketRepo {
+	return newBasketRepo(s.db, s.log)
+}
+
+func (s *Store) RTransaction() storage.IRepositoryTransactionRepo {
+	return newRepositoryTransactionRepo(s.db, s.log)
+}
+
+func (s *Store) Shift() storage.IShiftStorage {
+	return newShiftRepo(s.db, s.log)
+}
+
+func (s *Store) Customer() storage.ICustomerStorage {
+	return newCustomerRepo(s.db, s.log)
+}
+
+func (s *Store) Payout() storage.IPayoutStorage {
+	return newPayoutRepo(s.db, s.log)
+}
+
+func (s *Store) Report() storage.IReportStorage {
+	return newReportRepo(s.db, s.log)
+}
+
+func (s *Store) Summary() storage.ISummaryStorage {
+	return newSummaryRepo(s.db, s.log)
+}
+
+func (s *Store) Idempotency() storage.IIdempotencyStorage {
+	return newIdempotencyRepo(s.db, s.log)
+}
+
+func (s *Store) Purge() storage.IPurgeStorage {
+	return newPurgeRepo(s.db, s.log)
+}
+
+func (s *Store) Audit() storage.IAuditStorage {
+	return newAuditRepo(s.db, s.log)
+}
+
+func (s *Store) Outbox() storage.IOutboxStorage {
+	return newOutboxRepo(s.db, s.log)
+}
+
+func (s *Store) Webhook() storage.IWebhookStorage {
+	return newWebhookRepo(s.db, s.log)
+}
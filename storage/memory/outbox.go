@@ -0,0 +1,122 @@
+package memory
+
+import (
+	"context"
+	"market/api/models"
+	"market/pkg/logger"
+	"market/storage"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// outboxEvent is a row of outbox_events, dispatchedAt is zero until the event is dispatched
+// and the event is due once nextAttemptAt has passed.
+type outboxEvent struct {
+	event         models.Event
+	lastError     string
+	nextAttemptAt time.Time
+	dispatchedAt  time.Time
+}
+
+type outboxRepo struct {
+	db  *data
+	log logger.ILogger
+}
+
+func newOutboxRepo(db *data, log logger.ILogger) storage.IOutboxStorage {
+	return outboxRepo{
+		db:  db,
+		log: log,
+	}
+}
+
+func (o outboxRepo) Add(ctx context.Context, event models.CreateEvent) error {
+	defer o.db.lock()()
+
+	id := uuid.New().String()
+	o.db.outboxEvents.insert(id, outboxEvent{event: models.Event{
+		ID:          id,
+		Type:        event.Type,
+		AggregateID: event.AggregateID,
+		Payload:     event.Payload,
+		CreatedAt:   now(),
+	}})
+
+	return nil
+}
+
+func (o outboxRepo) Claim(ctx context.Context, limit, maxAttempts int, lease time.Duration) ([]models.Event, error) {
+	defer o.db.lock()()
+
+	due := now()
+	pending := o.db.outboxEvents.list(func(row outboxEvent) bool {
+		return row.dispatchedAt.IsZero() && row.event.Attempts < maxAttempts && !row.nextAttemptAt.After(due)
+	})
+
+	events := []models.Event{}
+	for _, row := range page(pending, 1, limit) {
+		o.db.outboxEvents.rows[row.event.ID].value.nextAttemptAt = due.Add(lease)
+
+		event := row.event
+		event.DeliveredTo = append([]string{}, row.event.DeliveredTo...)
+		events = append(events, event)
+	}
+
+	return events, nil
+}
+
+func (o outboxRepo) MarkDelivered(ctx context.Context, id, receiver string) error {
+	defer o.db.lock()()
+
+	row, ok := o.db.outboxEvents.rows[id]
+	if !ok {
+		return nil
+	}
+
+	for _, delivered := range row.value.event.DeliveredTo {
+		if delivered == receiver {
+			return nil
+		}
+	}
+	row.value.event.DeliveredTo = append(row.value.event.DeliveredTo, receiver)
+
+	return nil
+}
+
+func (o outboxRepo) MarkDispatched(ctx context.Context, id string) error {
+	defer o.db.lock()()
+
+	if row, ok := o.db.outboxEvents.rows[id]; ok {
+		row.value.dispatchedAt = now()
+	}
+
+	return nil
+}
+
+func (o outboxRepo) MarkFailed(ctx context.Context, id, reason string, backoff time.Duration) error {
+	defer o.db.lock()()
+
+	if row, ok := o.db.outboxEvents.rows[id]; ok {
+		row.value.event.Attempts++
+		row.value.lastError = reason
+		row.value.nextAttemptAt = now().Add(backoff)
+	}
+
+	return nil
+}
+
+func (o outboxRepo) DeleteDispatched(ctx context.Context, retention time.Duration) (int64, error) {
+	defer o.db.lock()()
+
+	before := now().Add(-retention)
+	var deleted int64
+	for id, row := range o.db.outboxEvents.rows {
+		if !row.value.dispatchedAt.IsZero() && row.value.dispatchedAt.Before(before) {
+			delete(o.db.outboxEvents.rows, id)
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
@@ -0,0 +1,211 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"market/api/models"
+	"market/pkg/errs"
+	"market/pkg/logger"
+	"market/storage"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type payoutRepo struct {
+	db  *data
+	log logger.ILogger
+}
+
+func newPayoutRepo(db *data, log logger.ILogger) storage.IPayoutStorage {
+	return payoutRepo{
+		db:  db,
+		log: log,
+	}
+}
+
+// Run pays every staff of the branch what they earned in the period, but not more than
+// their balance, and records the payout with one item per staff.
+func (p payoutRepo) Run(ctx context.Context, request models.CreatePayout) (string, error) {
+	defer p.db.lock()()
+
+	from, to, err := period(request.PeriodFrom, request.PeriodTo)
+	if err != nil {
+		return "", err
+	}
+
+	if err = foreignKey(p.db.branches, "branch_id", request.BranchID); err != nil {
+		return "", err
+	}
+
+	for _, paid := range p.db.payouts.list(func(payout models.Payout) bool { return payout.BranchID == request.BranchID }) {
+		if paid.PeriodFrom <= request.PeriodTo && paid.PeriodTo >= request.PeriodFrom {
+			return "", errs.Conflict("branch is already paid for %s - %s, which overlaps the period", paid.PeriodFrom, paid.PeriodTo)
+		}
+	}
+
+	var (
+		id          = uuid.New().String()
+		payout      = models.Payout{ID: id, BranchID: request.BranchID, PeriodFrom: request.PeriodFrom, PeriodTo: request.PeriodTo, CreatedAt: now()}
+		description = fmt.Sprintf("payroll %s - %s", request.PeriodFrom, request.PeriodTo)
+	)
+
+	for _, staff := range p.db.staffs.list(func(staff models.Staff) bool { return staff.BranchID == request.BranchID }) {
+		earned := 0.0
+		for _, trans := range p.transactions(staff.ID, from, to) {
+			if trans.TransactionType == "topup" {
+				earned += trans.Amount
+			} else if trans.TransactionType == "withdraw" && trans.SourceType != "payout" {
+				earned -= trans.Amount
+			}
+		}
+
+		balance := int(staff.Balance)
+		paid := int(earned)
+		if paid > balance {
+			paid = balance
+		}
+		if paid < 0 {
+			paid = 0
+		}
+
+		item := models.PayoutItem{
+			ID:            uuid.New().String(),
+			PayoutID:      id,
+			StaffID:       staff.ID,
+			Earned:        earned,
+			BalanceBefore: balance,
+			Paid:          paid,
+		}
+
+		if paid > 0 {
+			item.TransactionID = uuid.New().String()
+			p.db.transactions.insert(item.TransactionID, models.Transaction{
+				ID:              item.TransactionID,
+				StaffID:         staff.ID,
+				TransactionType: "withdraw",
+				SourceType:      "payout",
+				Amount:          float64(paid),
+				Description:     description,
+				Version:         1,
+				CreatedAt:       now(),
+			})
+
+			row := p.db.staffs.rows[staff.ID]
+			row.value.Balance -= uint(paid)
+			row.value.Version++
+			row.value.UpdatedAt = now()
+		}
+
+		payout.Items = append(payout.Items, item)
+		payout.Total += float64(paid)
+	}
+
+	p.db.payouts.insert(id, payout)
+
+	return id, nil
+}
+
+func (p payoutRepo) GetByID(ctx context.Context, id string) (models.Payout, error) {
+	defer p.db.lock()()
+
+	return p.payout(id)
+}
+
+// GetList returns payouts, optionally of one branch, newest first. Items are returned only by GetByID.
+func (p payoutRepo) GetList(ctx context.Context, request models.GetListRequest) (models.PayoutsResponse, error) {
+	defer p.db.lock()()
+
+	payouts := reversed(p.db.payouts.list(func(payout models.Payout) bool {
+		return request.Search == "" || payout.BranchID == request.Search
+	}))
+
+	for i := range payouts {
+		payouts[i].Items = nil
+	}
+
+	return models.PayoutsResponse{
+		Payouts: pageAfter(payouts, request.After, request.Page, request.Limit, func(payout models.Payout) models.Cursor {
+			return models.Cursor{CreatedAt: payout.CreatedAt, ID: payout.ID}
+		}),
+		Count: len(payouts),
+	}, nil
+}
+
+func (p payoutRepo) Statement(ctx context.Context, payoutID, staffID string) (models.PayrollStatement, error) {
+	defer p.db.lock()()
+
+	payout, err := p.payout(payoutID)
+	if err != nil {
+		return models.PayrollStatement{}, err
+	}
+
+	statement := models.PayrollStatement{Payout: payout, Transactions: []models.Transaction{}}
+	statement.Payout.Items = nil
+
+	found := false
+	for _, item := range payout.Items {
+		if item.StaffID == staffID {
+			statement.Item, found = item, true
+		}
+	}
+
+	if !found {
+		return models.PayrollStatement{}, errs.NotFound("staff %s is not in payout %s", staffID, payoutID)
+	}
+
+	from, to, err := period(payout.PeriodFrom, payout.PeriodTo)
+	if err != nil {
+		return models.PayrollStatement{}, err
+	}
+
+	statement.Transactions = append(statement.Transactions, p.transactions(staffID, from, to)...)
+
+	return statement, nil
+}
+
+// payout returns the payout with its items ordered by staff name.
+func (p payoutRepo) payout(id string) (models.Payout, error) {
+	row, err := p.db.payouts.get(id)
+	if err != nil {
+		return models.Payout{}, err
+	}
+
+	payout := row.value
+	payout.Items = make([]models.PayoutItem, 0, len(row.value.Items))
+	for _, item := range row.value.Items {
+		if staff, ok := p.db.staffs.rows[item.StaffID]; ok {
+			item.StaffName = staff.value.Name
+		}
+		payout.Items = append(payout.Items, item)
+	}
+
+	sort.SliceStable(payout.Items, func(i, j int) bool {
+		return payout.Items[i].StaffName < payout.Items[j].StaffName
+	})
+
+	return payout, nil
+}
+
+// transactions returns transactions of staff created in [from, to), oldest first.
+func (p payoutRepo) transactions(staffID string, from, to time.Time) []models.Transaction {
+	return p.db.transactions.list(func(trans models.Transaction) bool {
+		return trans.StaffID == staffID && !trans.CreatedAt.Before(from) && trans.CreatedAt.Before(to)
+	})
+}
+
+// period returns the start of from day and the start of the day after to.
+func period(from, to string) (time.Time, time.Time, error) {
+	start, err := time.ParseInLocation("2006-01-02", from, time.Local)
+	if err != nil {
+		return time.Time{}, time.Time{}, errs.Validation("invalid input syntax for type date: %q", from)
+	}
+
+	end, err := time.ParseInLocation("2006-01-02", to, time.Local)
+	if err != nil {
+		return time.Time{}, time.Time{}, errs.Validation("invalid input syntax for type date: %q", to)
+	}
+
+	return start, end.AddDate(0, 0, 1), nil
+}
@@ -0,0 +1,251 @@
+package memory
+
+import (
+	"context"
+	"market/api/models"
+	"market/pkg/errs"
+	"market/pkg/logger"
+	"market/storage"
+
+	"github.com/google/uuid"
+)
+
+type productRepo struct {
+	db  *data
+	log logger.ILogger
+}
+
+func newProductRepo(db *data, log logger.ILogger) storage.IProducts {
+	return productRepo{
+		db:  db,
+		log: log,
+	}
+}
+
+func (p productRepo) Create(ctx context.Context, product models.CreateProduct) (string, error) {
+	defer p.db.lock()()
+
+	if err := p.check("", &product.Name, &product.Barcode, &product.CategoryID); err != nil {
+		return "", err
+	}
+
+	id := uuid.New().String()
+	p.db.products.insert(id, models.Product{
+		ID:         id,
+		Name:       product.Name,
+		Price:      product.Price,
+		Barcode:    product.Barcode,
+		CategoryID: product.CategoryID,
+		Version:    1,
+		CreatedAt:  now().Format(timestampLayout),
+	})
+
+	return id, nil
+}
+
+func (p productRepo) GetByID(ctx context.Context, id string) (models.Product, error) {
+	defer p.db.lock()()
+
+	row, err := p.db.products.get(id)
+	if err != nil {
+		return models.Product{}, err
+	}
+
+	return row.value, nil
+}
+
+func (p productRepo) GetList(ctx context.Context, request models.ProductGetListRequest) (models.ProductResponse, error) {
+	defer p.db.lock()()
+
+	products := p.db.products.listIncluding(func(product models.Product) bool {
+		return contains(product.Name, request.Name) && (request.Barcode == 0 || product.Barcode == request.Barcode)
+	}, request.IncludeDeleted)
+
+	return models.ProductResponse{
+		Products: pageAfter(products, request.After, request.Page, request.Limit, func(product models.Product) models.Cursor {
+			return textCursor(product.CreatedAt, product.ID)
+		}),
+		Count: len(products),
+	}, nil
+}
+
+func (p productRepo) Update(ctx context.Context, product models.UpdateProduct) (string, error) {
+	return p.Patch(ctx, models.PatchProduct{
+		ID:         product.ID,
+		Name:       &product.Name,
+		Price:      &product.Price,
+		CategoryID: &product.CategoryID,
+		Version:    product.Version,
+	})
+}
+
+func (p productRepo) Patch(ctx context.Context, product models.PatchProduct) (string, error) {
+	defer p.db.lock()()
+
+	row, err := p.db.products.get(product.ID)
+	if err != nil {
+		return "", err
+	}
+
+	if err = checkVersion(row.value.Version, product.Version); err != nil {
+		return "", err
+	}
+
+	if err = p.check(product.ID, product.Name, product.Barcode, product.CategoryID); err != nil {
+		return "", err
+	}
+
+	set(&row.value.Name, product.Name)
+	set(&row.value.Price, product.Price)
+	set(&row.value.Barcode, product.Barcode)
+	set(&row.value.CategoryID, product.CategoryID)
+	row.value.Version++
+	row.value.UpdatedAt = now().Format(timestampLayout)
+
+	return product.ID, nil
+}
+
+// Delete soft deletes product which is not in stock and not in baskets of sales in process.
+func (p productRepo) Delete(ctx context.Context, id string) error {
+	defer p.db.lock()()
+
+	if p.db.repositories.exists(func(repository models.Repository) bool {
+		return repository.ProductID == id && repository.Count > 0 && repository.DeletedAt == 0
+	}) {
+		return errs.Conflict("product is in stock, it can not be deleted")
+	}
+
+	if p.db.baskets.exists(func(basket models.Basket) bool {
+		if basket.ProductID != id || basket.DeletedAt != 0 {
+			return false
+		}
+		sale, err := p.db.sales.get(basket.SaleID)
+		return err == nil && sale.value.Status == "in_process"
+	}) {
+		return errs.Conflict("product is in sales in process, it can not be deleted")
+	}
+
+	p.db.products.delete(id)
+
+	return nil
+}
+
+func (p productRepo) Restore(ctx context.Context, id string) error {
+	defer p.db.lock()()
+
+	row, err := p.db.products.restore(id)
+	if err != nil {
+		return err
+	}
+
+	row.value.Version++
+	row.value.UpdatedAt = now().Format(timestampLayout)
+
+	return nil
+}
+
+// Existing returns which of the given names and barcodes are already taken.
+func (p productRepo) Existing(ctx context.Context, names []string, barcodes []int) ([]string, []int, error) {
+	defer p.db.lock()()
+
+	var (
+		existingNames    = []string{}
+		existingBarcodes = []int{}
+	)
+
+	for _, name := range names {
+		if p.nameTaken("", name) {
+			existingNames = append(existingNames, name)
+		}
+	}
+
+	for _, barcode := range barcodes {
+		if p.barcodeTaken("", barcode) {
+			existingBarcodes = append(existingBarcodes, barcode)
+		}
+	}
+
+	return existingNames, existingBarcodes, nil
+}
+
+// Import inserts products and their initial stock, nothing is inserted if any of them is invalid.
+// The ids of the inserted products and repositories are set in products.
+func (p productRepo) Import(ctx context.Context, products []models.ImportProduct) error {
+	defer p.db.lock()()
+
+	names, barcodes := map[string]bool{}, map[int]bool{}
+	for _, product := range products {
+		if names[product.Name] || barcodes[product.Barcode] {
+			return errs.Conflict("row %d: product name or barcode is repeated", product.Row)
+		}
+		names[product.Name], barcodes[product.Barcode] = true, true
+
+		if err := p.check("", &product.Name, &product.Barcode, &product.CategoryID); err != nil {
+			return err
+		}
+
+		for _, stock := range product.Stocks {
+			if err := foreignKey(p.db.branches, "branch_id", stock.BranchID); err != nil {
+				return err
+			}
+		}
+	}
+
+	for i, product := range products {
+		id := uuid.New().String()
+		products[i].ID = id
+		p.db.products.insert(id, models.Product{
+			ID:         id,
+			Name:       product.Name,
+			Price:      product.Price,
+			Barcode:    product.Barcode,
+			CategoryID: product.CategoryID,
+			Version:    1,
+			CreatedAt:  now().Format(timestampLayout),
+		})
+
+		for j, stock := range product.Stocks {
+			repositoryID := uuid.New().String()
+			products[i].Stocks[j].ID = repositoryID
+			p.db.repositories.insert(repositoryID, models.Repository{
+				ID:        repositoryID,
+				ProductID: id,
+				BranchID:  stock.BranchID,
+				Count:     stock.Count,
+				Version:   1,
+				CreatedAt: now(),
+			})
+		}
+	}
+
+	return nil
+}
+
+// check validates unique name and barcode and the category of the product, nil values are not changed.
+func (p productRepo) check(id string, name *string, barcode *int, categoryID *string) error {
+	if name != nil && p.nameTaken(id, *name) {
+		return errs.Conflict("Key (name)=(%s) already exists.", *name)
+	}
+
+	if barcode != nil && p.barcodeTaken(id, *barcode) {
+		return errs.Conflict("Key (barcode)=(%d) already exists.", *barcode)
+	}
+
+	if categoryID != nil {
+		return foreignKey(p.db.categories, "category_id", *categoryID)
+	}
+
+	return nil
+}
+
+func (p productRepo) nameTaken(id, name string) bool {
+	return p.db.products.exists(func(product models.Product) bool {
+		return product.ID != id && product.Name == name
+	})
+}
+
+func (p productRepo) barcodeTaken(id string, barcode int) bool {
+	return p.db.products.exists(func(product models.Product) bool {
+		return product.ID != id && product.Barcode == barcode
+	})
+}
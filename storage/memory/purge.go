@@ -0,0 +1,107 @@
+package memory
+
+import (
+	"context"
+	"market/api/models"
+	"market/pkg/logger"
+	"market/storage"
+	"time"
+)
+
+type purgeRepo struct {
+	db  *data
+	log logger.ILogger
+}
+
+func newPurgeRepo(db *data, log logger.ILogger) storage.IPurgeStorage {
+	return purgeRepo{
+		db:  db,
+		log: log,
+	}
+}
+
+// Purge removes rows which were deleted before the given time. Rows which are still
+// referenced by other rows, deleted or not, are kept. Children go first, so that their
+// parents are not referenced when it is their turn.
+func (p purgeRepo) Purge(ctx context.Context, before time.Time) (int64, error) {
+	defer p.db.lock()()
+
+	var (
+		db     = p.db
+		cutoff = int(before.Unix())
+		purged int64
+	)
+
+	purged += db.baskets.purge(cutoff, nil)
+
+	// webhook_deliveries are removed with their webhook
+	purged += db.webhooks.purge(cutoff, nil)
+	for id, row := range db.webhookDeliveries.rows {
+		if _, ok := db.webhooks.rows[row.value.WebhookID]; !ok {
+			delete(db.webhookDeliveries.rows, id)
+		}
+	}
+
+	purged += db.repositoryTransactions.purge(cutoff, nil)
+	purged += db.repositories.purge(cutoff, nil)
+
+	purged += db.transactions.purge(cutoff, func(id string) bool {
+		return db.payouts.exists(func(payout models.Payout) bool {
+			for _, item := range payout.Items {
+				if item.TransactionID == id {
+					return true
+				}
+			}
+			return false
+		})
+	})
+
+	purged += db.sales.purge(cutoff, func(id string) bool {
+		return db.baskets.exists(func(basket models.Basket) bool { return basket.SaleID == id }) ||
+			db.transactions.exists(func(trans models.Transaction) bool { return trans.SaleID == id })
+	})
+
+	purged += db.customers.purge(cutoff, func(id string) bool {
+		return db.sales.exists(func(sale models.Sale) bool { return sale.CustomerID == id })
+	})
+
+	purged += db.staffs.purge(cutoff, func(id string) bool {
+		return db.transactions.exists(func(trans models.Transaction) bool { return trans.StaffID == id }) ||
+			db.repositoryTransactions.exists(func(rtransaction models.RepositoryTransaction) bool { return rtransaction.StaffID == id }) ||
+			db.shifts.exists(func(shift models.Shift) bool { return shift.CashierID == id }) ||
+			db.payouts.exists(func(payout models.Payout) bool {
+				for _, item := range payout.Items {
+					if item.StaffID == id {
+						return true
+					}
+				}
+				return false
+			})
+	})
+
+	purged += db.staffTarifs.purge(cutoff, func(id string) bool {
+		return db.staffs.exists(func(staff models.Staff) bool { return staff.TariffID == id })
+	})
+
+	purged += db.products.purge(cutoff, func(id string) bool {
+		return db.repositories.exists(func(repository models.Repository) bool { return repository.ProductID == id }) ||
+			db.baskets.exists(func(basket models.Basket) bool { return basket.ProductID == id }) ||
+			db.repositoryTransactions.exists(func(rtransaction models.RepositoryTransaction) bool { return rtransaction.ProductID == id })
+	})
+
+	purged += db.categories.purge(cutoff, func(id string) bool {
+		return db.products.exists(func(product models.Product) bool { return product.CategoryID == id }) ||
+			db.categories.exists(func(category models.Category) bool { return category.ParentID == id })
+	})
+
+	purged += db.branches.purge(cutoff, func(id string) bool {
+		return db.repositories.exists(func(repository models.Repository) bool { return repository.BranchID == id }) ||
+			db.customers.exists(func(customer models.Customer) bool { return customer.BranchID == id }) ||
+			db.sales.exists(func(sale models.Sale) bool { return sale.BranchID == id }) ||
+			db.staffs.exists(func(staff models.Staff) bool { return staff.BranchID == id }) ||
+			db.shifts.exists(func(shift models.Shift) bool { return shift.BranchID == id }) ||
+			db.payouts.exists(func(payout models.Payout) bool { return payout.BranchID == id })
+	})
+
+	return purged, nil
+}
@@ -0,0 +1,299 @@
+package memory
+
+import (
+	"context"
+	"market/api/models"
+	"market/pkg/logger"
+	"market/storage"
+	"sort"
+	"time"
+)
+
+type reportRepo struct {
+	db  *data
+	log logger.ILogger
+}
+
+func newReportRepo(db *data, log logger.ILogger) storage.IReportStorage {
+	return reportRepo{
+		db:  db,
+		log: log,
+	}
+}
+
+// Reports are counted from sales and baskets directly, there are no daily summaries in memory.
+
+func (r reportRepo) Revenue(ctx context.Context, request models.ReportRequest) ([]models.RevenueReport, error) {
+	defer r.db.lock()()
+
+	sales, err := r.sales(request)
+	if err != nil {
+		return nil, err
+	}
+
+	type key struct{ period, branchID string }
+
+	var (
+		keys    = []key{}
+		reports = map[key]*models.RevenueReport{}
+	)
+
+	for _, sale := range sales {
+		k := key{period: truncate(sale.CreatedAt, request.Group).Format("2006-01-02"), branchID: sale.BranchID}
+
+		report, ok := reports[k]
+		if !ok {
+			report = &models.RevenueReport{Period: k.period, BranchID: k.branchID}
+			if branch, ok := r.db.branches.rows[k.branchID]; ok {
+				report.BranchName = branch.value.Name
+			}
+
+			keys = append(keys, k)
+			reports[k] = report
+		}
+
+		report.SalesCount++
+		report.Revenue += float64(sale.Price)
+	}
+
+	result := make([]models.RevenueReport, 0, len(keys))
+	for _, k := range keys {
+		result = append(result, *reports[k])
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Period != result[j].Period {
+			return result[i].Period < result[j].Period
+		}
+		return result[i].BranchName < result[j].BranchName
+	})
+
+	return result, nil
+}
+
+func (r reportRepo) TopProducts(ctx context.Context, request models.ReportRequest) ([]models.ProductReport, error) {
+	defer r.db.lock()()
+
+	sales, err := r.sales(request)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		ids     = []string{}
+		reports = map[string]*models.ProductReport{}
+	)
+
+	for _, sale := range sales {
+		for _, basket := range r.db.baskets.list(func(basket models.Basket) bool { return basket.SaleID == sale.ID }) {
+			product, ok := r.db.products.rows[basket.ProductID]
+			if !ok {
+				continue
+			}
+
+			report, ok := reports[basket.ProductID]
+			if !ok {
+				report = &models.ProductReport{ProductID: basket.ProductID, Name: product.value.Name}
+				ids = append(ids, basket.ProductID)
+				reports[basket.ProductID] = report
+			}
+
+			report.Quantity += basket.Quantity
+			report.Revenue += float64(basket.Price)
+		}
+	}
+
+	result := make([]models.ProductReport, 0, len(ids))
+	for _, id := range ids {
+		result = append(result, *reports[id])
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		if request.OrderBy == "revenue" {
+			return result[i].Revenue > result[j].Revenue
+		}
+		return result[i].Quantity > result[j].Quantity
+	})
+
+	if request.Limit >= 0 && len(result) > request.Limit {
+		result = result[:request.Limit]
+	}
+
+	return result, nil
+}
+
+func (r reportRepo) StaffSales(ctx context.Context, request models.ReportRequest) ([]models.StaffSalesReport, error) {
+	defer r.db.lock()()
+
+	sales, err := r.sales(request)
+	if err != nil {
+		return nil, err
+	}
+
+	type key struct{ staffID, role string }
+
+	var (
+		keys    = []key{}
+		reports = map[key]*models.StaffSalesReport{}
+	)
+
+	add := func(staffID, role string, price float32) {
+		if staffID == "" {
+			return
+		}
+
+		k := key{staffID: staffID, role: role}
+		report, ok := reports[k]
+		if !ok {
+			report = &models.StaffSalesReport{StaffID: staffID, Role: role}
+			if staff, ok := r.db.staffs.rows[staffID]; ok {
+				report.Name = staff.value.Name
+			}
+
+			keys = append(keys, k)
+			reports[k] = report
+		}
+
+		report.SalesCount++
+		report.Total += float64(price)
+	}
+
+	for _, sale := range sales {
+		add(sale.CashierID, "cashier", sale.Price)
+		add(sale.ShopAssistantID, "shop_assistant", sale.Price)
+	}
+
+	result := make([]models.StaffSalesReport, 0, len(keys))
+	for _, k := range keys {
+		result = append(result, *reports[k])
+	}
+
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Total > result[j].Total })
+
+	return result, nil
+}
+
+func (r reportRepo) PaymentTypes(ctx context.Context, request models.ReportRequest) ([]models.PaymentTypeReport, error) {
+	defer r.db.lock()()
+
+	sales, err := r.sales(request)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		types   = []string{}
+		reports = map[string]*models.PaymentTypeReport{}
+	)
+
+	for _, sale := range sales {
+		report, ok := reports[sale.PaymentType]
+		if !ok {
+			report = &models.PaymentTypeReport{PaymentType: sale.PaymentType}
+			types = append(types, sale.PaymentType)
+			reports[sale.PaymentType] = report
+		}
+
+		report.SalesCount++
+		report.Total += float64(sale.Price)
+	}
+
+	sort.Strings(types)
+
+	result := make([]models.PaymentTypeReport, 0, len(types))
+	for _, paymentType := range types {
+		result = append(result, *reports[paymentType])
+	}
+
+	return result, nil
+}
+
+// StaffStats counts finished sales where staff was the cashier or the shop assistant,
+// a sale where staff was both is counted once in revenue.
+func (r reportRepo) StaffStats(ctx context.Context, staffID string, request models.ReportRequest) (models.StaffStats, error) {
+	defer r.db.lock()()
+
+	from, to, err := period(request.From, request.To)
+	if err != nil {
+		return models.StaffStats{}, err
+	}
+
+	var (
+		stats    = models.StaffStats{StaffID: staffID, From: request.From, To: request.To}
+		finished int
+		items    int
+	)
+
+	sales := r.db.sales.list(func(sale models.Sale) bool {
+		return (sale.CashierID == staffID || sale.ShopAssistantID == staffID) &&
+			!sale.CreatedAt.Before(from) && sale.CreatedAt.Before(to)
+	})
+
+	for _, sale := range sales {
+		switch sale.Status {
+		case "success":
+			if sale.ShopAssistantID == staffID {
+				stats.SalesAsAssistant++
+			}
+			if sale.CashierID == staffID {
+				stats.SalesAsCashier++
+			}
+
+			stats.Revenue += float64(sale.Price)
+			finished++
+
+			for _, basket := range r.db.baskets.list(func(basket models.Basket) bool { return basket.SaleID == sale.ID }) {
+				items += basket.Quantity
+			}
+		case "cancel":
+			stats.CancelledSales++
+			finished++
+		}
+	}
+
+	for _, trans := range r.db.transactions.list(func(trans models.Transaction) bool {
+		return trans.StaffID == staffID && trans.SourceType == "sales" && trans.TransactionType == "topup" &&
+			!trans.CreatedAt.Before(from) && trans.CreatedAt.Before(to)
+	}) {
+		stats.Commissions += trans.Amount
+	}
+
+	if finished > 0 {
+		stats.CancellationRate = float64(stats.CancelledSales) / float64(finished)
+	}
+
+	if successful := finished - stats.CancelledSales; successful > 0 {
+		stats.AverageBasketSize = float64(items) / float64(successful)
+		stats.AverageSaleRevenue = stats.Revenue / float64(successful)
+	}
+
+	return stats, nil
+}
+
+// sales returns successful sales of the report period, optionally of one branch, oldest first.
+func (r reportRepo) sales(request models.ReportRequest) ([]models.Sale, error) {
+	from, to, err := period(request.From, request.To)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.db.sales.list(func(sale models.Sale) bool {
+		return sale.Status == "success" && sale.BranchID != "" &&
+			!sale.CreatedAt.Before(from) && sale.CreatedAt.Before(to) &&
+			(request.BranchID == "" || sale.BranchID == request.BranchID)
+	}), nil
+}
+
+// truncate returns the first day of the day, week (starting on monday) or month of t.
+func truncate(t time.Time, group string) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+
+	switch group {
+	case "week":
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	case "month":
+		return day.AddDate(0, 0, 1-day.Day())
+	}
+
+	return day
+}
@@ -0,0 +1,168 @@
+package memory
+
+import (
+	"context"
+	"market/api/models"
+	"market/pkg/errs"
+	"market/pkg/logger"
+	"market/storage"
+
+	"github.com/google/uuid"
+)
+
+type repositoryRepo struct {
+	db  *data
+	log logger.ILogger
+}
+
+func newRepositoryRepo(db *data, log logger.ILogger) storage.IRepositoryRepo {
+	return repositoryRepo{
+		db:  db,
+		log: log,
+	}
+}
+
+func (r repositoryRepo) Create(ctx context.Context, repository models.CreateRepository) (string, error) {
+	defer r.db.lock()()
+
+	if err := r.check(&repository.ProductID, &repository.BranchID); err != nil {
+		return "", err
+	}
+
+	id := uuid.New().String()
+	r.db.repositories.insert(id, models.Repository{
+		ID:        id,
+		ProductID: repository.ProductID,
+		BranchID:  repository.BranchID,
+		Count:     repository.Count,
+		Version:   1,
+		CreatedAt: now(),
+	})
+
+	return id, nil
+}
+
+func (r repositoryRepo) GetByID(ctx context.Context, key models.PrimaryKey) (models.Repository, error) {
+	defer r.db.lock()()
+
+	row, err := r.db.repositories.get(key.ID)
+	if err != nil {
+		return models.Repository{}, err
+	}
+
+	return row.value, nil
+}
+
+// ForUpdate does not lock anything, transactions of the memory store already run one at a time.
+func (r repositoryRepo) ForUpdate(ctx context.Context, branchID, productID string) (models.Repository, error) {
+	defer r.db.lock()()
+
+	repositories := r.db.repositories.list(func(repository models.Repository) bool {
+		return repository.BranchID == branchID && repository.ProductID == productID
+	})
+	if len(repositories) == 0 {
+		return models.Repository{}, errs.NotFound("record not found")
+	}
+
+	return repositories[0], nil
+}
+
+// ProductByID returns the count of the product in the first repository which has it.
+func (r repositoryRepo) ProductByID(ctx context.Context, id string) (int, error) {
+	defer r.db.lock()()
+
+	repositories := r.db.repositories.list(func(repository models.Repository) bool {
+		return repository.ProductID == id
+	})
+	if len(repositories) == 0 {
+		return 0, errs.NotFound("record not found")
+	}
+
+	return repositories[0].Count, nil
+}
+
+func (r repositoryRepo) GetList(ctx context.Context, request models.GetListRequest) (models.RepositoriesResponse, error) {
+	defer r.db.lock()()
+
+	repositories := r.db.repositories.listIncluding(func(repository models.Repository) bool {
+		return request.Search == "" || repository.ProductID == request.Search
+	}, request.IncludeDeleted)
+
+	return models.RepositoriesResponse{
+		Repositories: pageAfter(repositories, request.After, request.Page, request.Limit, func(repository models.Repository) models.Cursor {
+			return models.Cursor{CreatedAt: repository.CreatedAt, ID: repository.ID}
+		}),
+		Count: len(repositories),
+	}, nil
+}
+
+func (r repositoryRepo) Update(ctx context.Context, repository models.UpdateRepository) (string, error) {
+	return r.Patch(ctx, models.PatchRepository{
+		ID:        repository.ID,
+		ProductID: &repository.ProductID,
+		BranchID:  &repository.BranchID,
+		Count:     &repository.Count,
+		Version:   repository.Version,
+	})
+}
+
+func (r repositoryRepo) Patch(ctx context.Context, repository models.PatchRepository) (string, error) {
+	defer r.db.lock()()
+
+	row, err := r.db.repositories.get(repository.ID)
+	if err != nil {
+		return "", err
+	}
+
+	if err = checkVersion(row.value.Version, repository.Version); err != nil {
+		return "", err
+	}
+
+	if err = r.check(repository.ProductID, repository.BranchID); err != nil {
+		return "", err
+	}
+
+	set(&row.value.ProductID, repository.ProductID)
+	set(&row.value.BranchID, repository.BranchID)
+	set(&row.value.Count, repository.Count)
+	row.value.Version++
+	row.value.UpdatedAt = now()
+
+	return repository.ID, nil
+}
+
+func (r repositoryRepo) Delete(ctx context.Context, id string) error {
+	defer r.db.lock()()
+
+	r.db.repositories.delete(id)
+
+	return nil
+}
+
+func (r repositoryRepo) Restore(ctx context.Context, id string) error {
+	defer r.db.lock()()
+
+	row, err := r.db.repositories.restore(id)
+	if err != nil {
+		return err
+	}
+
+	row.value.Version++
+	row.value.UpdatedAt = now()
+
+	return nil
+}
+
+func (r repositoryRepo) check(productID, branchID *string) error {
+	if productID != nil {
+		if err := foreignKey(r.db.products, "product_id", *productID); err != nil {
+			return err
+		}
+	}
+
+	if branchID != nil {
+		return foreignKey(r.db.branches, "branch_id", *branchID)
+	}
+
+	return nil
+}
@@ -0,0 +1,148 @@
+package memory
+
+import (
+	"context"
+	"market/api/models"
+	"market/pkg/logger"
+	"market/storage"
+	"strconv"
+
+	"github.com/google/uuid"
+)
+
+type repositoryTransactionRepo struct {
+	db  *data
+	log logger.ILogger
+}
+
+func newRepositoryTransactionRepo(db *data, log logger.ILogger) storage.IRepositoryTransactionRepo {
+	return repositoryTransactionRepo{
+		db:  db,
+		log: log,
+	}
+}
+
+func (r repositoryTransactionRepo) Create(ctx context.Context, rtransaction models.CreateRepositoryTransaction) (string, error) {
+	defer r.db.lock()()
+
+	if err := r.check(&rtransaction.StaffID, &rtransaction.ProductID); err != nil {
+		return "", err
+	}
+
+	id := uuid.New().String()
+	r.db.repositoryTransactions.insert(id, models.RepositoryTransaction{
+		ID:                        id,
+		StaffID:                   rtransaction.StaffID,
+		ProductID:                 rtransaction.ProductID,
+		RepositoryTransactionType: rtransaction.RepositoryTransactionType,
+		Price:                     rtransaction.Price,
+		Quantity:                  rtransaction.Quantity,
+		Version:                   1,
+		CreatedAt:                 now(),
+	})
+
+	return id, nil
+}
+
+func (r repositoryTransactionRepo) GetByID(ctx context.Context, key models.PrimaryKey) (models.RepositoryTransaction, error) {
+	defer r.db.lock()()
+
+	row, err := r.db.repositoryTransactions.get(key.ID)
+	if err != nil {
+		return models.RepositoryTransaction{}, err
+	}
+
+	return row.value, nil
+}
+
+// GetList searches repository transactions by quantity or price.
+func (r repositoryTransactionRepo) GetList(ctx context.Context, request models.GetListRequest) (models.RepositoryTransactionsResponse, error) {
+	defer r.db.lock()()
+
+	rtransactions := r.db.repositoryTransactions.listIncluding(func(rtransaction models.RepositoryTransaction) bool {
+		return contains(strconv.Itoa(rtransaction.Quantity), request.Search) ||
+			contains(strconv.Itoa(rtransaction.Price), request.Search)
+	}, request.IncludeDeleted)
+
+	return models.RepositoryTransactionsResponse{
+		RepositoryTransactions: pageAfter(rtransactions, request.After, request.Page, request.Limit, func(transaction models.RepositoryTransaction) models.Cursor {
+			return models.Cursor{CreatedAt: transaction.CreatedAt, ID: transaction.ID}
+		}),
+		Count: len(rtransactions),
+	}, nil
+}
+
+func (r repositoryTransactionRepo) Update(ctx context.Context, rtransaction models.UpdateRepositoryTransaction) (string, error) {
+	return r.Patch(ctx, models.PatchRepositoryTransaction{
+		ID:                        rtransaction.ID,
+		StaffID:                   &rtransaction.StaffID,
+		ProductID:                 &rtransaction.ProductID,
+		RepositoryTransactionType: &rtransaction.RepositoryTransactionType,
+		Price:                     &rtransaction.Price,
+		Quantity:                  &rtransaction.Quantity,
+		Version:                   rtransaction.Version,
+	})
+}
+
+func (r repositoryTransactionRepo) Patch(ctx context.Context, rtransaction models.PatchRepositoryTransaction) (string, error) {
+	defer r.db.lock()()
+
+	row, err := r.db.repositoryTransactions.get(rtransaction.ID)
+	if err != nil {
+		return "", err
+	}
+
+	if err = checkVersion(row.value.Version, rtransaction.Version); err != nil {
+		return "", err
+	}
+
+	if err = r.check(rtransaction.StaffID, rtransaction.ProductID); err != nil {
+		return "", err
+	}
+
+	set(&row.value.StaffID, rtransaction.StaffID)
+	set(&row.value.ProductID, rtransaction.ProductID)
+	set(&row.value.RepositoryTransactionType, rtransaction.RepositoryTransactionType)
+	set(&row.value.Price, rtransaction.Price)
+	set(&row.value.Quantity, rtransaction.Quantity)
+	row.value.Version++
+	row.value.UpdatedAt = now()
+
+	return rtransaction.ID, nil
+}
+
+func (r repositoryTransactionRepo) Delete(ctx context.Context, id string) error {
+	defer r.db.lock()()
+
+	r.db.repositoryTransactions.delete(id)
+
+	return nil
+}
+
+func (r repositoryTransactionRepo) Restore(ctx context.Context, id string) error {
+	defer r.db.lock()()
+
+	row, err := r.db.repositoryTransactions.restore(id)
+	if err != nil {
+		return err
+	}
+
+	row.value.Version++
+	row.value.UpdatedAt = now()
+
+	return nil
+}
+
+func (r repositoryTransactionRepo) check(staffID, productID *string) error {
+	if staffID != nil {
+		if err := foreignKey(r.db.staffs, "staff_id", *staffID); err != nil {
+			return err
+		}
+	}
+
+	if productID != nil {
+		return foreignKey(r.db.products, "product_id", *productID)
+	}
+
+	return nil
+}
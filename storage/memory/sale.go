@@ -0,0 +1,203 @@
+package memory
+
+import (
+	"context"
+	"market/api/models"
+	"market/pkg/logger"
+	"market/storage"
+
+	"github.com/google/uuid"
+)
+
+type saleRepo struct {
+	db  *data
+	log logger.ILogger
+}
+
+func newSaleRepo(db *data, log logger.ILogger) storage.ISaleStorage {
+	return saleRepo{
+		db:  db,
+		log: log,
+	}
+}
+
+func (s saleRepo) Create(ctx context.Context, sale models.CreateSale) (string, error) {
+	defer s.db.lock()()
+
+	if err := foreignKey(s.db.branches, "branch_id", sale.BranchID); err != nil {
+		return "", err
+	}
+
+	if err := foreignKey(s.db.customers, "customer_id", sale.CustomerID); err != nil {
+		return "", err
+	}
+
+	if err := foreignKey(s.db.shifts, "shift_id", sale.ShiftID); err != nil {
+		return "", err
+	}
+
+	id := uuid.New().String()
+	s.db.sales.insert(id, models.Sale{
+		ID:              id,
+		BranchID:        sale.BranchID,
+		ShopAssistantID: sale.ShopAssistantID,
+		CashierID:       sale.CashierID,
+		Status:          "in_process",
+		ClientName:      sale.ClientName,
+		CustomerID:      sale.CustomerID,
+		ShiftID:         sale.ShiftID,
+		Version:         1,
+		CreatedAt:       now(),
+	})
+
+	return id, nil
+}
+
+func (s saleRepo) GetByID(ctx context.Context, id string) (models.Sale, error) {
+	defer s.db.lock()()
+
+	row, err := s.db.sales.get(id)
+	if err != nil {
+		return models.Sale{}, err
+	}
+
+	return row.value, nil
+}
+
+func (s saleRepo) GetList(ctx context.Context, request models.GetListRequest) (models.SaleResponse, error) {
+	defer s.db.lock()()
+
+	sales := s.db.sales.listIncluding(func(sale models.Sale) bool {
+		return contains(sale.ClientName, request.Search)
+	}, request.IncludeDeleted)
+
+	return models.SaleResponse{
+		Sales: pageAfter(sales, request.After, request.Page, request.Limit, func(sale models.Sale) models.Cursor {
+			return models.Cursor{CreatedAt: sale.CreatedAt, ID: sale.ID}
+		}),
+		Count: len(sales),
+	}, nil
+}
+
+func (s saleRepo) Update(ctx context.Context, sale models.UpdateSale) (string, error) {
+	return s.Patch(ctx, models.PatchSale{
+		ID:              sale.ID,
+		ShopAssistantID: &sale.ShopAssistantID,
+		CashierID:       &sale.CashierID,
+		PaymentType:     &sale.PaymentType,
+		Price:           &sale.Price,
+		Status:          &sale.Status,
+		ShiftID:         &sale.ShiftID,
+		Version:         sale.Version,
+	})
+}
+
+func (s saleRepo) Patch(ctx context.Context, sale models.PatchSale) (string, error) {
+	defer s.db.lock()()
+
+	row, err := s.db.sales.get(sale.ID)
+	if err != nil {
+		return "", err
+	}
+
+	if err = checkVersion(row.value.Version, sale.Version); err != nil {
+		return "", err
+	}
+
+	if sale.CustomerID != nil {
+		if err = foreignKey(s.db.customers, "customer_id", *sale.CustomerID); err != nil {
+			return "", err
+		}
+	}
+
+	if sale.ShiftID != nil {
+		if err = foreignKey(s.db.shifts, "shift_id", *sale.ShiftID); err != nil {
+			return "", err
+		}
+	}
+
+	set(&row.value.ShopAssistantID, sale.ShopAssistantID)
+	set(&row.value.CashierID, sale.CashierID)
+	set(&row.value.PaymentType, sale.PaymentType)
+	set(&row.value.ClientName, sale.ClientName)
+	set(&row.value.CustomerID, sale.CustomerID)
+	set(&row.value.Price, sale.Price)
+	set(&row.value.Status, sale.Status)
+	set(&row.value.ShiftID, sale.ShiftID)
+	row.value.Version++
+	row.value.UpdatedAt = now()
+
+	return sale.ID, nil
+}
+
+func (s saleRepo) Delete(ctx context.Context, id string) error {
+	defer s.db.lock()()
+
+	s.db.sales.delete(id)
+
+	return nil
+}
+
+func (s saleRepo) Restore(ctx context.Context, id string) error {
+	defer s.db.lock()()
+
+	row, err := s.db.sales.restore(id)
+	if err != nil {
+		return err
+	}
+
+	row.value.Version++
+	row.value.UpdatedAt = now()
+
+	return nil
+}
+
+func (s saleRepo) GetReceipt(ctx context.Context, id string) (models.Receipt, error) {
+	defer s.db.lock()()
+
+	row, err := s.db.sales.get(id)
+	if err != nil {
+		return models.Receipt{}, err
+	}
+	sale := row.value
+
+	receipt := models.Receipt{
+		SaleID:      sale.ID,
+		ClientName:  sale.ClientName,
+		PaymentType: sale.PaymentType,
+		Status:      sale.Status,
+		Total:       float64(sale.Price),
+		CreatedAt:   sale.CreatedAt,
+		Lines:       []models.ReceiptLine{},
+	}
+
+	if branch, ok := s.db.branches.rows[sale.BranchID]; ok {
+		receipt.BranchName = branch.value.Name
+		receipt.BranchAddress = branch.value.Address
+	}
+
+	if cashier, ok := s.db.staffs.rows[sale.CashierID]; ok {
+		receipt.CashierName = cashier.value.Name
+	}
+
+	for _, basket := range s.db.baskets.list(func(basket models.Basket) bool { return basket.SaleID == id }) {
+		product, ok := s.db.products.rows[basket.ProductID]
+		if !ok {
+			continue
+		}
+
+		line := models.ReceiptLine{
+			ProductName: product.value.Name,
+			Quantity:    basket.Quantity,
+			Price:       basket.Price,
+		}
+
+		if basket.Quantity != 0 {
+			line.UnitPrice = basket.Price / basket.Quantity
+		}
+
+		receipt.Lines = append(receipt.Lines, line)
+	}
+
+	return receipt, nil
+}
@@ -0,0 +1,175 @@
+package memory
+
+import (
+	"context"
+	"market/api/models"
+	"market/pkg/errs"
+	"market/pkg/logger"
+	"market/storage"
+	"sort"
+
+	"github.com/google/uuid"
+)
+
+type shiftRepo struct {
+	db  *data
+	log logger.ILogger
+}
+
+func newShiftRepo(db *data, log logger.ILogger) storage.IShiftStorage {
+	return shiftRepo{
+		db:  db,
+		log: log,
+	}
+}
+
+func (s shiftRepo) Open(ctx context.Context, shift models.OpenShift) (string, error) {
+	defer s.db.lock()()
+
+	if err := foreignKey(s.db.branches, "branch_id", shift.BranchID); err != nil {
+		return "", err
+	}
+
+	if err := foreignKey(s.db.staffs, "cashier_id", shift.CashierID); err != nil {
+		return "", err
+	}
+
+	if len(s.db.shifts.list(func(open models.Shift) bool {
+		return open.CashierID == shift.CashierID && open.Status == "open"
+	})) > 0 {
+		return "", errs.Conflict("Key (cashier_id)=(%s) already exists.", shift.CashierID)
+	}
+
+	id := uuid.New().String()
+	openedAt := now()
+	s.db.shifts.insert(id, models.Shift{
+		ID:          id,
+		BranchID:    shift.BranchID,
+		CashierID:   shift.CashierID,
+		Status:      "open",
+		OpeningCash: shift.OpeningCash,
+		OpenedAt:    openedAt,
+		CreatedAt:   openedAt,
+	})
+
+	return id, nil
+}
+
+func (s shiftRepo) GetByID(ctx context.Context, id string) (models.Shift, error) {
+	defer s.db.lock()()
+
+	row, err := s.db.shifts.get(id)
+	if err != nil {
+		return models.Shift{}, err
+	}
+
+	return row.value, nil
+}
+
+func (s shiftRepo) GetOpenByCashier(ctx context.Context, cashierID string) (models.Shift, error) {
+	defer s.db.lock()()
+
+	shifts := s.db.shifts.list(func(shift models.Shift) bool {
+		return shift.CashierID == cashierID && shift.Status == "open"
+	})
+	if len(shifts) == 0 {
+		return models.Shift{}, errs.NotFound("record not found")
+	}
+
+	return shifts[0], nil
+}
+
+// ForUpdate does not lock anything, transactions of the memory store already run one at a time.
+func (s shiftRepo) ForUpdate(ctx context.Context, id string) (models.Shift, error) {
+	return s.GetByID(ctx, id)
+}
+
+// OpenByCashierForShare does not lock anything either.
+func (s shiftRepo) OpenByCashierForShare(ctx context.Context, cashierID string) (models.Shift, error) {
+	return s.GetOpenByCashier(ctx, cashierID)
+}
+
+// GetList returns shifts, optionally of one cashier, latest opened first.
+func (s shiftRepo) GetList(ctx context.Context, request models.GetListRequest) (models.ShiftsResponse, error) {
+	defer s.db.lock()()
+
+	shifts := s.db.shifts.list(func(shift models.Shift) bool {
+		return request.Search == "" || shift.CashierID == request.Search
+	})
+
+	sort.SliceStable(shifts, func(i, j int) bool {
+		return shifts[i].OpenedAt.After(shifts[j].OpenedAt)
+	})
+
+	return models.ShiftsResponse{
+		Shifts: pageAfter(shifts, request.After, request.Page, request.Limit, func(shift models.Shift) models.Cursor {
+			return models.Cursor{CreatedAt: shift.CreatedAt, ID: shift.ID}
+		}),
+		Count: len(shifts),
+	}, nil
+}
+
+func (s shiftRepo) Close(ctx context.Context, shift models.CloseShift) (string, error) {
+	defer s.db.lock()()
+
+	row, err := s.db.shifts.get(shift.ID)
+	if err != nil {
+		return "", err
+	}
+
+	if row.value.Status != "open" {
+		return "", errs.Conflict("shift %s is not open", shift.ID)
+	}
+
+	row.value.Status = "closed"
+	row.value.ExpectedCash = shift.ExpectedCash
+	row.value.CountedCash = shift.CountedCash
+	row.value.ClosedAt = now()
+	row.value.UpdatedAt = row.value.ClosedAt
+
+	return shift.ID, nil
+}
+
+// ZReport sums up the sales made in the shift.
+func (s shiftRepo) ZReport(ctx context.Context, shift models.Shift) (models.ZReport, error) {
+	defer s.db.lock()()
+
+	report := models.ZReport{
+		ShiftID:     shift.ID,
+		BranchID:    shift.BranchID,
+		CashierID:   shift.CashierID,
+		OpenedAt:    shift.OpenedAt,
+		ClosedAt:    shift.ClosedAt,
+		OpeningCash: shift.OpeningCash,
+		CountedCash: shift.CountedCash,
+	}
+
+	sales := s.db.sales.list(func(sale models.Sale) bool {
+		return sale.ShiftID == shift.ID
+	})
+
+	for _, sale := range sales {
+		price := float64(sale.Price)
+
+		switch {
+		case sale.Status == "success":
+			report.SalesCount++
+			if sale.PaymentType == "cash" {
+				report.CashTotal += price
+			} else if sale.PaymentType == "card" {
+				report.CardTotal += price
+			}
+		case sale.Status == "cancel":
+			report.CancelledCount++
+			report.CancelledTotal += price
+		}
+	}
+
+	report.Total = report.CashTotal + report.CardTotal
+	report.ExpectedCash = report.OpeningCash + report.CashTotal
+	if shift.Status == "closed" {
+		report.Difference = report.CountedCash - report.ExpectedCash
+	}
+
+	return report, nil
+}
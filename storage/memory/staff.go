@@ -0,0 +1,260 @@
+package memory
+
+import (
+	"context"
+	"market/api/models"
+	"market/pkg/errs"
+	"market/pkg/logger"
+	"market/storage"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type staffRepo struct {
+	db  *data
+	log logger.ILogger
+}
+
+func newStaffRepo(db *data, log logger.ILogger) storage.IStaffRepo {
+	return staffRepo{
+		db:  db,
+		log: log,
+	}
+}
+
+func (s staffRepo) Create(ctx context.Context, staff models.CreateStaff) (string, error) {
+	defer s.db.lock()()
+
+	birthDate, err := parseDate(staff.BirthDate)
+	if err != nil {
+		return "", err
+	}
+
+	if err = s.check("", &staff.BranchID, &staff.TariffID, &staff.Login); err != nil {
+		return "", err
+	}
+
+	id := uuid.New().String()
+	s.db.staffs.insert(id, models.Staff{
+		ID:        id,
+		BranchID:  staff.BranchID,
+		TariffID:  staff.TariffID,
+		StaffType: staff.StaffType,
+		Name:      staff.Name,
+		Balance:   staff.Balance,
+		Age:       age(birthDate),
+		BirthDate: birthDate,
+		Login:     staff.Login,
+		Password:  staff.Password,
+		Version:   1,
+		CreatedAt: now(),
+	})
+
+	return id, nil
+}
+
+func (s staffRepo) StaffByID(ctx context.Context, key models.PrimaryKey) (models.Staff, error) {
+	defer s.db.lock()()
+
+	row, err := s.db.staffs.get(key.ID)
+	if err != nil {
+		return models.Staff{}, err
+	}
+
+	staff := row.value
+	staff.Password = ""
+
+	return staff, nil
+}
+
+func (s staffRepo) GetStaffTList(ctx context.Context, request models.GetListRequest) (models.StaffsResponse, error) {
+	defer s.db.lock()()
+
+	staffs := s.db.staffs.listIncluding(func(staff models.Staff) bool {
+		return contains(staff.Name, request.Search) || contains(staff.Login, request.Search)
+	}, request.IncludeDeleted)
+
+	for i := range staffs {
+		staffs[i].Password = ""
+	}
+
+	return models.StaffsResponse{
+		Staffs: pageAfter(staffs, request.After, request.Page, request.Limit, func(staff models.Staff) models.Cursor {
+			return models.Cursor{CreatedAt: staff.CreatedAt, ID: staff.ID}
+		}),
+		Count: len(staffs),
+	}, nil
+}
+
+func (s staffRepo) UpdateStaff(ctx context.Context, staff models.UpdateStaff) (string, error) {
+	return s.PatchStaff(ctx, models.PatchStaff{
+		ID:        staff.ID,
+		BranchID:  &staff.BranchID,
+		TariffID:  &staff.TariffID,
+		StaffType: &staff.StaffType,
+		Name:      &staff.Name,
+		Balance:   &staff.Balance,
+		Login:     &staff.Login,
+		Version:   staff.Version,
+	})
+}
+
+func (s staffRepo) PatchStaff(ctx context.Context, staff models.PatchStaff) (string, error) {
+	defer s.db.lock()()
+
+	row, err := s.db.staffs.get(staff.ID)
+	if err != nil {
+		return "", err
+	}
+
+	if err = checkVersion(row.value.Version, staff.Version); err != nil {
+		return "", err
+	}
+
+	if err = s.check(staff.ID, staff.BranchID, staff.TariffID, staff.Login); err != nil {
+		return "", err
+	}
+
+	if staff.BirthDate != nil {
+		birthDate, err := parseDate(*staff.BirthDate)
+		if err != nil {
+			return "", err
+		}
+		row.value.BirthDate = birthDate
+	}
+
+	set(&row.value.BranchID, staff.BranchID)
+	set(&row.value.TariffID, staff.TariffID)
+	set(&row.value.StaffType, staff.StaffType)
+	set(&row.value.Name, staff.Name)
+	set(&row.value.Login, staff.Login)
+	set(&row.value.Balance, staff.Balance)
+	row.value.Version++
+	row.value.UpdatedAt = now()
+
+	return staff.ID, nil
+}
+
+func (s staffRepo) DeleteStaff(ctx context.Context, id string) error {
+	defer s.db.lock()()
+
+	s.db.staffs.delete(id)
+
+	return nil
+}
+
+func (s staffRepo) RestoreStaff(ctx context.Context, id string) error {
+	defer s.db.lock()()
+
+	row, err := s.db.staffs.restore(id)
+	if err != nil {
+		return err
+	}
+
+	row.value.Version++
+	row.value.UpdatedAt = now()
+
+	return nil
+}
+
+func (s staffRepo) GetPassword(ctx context.Context, id string) (string, error) {
+	defer s.db.lock()()
+
+	row, ok := s.db.staffs.rows[id]
+	if !ok {
+		return "", errs.NotFound("record not found")
+	}
+
+	return row.value.Password, nil
+}
+
+func (s staffRepo) UpdatePassword(ctx context.Context, request models.UpdateStaffPassword) error {
+	defer s.db.lock()()
+
+	if row, ok := s.db.staffs.rows[request.ID]; ok {
+		row.value.Password = request.NewPassword
+		row.value.Version++
+	}
+
+	return nil
+}
+
+// AdjustBalance changes staff balance by a bonus or penalty and records it
+// in transactions. Withdrawals which would make the balance negative are rejected.
+func (s staffRepo) AdjustBalance(ctx context.Context, request models.StaffBalanceAdjustment) (string, error) {
+	defer s.db.lock()()
+
+	amount := int(request.Amount)
+	if request.TransactionType == "withdraw" {
+		amount = -amount
+	}
+
+	row, err := s.db.staffs.get(request.StaffID)
+	if err != nil || int(row.value.Balance)+amount < 0 {
+		return "", errs.Validation("staff not found or balance is not enough")
+	}
+
+	row.value.Balance = uint(int(row.value.Balance) + amount)
+	row.value.Version++
+	row.value.UpdatedAt = now()
+
+	id := uuid.New().String()
+	s.db.transactions.insert(id, models.Transaction{
+		ID:              id,
+		StaffID:         request.StaffID,
+		TransactionType: request.TransactionType,
+		SourceType:      "bonus",
+		Amount:          request.Amount,
+		Description:     request.Description,
+		Version:         1,
+		CreatedAt:       now(),
+	})
+
+	return id, nil
+}
+
+// check validates unique login and references of the staff, nil values are not changed.
+func (s staffRepo) check(id string, branchID, tariffID, login *string) error {
+	if branchID != nil {
+		if err := foreignKey(s.db.branches, "branch_id", *branchID); err != nil {
+			return err
+		}
+	}
+
+	if tariffID != nil {
+		if err := foreignKey(s.db.staffTarifs, "tariff_id", *tariffID); err != nil {
+			return err
+		}
+	}
+
+	if login != nil && s.db.staffs.exists(func(staff models.Staff) bool {
+		return staff.ID != id && staff.Login == *login
+	}) {
+		return errs.Conflict("Key (login)=(%s) already exists.", *login)
+	}
+
+	return nil
+}
+
+// parseDate parses date column value, empty value is NULL.
+func parseDate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, errs.Validation("invalid input syntax for type date: %q", value)
+	}
+
+	return date, nil
+}
+
+func age(birthDate time.Time) uint {
+	if birthDate.IsZero() {
+		return 0
+	}
+
+	return uint(time.Since(birthDate).Hours() / 24 / 365)
+}
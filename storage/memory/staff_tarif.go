@@ -0,0 +1,136 @@
+package memory
+
+import (
+	"context"
+	"market/api/models"
+	"market/pkg/errs"
+	"market/pkg/logger"
+	"market/storage"
+
+	"github.com/google/uuid"
+)
+
+type staffTarifRepo struct {
+	db  *data
+	log logger.ILogger
+}
+
+func newStaffTarifRepo(db *data, log logger.ILogger) storage.IStaffTariffRepo {
+	return staffTarifRepo{
+		db:  db,
+		log: log,
+	}
+}
+
+func (s staffTarifRepo) Create(ctx context.Context, starif models.CreateStaffTarif) (string, error) {
+	defer s.db.lock()()
+
+	if s.nameTaken("", starif.Name) {
+		return "", errs.Conflict("Key (name)=(%s) already exists.", starif.Name)
+	}
+
+	id := uuid.New().String()
+	s.db.staffTarifs.insert(id, models.StaffTarif{
+		ID:            id,
+		Name:          starif.Name,
+		TarifType:     starif.TarifType,
+		AmountForCash: starif.AmountForCash,
+		AmountForCard: starif.AmountForCard,
+		Version:       1,
+		CreatedAt:     now(),
+	})
+
+	return id, nil
+}
+
+func (s staffTarifRepo) GetStaffTariffByID(ctx context.Context, key models.PrimaryKey) (models.StaffTarif, error) {
+	defer s.db.lock()()
+
+	row, err := s.db.staffTarifs.get(key.ID)
+	if err != nil {
+		return models.StaffTarif{}, err
+	}
+
+	return row.value, nil
+}
+
+func (s staffTarifRepo) GetStaffTariffList(ctx context.Context, request models.GetListRequest) (models.StaffTarifResponse, error) {
+	defer s.db.lock()()
+
+	starifs := s.db.staffTarifs.listIncluding(func(starif models.StaffTarif) bool {
+		return contains(starif.Name, request.Search)
+	}, request.IncludeDeleted)
+
+	return models.StaffTarifResponse{
+		StaffTarifs: pageAfter(starifs, request.After, request.Page, request.Limit, func(tarif models.StaffTarif) models.Cursor {
+			return models.Cursor{CreatedAt: tarif.CreatedAt, ID: tarif.ID}
+		}),
+		Count: len(starifs),
+	}, nil
+}
+
+func (s staffTarifRepo) UpdateStaffTariff(ctx context.Context, starif models.UpdateStaffTarif) (string, error) {
+	return s.PatchStaffTariff(ctx, models.PatchStaffTarif{
+		ID:            starif.ID,
+		Name:          &starif.Name,
+		TarifType:     &starif.TarifType,
+		AmountForCash: &starif.AmountForCash,
+		AmountForCard: &starif.AmountForCard,
+		Version:       starif.Version,
+	})
+}
+
+func (s staffTarifRepo) PatchStaffTariff(ctx context.Context, starif models.PatchStaffTarif) (string, error) {
+	defer s.db.lock()()
+
+	row, err := s.db.staffTarifs.get(starif.ID)
+	if err != nil {
+		return "", err
+	}
+
+	if err = checkVersion(row.value.Version, starif.Version); err != nil {
+		return "", err
+	}
+
+	if starif.Name != nil && s.nameTaken(starif.ID, *starif.Name) {
+		return "", errs.Conflict("Key (name)=(%s) already exists.", *starif.Name)
+	}
+
+	set(&row.value.Name, starif.Name)
+	set(&row.value.TarifType, starif.TarifType)
+	set(&row.value.AmountForCash, starif.AmountForCash)
+	set(&row.value.AmountForCard, starif.AmountForCard)
+	row.value.Version++
+	row.value.UpdatedAt = now()
+
+	return starif.ID, nil
+}
+
+func (s staffTarifRepo) DeleteStaffTariff(ctx context.Context, id string) error {
+	defer s.db.lock()()
+
+	s.db.staffTarifs.delete(id)
+
+	return nil
+}
+
+func (s staffTarifRepo) RestoreStaffTariff(ctx context.Context, id string) error {
+	defer s.db.lock()()
+
+	row, err := s.db.staffTarifs.restore(id)
+	if err != nil {
+		return err
+	}
+
+	row.value.Version++
+	row.value.UpdatedAt = now()
+
+	return nil
+}
+
+// nameTaken checks unique name of staff_tarifs table.
+func (s staffTarifRepo) nameTaken(id, name string) bool {
+	return s.db.staffTarifs.exists(func(starif models.StaffTarif) bool {
+		return starif.ID != id && starif.Name == name
+	})
+}
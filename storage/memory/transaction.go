@@ -0,0 +1,165 @@
+package memory
+
+import (
+	"context"
+	"market/api/models"
+	"market/pkg/logger"
+	"market/storage"
+	"sort"
+
+	"github.com/google/uuid"
+)
+
+type transactionRepo struct {
+	db  *data
+	log logger.ILogger
+}
+
+func newTransactionRepo(db *data, log logger.ILogger) storage.ITransactionStorage {
+	return transactionRepo{
+		db:  db,
+		log: log,
+	}
+}
+
+func (t transactionRepo) Create(ctx context.Context, trans models.CreateTransaction) (string, error) {
+	defer t.db.lock()()
+
+	if err := t.check(&trans.SaleID, &trans.StaffID); err != nil {
+		return "", err
+	}
+
+	id := uuid.New().String()
+	t.db.transactions.insert(id, models.Transaction{
+		ID:              id,
+		SaleID:          trans.SaleID,
+		StaffID:         trans.StaffID,
+		TransactionType: trans.TransactionType,
+		SourceType:      trans.SourceType,
+		Amount:          trans.Amount,
+		Description:     trans.Description,
+		Version:         1,
+		CreatedAt:       now(),
+	})
+
+	return id, nil
+}
+
+func (t transactionRepo) GetByID(ctx context.Context, id string) (models.Transaction, error) {
+	defer t.db.lock()()
+
+	row, err := t.db.transactions.get(id)
+	if err != nil {
+		return models.Transaction{}, err
+	}
+
+	return row.value, nil
+}
+
+// GetList filters transactions by amount the same way postgres repo does: between from and to
+// when both are given, at most from when only from is given and at least to otherwise.
+// Cheaper transactions go first, newer first among the same amount.
+func (t transactionRepo) GetList(ctx context.Context, request models.TransactionGetListRequest) (models.TransactionResponse, error) {
+	defer t.db.lock()()
+
+	fromAmount, toAmount := request.FromAmount, request.ToAmount
+
+	transactions := reversed(t.db.transactions.listIncluding(func(trans models.Transaction) bool {
+		switch {
+		case fromAmount != 0 && toAmount != 0:
+			return trans.Amount >= fromAmount && trans.Amount <= toAmount
+		case fromAmount != 0:
+			return trans.Amount <= fromAmount
+		default:
+			return trans.Amount >= toAmount
+		}
+	}, request.IncludeDeleted))
+
+	sort.SliceStable(transactions, func(i, j int) bool {
+		return transactions[i].Amount < transactions[j].Amount
+	})
+
+	return models.TransactionResponse{
+		Transactions: pageAfter(transactions, request.After, request.Page, request.Limit, func(transaction models.Transaction) models.Cursor {
+			return models.Cursor{CreatedAt: transaction.CreatedAt, ID: transaction.ID}
+		}),
+		Count: len(transactions),
+	}, nil
+}
+
+func (t transactionRepo) Update(ctx context.Context, trans models.UpdateTransaction) (string, error) {
+	return t.Patch(ctx, models.PatchTransaction{
+		ID:              trans.ID,
+		SaleID:          &trans.SaleID,
+		StaffID:         &trans.StaffID,
+		TransactionType: &trans.TransactionType,
+		SourceType:      &trans.SourceType,
+		Amount:          &trans.Amount,
+		Description:     &trans.Description,
+		Version:         trans.Version,
+	})
+}
+
+func (t transactionRepo) Patch(ctx context.Context, trans models.PatchTransaction) (string, error) {
+	defer t.db.lock()()
+
+	row, err := t.db.transactions.get(trans.ID)
+	if err != nil {
+		return "", err
+	}
+
+	if err = checkVersion(row.value.Version, trans.Version); err != nil {
+		return "", err
+	}
+
+	if err = t.check(trans.SaleID, trans.StaffID); err != nil {
+		return "", err
+	}
+
+	set(&row.value.SaleID, trans.SaleID)
+	set(&row.value.StaffID, trans.StaffID)
+	set(&row.value.TransactionType, trans.TransactionType)
+	set(&row.value.SourceType, trans.SourceType)
+	set(&row.value.Amount, trans.Amount)
+	set(&row.value.Description, trans.Description)
+	row.value.Version++
+	row.value.UpdatedAt = now()
+
+	return trans.ID, nil
+}
+
+func (t transactionRepo) Delete(ctx context.Context, id string) error {
+	defer t.db.lock()()
+
+	t.db.transactions.delete(id)
+
+	return nil
+}
+
+func (t transactionRepo) Restore(ctx context.Context, id string) error {
+	defer t.db.lock()()
+
+	row, err := t.db.transactions.restore(id)
+	if err != nil {
+		return err
+	}
+
+	row.value.Version++
+	row.value.UpdatedAt = now()
+
+	return nil
+}
+
+func (t transactionRepo) check(saleID, staffID *string) error {
+	if saleID != nil {
+		if err := foreignKey(t.db.sales, "sale_id", *saleID); err != nil {
+			return err
+		}
+	}
+
+	if staffID != nil {
+		return foreignKey(t.db.staffs, "staff_id", *staffID)
+	}
+
+	return nil
+}
@@ -0,0 +1,226 @@
+package memory
+
+import (
+	"context"
+	"market/api/models"
+	"market/pkg/errs"
+	"market/pkg/logger"
+	"market/storage"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type webhookRepo struct {
+	db  *data
+	log logger.ILogger
+}
+
+func newWebhookRepo(db *data, log logger.ILogger) storage.IWebhookStorage {
+	return webhookRepo{
+		db:  db,
+		log: log,
+	}
+}
+
+func (w webhookRepo) Create(ctx context.Context, webhook models.CreateWebhook) (string, error) {
+	defer w.db.lock()()
+
+	id := uuid.New().String()
+	w.db.webhooks.insert(id, models.Webhook{
+		ID:         id,
+		URL:        webhook.URL,
+		Secret:     webhook.Secret,
+		EventTypes: append([]string{}, webhook.EventTypes...),
+		Active:     true,
+		Version:    1,
+		CreatedAt:  now(),
+	})
+
+	return id, nil
+}
+
+func (w webhookRepo) GetByID(ctx context.Context, id string) (models.Webhook, error) {
+	defer w.db.lock()()
+
+	row, err := w.db.webhooks.get(id)
+	if err != nil {
+		return models.Webhook{}, err
+	}
+
+	return row.value, nil
+}
+
+func (w webhookRepo) GetList(ctx context.Context, request models.GetListRequest) (models.WebhooksResponse, error) {
+	defer w.db.lock()()
+
+	webhooks := w.db.webhooks.listIncluding(func(webhook models.Webhook) bool {
+		return contains(webhook.URL, request.Search)
+	}, request.IncludeDeleted)
+
+	return models.WebhooksResponse{
+		Webhooks: page(webhooks, request.Page, request.Limit),
+		Count:    len(webhooks),
+	}, nil
+}
+
+func (w webhookRepo) Update(ctx context.Context, webhook models.UpdateWebhook) (string, error) {
+	defer w.db.lock()()
+
+	row, err := w.db.webhooks.get(webhook.ID)
+	if err != nil {
+		return "", err
+	}
+
+	if err = checkVersion(row.value.Version, webhook.Version); err != nil {
+		return "", err
+	}
+
+	row.value.URL = webhook.URL
+	row.value.EventTypes = append([]string{}, webhook.EventTypes...)
+	row.value.Active = webhook.Active
+	if webhook.Secret != "" {
+		row.value.Secret = webhook.Secret
+	}
+	row.value.Version++
+	row.value.UpdatedAt = now()
+
+	return webhook.ID, nil
+}
+
+func (w webhookRepo) Delete(ctx context.Context, id string) error {
+	defer w.db.lock()()
+
+	w.db.webhooks.delete(id)
+
+	return nil
+}
+
+func (w webhookRepo) Restore(ctx context.Context, id string) error {
+	defer w.db.lock()()
+
+	row, err := w.db.webhooks.restore(id)
+	if err != nil {
+		return err
+	}
+
+	row.value.Version++
+	row.value.UpdatedAt = now()
+
+	return nil
+}
+
+func (w webhookRepo) Subscribed(ctx context.Context, eventType string) ([]models.Webhook, error) {
+	defer w.db.lock()()
+
+	return w.db.webhooks.list(func(webhook models.Webhook) bool {
+		if !webhook.Active {
+			return false
+		}
+
+		for _, subscribed := range webhook.EventTypes {
+			if subscribed == eventType {
+				return true
+			}
+		}
+
+		return false
+	}), nil
+}
+
+func (w webhookRepo) AddDelivery(ctx context.Context, delivery models.CreateWebhookDelivery) (string, error) {
+	defer w.db.lock()()
+
+	if err := foreignKey(w.db.webhooks, "webhook_id", delivery.WebhookID); err != nil {
+		return "", err
+	}
+
+	if w.db.webhookDeliveries.exists(func(row models.WebhookDelivery) bool {
+		return row.WebhookID == delivery.WebhookID && row.EventID == delivery.EventID
+	}) {
+		return "", nil
+	}
+
+	id := uuid.New().String()
+	createdAt := now()
+	w.db.webhookDeliveries.insert(id, models.WebhookDelivery{
+		ID:            id,
+		WebhookID:     delivery.WebhookID,
+		EventID:       delivery.EventID,
+		EventType:     delivery.EventType,
+		Payload:       delivery.Payload,
+		Status:        models.WebhookPending,
+		NextAttemptAt: createdAt.Add(delivery.Delay),
+		CreatedAt:     createdAt,
+	})
+
+	return id, nil
+}
+
+func (w webhookRepo) DeliveryByID(ctx context.Context, id string) (models.WebhookDelivery, error) {
+	defer w.db.lock()()
+
+	row, err := w.db.webhookDeliveries.get(id)
+	if err != nil {
+		return models.WebhookDelivery{}, err
+	}
+
+	return row.value, nil
+}
+
+func (w webhookRepo) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]models.WebhookDelivery, error) {
+	defer w.db.lock()()
+
+	current := now()
+	due := w.db.webhookDeliveries.list(func(delivery models.WebhookDelivery) bool {
+		return delivery.Status == models.WebhookPending && !delivery.NextAttemptAt.After(current)
+	})
+
+	sort.SliceStable(due, func(i, j int) bool { return due[i].NextAttemptAt.Before(due[j].NextAttemptAt) })
+
+	claimed := page(due, 1, limit)
+	for i := range claimed {
+		claimed[i].NextAttemptAt = current.Add(lease)
+		w.db.webhookDeliveries.rows[claimed[i].ID].value.NextAttemptAt = claimed[i].NextAttemptAt
+	}
+
+	return claimed, nil
+}
+
+func (w webhookRepo) RecordAttempt(ctx context.Context, attempt models.WebhookAttempt) error {
+	defer w.db.lock()()
+
+	row, ok := w.db.webhookDeliveries.rows[attempt.ID]
+	if !ok {
+		return errs.NotFound("record not found")
+	}
+
+	row.value.Attempts++
+	row.value.Status = attempt.Status
+	row.value.ResponseCode = attempt.ResponseCode
+	row.value.LastError = attempt.Error
+	switch attempt.Status {
+	case models.WebhookPending:
+		row.value.NextAttemptAt = now().Add(attempt.Backoff)
+	case models.WebhookDelivered:
+		row.value.DeliveredAt = now()
+	}
+
+	return nil
+}
+
+// Deliveries returns the delivery log newest first.
+func (w webhookRepo) Deliveries(ctx context.Context, request models.WebhookDeliveryListRequest) (models.WebhookDeliveriesResponse, error) {
+	defer w.db.lock()()
+
+	deliveries := reversed(w.db.webhookDeliveries.list(func(delivery models.WebhookDelivery) bool {
+		return (request.WebhookID == "" || delivery.WebhookID == request.WebhookID) &&
+			(request.Status == "" || delivery.Status == request.Status)
+	}))
+
+	return models.WebhookDeliveriesResponse{
+		Deliveries: page(deliveries, request.Page, request.Limit),
+		Count:      len(deliveries),
+	}, nil
+}
@@ -0,0 +1,95 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"market/api/models"
+	"market/pkg/logger"
+	"market/storage"
+
+	"github.com/google/uuid"
+)
+
+type auditRepo struct {
+	db  Querier
+	log logger.ILogger
+}
+
+func NewAuditRepo(db Querier, log logger.ILogger) storage.IAuditStorage {
+	return auditRepo{
+		db:  db,
+		log: log,
+	}
+}
+
+func (a auditRepo) Create(ctx context.Context, record models.CreateAuditLog) error {
+	if _, err := a.db.Exec(ctx, `INSERT INTO audit_logs (id, actor_id, action, entity, entity_id, before, after)
+			VALUES ($1, NULLIF($2, '')::uuid, $3, $4, $5, $6, $7)`,
+		uuid.New().String(),
+		record.ActorID,
+		record.Action,
+		record.Entity,
+		record.EntityID,
+		record.Before,
+		record.After,
+	); err != nil {
+		a.log.Error("error is while inserting audit log", logger.Error(err))
+		return dbError(err)
+	}
+
+	return nil
+}
+
+// GetList returns audit log newest first.
+func (a auditRepo) GetList(ctx context.Context, request models.AuditListRequest) (models.AuditLogsResponse, error) {
+	var (
+		offset    = (request.Page - 1) * request.Limit
+		count     = 0
+		auditLogs = []models.AuditLog{}
+		filter    = `($1 = '' OR entity = $1) AND ($2 = '' OR entity_id::text = $2) AND ($3 = '' OR actor_id::text = $3)`
+	)
+
+	if err := a.db.QueryRow(ctx, `SELECT COUNT(*) FROM audit_logs WHERE `+filter,
+		request.Entity, request.EntityID, request.ActorID).Scan(&count); err != nil {
+		a.log.Error("error is while scanning count of audit logs", logger.Error(err))
+		return models.AuditLogsResponse{}, dbError(err)
+	}
+
+	rows, err := a.db.Query(ctx, `SELECT id, actor_id, action, entity, entity_id, before, after, created_at
+			FROM audit_logs WHERE `+filter+` ORDER BY created_at DESC LIMIT $4 OFFSET $5`,
+		request.Entity, request.EntityID, request.ActorID, request.Limit, offset)
+	if err != nil {
+		a.log.Error("error is while selecting audit logs", logger.Error(err))
+		return models.AuditLogsResponse{}, dbError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			auditLog = models.AuditLog{}
+			actorID  sql.NullString
+		)
+
+		if err = rows.Scan(
+			&auditLog.ID,
+			&actorID,
+			&auditLog.Action,
+			&auditLog.Entity,
+			&auditLog.EntityID,
+			&auditLog.Before,
+			&auditLog.After,
+			&auditLog.CreatedAt,
+		); err != nil {
+			a.log.Error("error is while scanning audit log", logger.Error(err))
+			return models.AuditLogsResponse{}, dbError(err)
+		}
+
+		auditLog.ActorID = actorID.String
+		auditLogs = append(auditLogs, auditLog)
+	}
+
+	return models.AuditLogsResponse{
+		AuditLogs: auditLogs,
+		Count:     count,
+	}, nil
+}
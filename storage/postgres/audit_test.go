@@ -0,0 +1,52 @@
+//go:build integration
+
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"market/api/models"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestAuditRepo(t *testing.T) {
+	ctx := context.Background()
+	repo := testStore.Audit()
+	entityID, actorID := uuid.NewString(), newTestStaff(t, newTestBranch(t), "cashier")
+
+	for _, record := range []models.CreateAuditLog{
+		{ActorID: actorID, Action: models.AuditCreate, Entity: "category", EntityID: entityID, After: json.RawMessage(`{"name": "Choy"}`)},
+		{Action: models.AuditUpdate, Entity: "category", EntityID: entityID,
+			Before: json.RawMessage(`{"name": "Choy"}`), After: json.RawMessage(`{"name": "Qahva"}`)},
+	} {
+		if err := repo.Create(ctx, record); err != nil {
+			t.Fatalf("create %s: %v", record.Action, err)
+		}
+	}
+
+	logs, err := repo.GetList(ctx, models.AuditListRequest{Page: 1, Limit: 10, Entity: "category", EntityID: entityID})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if logs.Count != 2 || len(logs.AuditLogs) != 2 {
+		t.Fatalf("got %d logs, want 2", logs.Count)
+	}
+
+	// newest first, a change without actor is read back with an empty one
+	update, create := logs.AuditLogs[0], logs.AuditLogs[1]
+	if update.Action != models.AuditUpdate || update.ActorID != "" || string(update.Before) != `{"name": "Choy"}` || string(update.After) != `{"name": "Qahva"}` {
+		t.Errorf("update log is %+v, want the rename without actor", update)
+	}
+	if create.Action != models.AuditCreate || create.ActorID != actorID || len(create.Before) != 0 || string(create.After) != `{"name": "Choy"}` {
+		t.Errorf("create log is %+v, want the creation by %s", create, actorID)
+	}
+
+	if logs, err = repo.GetList(ctx, models.AuditListRequest{Page: 1, Limit: 10, ActorID: actorID}); err != nil {
+		t.Fatalf("list by actor: %v", err)
+	}
+	if logs.Count != 1 || logs.AuditLogs[0].Action != models.AuditCreate {
+		t.Errorf("logs of %s are %+v, want only the creation", actorID, logs)
+	}
+}
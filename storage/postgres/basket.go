@@ -10,15 +10,14 @@ import (
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type basketRepo struct {
-	DB *pgxpool.Pool
+	DB Querier
 	log logger.ILogger
 }
 
-func NewBasketRepo(DB *pgxpool.Pool, log logger.ILogger) storage.IBasketRepo {
+func NewBasketRepo(DB Querier, log logger.ILogger) storage.IBasketRepo {
 	return &basketRepo{
 		DB: DB,
 		log: log,
@@ -40,7 +39,7 @@ func (s *basketRepo) Create(ctx context.Context, basket models.CreateBasket) (st
 		createdAT,
 	); err != nil {
 		s.log.Error("Error while inserting data:", logger.Error(err))
-		return "", err
+		return "", dbError(err)
 	}
 
 	return id, nil
@@ -49,7 +48,7 @@ func (s *basketRepo) Create(ctx context.Context, basket models.CreateBasket) (st
 func (s *basketRepo) GetByID(ctx context.Context, id models.PrimaryKey) (models.Basket, error) {
 	var updatedAt, createdAt sql.NullString
 	basket := models.Basket{}
-	query := `SELECT id, sale_id, product_id, quantity, price, created_at, updated_at
+	query := `SELECT id, sale_id, product_id, quantity, price, version, created_at, updated_at
 				FROM baskets WHERE id = $1 AND  deleted_at = 0`
 	err := s.DB.QueryRow(ctx, query, id.ID).Scan(
 		&basket.ID,
@@ -57,12 +56,13 @@ func (s *basketRepo) GetByID(ctx context.Context, id models.PrimaryKey) (models.
 		&basket.ProductID,
 		&basket.Quantity,
 		&basket.Price,
+		&basket.Version,
 		&createdAt,
 		&updatedAt,
 	)
 	if err != nil {
 		s.log.Error("Error while selecting basket by ID:", logger.Error(err))
-		return models.Basket{}, err
+		return models.Basket{}, dbError(err)
 	}
 
 	if createdAt.Valid {
@@ -86,7 +86,7 @@ func (s *basketRepo) GetList(ctx context.Context, request models.GetListRequest)
 		updatedAt, createdAt  sql.NullString
 	)
 
-	countQuery = `SELECT COUNT(*) FROM baskets WHERE deleted_at = 0`
+	countQuery = `SELECT COUNT(*) FROM baskets WHERE ` + deletedFilter(request.IncludeDeleted) + ` `
 	if request.Search != "" {
 		countQuery += fmt.Sprintf(`  AND sale_id = '%s'`, request.Search)
 	}
@@ -94,20 +94,20 @@ func (s *basketRepo) GetList(ctx context.Context, request models.GetListRequest)
 	err := s.DB.QueryRow(ctx, countQuery).Scan(&count)
 	if err != nil {
 		s.log.Error("Error while scanning count of baskets:", logger.Error(err))
-		return models.BasketsResponse{}, err
+		return models.BasketsResponse{}, dbError(err)
 	}
 
-	query = `SELECT id, sale_id, product_id, quantity, price, created_at, updated_at
-						FROM baskets WHERE deleted_at = 0`
+	query = `SELECT id, sale_id, product_id, quantity, price, version, created_at, updated_at, deleted_at
+						FROM baskets WHERE ` + deletedFilter(request.IncludeDeleted) + ` `
 	if request.Search != "" {
 		query += fmt.Sprintf(` AND sale_id = '%s'`, request.Search)
 	}
-	query += ` ORDER BY created_at DESC LIMIT $1 OFFSET $2`
+	query, args := pagingBy(query, nil, "created_at DESC", request.After, request.Limit, offset)
 
-	rows, err := s.DB.Query(ctx, query, request.Limit, offset)
+	rows, err := s.DB.Query(ctx, query, args...)
 	if err != nil {
 		s.log.Error("Error while querying baskets:", logger.Error(err))
-		return models.BasketsResponse{}, err
+		return models.BasketsResponse{}, dbError(err)
 	}
 	defer rows.Close()
 
@@ -119,12 +119,14 @@ func (s *basketRepo) GetList(ctx context.Context, request models.GetListRequest)
 			&basket.ProductID,
 			&basket.Quantity,
 			&basket.Price,
+			&basket.Version,
 			&createdAt,
 			&updatedAt,
+			&basket.DeletedAt,
 		)
 		if err != nil {
 			s.log.Error("Error while scanning row of baskets:", logger.Error(err))
-			return models.BasketsResponse{}, err
+			return models.BasketsResponse{}, dbError(err)
 		}
 
 		if createdAt.Valid {
@@ -145,17 +147,26 @@ func (s *basketRepo) GetList(ctx context.Context, request models.GetListRequest)
 }
 
 func (s *basketRepo) Update(ctx context.Context, basket models.UpdateBasket) (string, error) {
-	query := `UPDATE baskets SET sale_id = $1, product_id = $2, quantity = $3, price = $4, updated_at = NOW() WHERE id = $5 AND deleted_at = 0`
+	return s.Patch(ctx, models.PatchBasket{
+		ID:        basket.ID,
+		SaleID:    &basket.SaleID,
+		ProductID: &basket.ProductID,
+		Quantity:  &basket.Quantity,
+		Price:     &basket.Price,
+		Version:   basket.Version,
+	})
+}
 
-	_, err := s.DB.Exec(ctx, query,
-		&basket.SaleID,
-		&basket.ProductID,
-		&basket.Quantity,
-		&basket.Price,
-		&basket.ID,
-	)
-	if err != nil {
-		s.log.Error("Error while updating Basket :", logger.Error(err))
+// Patch updates only the given fields of baskets.
+func (s *basketRepo) Patch(ctx context.Context, basket models.PatchBasket) (string, error) {
+	fields := patch{}
+	set(&fields, "sale_id", basket.SaleID)
+	set(&fields, "product_id", basket.ProductID)
+	set(&fields, "quantity", basket.Quantity)
+	set(&fields, "price", basket.Price)
+
+	if err := fields.exec(ctx, s.DB, "baskets", basket.ID, basket.Version); err != nil {
+		s.log.Error("Error while patching Basket :", logger.Error(err))
 		return "", err
 	}
 
@@ -167,9 +178,18 @@ func (b *basketRepo) Delete(ctx context.Context, key models.PrimaryKey) error {
 	if rowsAffected, err := b.DB.Exec(ctx, query, key.ID); err != nil {
 		if r := rowsAffected.RowsAffected(); r == 0 {
 			b.log.Error("error is while deleting basket", logger.Error(err))
-			return err
+			return dbError(err)
 		}
+		return dbError(err)
+	}
+	return nil
+}
+
+func (b *basketRepo) Restore(ctx context.Context, id string) error {
+	if err := restore(ctx, b.DB, "baskets", id); err != nil {
+		b.log.Error("error is while restoring basket", logger.Error(err))
 		return err
 	}
+
 	return nil
 }
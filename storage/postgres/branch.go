@@ -9,15 +9,14 @@ import (
 	"market/storage"
 
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type branchRepo struct {
-	db *pgxpool.Pool
+	db Querier
 	log logger.ILogger
 }
 
-func NewBranchRepo(db *pgxpool.Pool, log logger.ILogger) storage.IBranchStorage {
+func NewBranchRepo(db Querier, log logger.ILogger) storage.IBranchStorage {
 	return branchRepo{
 		db: db,
 		log: log,
@@ -34,27 +33,32 @@ func (b branchRepo) Create(ctx context.Context, branch models.CreateBranch) (str
 		branch.Name,
 		branch.Address); err != nil {
 		b.log.Error("error is while inserting data", logger.Error(err))
-		return "", err
+		return "", dbError(err)
 	}
 
 	return id.String(), nil
 }
 
 func (b branchRepo) GetByID(ctx context.Context, id string) (models.Branch, error) {
-	var updatedAt sql.NullTime
+	var (
+		updatedAt sql.NullTime
+		address   sql.NullString
+	)
 	branch := models.Branch{}
-	query := `SELECT id, name, address, created_at, updated_at FROM branches WHERE id = $1 AND deleted_at = 0`
+	query := `SELECT id, name, address, version, created_at, updated_at FROM branches WHERE id = $1 AND deleted_at = 0`
 	if err := b.db.QueryRow(ctx, query, id).Scan(
 		&branch.ID,
 		&branch.Name,
-		&branch.Address,
+		&address,
+		&branch.Version,
 		&branch.CreatedAt,
 		&updatedAt,
 		); err != nil {
 		b.log.Error("error is while selecting by id", logger.Error(err))
-		return models.Branch{}, err
+		return models.Branch{}, dbError(err)
 	}
 
+	branch.Address = address.String
 	if updatedAt.Valid {
 		branch.UpdatedAt = updatedAt.Time
 	}
@@ -71,9 +75,10 @@ func (b branchRepo) GetList(ctx context.Context, request models.GetListRequest)
 		offset            = (page - 1) * request.Limit
 		search            = request.Search
 		updatedAt         sql.NullTime
+		address           sql.NullString
 	)
 
-	countQuery = `SELECT COUNT(1) FROM branches WHERE deleted_at = 0 `
+	countQuery = `SELECT COUNT(1) FROM branches WHERE ` + deletedFilter(request.IncludeDeleted) + ` `
 
 	if search != "" {
 		countQuery += fmt.Sprintf(` and name ilike '%%%s%%'`, search)
@@ -81,19 +86,19 @@ func (b branchRepo) GetList(ctx context.Context, request models.GetListRequest)
 
 	if err := b.db.QueryRow(ctx, countQuery).Scan(&count); err != nil {
 		b.log.Error("error is while scanning count", logger.Error(err))
-		return models.BranchResponse{}, err
+		return models.BranchResponse{}, dbError(err)
 	}
 
-	query = `SELECT id, name, address, created_at, updated_at FROM branches WHERE deleted_at = 0 `
+	query = `SELECT id, name, address, version, created_at, updated_at, deleted_at FROM branches WHERE ` + deletedFilter(request.IncludeDeleted) + ` `
 	if search != "" {
 		query += fmt.Sprintf(` AND name ILIKE '%%%s%%' `, search)
 	}
 
-	query += ` LIMIT $1 OFFSET $2`
-	rows, err := b.db.Query(ctx, query, request.Limit, offset)
+	query, args := paging(query, request.After, request.Limit, offset)
+	rows, err := b.db.Query(ctx, query, args...)
 	if err != nil {
 		b.log.Error("error is while selecting * from branches", logger.Error(err))
-		return models.BranchResponse{}, err
+		return models.BranchResponse{}, dbError(err)
 	}
 
 	for rows.Next() {
@@ -101,14 +106,17 @@ func (b branchRepo) GetList(ctx context.Context, request models.GetListRequest)
 		if err := rows.Scan(
 			&branch.ID,
 			&branch.Name,
-			&branch.Address,
+			&address,
+			&branch.Version,
 			&branch.CreatedAt,
 			&updatedAt,
+			&branch.DeletedAt,
 			); err != nil {
 			b.log.Error("error is while scanning branch", logger.Error(err))
-			return models.BranchResponse{}, err
+			return models.BranchResponse{}, dbError(err)
 		}
 
+		branch.Address = address.String
 		if updatedAt.Valid {
 			branch.UpdatedAt = updatedAt.Time
 		}
@@ -122,24 +130,49 @@ func (b branchRepo) GetList(ctx context.Context, request models.GetListRequest)
 	}, err
 }
 func (b branchRepo) Update(ctx context.Context, branch models.UpdateBranch) (string, error) {
-	query := `UPDATE branches SET name = $1, address = $2, updated_at = Now() WHERE id = $3 AND deleted_at = 0`
+	return b.Patch(ctx, models.PatchBranch{
+		ID:      branch.ID,
+		Name:    &branch.Name,
+		Address: &branch.Address,
+		Version: branch.Version,
+	})
+}
 
-	if _, err := b.db.Exec(ctx, query,
-		&branch.Name,
-		&branch.Address,
-		&branch.ID); err != nil {
-		b.log.Error("error is while updating branch", logger.Error(err))
+// Patch updates only the given fields of branches.
+func (b branchRepo) Patch(ctx context.Context, branch models.PatchBranch) (string, error) {
+	fields := patch{}
+	set(&fields, "name", branch.Name)
+	setNullable(&fields, "address", branch.Address, "")
+
+	if err := fields.exec(ctx, b.db, "branches", branch.ID, branch.Version); err != nil {
+		b.log.Error("error is while patching branch", logger.Error(err))
 		return "", err
 	}
 
 	return branch.ID, nil
 }
+// Delete soft deletes branch which has no stock and no sales in process.
 func (b branchRepo) Delete(ctx context.Context, id string) error {
-	query := `UPDATE branches SET deleted_at = extract(epoch from current_timestamp) WHERE id = $1`
-
-	if _, err := b.db.Exec(ctx, query, id); err != nil {
+	if err := softDelete(ctx, b.db, "branches", id,
+		deleteGuard{
+			condition: `EXISTS (SELECT 1 FROM repositories WHERE branch_id::text = $1 AND count > 0 AND deleted_at = 0)`,
+			reason:    "branch has products in stock, it can not be deleted",
+		},
+		deleteGuard{
+			condition: `EXISTS (SELECT 1 FROM sales WHERE branch_id::text = $1 AND status = 'in_process' AND deleted_at = 0)`,
+			reason:    "branch has sales in process, it can not be deleted",
+		},
+	); err != nil {
 		b.log.Error("error is while deleting branches", logger.Error(err))
 		return err
 	}
 	return nil
 }
+
+func (b branchRepo) Restore(ctx context.Context, id string) error {
+	if err := restore(ctx, b.db, "branches", id); err != nil {
+		b.log.Error("error is while restoring branch", logger.Error(err))
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+//go:build integration
+
+package postgres
+
+import (
+	"context"
+	"market/api/models"
+	"market/pkg/errs"
+	"testing"
+)
+
+func TestBranchRepo(t *testing.T) {
+	ctx := context.Background()
+	repo := testStore.Branch()
+	name := unique("Yunusobod")
+
+	id, err := repo.Create(ctx, models.CreateBranch{Name: name, Address: "Amir Temur 5"})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	branch, err := repo.GetByID(ctx, id)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if branch.Name != name || branch.Address != "Amir Temur 5" || branch.Version != 1 {
+		t.Errorf("got %+v, want %s at Amir Temur 5 with version 1", branch, name)
+	}
+
+	if _, err = repo.Update(ctx, models.UpdateBranch{ID: id, Name: name, Address: "Navoiy 2", Version: branch.Version}); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+
+	// the version read before the update is stale now
+	_, err = repo.Update(ctx, models.UpdateBranch{ID: id, Name: name, Address: "Navoiy 3", Version: branch.Version})
+	wantCode(t, err, errs.CodeConflict)
+
+	// an empty address is stored as NULL
+	empty := ""
+	if _, err = repo.Patch(ctx, models.PatchBranch{ID: id, Address: &empty, Version: branch.Version + 1}); err != nil {
+		t.Fatalf("patch: %v", err)
+	}
+
+	if branch, err = repo.GetByID(ctx, id); err != nil {
+		t.Fatalf("get after patch: %v", err)
+	}
+	if branch.Address != "" || branch.Version != 3 || branch.UpdatedAt.IsZero() {
+		t.Errorf("after patch got address %q, version %d, updated_at %s, want empty, 3 and set", branch.Address, branch.Version, branch.UpdatedAt)
+	}
+
+	if err = repo.Delete(ctx, id); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+
+	_, err = repo.GetByID(ctx, id)
+	wantNotFound(t, err)
+
+	list, err := repo.GetList(ctx, models.GetListRequest{Page: 1, Limit: 10, Search: name})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if list.Count != 0 || len(list.Branches) != 0 {
+		t.Errorf("list has %d of deleted branch, want 0", list.Count)
+	}
+
+	if list, err = repo.GetList(ctx, models.GetListRequest{Page: 1, Limit: 10, Search: name, IncludeDeleted: true}); err != nil {
+		t.Fatalf("list with deleted: %v", err)
+	}
+	if list.Count != 1 || len(list.Branches) != 1 || list.Branches[0].DeletedAt == 0 {
+		t.Fatalf("list with deleted got %+v, want the deleted branch", list)
+	}
+
+	if err = repo.Restore(ctx, id); err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	wantNotFound(t, repo.Restore(ctx, id))
+
+	if _, err = repo.GetByID(ctx, id); err != nil {
+		t.Errorf("get after restore: %v", err)
+	}
+
+	_, err = repo.GetByID(ctx, "not-a-uuid")
+	wantCode(t, err, errs.CodeValidation)
+
+	_, err = repo.Create(ctx, models.CreateBranch{Name: "Branch name which is longer than thirty"})
+	wantCode(t, err, errs.CodeValidation)
+}
+
+func TestBranchListPaging(t *testing.T) {
+	ctx := context.Background()
+	repo := testStore.Branch()
+	prefix := unique("Sergeli")
+
+	for i := 0; i < 5; i++ {
+		if _, err := repo.Create(ctx, models.CreateBranch{Name: unique(prefix)}); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+	}
+
+	seen := map[string]bool{}
+	for page := 1; page <= 3; page++ {
+		list, err := repo.GetList(ctx, models.GetListRequest{Page: page, Limit: 2, Search: prefix})
+		if err != nil {
+			t.Fatalf("page %d: %v", page, err)
+		}
+
+		if list.Count != 5 {
+			t.Errorf("page %d: count is %d, want 5", page, list.Count)
+		}
+		if want := min(2, 5-(page-1)*2); len(list.Branches) != want {
+			t.Errorf("page %d: got %d branches, want %d", page, len(list.Branches), want)
+		}
+
+		for _, branch := range list.Branches {
+			if seen[branch.ID] {
+				t.Errorf("page %d: branch %s was on an earlier page", page, branch.ID)
+			}
+			seen[branch.ID] = true
+		}
+	}
+
+	// the cursor pages through the same branches, each once, and ends after the last one,
+	// an empty cursor starts from the newest
+	after := &models.Cursor{}
+	for {
+		list, err := repo.GetList(ctx, models.GetListRequest{Limit: 2, Search: prefix, After: after})
+		if err != nil {
+			t.Fatalf("cursor page: %v", err)
+		}
+		if len(list.Branches) == 0 {
+			break
+		}
+
+		for _, branch := range list.Branches {
+			if !seen[branch.ID] {
+				t.Fatalf("cursor paging returned branch %s twice or one offset paging did not", branch.ID)
+			}
+			delete(seen, branch.ID)
+		}
+
+		last := list.Branches[len(list.Branches)-1]
+		after = &models.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}
+	}
+
+	if len(seen) != 0 {
+		t.Errorf("cursor paging missed %d branches", len(seen))
+	}
+}
+
+func TestBranchDeleteGuards(t *testing.T) {
+	ctx := context.Background()
+	branchID := newTestBranch(t)
+
+	repositoryID, err := testStore.Repository().Create(ctx, models.CreateRepository{ProductID: newTestProduct(t, 1500), BranchID: branchID, Count: 3})
+	if err != nil {
+		t.Fatalf("create repository: %v", err)
+	}
+	wantCode(t, testStore.Branch().Delete(ctx, branchID), errs.CodeConflict)
+
+	if err = testStore.Repository().Delete(ctx, repositoryID); err != nil {
+		t.Fatalf("delete repository: %v", err)
+	}
+
+	saleID := newTestSale(t, branchID)
+	wantCode(t, testStore.Branch().Delete(ctx, branchID), errs.CodeConflict)
+
+	if err = testStore.Sale().Delete(ctx, saleID); err != nil {
+		t.Fatalf("delete sale: %v", err)
+	}
+
+	if err = testStore.Branch().Delete(ctx, branchID); err != nil {
+		t.Errorf("delete branch with nothing in stock or in process: %v", err)
+	}
+}
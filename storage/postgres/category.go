@@ -9,15 +9,14 @@ import (
 	"market/storage"
 
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type categoryRepo struct {
-	db *pgxpool.Pool
+	db Querier
 	log logger.ILogger
 }
 
-func NewCategoryRepo(db *pgxpool.Pool, log logger.ILogger) storage.ICategory {
+func NewCategoryRepo(db Querier, log logger.ILogger) storage.ICategory {
 	return categoryRepo{
 		db: db,
 		log: log,
@@ -26,29 +25,34 @@ func NewCategoryRepo(db *pgxpool.Pool, log logger.ILogger) storage.ICategory {
 
 func (c categoryRepo) Create(ctx context.Context, category models.CreateCategory) (string, error) {
 	id := uuid.New()
-	query := `insert into categories (id, name, parent_id) values($1, $2, $3)`
+	query := `insert into categories (id, name, parent_id) values($1, $2, NULLIF($3, ''))`
 	if _, err := c.db.Exec(ctx, query, id, category.Name, category.ParentID); err != nil {
 		c.log.Error("error is while inserting data", logger.Error(err))
-		return "", err
+		return "", dbError(err)
 	}
 	return id.String(), nil
 }
 
 func (c categoryRepo) GetByID(ctx context.Context, id models.PrimaryKey) (models.Category, error) {
-	var updatedAt sql.NullTime
+	var (
+		updatedAt sql.NullTime
+		parentID  sql.NullString
+	)
 	category := models.Category{}
-	query := `select id, name, parent_id, created_at, updated_at FROM categories WHERE id = $1 and deleted_at = 0`
-	if err := c.db.QueryRow(ctx, query, id).Scan(
+	query := `select id, name, parent_id, version, created_at, updated_at FROM categories WHERE id = $1 and deleted_at = 0`
+	if err := c.db.QueryRow(ctx, query, id.ID).Scan(
 		&category.ID,
 		&category.Name,
-		&category.ParentID,
+		&parentID,
+		&category.Version,
 		&category.CreatedAt,
 		&updatedAt,
 		); err != nil {
 		c.log.Error("error is while selecting by id", logger.Error(err))
-		return models.Category{}, err
+		return models.Category{}, dbError(err)
 	}
 
+	category.ParentID = parentID.String
 	if updatedAt.Valid {
 		category.UpdatedAt = updatedAt.Time
 	}
@@ -65,26 +69,27 @@ func (c categoryRepo) GetList(ctx context.Context, request models.GetListRequest
 		categories        = []models.Category{}
 		search            = request.Search
 		updatedAt		  sql.NullTime
+		parentID          sql.NullString
 	)
-	countQuery = `SELECT count(1) FROM categories WHERE deleted_at = 0 `
+	countQuery = `SELECT count(1) FROM categories WHERE ` + deletedFilter(request.IncludeDeleted) + ` `
 	if search != "" {
 		countQuery += fmt.Sprintf(` and name ilike '%%%s%%'`, search)
 	}
 	if err := c.db.QueryRow(ctx, countQuery).Scan(&count); err != nil {
 		c.log.Error("error is while scanning count", logger.Error(err))
-		return models.CategoryResponse{}, err
+		return models.CategoryResponse{}, dbError(err)
 	}
 
-	query = `SELECT id, name, parent_id, created_at, updated_at FROM categories WHERE deleted_at = 0 `
+	query = `SELECT id, name, parent_id, version, created_at, updated_at, deleted_at FROM categories WHERE ` + deletedFilter(request.IncludeDeleted) + ` `
 	if search != "" {
 		query += fmt.Sprintf(` and name ilike '%%%s%%'`, search)
 	}
 
-	query += ` LIMIT $1 OFFSET $2`
-	rows, err := c.db.Query(ctx, query, request.Limit, offset)
+	query, args := paging(query, request.After, request.Limit, offset)
+	rows, err := c.db.Query(ctx, query, args...)
 	if err != nil {
 		c.log.Error("error is while selecting all", logger.Error(err))
-		return models.CategoryResponse{}, err
+		return models.CategoryResponse{}, dbError(err)
 	}
 
 	for rows.Next() {
@@ -92,14 +97,17 @@ func (c categoryRepo) GetList(ctx context.Context, request models.GetListRequest
 		if err = rows.Scan(
 			&category.ID,
 			&category.Name,
-			&category.ParentID,
+			&parentID,
+			&category.Version,
 			&category.CreatedAt,
 			&updatedAt,
+			&category.DeletedAt,
 			); err != nil {
 			c.log.Error("error is while scanning category", logger.Error(err))
-			return models.CategoryResponse{}, err
+			return models.CategoryResponse{}, dbError(err)
 		}
 
+		category.ParentID = parentID.String
 		if updatedAt.Valid {
 			category.UpdatedAt = updatedAt.Time
 		}
@@ -113,11 +121,25 @@ func (c categoryRepo) GetList(ctx context.Context, request models.GetListRequest
 }
 
 func (c categoryRepo) Update(ctx context.Context, category models.UpdateCategory) (string, error) {
-	query := `UPDATE categories SET name = $1, parent_id = $2, updated_at = now() WHERE id = $3 AND deleted_at = 0`
-	if _, err := c.db.Exec(ctx, query, &category.Name, &category.ParentID, &category.ID); err != nil {
-		c.log.Error("error is while updating", logger.Error(err))
+	return c.Patch(ctx, models.PatchCategory{
+		ID:       category.ID,
+		Name:     &category.Name,
+		ParentID: &category.ParentID,
+		Version:  category.Version,
+	})
+}
+
+// Patch updates only the given fields of categories.
+func (c categoryRepo) Patch(ctx context.Context, category models.PatchCategory) (string, error) {
+	fields := patch{}
+	set(&fields, "name", category.Name)
+	setNullable(&fields, "parent_id", category.ParentID, "")
+
+	if err := fields.exec(ctx, c.db, "categories", category.ID, category.Version); err != nil {
+		c.log.Error("error is while patching category", logger.Error(err))
 		return "", err
 	}
+
 	return category.ID, nil
 }
 
@@ -125,7 +147,16 @@ func (c categoryRepo) Delete(ctx context.Context, id string) error {
 	query := `update categories set deleted_at = extract(epoch FROM current_timestamp) WHERE id = $1`
 	if _, err := c.db.Exec(ctx, query, id); err != nil {
 		c.log.Error("error is while deleting", logger.Error(err))
+		return dbError(err)
+	}
+	return nil
+}
+
+func (c categoryRepo) Restore(ctx context.Context, id string) error {
+	if err := restore(ctx, c.db, "categories", id); err != nil {
+		c.log.Error("error is while restoring category", logger.Error(err))
 		return err
 	}
+
 	return nil
 }
@@ -0,0 +1,294 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"market/api/models"
+	"market/pkg/errs"
+	"market/pkg/logger"
+	"market/storage"
+
+	"github.com/google/uuid"
+)
+
+type customerRepo struct {
+	db  Querier
+	log logger.ILogger
+}
+
+func NewCustomerRepo(db Querier, log logger.ILogger) storage.ICustomerStorage {
+	return customerRepo{
+		db:  db,
+		log: log,
+	}
+}
+
+func (c customerRepo) Create(ctx context.Context, customer models.CreateCustomer) (string, error) {
+	id := uuid.New()
+	query := `INSERT INTO customers (id, phone, name, branch_id) VALUES($1, $2, $3, $4)`
+
+	if _, err := c.db.Exec(ctx, query,
+		id,
+		customer.Phone,
+		customer.Name,
+		customer.BranchID,
+	); err != nil {
+		c.log.Error("error is while inserting customer", logger.Error(err))
+		return "", dbError(err)
+	}
+
+	return id.String(), nil
+}
+
+func (c customerRepo) GetByID(ctx context.Context, id string) (models.Customer, error) {
+	var (
+		updatedAt sql.NullTime
+		name      sql.NullString
+		customer  = models.Customer{}
+	)
+
+	query := `SELECT id, phone, name, branch_id, points, version, created_at, updated_at
+					FROM customers WHERE id = $1 AND deleted_at = 0`
+
+	if err := c.db.QueryRow(ctx, query, id).Scan(
+		&customer.ID,
+		&customer.Phone,
+		&name,
+		&customer.BranchID,
+		&customer.Points,
+		&customer.Version,
+		&customer.CreatedAt,
+		&updatedAt,
+	); err != nil {
+		c.log.Error("error is while selecting customer by id", logger.Error(err))
+		return models.Customer{}, dbError(err)
+	}
+
+	customer.Name = name.String
+	if updatedAt.Valid {
+		customer.UpdatedAt = updatedAt.Time
+	}
+
+	return customer, nil
+}
+
+func (c customerRepo) GetList(ctx context.Context, request models.GetListRequest) (models.CustomersResponse, error) {
+	var (
+		offset    = (request.Page - 1) * request.Limit
+		count     = 0
+		customers = []models.Customer{}
+		updatedAt sql.NullTime
+		name      sql.NullString
+	)
+
+	countQuery := `SELECT COUNT(*) FROM customers WHERE ` + deletedFilter(request.IncludeDeleted) + `
+					AND ($1 = '' OR phone ILIKE '%' || $1 || '%' OR name ILIKE '%' || $1 || '%')`
+	if err := c.db.QueryRow(ctx, countQuery, request.Search).Scan(&count); err != nil {
+		c.log.Error("error is while scanning count of customers", logger.Error(err))
+		return models.CustomersResponse{}, dbError(err)
+	}
+
+	query := `SELECT id, phone, name, branch_id, points, version, created_at, updated_at, deleted_at FROM customers WHERE ` + deletedFilter(request.IncludeDeleted) + `
+					AND ($1 = '' OR phone ILIKE '%' || $1 || '%' OR name ILIKE '%' || $1 || '%')`
+	query, args := pagingBy(query, []any{request.Search}, "created_at DESC", request.After, request.Limit, offset)
+
+	rows, err := c.db.Query(ctx, query, args...)
+	if err != nil {
+		c.log.Error("error is while selecting customers", logger.Error(err))
+		return models.CustomersResponse{}, dbError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		customer := models.Customer{}
+		if err = rows.Scan(
+			&customer.ID,
+			&customer.Phone,
+			&name,
+			&customer.BranchID,
+			&customer.Points,
+			&customer.Version,
+			&customer.CreatedAt,
+			&updatedAt,
+			&customer.DeletedAt,
+		); err != nil {
+			c.log.Error("error is while scanning customer", logger.Error(err))
+			return models.CustomersResponse{}, dbError(err)
+		}
+
+		customer.Name = name.String
+		if updatedAt.Valid {
+			customer.UpdatedAt = updatedAt.Time
+		}
+
+		customers = append(customers, customer)
+	}
+
+	return models.CustomersResponse{
+		Customers: customers,
+		Count:     count,
+	}, nil
+}
+
+func (c customerRepo) Update(ctx context.Context, customer models.UpdateCustomer) (string, error) {
+	return c.Patch(ctx, models.PatchCustomer{
+		ID:      customer.ID,
+		Phone:   &customer.Phone,
+		Name:    &customer.Name,
+		Version: customer.Version,
+	})
+}
+
+// Patch updates only the given fields of customers.
+func (c customerRepo) Patch(ctx context.Context, customer models.PatchCustomer) (string, error) {
+	fields := patch{}
+	set(&fields, "phone", customer.Phone)
+	setNullable(&fields, "name", customer.Name, "")
+
+	if err := fields.exec(ctx, c.db, "customers", customer.ID, customer.Version); err != nil {
+		c.log.Error("error is while patching customer", logger.Error(err))
+		return "", err
+	}
+
+	return customer.ID, nil
+}
+
+func (c customerRepo) Delete(ctx context.Context, id string) error {
+	query := `UPDATE customers SET deleted_at = extract(epoch from current_timestamp) WHERE id = $1`
+
+	if _, err := c.db.Exec(ctx, query, id); err != nil {
+		c.log.Error("error is while deleting customer", logger.Error(err))
+		return dbError(err)
+	}
+
+	return nil
+}
+
+func (c customerRepo) Restore(ctx context.Context, id string) error {
+	if err := restore(ctx, c.db, "customers", id); err != nil {
+		c.log.Error("error is while restoring customer", logger.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// AddPoints adds points to the customer balance, negative points are withdrawn
+// only if the customer has enough of them.
+func (c customerRepo) AddPoints(ctx context.Context, id string, points int) error {
+	query := `UPDATE customers SET points = points + $1, updated_at = NOW(), version = version + 1
+				WHERE id = $2 AND deleted_at = 0 AND points + $1 >= 0`
+
+	tag, err := c.db.Exec(ctx, query, points, id)
+	if err != nil {
+		c.log.Error("error is while updating customer points", logger.Error(err))
+		return dbError(err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return errs.Validation("customer does not have enough points")
+	}
+
+	return nil
+}
+
+func (c customerRepo) SaleHistory(ctx context.Context, id string, request models.GetListRequest) ([]models.CustomerSale, int, error) {
+	var (
+		offset  = (request.Page - 1) * request.Limit
+		count   = 0
+		history = []models.CustomerSale{}
+		index   = map[string]int{}
+		saleIDs = []string{}
+	)
+
+	countQuery := `SELECT COUNT(*) FROM sales WHERE customer_id = $1 AND deleted_at = 0`
+	if err := c.db.QueryRow(ctx, countQuery, id).Scan(&count); err != nil {
+		c.log.Error("error is while scanning count of customer sales", logger.Error(err))
+		return nil, 0, dbError(err)
+	}
+
+	query := `SELECT id, branch_id, shop_assistant_id, cashier_id, payment_type, price, status, client_name,
+					customer_id, version, created_at, updated_at FROM sales WHERE customer_id = $1 AND deleted_at = 0
+					ORDER BY created_at DESC LIMIT $2 OFFSET $3`
+
+	rows, err := c.db.Query(ctx, query, id, request.Limit, offset)
+	if err != nil {
+		c.log.Error("error is while selecting customer sales", logger.Error(err))
+		return nil, 0, dbError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			sale                                   = models.Sale{}
+			paymentType, clientName, cashierID, sa sql.NullString
+			updatedAt                              sql.NullTime
+		)
+
+		if err = rows.Scan(
+			&sale.ID,
+			&sale.BranchID,
+			&sa,
+			&cashierID,
+			&paymentType,
+			&sale.Price,
+			&sale.Status,
+			&clientName,
+			&sale.CustomerID,
+			&sale.Version,
+			&sale.CreatedAt,
+			&updatedAt,
+		); err != nil {
+			c.log.Error("error is while scanning customer sale", logger.Error(err))
+			return nil, 0, dbError(err)
+		}
+
+		sale.ShopAssistantID = sa.String
+		sale.CashierID = cashierID.String
+		sale.PaymentType = paymentType.String
+		sale.ClientName = clientName.String
+		if updatedAt.Valid {
+			sale.UpdatedAt = updatedAt.Time
+		}
+
+		index[sale.ID] = len(history)
+		saleIDs = append(saleIDs, sale.ID)
+		history = append(history, models.CustomerSale{Sale: sale, Baskets: []models.Basket{}})
+	}
+
+	if len(saleIDs) == 0 {
+		return history, count, nil
+	}
+
+	basketQuery := `SELECT id, sale_id, product_id, quantity, price, version, created_at::text, COALESCE(updated_at::text, '')
+					FROM baskets WHERE sale_id::text = ANY($1) AND deleted_at = 0 ORDER BY created_at`
+
+	basketRows, err := c.db.Query(ctx, basketQuery, saleIDs)
+	if err != nil {
+		c.log.Error("error is while selecting customer baskets", logger.Error(err))
+		return nil, 0, dbError(err)
+	}
+	defer basketRows.Close()
+
+	for basketRows.Next() {
+		basket := models.Basket{}
+		if err = basketRows.Scan(
+			&basket.ID,
+			&basket.SaleID,
+			&basket.ProductID,
+			&basket.Quantity,
+			&basket.Price,
+			&basket.Version,
+			&basket.CreatedAt,
+			&basket.UpdatedAt,
+		); err != nil {
+			c.log.Error("error is while scanning customer basket", logger.Error(err))
+			return nil, 0, dbError(err)
+		}
+
+		i := index[basket.SaleID]
+		history[i].Baskets = append(history[i].Baskets, basket)
+	}
+
+	return history, count, nil
+}
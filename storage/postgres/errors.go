@@ -0,0 +1,57 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"market/pkg/errs"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// dbError turns pgx and postgres errors into domain errors, other errors are returned as is.
+func dbError(err error) error {
+	if err == nil || errs.CodeOf(err) != "" {
+		return err
+	}
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return errs.Wrap(errs.CodeNotFound, err, "record not found")
+	}
+
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return err
+	}
+
+	message := pgErr.Detail
+	if message == "" {
+		message = pgErr.Message
+	}
+
+	switch pgErr.Code {
+	case "23505": // unique_violation
+		return errs.Wrap(errs.CodeConflict, err, message)
+	case "23503", "23502", "23514", "22P02", "22001": // foreign_key, not_null, check, invalid_text, too_long
+		return errs.Wrap(errs.CodeValidation, err, message)
+	}
+
+	return err
+}
+
+// versionError explains why an update of the row with expected version changed nothing:
+// the row does not exist or it was changed by someone else after it was read.
+func versionError(ctx context.Context, db Querier, table, id string) error {
+	var exists bool
+
+	query := `SELECT EXISTS (SELECT 1 FROM ` + table + ` WHERE id::text = $1 AND deleted_at = 0)`
+	if err := db.QueryRow(ctx, query, id).Scan(&exists); err != nil {
+		return dbError(err)
+	}
+
+	if !exists {
+		return errs.NotFound("record not found")
+	}
+
+	return errs.Conflict("record was changed by someone else, get it again and retry")
+}
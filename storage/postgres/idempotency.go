@@ -0,0 +1,135 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"market/api/models"
+	"market/pkg/errs"
+	"market/pkg/logger"
+	"market/storage"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type idempotencyRepo struct {
+	db  Querier
+	log logger.ILogger
+}
+
+func NewIdempotencyRepo(db Querier, log logger.ILogger) storage.IIdempotencyStorage {
+	return idempotencyRepo{
+		db:  db,
+		log: log,
+	}
+}
+
+// Reserve stores the key before the request is handled and returns it with the time it
+// was reserved at. If the key is already stored and not older than ttl, the stored row is
+// returned with false. A key still in progress which was reserved longer than lease ago is
+// reserved again, only by the same request. Both are checked against the db clock.
+func (i idempotencyRepo) Reserve(ctx context.Context, request models.IdempotentResponse, ttl, lease time.Duration) (models.IdempotentResponse, bool, error) {
+	if _, err := i.db.Exec(ctx, `DELETE FROM idempotency_keys WHERE key = $1 AND endpoint = $2 AND created_at < NOW() - $3::interval`,
+		request.Key, request.Endpoint, ttl); err != nil {
+		i.log.Error("error is while deleting expired idempotency key", logger.Error(err))
+		return models.IdempotentResponse{}, false, dbError(err)
+	}
+
+	reservation := request
+	err := i.db.QueryRow(ctx, `INSERT INTO idempotency_keys (key, endpoint, request_hash)
+			VALUES ($1, $2, $3) ON CONFLICT DO NOTHING RETURNING reserved_at`,
+		request.Key, request.Endpoint, request.RequestHash).Scan(&reservation.ReservedAt)
+	if err == nil {
+		return reservation, true, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		i.log.Error("error is while reserving idempotency key", logger.Error(err))
+		return models.IdempotentResponse{}, false, dbError(err)
+	}
+
+	err = i.db.QueryRow(ctx, `UPDATE idempotency_keys SET reserved_at = NOW()
+			WHERE key = $1 AND endpoint = $2 AND request_hash = $3 AND status_code IS NULL
+				AND reserved_at < NOW() - $4::interval
+			RETURNING reserved_at`,
+		request.Key, request.Endpoint, request.RequestHash, lease).Scan(&reservation.ReservedAt)
+	if err == nil {
+		return reservation, true, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		i.log.Error("error is while taking over idempotency key", logger.Error(err))
+		return models.IdempotentResponse{}, false, dbError(err)
+	}
+
+	var (
+		stored      = models.IdempotentResponse{}
+		statusCode  sql.NullInt32
+		contentType sql.NullString
+	)
+
+	if err := i.db.QueryRow(ctx, `SELECT key, endpoint, request_hash, status_code, content_type, response, reserved_at, created_at
+			FROM idempotency_keys WHERE key = $1 AND endpoint = $2`, request.Key, request.Endpoint).Scan(
+		&stored.Key,
+		&stored.Endpoint,
+		&stored.RequestHash,
+		&statusCode,
+		&contentType,
+		&stored.Body,
+		&stored.ReservedAt,
+		&stored.CreatedAt,
+	); err != nil {
+		i.log.Error("error is while getting idempotency key", logger.Error(err))
+		return models.IdempotentResponse{}, false, dbError(err)
+	}
+
+	if statusCode.Valid {
+		stored.StatusCode = int(statusCode.Int32)
+	}
+
+	stored.ContentType = contentType.String
+
+	return stored, false, nil
+}
+
+// Save stores the response of the request which reserved the key. If the key was
+// reserved again by a retry after the lease of response ran out, it is a conflict.
+func (i idempotencyRepo) Save(ctx context.Context, response models.IdempotentResponse) error {
+	tag, err := i.db.Exec(ctx, `UPDATE idempotency_keys SET status_code = $1, content_type = $2, response = $3
+			WHERE key = $4 AND endpoint = $5 AND reserved_at = $6 AND request_hash = $7`,
+		response.StatusCode, response.ContentType, response.Body, response.Key, response.Endpoint,
+		response.ReservedAt, response.RequestHash)
+	if err != nil {
+		i.log.Error("error is while saving idempotent response", logger.Error(err))
+		return dbError(err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return errs.Conflict("Idempotency-Key %s is reserved by another request", response.Key)
+	}
+
+	return nil
+}
+
+// Release removes the key, so that the request can be retried with it. A key which was
+// reserved again by a retry is left to it.
+func (i idempotencyRepo) Release(ctx context.Context, request models.IdempotentResponse) error {
+	if _, err := i.db.Exec(ctx, `DELETE FROM idempotency_keys
+			WHERE key = $1 AND endpoint = $2 AND reserved_at = $3 AND request_hash = $4`,
+		request.Key, request.Endpoint, request.ReservedAt, request.RequestHash); err != nil {
+		i.log.Error("error is while releasing idempotency key", logger.Error(err))
+		return dbError(err)
+	}
+
+	return nil
+}
+
+// DeleteExpired removes the keys older than ttl.
+func (i idempotencyRepo) DeleteExpired(ctx context.Context, ttl time.Duration) (int64, error) {
+	tag, err := i.db.Exec(ctx, `DELETE FROM idempotency_keys WHERE created_at < NOW() - $1::interval`, ttl)
+	if err != nil {
+		i.log.Error("error is while deleting expired idempotency keys", logger.Error(err))
+		return 0, dbError(err)
+	}
+
+	return tag.RowsAffected(), nil
+}
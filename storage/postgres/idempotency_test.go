@@ -0,0 +1,96 @@
+//go:build integration
+
+package postgres
+
+import (
+	"context"
+	"market/api/models"
+	"market/pkg/errs"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestIdempotencyRepo(t *testing.T) {
+	ctx := context.Background()
+	repo := testStore.Idempotency()
+	request := models.IdempotentResponse{Key: uuid.NewString(), Endpoint: "POST /sale", RequestHash: "hash-1"}
+
+	// a negative lease or ttl has run out already, they are counted by the db clock
+	day, ranOut := 24*time.Hour, -time.Hour
+
+	first, reserved, err := repo.Reserve(ctx, request, day, day)
+	if err != nil || !reserved || first.ReservedAt.IsZero() {
+		t.Fatalf("reserve: reserved %v at %s with error %v, want reserved", reserved, first.ReservedAt, err)
+	}
+
+	// the key is in progress and its lease has not run out
+	stored, reserved, err := repo.Reserve(ctx, request, day, day)
+	if err != nil || reserved {
+		t.Fatalf("reserve in progress: reserved %v with error %v, want not reserved", reserved, err)
+	}
+	if stored.RequestHash != "hash-1" || stored.StatusCode != 0 || !stored.ReservedAt.Equal(first.ReservedAt) {
+		t.Errorf("in progress key is %+v, want hash-1 without response", stored)
+	}
+
+	// another request can not take the key over, even after the lease
+	other := request
+	other.RequestHash = "hash-2"
+	if stored, reserved, err = repo.Reserve(ctx, other, day, ranOut); err != nil || reserved || stored.RequestHash != "hash-1" {
+		t.Fatalf("reserve by another request: reserved %v as %q with error %v, want hash-1 kept", reserved, stored.RequestHash, err)
+	}
+
+	// the retry of the same request takes it over once the lease runs out
+	retry, reserved, err := repo.Reserve(ctx, request, day, ranOut)
+	if err != nil || !reserved || !retry.ReservedAt.After(first.ReservedAt) {
+		t.Fatalf("reserve after lease: reserved %v at %s with error %v, want reserved again", reserved, retry.ReservedAt, err)
+	}
+
+	// the first holder can not store its response over the retry, nor release the key
+	first.StatusCode = 201
+	first.Body = []byte(`{"id":"first"}`)
+	wantCode(t, repo.Save(ctx, first), errs.CodeConflict)
+	if err = repo.Release(ctx, first); err != nil {
+		t.Fatalf("release by the first holder: %v", err)
+	}
+
+	retry.StatusCode = 201
+	retry.ContentType = "application/json; charset=utf-8"
+	retry.Body = []byte(`{"id":"retry"}`)
+	if err = repo.Save(ctx, retry); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	// a saved response is replayed, even after its lease
+	if stored, reserved, err = repo.Reserve(ctx, request, day, ranOut); err != nil || reserved {
+		t.Fatalf("reserve saved: reserved %v with error %v, want not reserved", reserved, err)
+	}
+	if stored.StatusCode != 201 || stored.ContentType != retry.ContentType || string(stored.Body) != `{"id":"retry"}` {
+		t.Errorf("saved key is %+v, want the response of the retry", stored)
+	}
+
+	// a released key can be reserved again
+	if err = repo.Release(ctx, retry); err != nil {
+		t.Fatalf("release: %v", err)
+	}
+	if _, reserved, err = repo.Reserve(ctx, request, day, day); err != nil || !reserved {
+		t.Fatalf("reserve released: reserved %v with error %v, want reserved", reserved, err)
+	}
+
+	// so can an expired one, by any request
+	if _, reserved, err = repo.Reserve(ctx, other, ranOut, day); err != nil || !reserved {
+		t.Errorf("reserve expired: reserved %v with error %v, want reserved", reserved, err)
+	}
+
+	// the same key of another endpoint is another key
+	basket := request
+	basket.Endpoint = "POST /basket"
+	if _, reserved, err = repo.Reserve(ctx, basket, day, day); err != nil || !reserved {
+		t.Errorf("reserve for another endpoint: reserved %v with error %v, want reserved", reserved, err)
+	}
+
+	if deleted, err := repo.DeleteExpired(ctx, ranOut); err != nil || deleted < 2 {
+		t.Errorf("deleted %d expired keys with error %v, want at least 2", deleted, err)
+	}
+}
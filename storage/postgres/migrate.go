@@ -0,0 +1,141 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"market/config"
+	"market/pkg/logger"
+
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/postgres" //postgres is used for database
+	_ "github.com/golang-migrate/migrate/v4/source/file"       //file is needed for migration url
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// migrationsURL is the directory of numbered NNNNNN_name.up.sql and NNNNNN_name.down.sql files,
+// relative to the working directory of the binary.
+const migrationsURL = "file://migrations/postgres/"
+
+// Migrator applies the versioned migrations to the database from config.
+type Migrator struct {
+	m   *migrate.Migrate
+	log logger.ILogger
+}
+
+func NewMigrator(cfg config.Config, log logger.ILogger) (*Migrator, error) {
+	m, err := migrate.New(migrationsURL, databaseURL(cfg))
+	if err != nil {
+		log.Error("error while reading migrations", logger.Error(err))
+		return nil, err
+	}
+
+	return &Migrator{
+		m:   m,
+		log: log,
+	}, nil
+}
+
+// Up applies all migrations which are not applied yet.
+func (m *Migrator) Up() error {
+	if err := m.m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		m.log.Error("error while migrating up", logger.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// Down rolls back the last steps migrations.
+func (m *Migrator) Down(steps int) error {
+	if steps <= 0 {
+		return fmt.Errorf("number of migrations to roll back should be positive, got %d", steps)
+	}
+
+	if err := m.m.Steps(-steps); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		m.log.Error("error while migrating down", logger.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// Force sets the version and clears the dirty flag without running migrations. It is used
+// after a failed migration was fixed by hand, version -1 means no migration is applied.
+func (m *Migrator) Force(version int) error {
+	if err := m.m.Force(version); err != nil {
+		m.log.Error("error while forcing migration version", logger.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// Version returns the last applied migration, 0 if none is applied. Dirty means
+// that migration failed in the middle and the schema should be checked by hand.
+func (m *Migrator) Version() (uint, bool, error) {
+	version, dirty, err := m.m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return 0, false, nil
+	}
+	if err != nil {
+		m.log.Error("error while getting migration version", logger.Error(err))
+		return 0, false, err
+	}
+
+	return version, dirty, nil
+}
+
+func (m *Migrator) Close() {
+	if sourceErr, dbErr := m.m.Close(); sourceErr != nil || dbErr != nil {
+		m.log.Error("error while closing migrator", logger.Any("source", sourceErr), logger.Any("database", dbErr))
+	}
+}
+
+// migrateUp applies new migrations on start. A dirty schema is not guessed about,
+// the server refuses to start until it is fixed with migrate force. So is a schema
+// which was set up by hand before migrations, since the first migration would fail
+// on its existing types and tables.
+func migrateUp(ctx context.Context, db *pgxpool.Pool, cfg config.Config, log logger.ILogger) error {
+	m, err := NewMigrator(cfg, log)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	version, dirty, err := m.Version()
+	if err != nil {
+		return err
+	}
+
+	if dirty {
+		return fmt.Errorf("database schema is dirty at migration %d, fix it by hand and run migrate force", version)
+	}
+
+	if version == 0 {
+		// payment_type_enum is the first thing the init migration creates
+		existing := false
+		if err = db.QueryRow(ctx, `SELECT to_regtype('payment_type_enum') IS NOT NULL`).Scan(&existing); err != nil {
+			log.Error("error while checking for schema without migrations", logger.Error(err))
+			return err
+		}
+
+		if existing {
+			return errors.New("database has a schema but no migration version, it was set up by hand: " +
+				"check which migrations it matches and run market migrate force VERSION, see README")
+		}
+	}
+
+	return m.Up()
+}
+
+func databaseURL(cfg config.Config) string {
+	return fmt.Sprintf(
+		`postgres://%s:%s@%s:%s/%s?sslmode=disable`,
+		cfg.PostgresUser,
+		cfg.PostgresPassword,
+		cfg.PostgresHost,
+		cfg.PostgresPort,
+		cfg.PostgresDB,
+	)
+}
@@ -0,0 +1,123 @@
+package postgres
+
+import (
+	"context"
+	"market/api/models"
+	"market/pkg/logger"
+	"market/storage"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type outboxRepo struct {
+	db  Querier
+	log logger.ILogger
+}
+
+func NewOutboxRepo(db Querier, log logger.ILogger) storage.IOutboxStorage {
+	return outboxRepo{
+		db:  db,
+		log: log,
+	}
+}
+
+// Add writes the event to the outbox, with the store of WithTx it is saved only if the change is committed.
+func (o outboxRepo) Add(ctx context.Context, event models.CreateEvent) error {
+	if _, err := o.db.Exec(ctx, `INSERT INTO outbox_events (id, event_type, aggregate_id, payload) VALUES ($1, $2, $3, $4)`,
+		uuid.New().String(),
+		event.Type,
+		event.AggregateID,
+		event.Payload,
+	); err != nil {
+		o.log.Error("error is while inserting outbox event", logger.Error(err))
+		return dbError(err)
+	}
+
+	return nil
+}
+
+// Claim returns at most limit due events which are not dispatched yet and failed less
+// than maxAttempts times, in the order they were added. They are not due again until
+// lease has passed by the db clock, so other dispatchers skip them while they are
+// dispatched, and rows another dispatcher is claiming right now are skipped as well.
+func (o outboxRepo) Claim(ctx context.Context, limit, maxAttempts int, lease time.Duration) ([]models.Event, error) {
+	rows, err := o.db.Query(ctx, `WITH claimed AS (
+				UPDATE outbox_events SET next_attempt_at = NOW() + $3::interval
+				WHERE id IN (SELECT id FROM outbox_events
+						WHERE dispatched_at IS NULL AND attempts < $1 AND next_attempt_at <= NOW()
+						ORDER BY seq LIMIT $2 FOR UPDATE SKIP LOCKED)
+				RETURNING id, seq, event_type, aggregate_id, payload, attempts, delivered_to, created_at)
+			SELECT id, event_type, aggregate_id, payload, attempts, delivered_to, created_at FROM claimed ORDER BY seq`,
+		maxAttempts, limit, lease)
+	if err != nil {
+		o.log.Error("error is while claiming pending outbox events", logger.Error(err))
+		return nil, dbError(err)
+	}
+	defer rows.Close()
+
+	events := []models.Event{}
+	for rows.Next() {
+		event := models.Event{}
+		if err = rows.Scan(
+			&event.ID,
+			&event.Type,
+			&event.AggregateID,
+			&event.Payload,
+			&event.Attempts,
+			&event.DeliveredTo,
+			&event.CreatedAt,
+		); err != nil {
+			o.log.Error("error is while scanning outbox event", logger.Error(err))
+			return nil, dbError(err)
+		}
+
+		events = append(events, event)
+	}
+
+	return events, nil
+}
+
+// MarkDelivered records that the receiver got the event, so it is not delivered
+// to it again when another receiver fails.
+func (o outboxRepo) MarkDelivered(ctx context.Context, id, receiver string) error {
+	if _, err := o.db.Exec(ctx, `UPDATE outbox_events SET delivered_to = array_append(delivered_to, $1)
+			WHERE id = $2 AND NOT $1 = ANY(delivered_to)`, receiver, id); err != nil {
+		o.log.Error("error is while marking outbox event delivered", logger.Error(err))
+		return dbError(err)
+	}
+
+	return nil
+}
+
+func (o outboxRepo) MarkDispatched(ctx context.Context, id string) error {
+	if _, err := o.db.Exec(ctx, `UPDATE outbox_events SET dispatched_at = NOW() WHERE id = $1`, id); err != nil {
+		o.log.Error("error is while marking outbox event dispatched", logger.Error(err))
+		return dbError(err)
+	}
+
+	return nil
+}
+
+// MarkFailed counts the failed attempt, the event is dispatched again once backoff
+// has passed by the db clock until it runs out of attempts.
+func (o outboxRepo) MarkFailed(ctx context.Context, id, reason string, backoff time.Duration) error {
+	if _, err := o.db.Exec(ctx, `UPDATE outbox_events SET attempts = attempts + 1, last_error = $1,
+			next_attempt_at = NOW() + $2::interval WHERE id = $3`, reason, backoff, id); err != nil {
+		o.log.Error("error is while marking outbox event failed", logger.Error(err))
+		return dbError(err)
+	}
+
+	return nil
+}
+
+// DeleteDispatched deletes the events dispatched longer than retention ago.
+func (o outboxRepo) DeleteDispatched(ctx context.Context, retention time.Duration) (int64, error) {
+	tag, err := o.db.Exec(ctx, `DELETE FROM outbox_events WHERE dispatched_at < NOW() - $1::interval`, retention)
+	if err != nil {
+		o.log.Error("error is while deleting dispatched outbox events", logger.Error(err))
+		return 0, dbError(err)
+	}
+
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,104 @@
+//go:build integration
+
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"market/api/models"
+	"market/storage"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestOutboxRepo(t *testing.T) {
+	ctx := context.Background()
+	repo := testStore.Outbox()
+	aggregateID := uuid.NewString()
+
+	for _, eventType := range []string{"sale.completed", "sale.cancelled"} {
+		if err := repo.Add(ctx, models.CreateEvent{Type: eventType, AggregateID: aggregateID, Payload: json.RawMessage(`{"price": 1500}`)}); err != nil {
+			t.Fatalf("add %s: %v", eventType, err)
+		}
+	}
+
+	// the event is added only if the change it belongs to is committed
+	rollback := errors.New("rollback")
+	if err := testStore.WithTx(ctx, func(store storage.IStorage) error {
+		if err := store.Outbox().Add(ctx, models.CreateEvent{Type: "sale.rolled_back", AggregateID: aggregateID, Payload: json.RawMessage(`{}`)}); err != nil {
+			return err
+		}
+
+		return rollback
+	}); !errors.Is(err, rollback) {
+		t.Fatalf("transaction returned %v, want the rollback", err)
+	}
+
+	events := claimEvents(t, aggregateID)
+	if len(events) != 2 || events[0].Type != "sale.completed" || events[1].Type != "sale.cancelled" {
+		t.Fatalf("claimed %+v, want both events in the order they were added", events)
+	}
+
+	// claimed events are not claimed again until they are due
+	if again := claimEvents(t, aggregateID); len(again) != 0 {
+		t.Errorf("claimed %+v again, want none", again)
+	}
+
+	// a receiver is recorded once however many times it gets the event
+	for i := 0; i < 2; i++ {
+		if err := repo.MarkDelivered(ctx, events[1].ID, "webhooks"); err != nil {
+			t.Fatalf("mark delivered: %v", err)
+		}
+	}
+
+	if err := repo.MarkDispatched(ctx, events[0].ID); err != nil {
+		t.Fatalf("mark dispatched: %v", err)
+	}
+	if err := repo.MarkFailed(ctx, events[1].ID, "webhook is down", -24*time.Hour); err != nil {
+		t.Fatalf("mark failed: %v", err)
+	}
+
+	retried := claimEvents(t, aggregateID)
+	if len(retried) != 1 || retried[0].ID != events[1].ID || retried[0].Attempts != 1 ||
+		len(retried[0].DeliveredTo) != 1 || retried[0].DeliveredTo[0] != "webhooks" {
+		t.Fatalf("claimed after failure %+v, want %s with one attempt, delivered to webhooks", retried, events[1].ID)
+	}
+
+	// an event which ran out of attempts is not claimed anymore
+	if err := repo.MarkFailed(ctx, events[1].ID, "webhook is down", -24*time.Hour); err != nil {
+		t.Fatalf("mark failed again: %v", err)
+	}
+
+	events, err := repo.Claim(ctx, 100, 2, 24*time.Hour)
+	if err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+	for _, event := range events {
+		if event.AggregateID == aggregateID {
+			t.Errorf("claimed %+v with 2 failed attempts of 2", event)
+		}
+	}
+}
+
+// claimEvents claims the due events and returns the ones of the aggregate,
+// the outbox is shared by the tests.
+func claimEvents(t *testing.T, aggregateID string) []models.Event {
+	t.Helper()
+
+	events, err := testStore.Outbox().Claim(context.Background(), 100, 5, 24*time.Hour)
+	if err != nil {
+		t.Fatalf("claim events: %v", err)
+	}
+
+	claimed := []models.Event{}
+	for _, event := range events {
+		if event.AggregateID == aggregateID {
+			claimed = append(claimed, event)
+		}
+	}
+
+	return claimed
+}
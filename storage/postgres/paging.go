@@ -0,0 +1,38 @@
+package postgres
+
+import (
+	"market/api/models"
+	"strconv"
+)
+
+// paging ends a list query which has no arguments and no order of its own with its page,
+// see pagingBy.
+func paging(query string, after *models.Cursor, limit, offset int) (string, []any) {
+	return pagingBy(query, nil, "", after, limit, offset)
+}
+
+// pagingBy ends a list query with its page and returns the arguments of the query, args
+// are those the query already has. Without a cursor rows are ordered by order, which may
+// be empty, and the page is taken by LIMIT and OFFSET. With a cursor rows are ordered
+// newest first by created_at and id and the page starts after the cursor row, so the
+// query has to end with a WHERE condition which the cursor condition is ANDed to.
+func pagingBy(query string, args []any, order string, after *models.Cursor, limit, offset int) (string, []any) {
+	next := func(value any) string {
+		args = append(args, value)
+		return `$` + strconv.Itoa(len(args))
+	}
+
+	if after == nil {
+		if order != "" {
+			query += ` ORDER BY ` + order
+		}
+
+		return query + ` LIMIT ` + next(limit) + ` OFFSET ` + next(offset), args
+	}
+
+	if after.ID != "" {
+		query += ` AND (created_at, id) < (` + next(after.CreatedAt) + `, ` + next(after.ID) + `)`
+	}
+
+	return query + ` ORDER BY created_at DESC, id DESC LIMIT ` + next(limit), args
+}
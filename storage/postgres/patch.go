@@ -0,0 +1,61 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// patch builds an UPDATE statement which sets only the columns that were given.
+type patch struct {
+	columns []string
+	args    []interface{}
+}
+
+// set adds column to the statement when value is not nil.
+func set[T any](p *patch, column string, value *T) {
+	if value == nil {
+		return
+	}
+
+	p.args = append(p.args, *value)
+	p.columns = append(p.columns, fmt.Sprintf("%s = $%d", column, len(p.args)))
+}
+
+// setNullable adds nullable text column, empty value is stored as NULL.
+// cast is appended to the parameter for columns which are not text, e.g. "::uuid".
+func setNullable(p *patch, column string, value *string, cast string) {
+	if value == nil {
+		return
+	}
+
+	p.args = append(p.args, *value)
+	p.columns = append(p.columns, fmt.Sprintf("%s = NULLIF($%d, '')%s", column, len(p.args), cast))
+}
+
+// exec updates the row with expected version, an empty patch only checks that version is still current.
+func (p *patch) exec(ctx context.Context, db Querier, table, id string, version int) error {
+	columns := make([]string, 0, len(p.columns)+2)
+	columns = append(columns, p.columns...)
+	columns = append(columns, "updated_at = NOW()", "version = version + 1")
+
+	args := append(append([]interface{}{}, p.args...), id, version)
+	query := fmt.Sprintf(`UPDATE %s SET %s WHERE id = $%d AND deleted_at = 0 AND version = $%d`,
+		table, strings.Join(columns, ", "), len(args)-1, len(args))
+
+	if len(p.columns) == 0 {
+		query = fmt.Sprintf(`SELECT 1 FROM %s WHERE id = $1 AND deleted_at = 0 AND version = $2`, table)
+		args = []interface{}{id, version}
+	}
+
+	tag, err := db.Exec(ctx, query, args...)
+	if err != nil {
+		return dbError(err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return versionError(ctx, db, table, id)
+	}
+
+	return nil
+}
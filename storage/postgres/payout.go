@@ -0,0 +1,339 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"market/api/models"
+	"market/pkg/errs"
+	"market/pkg/logger"
+	"market/storage"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+type payoutRepo struct {
+	db  Querier
+	log logger.ILogger
+}
+
+func NewPayoutRepo(db Querier, log logger.ILogger) storage.IPayoutStorage {
+	return payoutRepo{
+		db:  db,
+		log: log,
+	}
+}
+
+// Run pays every staff member of the branch what they earned in the period,
+// limited by their current balance. For each paid staff a 'withdraw' transaction
+// is recorded and the balance is reduced, all in one db transaction.
+func (p payoutRepo) Run(ctx context.Context, request models.CreatePayout) (string, error) {
+	type earning struct {
+		staffID string
+		balance int
+		earned  float64
+	}
+
+	var (
+		id       = uuid.New().String()
+		total    = 0
+		earnings = []earning{}
+	)
+
+	tx, err := p.db.Begin(ctx)
+	if err != nil {
+		p.log.Error("error is while beginning payout transaction", logger.Error(err))
+		return "", dbError(err)
+	}
+	defer tx.Rollback(ctx)
+
+	// runs of one branch wait for each other here, so that two of them can not both
+	// find no payout for the period and pay it twice
+	if _, err = tx.Exec(ctx, `SELECT id FROM branches WHERE id = $1 FOR UPDATE`, request.BranchID); err != nil {
+		p.log.Error("error is while locking branch for payout", logger.Error(err))
+		return "", dbError(err)
+	}
+
+	paidFrom, paidTo := "", ""
+	if err = tx.QueryRow(ctx, `SELECT period_from::text, period_to::text FROM payouts
+			WHERE branch_id = $1 AND deleted_at = 0 AND period_from <= $3::date AND period_to >= $2::date
+			LIMIT 1`, request.BranchID, request.PeriodFrom, request.PeriodTo).Scan(&paidFrom, &paidTo); err == nil {
+		return "", errs.Conflict("branch is already paid for %s - %s, which overlaps the period", paidFrom, paidTo)
+	} else if !errors.Is(err, pgx.ErrNoRows) {
+		p.log.Error("error is while checking paid periods", logger.Error(err))
+		return "", dbError(err)
+	}
+
+	if _, err = tx.Exec(ctx, `SELECT id FROM staffs WHERE branch_id = $1 AND deleted_at = 0 FOR UPDATE`, request.BranchID); err != nil {
+		p.log.Error("error is while locking staffs for payout", logger.Error(err))
+		return "", dbError(err)
+	}
+
+	rows, err := tx.Query(ctx, `SELECT s.id, COALESCE(s.balance, 0),
+				COALESCE(SUM(t.amount) FILTER (WHERE t.transaction_type = 'topup'), 0) -
+				COALESCE(SUM(t.amount) FILTER (WHERE t.transaction_type = 'withdraw' AND t.source_type <> 'payout'), 0)
+			FROM staffs s
+			LEFT JOIN transactions t ON t.staff_id = s.id AND t.deleted_at = 0
+				AND t.created_at >= $2::date AND t.created_at < $3::date + 1
+			WHERE s.branch_id = $1 AND s.deleted_at = 0
+			GROUP BY s.id, s.balance`, request.BranchID, request.PeriodFrom, request.PeriodTo)
+	if err != nil {
+		p.log.Error("error is while calculating earnings", logger.Error(err))
+		return "", dbError(err)
+	}
+
+	for rows.Next() {
+		e := earning{}
+		if err = rows.Scan(&e.staffID, &e.balance, &e.earned); err != nil {
+			rows.Close()
+			p.log.Error("error is while scanning earning", logger.Error(err))
+			return "", dbError(err)
+		}
+
+		earnings = append(earnings, e)
+	}
+	rows.Close()
+
+	if _, err = tx.Exec(ctx, `INSERT INTO payouts (id, branch_id, period_from, period_to) VALUES ($1, $2, $3, $4)`,
+		id, request.BranchID, request.PeriodFrom, request.PeriodTo); err != nil {
+		p.log.Error("error is while inserting payout", logger.Error(err))
+		return "", dbError(err)
+	}
+
+	description := fmt.Sprintf("payroll %s - %s", request.PeriodFrom, request.PeriodTo)
+
+	for _, e := range earnings {
+		paid := int(e.earned)
+		if paid > e.balance {
+			paid = e.balance
+		}
+		if paid < 0 {
+			paid = 0
+		}
+
+		var transactionID sql.NullString
+		if paid > 0 {
+			transactionID = sql.NullString{String: uuid.New().String(), Valid: true}
+
+			if _, err = tx.Exec(ctx, `INSERT INTO transactions
+				(id, staff_id, transaction_type, source_type, amount, description)
+					VALUES ($1, $2, 'withdraw', 'payout', $3, $4)`,
+				transactionID.String, e.staffID, paid, description); err != nil {
+				p.log.Error("error is while inserting payout transaction", logger.Error(err))
+				return "", dbError(err)
+			}
+
+			if _, err = tx.Exec(ctx, `UPDATE staffs SET balance = balance - $1, updated_at = NOW(), version = version + 1 WHERE id = $2`,
+				paid, e.staffID); err != nil {
+				p.log.Error("error is while reducing staff balance", logger.Error(err))
+				return "", dbError(err)
+			}
+		}
+
+		if _, err = tx.Exec(ctx, `INSERT INTO payout_items
+			(id, payout_id, staff_id, earned, balance_before, paid, transaction_id)
+				VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+			uuid.New().String(), id, e.staffID, e.earned, e.balance, paid, transactionID); err != nil {
+			p.log.Error("error is while inserting payout item", logger.Error(err))
+			return "", dbError(err)
+		}
+
+		total += paid
+	}
+
+	if _, err = tx.Exec(ctx, `UPDATE payouts SET total = $1 WHERE id = $2`, total, id); err != nil {
+		p.log.Error("error is while updating payout total", logger.Error(err))
+		return "", dbError(err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		p.log.Error("error is while committing payout", logger.Error(err))
+		return "", dbError(err)
+	}
+
+	return id, nil
+}
+
+func (p payoutRepo) GetByID(ctx context.Context, id string) (models.Payout, error) {
+	payout := models.Payout{}
+
+	query := `SELECT id, branch_id, period_from::text, period_to::text, total, created_at
+					FROM payouts WHERE id = $1 AND deleted_at = 0`
+
+	if err := p.db.QueryRow(ctx, query, id).Scan(
+		&payout.ID,
+		&payout.BranchID,
+		&payout.PeriodFrom,
+		&payout.PeriodTo,
+		&payout.Total,
+		&payout.CreatedAt,
+	); err != nil {
+		p.log.Error("error is while selecting payout by id", logger.Error(err))
+		return models.Payout{}, dbError(err)
+	}
+
+	items, err := p.items(ctx, id, "")
+	if err != nil {
+		return models.Payout{}, dbError(err)
+	}
+
+	payout.Items = items
+
+	return payout, nil
+}
+
+func (p payoutRepo) GetList(ctx context.Context, request models.GetListRequest) (models.PayoutsResponse, error) {
+	var (
+		offset  = (request.Page - 1) * request.Limit
+		count   = 0
+		payouts = []models.Payout{}
+	)
+
+	countQuery := `SELECT COUNT(*) FROM payouts WHERE deleted_at = 0 AND ($1 = '' OR branch_id::text = $1)`
+	if err := p.db.QueryRow(ctx, countQuery, request.Search).Scan(&count); err != nil {
+		p.log.Error("error is while scanning count of payouts", logger.Error(err))
+		return models.PayoutsResponse{}, dbError(err)
+	}
+
+	query := `SELECT id, branch_id, period_from::text, period_to::text, total, created_at FROM payouts
+					WHERE deleted_at = 0 AND ($1 = '' OR branch_id::text = $1)`
+	query, args := pagingBy(query, []any{request.Search}, "created_at DESC", request.After, request.Limit, offset)
+
+	rows, err := p.db.Query(ctx, query, args...)
+	if err != nil {
+		p.log.Error("error is while selecting payouts", logger.Error(err))
+		return models.PayoutsResponse{}, dbError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		payout := models.Payout{}
+		if err = rows.Scan(
+			&payout.ID,
+			&payout.BranchID,
+			&payout.PeriodFrom,
+			&payout.PeriodTo,
+			&payout.Total,
+			&payout.CreatedAt,
+		); err != nil {
+			p.log.Error("error is while scanning payout", logger.Error(err))
+			return models.PayoutsResponse{}, dbError(err)
+		}
+
+		payouts = append(payouts, payout)
+	}
+
+	return models.PayoutsResponse{
+		Payouts: payouts,
+		Count:   count,
+	}, nil
+}
+
+func (p payoutRepo) Statement(ctx context.Context, payoutID, staffID string) (models.PayrollStatement, error) {
+	payout, err := p.GetByID(ctx, payoutID)
+	if err != nil {
+		return models.PayrollStatement{}, dbError(err)
+	}
+
+	items, err := p.items(ctx, payoutID, staffID)
+	if err != nil {
+		return models.PayrollStatement{}, dbError(err)
+	}
+
+	if len(items) == 0 {
+		return models.PayrollStatement{}, errs.NotFound("staff %s is not in payout %s", staffID, payoutID)
+	}
+
+	statement := models.PayrollStatement{
+		Payout:       payout,
+		Item:         items[0],
+		Transactions: []models.Transaction{},
+	}
+	statement.Payout.Items = nil
+
+	query := `SELECT id, sale_id, staff_id, transaction_type, source_type, amount, description, created_at, updated_at
+					FROM transactions WHERE staff_id = $1 AND deleted_at = 0
+					AND created_at >= $2::date AND created_at < $3::date + 1
+					ORDER BY created_at`
+
+	rows, err := p.db.Query(ctx, query, staffID, payout.PeriodFrom, payout.PeriodTo)
+	if err != nil {
+		p.log.Error("error is while selecting statement transactions", logger.Error(err))
+		return models.PayrollStatement{}, dbError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			trans               = models.Transaction{}
+			saleID, description sql.NullString
+			updatedAt           sql.NullTime
+		)
+
+		if err = rows.Scan(
+			&trans.ID,
+			&saleID,
+			&trans.StaffID,
+			&trans.TransactionType,
+			&trans.SourceType,
+			&trans.Amount,
+			&description,
+			&trans.CreatedAt,
+			&updatedAt,
+		); err != nil {
+			p.log.Error("error is while scanning statement transaction", logger.Error(err))
+			return models.PayrollStatement{}, dbError(err)
+		}
+
+		trans.SaleID = saleID.String
+		trans.Description = description.String
+		if updatedAt.Valid {
+			trans.UpdatedAt = updatedAt.Time
+		}
+
+		statement.Transactions = append(statement.Transactions, trans)
+	}
+
+	return statement, nil
+}
+
+func (p payoutRepo) items(ctx context.Context, payoutID, staffID string) ([]models.PayoutItem, error) {
+	items := []models.PayoutItem{}
+
+	query := `SELECT pi.id, pi.payout_id, pi.staff_id, COALESCE(s.name, ''), pi.earned, pi.balance_before, pi.paid,
+					COALESCE(pi.transaction_id::text, '')
+				FROM payout_items pi
+				LEFT JOIN staffs s ON s.id = pi.staff_id
+				WHERE pi.payout_id = $1 AND ($2 = '' OR pi.staff_id::text = $2)
+				ORDER BY s.name`
+
+	rows, err := p.db.Query(ctx, query, payoutID, staffID)
+	if err != nil {
+		p.log.Error("error is while selecting payout items", logger.Error(err))
+		return nil, dbError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		item := models.PayoutItem{}
+		if err = rows.Scan(
+			&item.ID,
+			&item.PayoutID,
+			&item.StaffID,
+			&item.StaffName,
+			&item.Earned,
+			&item.BalanceBefore,
+			&item.Paid,
+			&item.TransactionID,
+		); err != nil {
+			p.log.Error("error is while scanning payout item", logger.Error(err))
+			return nil, dbError(err)
+		}
+
+		items = append(items, item)
+	}
+
+	return items, nil
+}
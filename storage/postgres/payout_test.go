@@ -0,0 +1,85 @@
+//go:build integration
+
+package postgres
+
+import (
+	"context"
+	"market/api/models"
+	"market/pkg/errs"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestPayoutRepo(t *testing.T) {
+	ctx := context.Background()
+	repo := testStore.Payout()
+	branchID := newTestBranch(t)
+	paidID := newTestStaff(t, branchID, "cashier")
+	unpaidID := newTestStaff(t, branchID, "shop_assistant")
+	day := today(t)
+
+	if _, err := testStore.Staff().AdjustBalance(ctx, models.StaffBalanceAdjustment{StaffID: paidID, TransactionType: "topup",
+		Amount: 5000, Description: "bonus"}); err != nil {
+		t.Fatalf("adjust balance: %v", err)
+	}
+
+	id, err := repo.Run(ctx, models.CreatePayout{BranchID: branchID, PeriodFrom: day, PeriodTo: day})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	payout, err := repo.GetByID(ctx, id)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if payout.BranchID != branchID || payout.PeriodFrom != day || payout.PeriodTo != day || payout.Total != 5000 || len(payout.Items) != 2 {
+		t.Fatalf("got %+v, want payout of 5000 to two staff for %s", payout, day)
+	}
+
+	items := map[string]models.PayoutItem{}
+	for _, item := range payout.Items {
+		items[item.StaffID] = item
+	}
+	if paid := items[paidID]; paid.Earned != 5000 || paid.BalanceBefore != 5000 || paid.Paid != 5000 || paid.TransactionID == "" {
+		t.Errorf("paid item is %+v, want 5000 earned and paid with a transaction", paid)
+	}
+	if unpaid := items[unpaidID]; unpaid.Earned != 0 || unpaid.Paid != 0 || unpaid.TransactionID != "" {
+		t.Errorf("unpaid item is %+v, want nothing paid without a transaction", unpaid)
+	}
+
+	staff, err := testStore.Staff().StaffByID(ctx, models.PrimaryKey{ID: paidID})
+	if err != nil {
+		t.Fatalf("get staff: %v", err)
+	}
+	if staff.Balance != 0 {
+		t.Errorf("balance after payout is %d, want 0", staff.Balance)
+	}
+
+	// the period is paid once
+	_, err = repo.Run(ctx, models.CreatePayout{BranchID: branchID, PeriodFrom: day, PeriodTo: day})
+	wantCode(t, err, errs.CodeConflict)
+
+	statement, err := repo.Statement(ctx, id, paidID)
+	if err != nil {
+		t.Fatalf("statement: %v", err)
+	}
+	if statement.Payout.ID != id || statement.Payout.Items != nil || statement.Item.StaffID != paidID || len(statement.Transactions) != 2 ||
+		statement.Transactions[0].TransactionType != "topup" || statement.Transactions[1].SourceType != "payout" {
+		t.Errorf("statement is %+v, want the bonus and the payout of %s", statement, paidID)
+	}
+
+	_, err = repo.Statement(ctx, id, uuid.NewString())
+	wantNotFound(t, err)
+
+	_, err = repo.Statement(ctx, uuid.NewString(), paidID)
+	wantNotFound(t, err)
+
+	list, err := repo.GetList(ctx, models.GetListRequest{Page: 1, Limit: 10, Search: branchID})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if list.Count != 1 || len(list.Payouts) != 1 || list.Payouts[0].ID != id || list.Payouts[0].Total != 5000 {
+		t.Errorf("list is %+v, want only %s", list, id)
+	}
+}
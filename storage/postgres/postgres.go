@@ -2,38 +2,28 @@ package postgres
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"market/config"
 	"market/pkg/logger"
+	"market/pkg/metrics"
 	"market/storage"
-	"strings"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 
-	"github.com/golang-migrate/migrate/v4"
-	_ "github.com/golang-migrate/migrate/v4/database"          //database is needed for migration
-	_ "github.com/golang-migrate/migrate/v4/database/postgres" //postgres is used for database
-	_ "github.com/golang-migrate/migrate/v4/source/file"       //file is needed for migration url
 	_ "github.com/lib/pq"
 )
 
 type Store struct {
 	Pool  *pgxpool.Pool
+	db    Querier
 	log   logger.ILogger
 	cfg   config.Config
 }
 
 func New(ctx context.Context, cfg config.Config, log logger.ILogger) (storage.IStorage, error) {
-	url := fmt.Sprintf(
-		`postgres://%s:%s@%s:%s/%s?sslmode=disable`,
-		cfg.PostgresUser,
-		cfg.PostgresPassword,
-		cfg.PostgresHost,
-		cfg.PostgresPort,
-		cfg.PostgresDB,
-	)
-
-	poolConfig, err := pgxpool.ParseConfig(url)
+	poolConfig, err := pgxpool.ParseConfig(databaseURL(cfg))
 	if err != nil {
 		log.Error("error while parsing config", logger.Error(err))
 		return nil, err
@@ -47,42 +37,14 @@ func New(ctx context.Context, cfg config.Config, log logger.ILogger) (storage.IS
 		return nil, err
 	}
 
-	//migration
-	m, err := migrate.New("file://migrations/postgres/", url)
-	if err != nil {
-		log.Error("error while migrating", logger.Error(err))
+	if err = migrateUp(ctx, pool, cfg, log); err != nil {
+		pool.Close()
 		return nil, err
 	}
 
-	log.Info("???? came")
-
-	if err = m.Up(); err != nil {
-		log.Warning("migration up", logger.Error(err))
-		if !strings.Contains(err.Error(), "no change") {
-			fmt.Println("entered")
-			version, dirty, err := m.Version()
-			log.Info("version and dirty", logger.Any("version", version), logger.Any("dirty", dirty))
-			if err != nil {
-				log.Error("err in checking version and dirty", logger.Error(err))
-				return nil, err
-			}
-
-			if dirty {
-				version--
-				if err = m.Force(int(version)); err != nil {
-					log.Error("ERR in making force", logger.Error(err))
-					return nil, err
-				}
-			}
-			log.Warning("WARNING in migrating", logger.Error(err))
-			return nil, err
-		}
-	}
-
-	log.Info("!!!!! came here")
-
 	return &Store{
 		Pool:  pool,
+		db:    pool,
 		log:   log,
 		cfg:   cfg,
 	}, nil
@@ -92,43 +54,159 @@ func (s *Store) Close() {
 	s.Pool.Close()
 }
 
+// Ping checks that the database answers and that the last migration did not fail in the middle.
+func (s *Store) Ping(ctx context.Context) error {
+	if err := s.Pool.Ping(ctx); err != nil {
+		s.log.Error("error while pinging db", logger.Error(err))
+		return err
+	}
+
+	var (
+		version int64
+		dirty   bool
+	)
+	if err := s.db.QueryRow(ctx, `SELECT version, dirty FROM schema_migrations LIMIT 1`).Scan(&version, &dirty); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return errors.New("no migration is applied")
+		}
+		s.log.Error("error while getting migration version", logger.Error(err))
+		return err
+	}
+
+	if dirty {
+		return fmt.Errorf("migration %d is dirty, it failed in the middle", version)
+	}
+
+	return nil
+}
+
+// RegisterMetrics adds stats of the connection pool to registry.
+func (s *Store) RegisterMetrics(registry *metrics.Registry) {
+	gauge := func(name, help string, value func(*pgxpool.Stat) float64) {
+		registry.GaugeFunc(name, help, func() float64 { return value(s.Pool.Stat()) })
+	}
+	counter := func(name, help string, value func(*pgxpool.Stat) float64) {
+		registry.CounterFunc(name, help, func() float64 { return value(s.Pool.Stat()) })
+	}
+
+	gauge("pgxpool_total_conns", "Connections in the pool.",
+		func(stat *pgxpool.Stat) float64 { return float64(stat.TotalConns()) })
+	gauge("pgxpool_acquired_conns", "Connections in use.",
+		func(stat *pgxpool.Stat) float64 { return float64(stat.AcquiredConns()) })
+	gauge("pgxpool_idle_conns", "Idle connections.",
+		func(stat *pgxpool.Stat) float64 { return float64(stat.IdleConns()) })
+	gauge("pgxpool_max_conns", "Most connections the pool opens.",
+		func(stat *pgxpool.Stat) float64 { return float64(stat.MaxConns()) })
+	counter("pgxpool_acquire_total", "Connections acquired from the pool.",
+		func(stat *pgxpool.Stat) float64 { return float64(stat.AcquireCount()) })
+	counter("pgxpool_empty_acquire_total", "Acquires which waited because the pool had no idle connection.",
+		func(stat *pgxpool.Stat) float64 { return float64(stat.EmptyAcquireCount()) })
+	counter("pgxpool_canceled_acquire_total", "Acquires which were canceled while waiting.",
+		func(stat *pgxpool.Stat) float64 { return float64(stat.CanceledAcquireCount()) })
+	counter("pgxpool_acquire_duration_seconds_total", "Time spent acquiring connections.",
+		func(stat *pgxpool.Stat) float64 { return stat.AcquireDuration().Seconds() })
+}
+
+// WithTx runs fn with the store whose repos use one db transaction, which is committed
+// if fn returns nil and rolled back otherwise. Calling WithTx on that store again uses
+// a savepoint, so services can group operations which already use WithTx.
+func (s *Store) WithTx(ctx context.Context, fn func(storage.IStorage) error) error {
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		s.log.Error("error while beginning transaction", logger.Error(err))
+		return dbError(err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err = fn(&Store{Pool: s.Pool, db: tx, log: s.log, cfg: s.cfg}); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		s.log.Error("error while committing transaction", logger.Error(err))
+		return dbError(err)
+	}
+
+	return nil
+}
+
 func (s *Store) StaffTariff() storage.IStaffTariffRepo {
-	return NewStaffTarifRepo(s.Pool, s.log)
+	return NewStaffTarifRepo(s.db, s.log)
 }
 
 func (s *Store) Category() storage.ICategory {
-	return NewCategoryRepo(s.Pool, s.log)
+	return NewCategoryRepo(s.db, s.log)
 }
 
 func (s *Store) Product() storage.IProducts {
-	return NewProductRepo(s.Pool, s.log)
+	return NewProductRepo(s.db, s.log)
 }
 
 func (s *Store) Branch() storage.IBranchStorage {
-	return NewBranchRepo(s.Pool, s.log)
+	return NewBranchRepo(s.db, s.log)
 }
 
 func (s *Store) Sale() storage.ISaleStorage {
-	return NewSaleRepo(s.Pool, s.log)
+	return NewSaleRepo(s.db, s.log)
 }
 
 func (s *Store) Transaction() storage.ITransactionStorage {
-	return NewTransactionRepo(s.Pool, s.log)
+	return NewTransactionRepo(s.db, s.log)
 
 }
 
 func (s *Store) Staff() storage.IStaffRepo {
-	return NewStaffRepo(s.Pool, s.log)
+	return NewStaffRepo(s.db, s.log)
 }
 
 func (s *Store) Repository() storage.IRepositoryRepo {
-	return NewRepositoryRepo(s.Pool, s.log)
+	return NewRepositoryRepo(s.db, s.log)
 }
 
 func (s *Store) Basket() storage.IBasketRepo {
-	return NewBasketRepo(s.Pool, s.log)
+	return NewBasketRepo(s.db, s.log)
 }
 
 func (s *Store) RTransaction() storage.IRepositoryTransactionRepo {
-	return NewRepositoryTransactionRepo(s.Pool, s.log)
+	return NewRepositoryTransactionRepo(s.db, s.log)
+}
+
+func (s *Store) Shift() storage.IShiftStorage {
+	return NewShiftRepo(s.db, s.log)
+}
+
+func (s *Store) Customer() storage.ICustomerStorage {
+	return NewCustomerRepo(s.db, s.log)
+}
+
+func (s *Store) Payout() storage.IPayoutStorage {
+	return NewPayoutRepo(s.db, s.log)
+}
+
+func (s *Store) Report() storage.IReportStorage {
+	return NewReportRepo(s.db, s.log)
+}
+
+func (s *Store) Summary() storage.ISummaryStorage {
+	return NewSummaryRepo(s.db, s.log)
+}
+
+func (s *Store) Idempotency() storage.IIdempotencyStorage {
+	return NewIdempotencyRepo(s.db, s.log)
+}
+
+func (s *Store) Purge() storage.IPurgeStorage {
+	return NewPurgeRepo(s.db, s.log)
+}
+
+func (s *Store) Audit() storage.IAuditStorage {
+	return NewAuditRepo(s.db, s.log)
+}
+
+func (s *Store) Outbox() storage.IOutboxStorage {
+	return NewOutboxRepo(s.db, s.log)
+}
+
+func (s *Store) Webhook() storage.IWebhookStorage {
+	return NewWebhookRepo(s.db, s.log)
 }
@@ -0,0 +1,239 @@
+//go:build integration
+
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"market/api/models"
+	"market/config"
+	"market/pkg/errs"
+	"market/pkg/logger"
+	"market/storage"
+	"os"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	embeddedpostgres "github.com/fergusstrange/embedded-postgres"
+)
+
+// The suite runs the repos against a real postgres which is downloaded and started by
+// embedded-postgres, the migrations from migrations/postgres are applied by New:
+//
+//	go test -tags integration ./storage/postgres
+//
+// POSTGRES_TEST_PORT changes the port it listens on, 54329 by default.
+
+var testStore storage.IStorage
+
+func TestMain(m *testing.M) {
+	os.Exit(runTests(m))
+}
+
+func runTests(m *testing.M) int {
+	port := os.Getenv("POSTGRES_TEST_PORT")
+	if port == "" {
+		port = "54329"
+	}
+
+	portNumber, err := strconv.ParseUint(port, 10, 32)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid POSTGRES_TEST_PORT %q: %v\n", port, err)
+		return 1
+	}
+
+	runtimePath, err := os.MkdirTemp("", "market-postgres-")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error while creating postgres directory:", err)
+		return 1
+	}
+	defer os.RemoveAll(runtimePath)
+
+	cfg := config.Config{
+		PostgresHost:     "localhost",
+		PostgresPort:     port,
+		PostgresUser:     "market",
+		PostgresPassword: "market",
+		PostgresDB:       "market",
+	}
+
+	db := embeddedpostgres.NewDatabase(embeddedpostgres.DefaultConfig().
+		Version(embeddedpostgres.V15).
+		Port(uint32(portNumber)).
+		Username(cfg.PostgresUser).
+		Password(cfg.PostgresPassword).
+		Database(cfg.PostgresDB).
+		RuntimePath(runtimePath))
+	if err = db.Start(); err != nil {
+		fmt.Fprintln(os.Stderr, "error while starting postgres:", err)
+		return 1
+	}
+	defer db.Stop()
+
+	// migrations are read relative to the working directory, as the server does from the repo root
+	if err = os.Chdir("../.."); err != nil {
+		fmt.Fprintln(os.Stderr, "error while changing to the repo root:", err)
+		return 1
+	}
+
+	testStore, err = New(context.Background(), cfg, logger.New("test"))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error while connecting to postgres:", err)
+		return 1
+	}
+	defer testStore.Close()
+
+	return m.Run()
+}
+
+var testSeq atomic.Int64
+
+// unique returns name with a suffix which no other call returns, tests share the database
+// so the rows they search for are told apart by it. The suffix has a fixed width, so that
+// one name is never the start of another, and is short for VARCHAR(30) columns.
+func unique(name string) string {
+	return fmt.Sprintf("%s %04d", name, testSeq.Add(1))
+}
+
+// uniqueNumber returns a number which no other call returns, e.g. for product barcodes.
+func uniqueNumber() int {
+	return 4780000 + int(testSeq.Add(1))
+}
+
+// wantCode fails the test unless err is the domain error with code.
+func wantCode(t *testing.T, err error, code errs.Code) {
+	t.Helper()
+
+	if got := errs.CodeOf(err); got != code {
+		t.Fatalf("got error %v with code %q, want code %q", err, got, code)
+	}
+}
+
+func wantNotFound(t *testing.T, err error) {
+	t.Helper()
+
+	if !errors.Is(err, errs.ErrNotFound) {
+		t.Fatalf("got error %v, want not found", err)
+	}
+}
+
+func newTestBranch(t *testing.T) string {
+	t.Helper()
+
+	id, err := testStore.Branch().Create(context.Background(), models.CreateBranch{Name: unique("Chilonzor"), Address: "Bunyodkor 1"})
+	if err != nil {
+		t.Fatalf("create branch: %v", err)
+	}
+
+	return id
+}
+
+func newTestCategory(t *testing.T) string {
+	t.Helper()
+
+	id, err := testStore.Category().Create(context.Background(), models.CreateCategory{Name: unique("Ichimliklar")})
+	if err != nil {
+		t.Fatalf("create category: %v", err)
+	}
+
+	return id
+}
+
+func newTestProduct(t *testing.T, price int) string {
+	t.Helper()
+
+	id, err := testStore.Product().Create(context.Background(), models.CreateProduct{
+		Name:       unique("Choy"),
+		Price:      price,
+		Barcode:    uniqueNumber(),
+		CategoryID: newTestCategory(t),
+	})
+	if err != nil {
+		t.Fatalf("create product: %v", err)
+	}
+
+	return id
+}
+
+func newTestTariff(t *testing.T) string {
+	t.Helper()
+
+	id, err := testStore.StaffTariff().Create(context.Background(), models.CreateStaffTarif{
+		Name:          unique("Oylik"),
+		TarifType:     "percent",
+		AmountForCash: 5,
+		AmountForCard: 3,
+	})
+	if err != nil {
+		t.Fatalf("create staff tariff: %v", err)
+	}
+
+	return id
+}
+
+func newTestStaff(t *testing.T, branchID, staffType string) string {
+	t.Helper()
+
+	id, err := testStore.Staff().Create(context.Background(), models.CreateStaff{
+		BranchID:  branchID,
+		TariffID:  newTestTariff(t),
+		StaffType: staffType,
+		Name:      unique("Aziz"),
+		BirthDate: "1998-04-12",
+		Login:     fmt.Sprintf("user%04d", testSeq.Add(1)),
+		Password:  "secret123",
+	})
+	if err != nil {
+		t.Fatalf("create staff: %v", err)
+	}
+
+	return id
+}
+
+func newTestSale(t *testing.T, branchID string) string {
+	t.Helper()
+
+	id, err := testStore.Sale().Create(context.Background(), models.CreateSale{BranchID: branchID, ClientName: unique("Anvar")})
+	if err != nil {
+		t.Fatalf("create sale: %v", err)
+	}
+
+	return id
+}
+
+// today is the current date by the db clock, which sets created_at of the rows.
+func today(t *testing.T) string {
+	t.Helper()
+
+	var day string
+	if err := testStore.(*Store).Pool.QueryRow(context.Background(), `SELECT CURRENT_DATE::text`).Scan(&day); err != nil {
+		t.Fatalf("select current date: %v", err)
+	}
+
+	return day
+}
+
+// newTestFinishedSale creates a sale of quantity pieces of the product in the branch for price
+// and finishes it with status, cashierID and assistantID can be empty.
+func newTestFinishedSale(t *testing.T, branchID, productID, cashierID, assistantID, paymentType, status string, quantity, price int) string {
+	t.Helper()
+	ctx := context.Background()
+
+	id, err := testStore.Sale().Create(ctx, models.CreateSale{BranchID: branchID, CashierID: cashierID, ShopAssistantID: assistantID})
+	if err != nil {
+		t.Fatalf("create sale: %v", err)
+	}
+
+	if _, err = testStore.Basket().Create(ctx, models.CreateBasket{SaleID: id, ProductID: productID, Quantity: quantity, Price: price}); err != nil {
+		t.Fatalf("create basket: %v", err)
+	}
+
+	if _, err = testStore.Sale().Update(ctx, models.UpdateSale{ID: id, CashierID: cashierID, ShopAssistantID: assistantID,
+		PaymentType: paymentType, Price: float32(price), Status: status, Version: 1}); err != nil {
+		t.Fatalf("finish sale: %v", err)
+	}
+
+	return id
+}
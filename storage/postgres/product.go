@@ -10,15 +10,15 @@ import (
 	"strconv"
 
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5"
 )
 
 type productRepo struct {
-	db *pgxpool.Pool
+	db Querier
 	log logger.ILogger
 }
 
-func NewProductRepo(db *pgxpool.Pool, log logger.ILogger) storage.IProducts {
+func NewProductRepo(db Querier, log logger.ILogger) storage.IProducts {
 	return productRepo{
 		db: db,
 		log: log,
@@ -36,7 +36,7 @@ func (p productRepo) Create(ctx context.Context, product models.CreateProduct) (
 		product.CategoryID,
 	); err != nil {
 		fmt.Println("error is while inserting data", err.Error())
-		return "", err
+		return "", dbError(err)
 	}
 	return id.String(), nil
 }
@@ -44,7 +44,7 @@ func (p productRepo) Create(ctx context.Context, product models.CreateProduct) (
 func (p productRepo) GetByID(ctx context.Context, id string) (models.Product, error) {
 	var updatedAt, createdAt sql.NullString
 	product := models.Product{}
-	query := `SELECT id, name, price, barcode, category_id, created_at, updated_at 
+	query := `SELECT id, name, price, barcode, category_id, version, created_at, updated_at 
 	FROM products WHERE id = $1 AND deleted_at = 0`
 	if err := p.db.QueryRow(ctx, query, id).Scan(
 		&product.ID,
@@ -52,11 +52,12 @@ func (p productRepo) GetByID(ctx context.Context, id string) (models.Product, er
 		&product.Price,
 		&product.Barcode,
 		&product.CategoryID,
+		&product.Version,
 		&createdAt,
 		&updatedAt,
 	); err != nil {
 		fmt.Println("error is while scanning: ", err.Error())
-		return models.Product{}, err
+		return models.Product{}, dbError(err)
 	}
 
 	if createdAt.Valid {
@@ -82,7 +83,7 @@ func (p productRepo) GetList(ctx context.Context, request models.ProductGetListR
 		createdAt         sql.NullString
 		updatedAt         sql.NullString
 	)
-	countQuery = `SELECT count(1) FROM products WHERE deleted_at = 0 `
+	countQuery = `SELECT count(1) FROM products WHERE ` + deletedFilter(request.IncludeDeleted) + ` `
 
 	if name != "" {
 		countQuery += fmt.Sprintf(` AND name ilike '%%%s%%' `, name)
@@ -94,11 +95,11 @@ func (p productRepo) GetList(ctx context.Context, request models.ProductGetListR
 
 	if err := p.db.QueryRow(ctx, countQuery).Scan(&count); err != nil {
 		fmt.Println("error is while scanning count ....", err.Error())
-		return models.ProductResponse{}, err
+		return models.ProductResponse{}, dbError(err)
 	}
 
-	query = `SELECT  id, name, price, barcode, category_id, created_at, updated_at 
-							FROM products WHERE deleted_at = 0 `
+	query = `SELECT  id, name, price, barcode, category_id, version, created_at, updated_at, deleted_at 
+							FROM products WHERE ` + deletedFilter(request.IncludeDeleted) + ` `
 
 	if name != "" {
 		query += fmt.Sprintf(` AND name ilike '%%%s%%' `, name)
@@ -107,11 +108,11 @@ func (p productRepo) GetList(ctx context.Context, request models.ProductGetListR
 		query += ` AND barcode = ` + strconv.Itoa(barcode)
 	}
 
-	query += ` LIMIT $1 OFFSET $2`
-	rows, err := p.db.Query(ctx, query, request.Limit, offset)
+	query, args := paging(query, request.After, request.Limit, offset)
+	rows, err := p.db.Query(ctx, query, args...)
 	if err != nil {
 		fmt.Println("error is while selecting all products", err.Error())
-		return models.ProductResponse{}, err
+		return models.ProductResponse{}, dbError(err)
 	}
 
 	for rows.Next() {
@@ -122,11 +123,13 @@ func (p productRepo) GetList(ctx context.Context, request models.ProductGetListR
 			&product.Price,
 			&product.Barcode,
 			&product.CategoryID,
+			&product.Version,
 			&createdAt,
 			&updatedAt,
+			&product.DeletedAt,
 		); err != nil {
 			fmt.Println("error is while scanning category", err.Error())
-			return models.ProductResponse{}, err
+			return models.ProductResponse{}, dbError(err)
 		}
 
 		if createdAt.Valid {
@@ -147,24 +150,152 @@ func (p productRepo) GetList(ctx context.Context, request models.ProductGetListR
 }
 
 func (p productRepo) Update(ctx context.Context, product models.UpdateProduct) (string, error) {
-	query := `UPDATE products SET name = $1, price = $2, category_id = $3, updated_at = now() 
-									WHERE id = $4 AND deleted_at = 0`
-	if _, err := p.db.Exec(ctx, query,
-		&product.Name,
-		&product.Price,
-		&product.CategoryID,
-		&product.ID); err != nil {
-		fmt.Println("error is while updating", err.Error())
+	return p.Patch(ctx, models.PatchProduct{
+		ID:         product.ID,
+		Name:       &product.Name,
+		Price:      &product.Price,
+		CategoryID: &product.CategoryID,
+		Version:    product.Version,
+	})
+}
+
+// Patch updates only the given fields of products.
+func (p productRepo) Patch(ctx context.Context, product models.PatchProduct) (string, error) {
+	fields := patch{}
+	set(&fields, "name", product.Name)
+	set(&fields, "price", product.Price)
+	set(&fields, "barcode", product.Barcode)
+	set(&fields, "category_id", product.CategoryID)
+
+	if err := fields.exec(ctx, p.db, "products", product.ID, product.Version); err != nil {
+		fmt.Println("error is while patching product", err.Error())
 		return "", err
 	}
+
 	return product.ID, nil
 }
 
+// Delete soft deletes product which is not in stock and not in baskets of sales in process.
 func (p productRepo) Delete(ctx context.Context, id string) error {
-	query := `UPDATE products SET deleted_at = extract(epoch from current_timestamp) WHERE id = $1`
-	if _, err := p.db.Exec(ctx, query, &id); err != nil {
+	if err := softDelete(ctx, p.db, "products", id,
+		deleteGuard{
+			condition: `EXISTS (SELECT 1 FROM repositories WHERE product_id::text = $1 AND count > 0 AND deleted_at = 0)`,
+			reason:    "product is in stock, it can not be deleted",
+		},
+		deleteGuard{
+			condition: `EXISTS (SELECT 1 FROM baskets b JOIN sales s ON s.id = b.sale_id
+				WHERE b.product_id::text = $1 AND b.deleted_at = 0 AND s.status = 'in_process' AND s.deleted_at = 0)`,
+			reason: "product is in sales in process, it can not be deleted",
+		},
+	); err != nil {
 		fmt.Println("error is while deleting", err.Error())
 		return err
 	}
 	return nil
 }
+
+func (p productRepo) Restore(ctx context.Context, id string) error {
+	if err := restore(ctx, p.db, "products", id); err != nil {
+		fmt.Println("error is while restoring product", err.Error())
+		return err
+	}
+	return nil
+}
+
+// Existing returns which of the given names and barcodes are already taken.
+func (p productRepo) Existing(ctx context.Context, names []string, barcodes []int) ([]string, []int, error) {
+	var (
+		existingNames    = []string{}
+		existingBarcodes = []int{}
+	)
+
+	rows, err := p.db.Query(ctx, `SELECT name FROM products WHERE name = ANY($1)`, names)
+	if err != nil {
+		fmt.Println("error is while selecting existing product names", err.Error())
+		return nil, nil, dbError(err)
+	}
+
+	for rows.Next() {
+		name := ""
+		if err = rows.Scan(&name); err != nil {
+			rows.Close()
+			fmt.Println("error is while scanning existing product name", err.Error())
+			return nil, nil, dbError(err)
+		}
+		existingNames = append(existingNames, name)
+	}
+	rows.Close()
+
+	rows, err = p.db.Query(ctx, `SELECT barcode FROM products WHERE barcode = ANY($1)`, barcodes)
+	if err != nil {
+		fmt.Println("error is while selecting existing product barcodes", err.Error())
+		return nil, nil, dbError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		barcode := 0
+		if err = rows.Scan(&barcode); err != nil {
+			fmt.Println("error is while scanning existing product barcode", err.Error())
+			return nil, nil, dbError(err)
+		}
+		existingBarcodes = append(existingBarcodes, barcode)
+	}
+
+	return existingNames, existingBarcodes, nil
+}
+
+// Import inserts products and their initial stock with COPY in one db transaction
+// and sets the ids of the inserted products and repositories.
+func (p productRepo) Import(ctx context.Context, products []models.ImportProduct) error {
+	var (
+		productRows    = make([][]any, 0, len(products))
+		repositoryRows = [][]any{}
+	)
+
+	for i, product := range products {
+		id := uuid.New()
+		productRows = append(productRows, []any{[16]byte(id), product.Name, product.Price, product.Barcode, product.CategoryID})
+		products[i].ID = id.String()
+
+		for j, stock := range product.Stocks {
+			branchID, err := uuid.Parse(stock.BranchID)
+			if err != nil {
+				return dbError(err)
+			}
+
+			repositoryID := uuid.New()
+			repositoryRows = append(repositoryRows, []any{[16]byte(repositoryID), [16]byte(id), [16]byte(branchID), stock.Count})
+			products[i].Stocks[j].ID = repositoryID.String()
+		}
+	}
+
+	tx, err := p.db.Begin(ctx)
+	if err != nil {
+		fmt.Println("error is while beginning import transaction", err.Error())
+		return dbError(err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err = tx.CopyFrom(ctx,
+		pgx.Identifier{"products"},
+		[]string{"id", "name", "price", "barcode", "category_id"},
+		pgx.CopyFromRows(productRows),
+	); err != nil {
+		fmt.Println("error is while copying products", err.Error())
+		return dbError(err)
+	}
+
+	if len(repositoryRows) > 0 {
+		if _, err = tx.CopyFrom(ctx,
+			pgx.Identifier{"repositories"},
+			[]string{"id", "product_id", "branch_id", "count"},
+			pgx.CopyFromRows(repositoryRows),
+		); err != nil {
+			fmt.Println("error is while copying repositories", err.Error())
+			return dbError(err)
+		}
+	}
+
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,285 @@
+//go:build integration
+
+package postgres
+
+import (
+	"context"
+	"market/api/models"
+	"market/pkg/errs"
+	"market/storage"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestCategoryRepo(t *testing.T) {
+	ctx := context.Background()
+	repo := testStore.Category()
+	parentID := newTestCategory(t)
+	name := unique("Sharbatlar")
+
+	id, err := repo.Create(ctx, models.CreateCategory{Name: name, ParentID: parentID})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	category, err := repo.GetByID(ctx, models.PrimaryKey{ID: id})
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if category.Name != name || category.ParentID != parentID {
+		t.Errorf("got %s with parent %q, want %s with parent %s", category.Name, category.ParentID, name, parentID)
+	}
+
+	// an empty parent makes it a top level category
+	if _, err = repo.Update(ctx, models.UpdateCategory{ID: id, Name: name, Version: category.Version}); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+
+	list, err := repo.GetList(ctx, models.GetListRequest{Page: 1, Limit: 10, Search: name})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if list.Count != 1 || len(list.Categories) != 1 || list.Categories[0].ParentID != "" || list.Categories[0].Version != 2 {
+		t.Errorf("list got %+v, want the category without parent with version 2", list)
+	}
+
+	_, err = repo.Create(ctx, models.CreateCategory{Name: unique("Yetim"), ParentID: uuid.NewString()})
+	wantCode(t, err, errs.CodeValidation)
+
+	if err = repo.Delete(ctx, id); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+
+	_, err = repo.GetByID(ctx, models.PrimaryKey{ID: id})
+	wantNotFound(t, err)
+
+	if err = repo.Restore(ctx, id); err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+
+	if _, err = repo.GetByID(ctx, models.PrimaryKey{ID: id}); err != nil {
+		t.Errorf("get after restore: %v", err)
+	}
+}
+
+func TestProductRepo(t *testing.T) {
+	ctx := context.Background()
+	repo := testStore.Product()
+	categoryID := newTestCategory(t)
+	name := unique("Qahva")
+	barcode := uniqueNumber()
+
+	id, err := repo.Create(ctx, models.CreateProduct{Name: name, Price: 25000, Barcode: barcode, CategoryID: categoryID})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	product, err := repo.GetByID(ctx, id)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if product.Name != name || product.Price != 25000 || product.Barcode != barcode || product.CategoryID != categoryID {
+		t.Errorf("got %+v, want %s for 25000 with barcode %d in %s", product, name, barcode, categoryID)
+	}
+
+	// name and barcode are unique
+	_, err = repo.Create(ctx, models.CreateProduct{Name: name, Price: 1000, Barcode: uniqueNumber(), CategoryID: categoryID})
+	wantCode(t, err, errs.CodeConflict)
+
+	_, err = repo.Create(ctx, models.CreateProduct{Name: unique("Qahva"), Price: 1000, Barcode: barcode, CategoryID: categoryID})
+	wantCode(t, err, errs.CodeConflict)
+
+	_, err = repo.Create(ctx, models.CreateProduct{Name: unique("Qahva"), Price: 1000, Barcode: uniqueNumber(), CategoryID: uuid.NewString()})
+	wantCode(t, err, errs.CodeValidation)
+
+	for _, request := range []models.ProductGetListRequest{
+		{Page: 1, Limit: 10, Name: name},
+		{Page: 1, Limit: 10, Barcode: barcode},
+	} {
+		list, err := repo.GetList(ctx, request)
+		if err != nil {
+			t.Fatalf("list by %+v: %v", request, err)
+		}
+		if list.Count != 1 || len(list.Products) != 1 || list.Products[0].ID != id {
+			t.Errorf("list by %+v got %d products, want the one", request, list.Count)
+		}
+	}
+
+	price := 27000
+	if _, err = repo.Patch(ctx, models.PatchProduct{ID: id, Price: &price, Version: product.Version}); err != nil {
+		t.Fatalf("patch: %v", err)
+	}
+
+	if product, err = repo.GetByID(ctx, id); err != nil {
+		t.Fatalf("get after patch: %v", err)
+	}
+	if product.Price != 27000 || product.Name != name || product.Version != 2 {
+		t.Errorf("after patch got %s for %d with version %d, want %s for 27000 with version 2", product.Name, product.Price, product.Version, name)
+	}
+
+	_, err = repo.Update(ctx, models.UpdateProduct{ID: uuid.NewString(), Name: name, Price: 1, CategoryID: categoryID, Version: 1})
+	wantNotFound(t, err)
+
+	// a product in stock can not be deleted
+	branchID := newTestBranch(t)
+	repositoryID, err := testStore.Repository().Create(ctx, models.CreateRepository{ProductID: id, BranchID: branchID, Count: 2})
+	if err != nil {
+		t.Fatalf("create repository: %v", err)
+	}
+	wantCode(t, repo.Delete(ctx, id), errs.CodeConflict)
+
+	if err = testStore.Repository().Delete(ctx, repositoryID); err != nil {
+		t.Fatalf("delete repository: %v", err)
+	}
+
+	if err = repo.Delete(ctx, id); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+
+	_, err = repo.GetByID(ctx, id)
+	wantNotFound(t, err)
+
+	list, err := repo.GetList(ctx, models.ProductGetListRequest{Page: 1, Limit: 10, Name: name, IncludeDeleted: true})
+	if err != nil {
+		t.Fatalf("list with deleted: %v", err)
+	}
+	if list.Count != 1 || list.Products[0].DeletedAt == 0 {
+		t.Errorf("list with deleted got %+v, want the deleted product", list)
+	}
+
+	if err = repo.Restore(ctx, id); err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+}
+
+func TestProductImport(t *testing.T) {
+	ctx := context.Background()
+	repo := testStore.Product()
+	categoryID := newTestCategory(t)
+	branchID := newTestBranch(t)
+
+	products := []models.ImportProduct{
+		{Name: unique("Non"), Price: 4000, Barcode: uniqueNumber(), CategoryID: categoryID,
+			Stocks: []models.ImportStock{{BranchID: branchID, Count: 30}}},
+		{Name: unique("Sut"), Price: 12000, Barcode: uniqueNumber(), CategoryID: categoryID},
+	}
+
+	names, barcodes, err := repo.Existing(ctx, []string{products[0].Name}, []int{products[1].Barcode})
+	if err != nil {
+		t.Fatalf("existing: %v", err)
+	}
+	if len(names) != 0 || len(barcodes) != 0 {
+		t.Fatalf("got existing %v and %v before import, want none", names, barcodes)
+	}
+
+	if err = repo.Import(ctx, products); err != nil {
+		t.Fatalf("import: %v", err)
+	}
+
+	if products[0].ID == "" || products[1].ID == "" || products[0].Stocks[0].ID == "" {
+		t.Fatalf("import did not set the ids: %+v", products)
+	}
+
+	repository, err := testStore.Repository().GetByID(ctx, models.PrimaryKey{ID: products[0].Stocks[0].ID})
+	if err != nil {
+		t.Fatalf("get imported repository: %v", err)
+	}
+	if repository.ProductID != products[0].ID || repository.BranchID != branchID || repository.Count != 30 {
+		t.Errorf("imported repository is %+v, want 30 of %s in %s", repository, products[0].ID, branchID)
+	}
+
+	if names, barcodes, err = repo.Existing(ctx, []string{products[0].Name}, []int{products[1].Barcode}); err != nil {
+		t.Fatalf("existing: %v", err)
+	}
+	if len(names) != 1 || len(barcodes) != 1 {
+		t.Errorf("got existing %v and %v after import, want both", names, barcodes)
+	}
+
+	// the products and the stock are imported together or not at all
+	failed := []models.ImportProduct{
+		{Name: unique("Qatiq"), Price: 9000, Barcode: uniqueNumber(), CategoryID: categoryID,
+			Stocks: []models.ImportStock{{BranchID: uuid.NewString(), Count: 5}}},
+	}
+	if err = repo.Import(ctx, failed); err == nil {
+		t.Fatal("import with a missing branch succeeded")
+	}
+
+	list, err := repo.GetList(ctx, models.ProductGetListRequest{Page: 1, Limit: 10, Name: failed[0].Name})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if list.Count != 0 {
+		t.Errorf("failed import left %d products", list.Count)
+	}
+}
+
+func TestRepositoryRepo(t *testing.T) {
+	ctx := context.Background()
+	repo := testStore.Repository()
+	branchID := newTestBranch(t)
+	productID := newTestProduct(t, 1500)
+
+	id, err := repo.Create(ctx, models.CreateRepository{ProductID: productID, BranchID: branchID, Count: 10})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	repository, err := repo.GetByID(ctx, models.PrimaryKey{ID: id})
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if repository.ProductID != productID || repository.BranchID != branchID || repository.Count != 10 {
+		t.Errorf("got %+v, want 10 of %s in %s", repository, productID, branchID)
+	}
+
+	count, err := repo.ProductByID(ctx, productID)
+	if err != nil || count != 10 {
+		t.Errorf("product count is %d with error %v, want 10", count, err)
+	}
+
+	if err = testStore.WithTx(ctx, func(store storage.IStorage) error {
+		locked, err := store.Repository().ForUpdate(ctx, branchID, productID)
+		if err != nil {
+			return err
+		}
+
+		count := locked.Count - 4
+		_, err = store.Repository().Patch(ctx, models.PatchRepository{ID: locked.ID, Count: &count, Version: locked.Version})
+		return err
+	}); err != nil {
+		t.Fatalf("take stock: %v", err)
+	}
+
+	list, err := repo.GetList(ctx, models.GetListRequest{Page: 1, Limit: 10, Search: productID})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if list.Count != 1 || len(list.Repositories) != 1 || list.Repositories[0].Count != 6 || list.Repositories[0].Version != 2 {
+		t.Errorf("list got %+v, want 6 left with version 2", list)
+	}
+
+	_, err = repo.ForUpdate(ctx, newTestBranch(t), productID)
+	wantNotFound(t, err)
+
+	_, err = repo.Create(ctx, models.CreateRepository{ProductID: uuid.NewString(), BranchID: branchID, Count: 1})
+	wantCode(t, err, errs.CodeValidation)
+
+	_, err = repo.Create(ctx, models.CreateRepository{ProductID: productID, BranchID: uuid.NewString(), Count: 1})
+	wantCode(t, err, errs.CodeValidation)
+
+	if err = repo.Delete(ctx, id); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+
+	_, err = repo.GetByID(ctx, models.PrimaryKey{ID: id})
+	wantNotFound(t, err)
+
+	if err = repo.Restore(ctx, id); err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+
+	if _, err = repo.GetByID(ctx, models.PrimaryKey{ID: id}); err != nil {
+		t.Errorf("get after restore: %v", err)
+	}
+}
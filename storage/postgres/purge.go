@@ -0,0 +1,81 @@
+package postgres
+
+import (
+	"context"
+	"market/pkg/logger"
+	"market/storage"
+	"strings"
+	"time"
+)
+
+type purgeRepo struct {
+	db  Querier
+	log logger.ILogger
+}
+
+func NewPurgeRepo(db Querier, log logger.ILogger) storage.IPurgeStorage {
+	return purgeRepo{
+		db:  db,
+		log: log,
+	}
+}
+
+// purgeTables are the soft deleted tables with the columns which reference them.
+// Children go first, so that their parents are not referenced when it is their turn.
+var purgeTables = []struct {
+	table      string
+	references []string
+}{
+	{table: "baskets"},
+	{table: "webhooks"}, // webhook_deliveries are removed with their webhook
+	{table: "repository_transactions"},
+	{table: "repositories"},
+	{table: "transactions", references: []string{"payout_items.transaction_id"}},
+	{table: "sales", references: []string{"baskets.sale_id", "transactions.sale_id"}},
+	{table: "customers", references: []string{"sales.customer_id"}},
+	{table: "staffs", references: []string{"transactions.staff_id", "repository_transactions.staff_id",
+		"shifts.cashier_id", "payout_items.staff_id"}},
+	{table: "staff_tarifs", references: []string{"staffs.tariff_id"}},
+	{table: "products", references: []string{"repositories.product_id", "baskets.product_id",
+		"repository_transactions.product_id", "daily_product_summaries.product_id"}},
+	{table: "categories", references: []string{"products.category_id", "categories.parent_id"}},
+	{table: "branches", references: []string{"repositories.branch_id", "customers.branch_id", "sales.branch_id",
+		"staffs.branch_id", "repository_transactions.branch_id", "shifts.branch_id", "payouts.branch_id",
+		"daily_branch_summaries.branch_id", "daily_product_summaries.branch_id"}},
+}
+
+// Purge removes rows which were soft deleted before the given time. Rows which are
+// still referenced by other rows, deleted or not, are kept.
+func (p purgeRepo) Purge(ctx context.Context, before time.Time) (int64, error) {
+	var purged int64
+
+	tx, err := p.db.Begin(ctx)
+	if err != nil {
+		p.log.Error("error is while beginning purge transaction", logger.Error(err))
+		return 0, dbError(err)
+	}
+	defer tx.Rollback(ctx)
+
+	for _, t := range purgeTables {
+		query := `DELETE FROM ` + t.table + ` t WHERE t.deleted_at <> 0 AND t.deleted_at < $1`
+		for _, reference := range t.references {
+			table, column, _ := strings.Cut(reference, ".")
+			query += ` AND NOT EXISTS (SELECT 1 FROM ` + table + ` r WHERE r.` + column + ` = t.id)`
+		}
+
+		tag, err := tx.Exec(ctx, query, before.Unix())
+		if err != nil {
+			p.log.Error("error is while purging "+t.table, logger.Error(err))
+			return 0, dbError(err)
+		}
+
+		purged += tag.RowsAffected()
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		p.log.Error("error is while committing purge", logger.Error(err))
+		return 0, dbError(err)
+	}
+
+	return purged, nil
+}
@@ -0,0 +1,53 @@
+//go:build integration
+
+package postgres
+
+import (
+	"context"
+	"market/api/models"
+	"testing"
+	"time"
+)
+
+func TestPurgeRepo(t *testing.T) {
+	ctx := context.Background()
+	categories := testStore.Category()
+
+	deletedID, referencedID := newTestCategory(t), newTestCategory(t)
+	if _, err := testStore.Product().Create(ctx, models.CreateProduct{Name: unique("Choy"), Price: 1500, Barcode: uniqueNumber(),
+		CategoryID: referencedID}); err != nil {
+		t.Fatalf("create product: %v", err)
+	}
+
+	for _, id := range []string{deletedID, referencedID} {
+		if err := categories.Delete(ctx, id); err != nil {
+			t.Fatalf("delete category: %v", err)
+		}
+	}
+
+	// rows deleted after the cutoff are kept
+	if _, err := testStore.Purge().Purge(ctx, time.Now().Add(-time.Hour)); err != nil {
+		t.Fatalf("purge before the deletion: %v", err)
+	}
+	if err := categories.Restore(ctx, deletedID); err != nil {
+		t.Fatalf("restore after purge before the deletion: %v", err)
+	}
+	if err := categories.Delete(ctx, deletedID); err != nil {
+		t.Fatalf("delete category again: %v", err)
+	}
+
+	purged, err := testStore.Purge().Purge(ctx, time.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatalf("purge: %v", err)
+	}
+	if purged < 1 {
+		t.Errorf("purged %d rows, want at least the deleted category", purged)
+	}
+
+	wantNotFound(t, categories.Restore(ctx, deletedID))
+
+	// a deleted row which is still referenced is kept
+	if err = categories.Restore(ctx, referencedID); err != nil {
+		t.Errorf("restore referenced category: %v", err)
+	}
+}
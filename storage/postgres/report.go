@@ -0,0 +1,242 @@
+package postgres
+
+import (
+	"context"
+	"market/api/models"
+	"market/pkg/logger"
+	"market/storage"
+)
+
+type reportRepo struct {
+	db  Querier
+	log logger.ILogger
+}
+
+func NewReportRepo(db Querier, log logger.ILogger) storage.IReportStorage {
+	return reportRepo{
+		db:  db,
+		log: log,
+	}
+}
+
+// Every report counts only successful sales created between from and to dates
+// (both inclusive), optionally of one branch.
+const salesFilter = `s.status = 'success' AND s.deleted_at = 0
+				AND s.created_at >= $1::date AND s.created_at < $2::date + 1
+				AND ($3 = '' OR s.branch_id::text = $3)`
+
+// summaryFilter is the same filter for daily summary tables, which already
+// count only successful sales.
+const summaryFilter = `d.day BETWEEN $1::date AND $2::date AND ($3 = '' OR d.branch_id::text = $3)`
+
+func (r reportRepo) Revenue(ctx context.Context, request models.ReportRequest) ([]models.RevenueReport, error) {
+	reports := []models.RevenueReport{}
+
+	query := `SELECT to_char(date_trunc($4, d.day), 'YYYY-MM-DD'), d.branch_id, COALESCE(b.name, ''),
+					SUM(d.sales_count), COALESCE(SUM(d.revenue), 0)
+				FROM daily_branch_summaries d
+				LEFT JOIN branches b ON b.id = d.branch_id
+				WHERE ` + summaryFilter + `
+				GROUP BY 1, d.branch_id, b.name
+				HAVING SUM(d.sales_count) > 0
+				ORDER BY 1, b.name`
+
+	rows, err := r.db.Query(ctx, query, request.From, request.To, request.BranchID, request.Group)
+	if err != nil {
+		r.log.Error("error is while selecting revenue report", logger.Error(err))
+		return nil, dbError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		report := models.RevenueReport{}
+		if err = rows.Scan(
+			&report.Period,
+			&report.BranchID,
+			&report.BranchName,
+			&report.SalesCount,
+			&report.Revenue,
+		); err != nil {
+			r.log.Error("error is while scanning revenue report", logger.Error(err))
+			return nil, dbError(err)
+		}
+
+		reports = append(reports, report)
+	}
+
+	return reports, nil
+}
+
+func (r reportRepo) TopProducts(ctx context.Context, request models.ReportRequest) ([]models.ProductReport, error) {
+	reports := []models.ProductReport{}
+
+	orderBy := "quantity"
+	if request.OrderBy == "revenue" {
+		orderBy = "revenue"
+	}
+
+	query := `SELECT p.id, p.name, SUM(d.quantity) AS quantity, SUM(d.revenue) AS revenue
+				FROM daily_product_summaries d
+				JOIN products p ON p.id = d.product_id
+				WHERE ` + summaryFilter + `
+				GROUP BY p.id, p.name
+				ORDER BY ` + orderBy + ` DESC
+				LIMIT $4`
+
+	rows, err := r.db.Query(ctx, query, request.From, request.To, request.BranchID, request.Limit)
+	if err != nil {
+		r.log.Error("error is while selecting top products report", logger.Error(err))
+		return nil, dbError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		report := models.ProductReport{}
+		if err = rows.Scan(
+			&report.ProductID,
+			&report.Name,
+			&report.Quantity,
+			&report.Revenue,
+		); err != nil {
+			r.log.Error("error is while scanning top products report", logger.Error(err))
+			return nil, dbError(err)
+		}
+
+		reports = append(reports, report)
+	}
+
+	return reports, nil
+}
+
+func (r reportRepo) StaffSales(ctx context.Context, request models.ReportRequest) ([]models.StaffSalesReport, error) {
+	reports := []models.StaffSalesReport{}
+
+	query := `SELECT x.staff_id, COALESCE(st.name, ''), x.role, COUNT(*), COALESCE(SUM(x.price), 0)
+				FROM (
+					SELECT s.cashier_id AS staff_id, 'cashier' AS role, s.price FROM sales s WHERE ` + salesFilter + `
+					UNION ALL
+					SELECT s.shop_assistant_id, 'shop_assistant', s.price FROM sales s WHERE ` + salesFilter + `
+				) x
+				LEFT JOIN staffs st ON st.id::text = x.staff_id
+				WHERE x.staff_id IS NOT NULL AND x.staff_id <> ''
+				GROUP BY x.staff_id, st.name, x.role
+				ORDER BY 5 DESC`
+
+	rows, err := r.db.Query(ctx, query, request.From, request.To, request.BranchID)
+	if err != nil {
+		r.log.Error("error is while selecting staff sales report", logger.Error(err))
+		return nil, dbError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		report := models.StaffSalesReport{}
+		if err = rows.Scan(
+			&report.StaffID,
+			&report.Name,
+			&report.Role,
+			&report.SalesCount,
+			&report.Total,
+		); err != nil {
+			r.log.Error("error is while scanning staff sales report", logger.Error(err))
+			return nil, dbError(err)
+		}
+
+		reports = append(reports, report)
+	}
+
+	return reports, nil
+}
+
+func (r reportRepo) PaymentTypes(ctx context.Context, request models.ReportRequest) ([]models.PaymentTypeReport, error) {
+	reports := []models.PaymentTypeReport{}
+
+	query := `SELECT d.payment_type, SUM(d.sales_count), COALESCE(SUM(d.revenue), 0)
+				FROM daily_branch_summaries d
+				WHERE ` + summaryFilter + `
+				GROUP BY 1
+				HAVING SUM(d.sales_count) > 0
+				ORDER BY 1`
+
+	rows, err := r.db.Query(ctx, query, request.From, request.To, request.BranchID)
+	if err != nil {
+		r.log.Error("error is while selecting payment types report", logger.Error(err))
+		return nil, dbError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		report := models.PaymentTypeReport{}
+		if err = rows.Scan(
+			&report.PaymentType,
+			&report.SalesCount,
+			&report.Total,
+		); err != nil {
+			r.log.Error("error is while scanning payment types report", logger.Error(err))
+			return nil, dbError(err)
+		}
+
+		reports = append(reports, report)
+	}
+
+	return reports, nil
+}
+
+// StaffStats counts finished sales where staff was the cashier or the shop assistant,
+// a sale where staff was both is counted once in revenue.
+func (r reportRepo) StaffStats(ctx context.Context, staffID string, request models.ReportRequest) (models.StaffStats, error) {
+	var (
+		stats    = models.StaffStats{StaffID: staffID, From: request.From, To: request.To}
+		finished int
+		items    int
+	)
+
+	query := `WITH staff_sales AS (
+					SELECT id, price, status, cashier_id = $1 AS as_cashier, shop_assistant_id = $1 AS as_assistant
+					FROM sales
+					WHERE deleted_at = 0 AND (cashier_id = $1 OR shop_assistant_id = $1)
+						AND created_at >= $2::date AND created_at < $3::date + 1
+				)
+				SELECT
+					COUNT(*) FILTER (WHERE as_assistant AND status = 'success'),
+					COUNT(*) FILTER (WHERE as_cashier AND status = 'success'),
+					COALESCE(SUM(price) FILTER (WHERE status = 'success'), 0),
+					COUNT(*) FILTER (WHERE status = 'cancel'),
+					COUNT(*) FILTER (WHERE status IN ('success', 'cancel')),
+					(SELECT COALESCE(SUM(b.quantity), 0) FROM baskets b
+						JOIN staff_sales ss ON ss.id = b.sale_id
+						WHERE ss.status = 'success' AND b.deleted_at = 0)
+				FROM staff_sales`
+
+	if err := r.db.QueryRow(ctx, query, staffID, request.From, request.To).Scan(
+		&stats.SalesAsAssistant,
+		&stats.SalesAsCashier,
+		&stats.Revenue,
+		&stats.CancelledSales,
+		&finished,
+		&items,
+	); err != nil {
+		r.log.Error("error is while selecting staff stats", logger.Error(err))
+		return models.StaffStats{}, dbError(err)
+	}
+
+	commissionQuery := `SELECT COALESCE(SUM(amount), 0) FROM transactions
+				WHERE staff_id = $1 AND source_type = 'sales' AND transaction_type = 'topup' AND deleted_at = 0
+					AND created_at >= $2::date AND created_at < $3::date + 1`
+
+	if err := r.db.QueryRow(ctx, commissionQuery, staffID, request.From, request.To).Scan(&stats.Commissions); err != nil {
+		r.log.Error("error is while selecting staff commissions", logger.Error(err))
+		return models.StaffStats{}, dbError(err)
+	}
+
+	if finished > 0 {
+		stats.CancellationRate = float64(stats.CancelledSales) / float64(finished)
+	}
+
+	if successful := finished - stats.CancelledSales; successful > 0 {
+		stats.AverageBasketSize = float64(items) / float64(successful)
+		stats.AverageSaleRevenue = stats.Revenue / float64(successful)
+	}
+
+	return stats, nil
+}
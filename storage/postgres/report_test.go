@@ -0,0 +1,95 @@
+//go:build integration
+
+package postgres
+
+import (
+	"context"
+	"market/api/models"
+	"testing"
+)
+
+func TestReportRepo(t *testing.T) {
+	ctx := context.Background()
+	repo := testStore.Report()
+	branchID := newTestBranch(t)
+	tea, coffee := newTestProduct(t, 1500), newTestProduct(t, 4000)
+	cashierID, assistantID := newTestStaff(t, branchID, "cashier"), newTestStaff(t, branchID, "shop_assistant")
+	day := today(t)
+
+	sales := []string{
+		newTestFinishedSale(t, branchID, tea, cashierID, assistantID, "card", "success", 2, 3000),
+		newTestFinishedSale(t, branchID, coffee, cashierID, "", "cash", "success", 1, 4000),
+		newTestFinishedSale(t, branchID, tea, cashierID, "", "cash", "cancel", 1, 1500),
+	}
+	for _, id := range sales {
+		if err := testStore.Summary().ApplySale(ctx, id); err != nil {
+			t.Fatalf("apply sale: %v", err)
+		}
+	}
+
+	request := models.ReportRequest{BranchID: branchID, From: day, To: day, Group: "day", Limit: 10}
+
+	revenue, err := repo.Revenue(ctx, request)
+	if err != nil {
+		t.Fatalf("revenue: %v", err)
+	}
+	if len(revenue) != 1 || revenue[0].Period != day || revenue[0].BranchID != branchID || revenue[0].SalesCount != 2 || revenue[0].Revenue != 7000 {
+		t.Errorf("revenue is %+v, want 2 sales for 7000 on %s", revenue, day)
+	}
+
+	paymentTypes, err := repo.PaymentTypes(ctx, request)
+	if err != nil {
+		t.Fatalf("payment types: %v", err)
+	}
+	if len(paymentTypes) != 2 || paymentTypes[0] != (models.PaymentTypeReport{PaymentType: "card", SalesCount: 1, Total: 3000}) ||
+		paymentTypes[1] != (models.PaymentTypeReport{PaymentType: "cash", SalesCount: 1, Total: 4000}) {
+		t.Errorf("payment types are %+v, want one card sale for 3000 and one cash for 4000", paymentTypes)
+	}
+
+	// cancelled sales are not counted
+	top, err := repo.TopProducts(ctx, request)
+	if err != nil {
+		t.Fatalf("top products: %v", err)
+	}
+	if len(top) != 2 || top[0].ProductID != tea || top[0].Quantity != 2 || top[1].ProductID != coffee {
+		t.Errorf("top products by quantity are %+v, want tea then coffee", top)
+	}
+
+	request.OrderBy = "revenue"
+	if top, err = repo.TopProducts(ctx, request); err != nil {
+		t.Fatalf("top products by revenue: %v", err)
+	}
+	if len(top) != 2 || top[0].ProductID != coffee || top[0].Revenue != 4000 {
+		t.Errorf("top products by revenue are %+v, want coffee first", top)
+	}
+
+	staffSales, err := repo.StaffSales(ctx, request)
+	if err != nil {
+		t.Fatalf("staff sales: %v", err)
+	}
+	want := []models.StaffSalesReport{
+		{StaffID: cashierID, Role: "cashier", SalesCount: 2, Total: 7000},
+		{StaffID: assistantID, Role: "shop_assistant", SalesCount: 1, Total: 3000},
+	}
+	if len(staffSales) != len(want) {
+		t.Fatalf("staff sales are %+v, want %+v", staffSales, want)
+	}
+	for i, report := range staffSales {
+		report.Name = ""
+		if report != want[i] {
+			t.Errorf("staff sales %d is %+v, want %+v", i, report, want[i])
+		}
+	}
+
+	stats, err := repo.StaffStats(ctx, cashierID, request)
+	if err != nil {
+		t.Fatalf("staff stats: %v", err)
+	}
+	if stats.SalesAsCashier != 2 || stats.SalesAsAssistant != 0 || stats.Revenue != 7000 || stats.CancelledSales != 1 ||
+		stats.AverageBasketSize != 1.5 || stats.AverageSaleRevenue != 3500 {
+		t.Errorf("cashier stats are %+v, want 2 sales for 7000, 1 cancelled and 1.5 items a sale", stats)
+	}
+	if rate := stats.CancellationRate; rate < 0.33 || rate > 0.34 {
+		t.Errorf("cancellation rate is %v, want 1 of 3", rate)
+	}
+}
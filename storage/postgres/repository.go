@@ -10,15 +10,14 @@ import (
 	"market/storage"
 
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type repositoryRepo struct {
-	DB *pgxpool.Pool
+	DB Querier
 	log logger.ILogger
 }
 
-func NewRepositoryRepo(DB *pgxpool.Pool, log logger.ILogger) storage.IRepositoryRepo {
+func NewRepositoryRepo(DB Querier, log logger.ILogger) storage.IRepositoryRepo {
 	return &repositoryRepo{
 		DB: DB,
 		log: log,
@@ -37,7 +36,7 @@ func (s *repositoryRepo) Create(ctx context.Context, repository models.CreateRep
 		repository.Count,
 	); err != nil {
 		log.Println("Error while inserting data:", err)
-		return "", err
+		return "", dbError(err)
 	}
 
 	return id.String(), nil
@@ -46,18 +45,46 @@ func (s *repositoryRepo) Create(ctx context.Context, repository models.CreateRep
 func (s *repositoryRepo) GetByID(ctx context.Context, id models.PrimaryKey) (models.Repository, error) {
 	var updatedAt sql.NullTime
 	repository := models.Repository{}
-	query := `SELECT id, product_id, branch_id, count, created_at, updated_at FROM repositories WHERE id = $1`
+	query := `SELECT id, product_id, branch_id, count, version, created_at, updated_at FROM repositories WHERE id = $1 AND deleted_at = 0`
 	err := s.DB.QueryRow(ctx, query, id.ID).Scan(
 		&repository.ID,
 		&repository.ProductID,
 		&repository.BranchID,
 		&repository.Count,
+		&repository.Version,
 		&repository.CreatedAt,
 		&updatedAt,
 	)
 	if err != nil {
 		log.Println("Error while selecting repository by ID:", err)
-		return models.Repository{}, err
+		return models.Repository{}, dbError(err)
+	}
+
+	if updatedAt.Valid {
+		repository.UpdatedAt = updatedAt.Time
+	}
+
+	return repository, nil
+}
+
+// ForUpdate locks the row, so that checkouts which take the same product wait for each other.
+func (s *repositoryRepo) ForUpdate(ctx context.Context, branchID, productID string) (models.Repository, error) {
+	var updatedAt sql.NullTime
+	repository := models.Repository{}
+	query := `SELECT id, product_id, branch_id, count, version, created_at, updated_at FROM repositories
+				WHERE branch_id = $1 AND product_id = $2 AND deleted_at = 0 LIMIT 1 FOR UPDATE`
+	err := s.DB.QueryRow(ctx, query, branchID, productID).Scan(
+		&repository.ID,
+		&repository.ProductID,
+		&repository.BranchID,
+		&repository.Count,
+		&repository.Version,
+		&repository.CreatedAt,
+		&updatedAt,
+	)
+	if err != nil {
+		s.log.Error("error is while selecting repository for update", logger.Error(err))
+		return models.Repository{}, dbError(err)
 	}
 
 	if updatedAt.Valid {
@@ -70,13 +97,13 @@ func (s *repositoryRepo) GetByID(ctx context.Context, id models.PrimaryKey) (mod
 func (s *repositoryRepo) ProductByID(ctx context.Context, id string) (int, error) {
 	var count int
 
-	query := `SELECT count FROM repositories WHERE product_id = $1`
+	query := `SELECT count FROM repositories WHERE product_id = $1 AND deleted_at = 0`
 	err := s.DB.QueryRow(ctx, query, id).Scan(
 		&count,
 	)
 	if err != nil {
 		fmt.Println("Error while selecting product count: ", err)
-		return count, err
+		return count, dbError(err)
 	}
 
 	return count, nil
@@ -91,7 +118,7 @@ func (s *repositoryRepo) GetList(ctx context.Context, request models.GetListRequ
 		updatedAt  		  sql.NullTime
 	)
 
-	countQuery := `SELECT COUNT(*) FROM repositories WHERE deleted_at IS NULL`
+	countQuery := `SELECT COUNT(*) FROM repositories WHERE ` + deletedFilter(request.IncludeDeleted)
 	if request.Search != "" {
 		countQuery += fmt.Sprintf(` AND product_id = '%s'`, request.Search)
 	}
@@ -99,20 +126,20 @@ func (s *repositoryRepo) GetList(ctx context.Context, request models.GetListRequ
 	err := s.DB.QueryRow(ctx, countQuery).Scan(&count)
 	if err != nil {
 		log.Println("Error while scanning count of repositories:", err)
-		return models.RepositoriesResponse{}, err
+		return models.RepositoriesResponse{}, dbError(err)
 	}
 
-	query := `SELECT id, product_id, branch_id, count, created_at, updated_at 
-			  FROM repositories WHERE deleted_at IS NULL`
+	query := `SELECT id, product_id, branch_id, count, version, created_at, updated_at, deleted_at 
+			  FROM repositories WHERE ` + deletedFilter(request.IncludeDeleted)
 	if request.Search != "" {
 		query += fmt.Sprintf(` AND product_id = '%s'`, request.Search)
 	}
-	query += ` LIMIT $1 OFFSET $2`
+	query, args := paging(query, request.After, request.Limit, offset)
 
-	rows, err := s.DB.Query(ctx, query, request.Limit, offset)
+	rows, err := s.DB.Query(ctx, query, args...)
 	if err != nil {
 		log.Println("Error while querying repositories:", err)
-		return models.RepositoriesResponse{}, err
+		return models.RepositoriesResponse{}, dbError(err)
 	}
 	defer rows.Close()
 
@@ -123,12 +150,14 @@ func (s *repositoryRepo) GetList(ctx context.Context, request models.GetListRequ
 			&repository.ProductID,
 			&repository.BranchID,
 			&repository.Count,
+			&repository.Version,
 			&repository.CreatedAt,
 			&updatedAt,
+			&repository.DeletedAt,
 		)
 		if err != nil {
 			log.Println("Error while scanning row of repositories:", err)
-			return models.RepositoriesResponse{}, err
+			return models.RepositoriesResponse{}, dbError(err)
 		}
 
 		if updatedAt.Valid {
@@ -145,16 +174,24 @@ func (s *repositoryRepo) GetList(ctx context.Context, request models.GetListRequ
 }
 
 func (s *repositoryRepo) Update(ctx context.Context, repository models.UpdateRepository) (string, error) {
-	query := `UPDATE repositories SET branch_id = $1, product_id = $2, count = $3, updated_at = NOW() WHERE id = $4`
+	return s.Patch(ctx, models.PatchRepository{
+		ID:        repository.ID,
+		BranchID:  &repository.BranchID,
+		ProductID: &repository.ProductID,
+		Count:     &repository.Count,
+		Version:   repository.Version,
+	})
+}
 
-	_, err := s.DB.Exec(ctx, query,
-		repository.BranchID,
-		repository.ProductID,
-		repository.Count,
-		repository.ID,
-	)
-	if err != nil {
-		log.Println("Error while updating Repository:", err)
+// Patch updates only the given fields of repositories.
+func (s *repositoryRepo) Patch(ctx context.Context, repository models.PatchRepository) (string, error) {
+	fields := patch{}
+	set(&fields, "branch_id", repository.BranchID)
+	set(&fields, "product_id", repository.ProductID)
+	set(&fields, "count", repository.Count)
+
+	if err := fields.exec(ctx, s.DB, "repositories", repository.ID, repository.Version); err != nil {
+		log.Println("Error while patching Repository:", err)
 		return "", err
 	}
 
@@ -167,6 +204,15 @@ func (s *repositoryRepo) Delete(ctx context.Context, id string) error {
 	_, err := s.DB.Exec(ctx, query, id)
 	if err != nil {
 		log.Println("Error while deleting Repository :", err)
+		return dbError(err)
+	}
+
+	return nil
+}
+
+func (s *repositoryRepo) Restore(ctx context.Context, id string) error {
+	if err := restore(ctx, s.DB, "repositories", id); err != nil {
+		log.Println("Error while restoring Repository :", err)
 		return err
 	}
 
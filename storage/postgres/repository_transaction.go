@@ -11,15 +11,14 @@ import (
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type repositoryTransactionRepo struct {
-	DB  *pgxpool.Pool
+	DB  Querier
 	log logger.ILogger
 }
 
-func NewRepositoryTransactionRepo(DB *pgxpool.Pool, log logger.ILogger) storage.IRepositoryTransactionRepo {
+func NewRepositoryTransactionRepo(DB Querier, log logger.ILogger) storage.IRepositoryTransactionRepo {
 	return &repositoryTransactionRepo{
 		DB:  DB,
 		log: log,
@@ -42,7 +41,7 @@ func (s *repositoryTransactionRepo) Create(ctx context.Context, rtransaction mod
 		createdAt,
 	); err != nil {
 		log.Println("Error while inserting data:", err)
-		return "", err
+		return "", dbError(err)
 	}
 
 	return id, nil
@@ -51,7 +50,7 @@ func (s *repositoryTransactionRepo) Create(ctx context.Context, rtransaction mod
 func (s *repositoryTransactionRepo) GetByID(ctx context.Context, id models.PrimaryKey) (models.RepositoryTransaction, error) {
 	var updatedAt sql.NullTime
 	rtransaction := models.RepositoryTransaction{}
-	query := `SELECT id, staff_id, product_id, repository_transaction_type, price, quantity, created_at, updated_at FROM repository_transactions WHERE id = $1`
+	query := `SELECT id, staff_id, product_id, repository_transaction_type, price, quantity, version, created_at, updated_at FROM repository_transactions WHERE id = $1`
 
 	err := s.DB.QueryRow(ctx, query, id.ID).Scan(
 		&rtransaction.ID,
@@ -60,11 +59,13 @@ func (s *repositoryTransactionRepo) GetByID(ctx context.Context, id models.Prima
 		&rtransaction.RepositoryTransactionType,
 		&rtransaction.Price,
 		&rtransaction.Quantity,
+		&rtransaction.Version,
+		&rtransaction.CreatedAt,
 		&updatedAt,
 	)
 	if err != nil {
 		log.Println("Error while selecting repository by ID:", err)
-		return models.RepositoryTransaction{}, err
+		return models.RepositoryTransaction{}, dbError(err)
 	}
 
 	if updatedAt.Valid {
@@ -84,27 +85,27 @@ func (s *repositoryTransactionRepo) GetList(ctx context.Context, request models.
 		updatedAt         sql.NullTime
 	)
 
-	countQuery = `SELECT COUNT(*) FROM repository_transactions where deleted_at = 0 `
+	countQuery = `SELECT COUNT(*) FROM repository_transactions WHERE ` + deletedFilter(request.IncludeDeleted) + ` `
 	if request.Search != "" {
-		countQuery += fmt.Sprintf(`WHERE quantity ILIKE '%%%s%%' or price ilike '%%%s%%'`, request.Search, request.Search)
+		countQuery += fmt.Sprintf(` AND (quantity::text ILIKE '%%%s%%' or price::text ilike '%%%s%%')`, request.Search, request.Search)
 	}
 
 	err := s.DB.QueryRow(ctx, countQuery).Scan(&count)
 	if err != nil {
 		log.Println("Error while scanning count of repository_transactions:", err)
-		return models.RepositoryTransactionsResponse{}, err
+		return models.RepositoryTransactionsResponse{}, dbError(err)
 	}
 
-	query = `SELECT id, staff_id, product_id, repository_transaction_type, price, quantity, created_at, updated_at FROM repository_transactions WHERE deleted_at = 0 `
+	query = `SELECT id, staff_id, product_id, repository_transaction_type, price, quantity, version, created_at, updated_at, deleted_at FROM repository_transactions WHERE ` + deletedFilter(request.IncludeDeleted) + ` `
 	if request.Search != "" {
-		query += fmt.Sprintf(` WHERE quantity ILIKE '%%%s%%' or price ilike '%%%s%%'`, request.Search, request.Search)
+		query += fmt.Sprintf(` AND (quantity::text ILIKE '%%%s%%' or price::text ilike '%%%s%%')`, request.Search, request.Search)
 	}
-	query += ` LIMIT $1 OFFSET $2`
+	query, args := paging(query, request.After, request.Limit, offset)
 
-	rows, err := s.DB.Query(ctx, query, request.Limit, offset)
+	rows, err := s.DB.Query(ctx, query, args...)
 	if err != nil {
 		log.Println("Error while querying repository_transactions:", err)
-		return models.RepositoryTransactionsResponse{}, err
+		return models.RepositoryTransactionsResponse{}, dbError(err)
 	}
 	defer rows.Close()
 
@@ -117,11 +118,14 @@ func (s *repositoryTransactionRepo) GetList(ctx context.Context, request models.
 			&rtransaction.RepositoryTransactionType,
 			&rtransaction.Price,
 			&rtransaction.Quantity,
+			&rtransaction.Version,
+			&rtransaction.CreatedAt,
 			&updatedAt,
+			&rtransaction.DeletedAt,
 		)
 		if err != nil {
 			log.Println("Error while scanning row of repository_transactions:", err)
-			return models.RepositoryTransactionsResponse{}, err
+			return models.RepositoryTransactionsResponse{}, dbError(err)
 		}
 
 		if updatedAt.Valid {
@@ -138,18 +142,28 @@ func (s *repositoryTransactionRepo) GetList(ctx context.Context, request models.
 }
 
 func (s *repositoryTransactionRepo) Update(ctx context.Context, rtransaction models.UpdateRepositoryTransaction) (string, error) {
-	query := `UPDATE repository_transactions SET staff_id = $1, product_id = $2, repository_transaction_type = $3, price = $4, quantity = $5, updated_at = NOW() WHERE id = $6`
+	return s.Patch(ctx, models.PatchRepositoryTransaction{
+		ID:                        rtransaction.ID,
+		StaffID:                   &rtransaction.StaffID,
+		ProductID:                 &rtransaction.ProductID,
+		RepositoryTransactionType: &rtransaction.RepositoryTransactionType,
+		Price:                     &rtransaction.Price,
+		Quantity:                  &rtransaction.Quantity,
+		Version:                   rtransaction.Version,
+	})
+}
 
-	_, err := s.DB.Exec(ctx, query,
-		&rtransaction.StaffID,
-		&rtransaction.ProductID,
-		&rtransaction.RepositoryTransactionType,
-		&rtransaction.Price,
-		&rtransaction.Quantity,
-		&rtransaction.ID,
-	)
-	if err != nil {
-		log.Println("Error while repository_transactions Repository :", err)
+// Patch updates only the given fields of repository transactions.
+func (s *repositoryTransactionRepo) Patch(ctx context.Context, rtransaction models.PatchRepositoryTransaction) (string, error) {
+	fields := patch{}
+	set(&fields, "staff_id", rtransaction.StaffID)
+	set(&fields, "product_id", rtransaction.ProductID)
+	set(&fields, "repository_transaction_type", rtransaction.RepositoryTransactionType)
+	set(&fields, "price", rtransaction.Price)
+	set(&fields, "quantity", rtransaction.Quantity)
+
+	if err := fields.exec(ctx, s.DB, "repository_transactions", rtransaction.ID, rtransaction.Version); err != nil {
+		log.Println("Error while patching repository_transactions:", err)
 		return "", err
 	}
 
@@ -162,6 +176,15 @@ func (s *repositoryTransactionRepo) Delete(ctx context.Context, id string) error
 	_, err := s.DB.Exec(ctx, query, id)
 	if err != nil {
 		log.Println("Error while deleting repository_transactions ", err)
+		return dbError(err)
+	}
+
+	return nil
+}
+
+func (s *repositoryTransactionRepo) Restore(ctx context.Context, id string) error {
+	if err := restore(ctx, s.DB, "repository_transactions", id); err != nil {
+		log.Println("Error while restoring repository_transactions ", err)
 		return err
 	}
 
@@ -9,15 +9,14 @@ import (
 	"market/storage"
 
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type saleRepo struct {
-	db *pgxpool.Pool
+	db Querier
 	log logger.ILogger
 }
 
-func NewSaleRepo(db *pgxpool.Pool, log logger.ILogger) storage.ISaleStorage {
+func NewSaleRepo(db Querier, log logger.ILogger) storage.ISaleStorage {
 	return saleRepo{
 		db: db,
 		log: log,
@@ -26,17 +25,19 @@ func NewSaleRepo(db *pgxpool.Pool, log logger.ILogger) storage.ISaleStorage {
 
 func (s saleRepo) Create(ctx context.Context, sale models.CreateSale) (string, error) {
 	id := uuid.New()
-	query := `INSERT INTO sales (id, branch_id, shop_assistant_id, cashier_id, client_name)
-								VALUES($1, $2, $3, $4, $5)`
+	query := `INSERT INTO sales (id, branch_id, shop_assistant_id, cashier_id, client_name, customer_id, shift_id)
+								VALUES($1, $2, $3, $4, $5, NULLIF($6, '')::uuid, NULLIF($7, '')::uuid)`
 
 	if _, err := s.db.Exec(ctx, query, id,
 		sale.BranchID,
 		sale.ShopAssistantID,
 		sale.CashierID,
 		sale.ClientName,
+		sale.CustomerID,
+		sale.ShiftID,
 		); err != nil {
 		fmt.Println("error is while inserting sale data", err.Error())
-		return "", err
+		return "", dbError(err)
 	}
 	return id.String(), nil
 }
@@ -44,28 +45,35 @@ func (s saleRepo) Create(ctx context.Context, sale models.CreateSale) (string, e
 func (s saleRepo) GetByID(ctx context.Context, id string) (models.Sale, error) {
 	var (
 		updatedAt sql.NullTime
-		paymentType sql.NullString
+		paymentType, customerID sql.NullString
+		shopAssistantID, cashierID, clientName sql.NullString
 	)
 	sale := models.Sale{}
 	query := `SELECT id, branch_id, shop_assistant_id, cashier_id, payment_type, price, status, client_name, 
-					created_at, updated_at FROM sales WHERE id = $1 and deleted_at = 0`
+					customer_id, COALESCE(shift_id::text, ''), version, created_at, updated_at FROM sales WHERE id = $1 and deleted_at = 0`
 
 	if err := s.db.QueryRow(ctx, query, id).Scan(
 		&sale.ID,
 		&sale.BranchID,
-		&sale.ShopAssistantID,
-		&sale.CashierID,
+		&shopAssistantID,
+		&cashierID,
 		&paymentType,
 		&sale.Price,
 		&sale.Status,
-		&sale.ClientName,
+		&clientName,
+		&customerID,
+		&sale.ShiftID,
+		&sale.Version,
 		&sale.CreatedAt,
 		&updatedAt,
 		); err != nil {
 		fmt.Println("error is while selecting by id", err.Error())
-		return models.Sale{}, err
+		return models.Sale{}, dbError(err)
 	}
 
+	sale.ShopAssistantID = shopAssistantID.String
+	sale.CashierID = cashierID.String
+	sale.ClientName = clientName.String
 	if updatedAt.Valid {
 		sale.UpdatedAt = updatedAt.Time
 	}
@@ -74,6 +82,10 @@ func (s saleRepo) GetByID(ctx context.Context, id string) (models.Sale, error) {
 		sale.PaymentType = paymentType.String
 	}
 
+	if customerID.Valid {
+		sale.CustomerID = customerID.String
+	}
+
 	return sale, nil
 }
 
@@ -86,31 +98,36 @@ func (s saleRepo) GetList(ctx context.Context, request models.GetListRequest) (m
 		sales             = []models.Sale{}
 		search            = request.Search
 		updatedAt  		  sql.NullTime
+		paymentType       sql.NullString
+		customerID        sql.NullString
+		shopAssistantID   sql.NullString
+		cashierID         sql.NullString
+		clientName        sql.NullString
 	)
 
-	countQuery = `SELECT COUNT(*) FROM sales WHERE deleted_at = 0 `
+	countQuery = `SELECT COUNT(*) FROM sales WHERE ` + deletedFilter(request.IncludeDeleted) + ` `
 	if search != "" {
 		countQuery += fmt.Sprintf(` AND client_name ILIKE '%%%s%%' `, search)
 	}
 
 	if err := s.db.QueryRow(ctx, countQuery).Scan(&count); err != nil {
 		fmt.Println("error is while scanning count", err.Error())
-		return models.SaleResponse{}, err
+		return models.SaleResponse{}, dbError(err)
 	}
 
 	query = `SELECT id, branch_id, shop_assistant_id, cashier_id, payment_type, price, status, client_name, 
-					created_at, updated_at FROM sales WHERE deleted_at = 0 `
+					customer_id, COALESCE(shift_id::text, ''), version, created_at, updated_at, deleted_at FROM sales WHERE ` + deletedFilter(request.IncludeDeleted) + ` `
 
 	if search != "" {
 		query += fmt.Sprintf(` AND client_name ilike '%%%s%%' `, search)
 	}
 
-	query += ` LIMIT $1 OFFSET $2`
+	query, args := paging(query, request.After, request.Limit, offset)
 
-	rows, err := s.db.Query(ctx, query, request.Limit, offset)
+	rows, err := s.db.Query(ctx, query, args...)
 	if err != nil {
 		fmt.Println("error is while selecting all sales", err.Error())
-		return models.SaleResponse{}, err
+		return models.SaleResponse{}, dbError(err)
 	}
 
 
@@ -119,19 +136,29 @@ func (s saleRepo) GetList(ctx context.Context, request models.GetListRequest) (m
 		if err = rows.Scan(
 			&sale.ID,
 			&sale.BranchID,
-			&sale.ShopAssistantID,
-			&sale.CashierID,
-			&sale.PaymentType,
+			&shopAssistantID,
+			&cashierID,
+			&paymentType,
 			&sale.Price,
 			&sale.Status,
-			&sale.ClientName,
+			&clientName,
+			&customerID,
+			&sale.ShiftID,
+			&sale.Version,
 			&sale.CreatedAt,
 			&updatedAt,
+			&sale.DeletedAt,
 			); err != nil {
 			fmt.Println("error is while scanning sales", err.Error())
-			return models.SaleResponse{}, err
+			return models.SaleResponse{}, dbError(err)
 		}
 
+		sale.ShopAssistantID = shopAssistantID.String
+		sale.CashierID = cashierID.String
+		sale.PaymentType = paymentType.String
+		sale.ClientName = clientName.String
+		sale.CustomerID = customerID.String
+
 		if updatedAt.Valid {
 			sale.UpdatedAt = updatedAt.Time
 		}
@@ -145,20 +172,35 @@ func (s saleRepo) GetList(ctx context.Context, request models.GetListRequest) (m
 }
 
 func (s saleRepo) Update(ctx context.Context, sale models.UpdateSale) (string, error) {
-	query := `UPDATE sales SET shop_assistant_id = $1, cashier_id = $2, payment_type = $3, 
-				price = $4, status = $5, updated_at = NOW() WHERE id = $6`
+	return s.Patch(ctx, models.PatchSale{
+		ID:              sale.ID,
+		ShopAssistantID: &sale.ShopAssistantID,
+		CashierID:       &sale.CashierID,
+		PaymentType:     &sale.PaymentType,
+		Price:           &sale.Price,
+		Status:          &sale.Status,
+		ShiftID:         &sale.ShiftID,
+		Version:         sale.Version,
+	})
+}
 
-	if _, err := s.db.Exec(ctx, query,
-		sale.ShopAssistantID,
-		sale.CashierID,
-		sale.PaymentType,
-		sale.Price,
-		sale.Status,
-		sale.ID,
-	); err != nil {
-		fmt.Println("error is while updating sale", err.Error())
+// Patch updates only the given fields of sales.
+func (s saleRepo) Patch(ctx context.Context, sale models.PatchSale) (string, error) {
+	fields := patch{}
+	setNullable(&fields, "shop_assistant_id", sale.ShopAssistantID, "")
+	setNullable(&fields, "cashier_id", sale.CashierID, "")
+	setNullable(&fields, "payment_type", sale.PaymentType, "::payment_type_enum")
+	setNullable(&fields, "client_name", sale.ClientName, "")
+	setNullable(&fields, "customer_id", sale.CustomerID, "::uuid")
+	set(&fields, "price", sale.Price)
+	set(&fields, "status", sale.Status)
+	setNullable(&fields, "shift_id", sale.ShiftID, "::uuid")
+
+	if err := fields.exec(ctx, s.db, "sales", sale.ID, sale.Version); err != nil {
+		fmt.Println("error is while patching sale", err.Error())
 		return "", err
 	}
+
 	return sale.ID, nil
 }
 
@@ -167,7 +209,83 @@ func (s saleRepo) Delete(ctx context.Context, id string) error {
 	query := `UPDATE sales SET deleted_at = extract(epoch from current_timestamp) WHERE id = $1`
 	if _, err := s.db.Exec(ctx, query, id); err != nil {
 		fmt.Println("error is while deleting sale", err.Error())
+		return dbError(err)
+	}
+	return nil
+}
+
+func (s saleRepo) Restore(ctx context.Context, id string) error {
+	if err := restore(ctx, s.db, "sales", id); err != nil {
+		fmt.Println("error is while restoring sale", err.Error())
 		return err
 	}
+
 	return nil
 }
+
+func (s saleRepo) GetReceipt(ctx context.Context, id string) (models.Receipt, error) {
+	var (
+		paymentType, cashierName, clientName sql.NullString
+		branchName, branchAddress            sql.NullString
+		receipt                              = models.Receipt{}
+	)
+
+	query := `SELECT s.id, b.name, b.address, st.name, s.client_name, s.payment_type, s.status, s.price, s.created_at
+					FROM sales s
+					LEFT JOIN branches b ON b.id = s.branch_id
+					LEFT JOIN staffs st ON st.id::text = s.cashier_id
+					WHERE s.id = $1 AND s.deleted_at = 0`
+
+	if err := s.db.QueryRow(ctx, query, id).Scan(
+		&receipt.SaleID,
+		&branchName,
+		&branchAddress,
+		&cashierName,
+		&clientName,
+		&paymentType,
+		&receipt.Status,
+		&receipt.Total,
+		&receipt.CreatedAt,
+	); err != nil {
+		fmt.Println("error is while selecting sale for receipt", err.Error())
+		return models.Receipt{}, dbError(err)
+	}
+
+	receipt.BranchName = branchName.String
+	receipt.BranchAddress = branchAddress.String
+	receipt.CashierName = cashierName.String
+	receipt.ClientName = clientName.String
+	receipt.PaymentType = paymentType.String
+
+	// unit price is taken from the basket, products.price may have changed since the sale
+	linesQuery := `SELECT p.name, bs.quantity, COALESCE(bs.price / NULLIF(bs.quantity, 0), 0), bs.price
+					FROM baskets bs
+					JOIN products p ON p.id = bs.product_id
+					WHERE bs.sale_id = $1 AND bs.deleted_at = 0
+					ORDER BY bs.created_at`
+
+	rows, err := s.db.Query(ctx, linesQuery, id)
+	if err != nil {
+		fmt.Println("error is while selecting receipt lines", err.Error())
+		return models.Receipt{}, dbError(err)
+	}
+	defer rows.Close()
+
+	receipt.Lines = []models.ReceiptLine{}
+	for rows.Next() {
+		line := models.ReceiptLine{}
+		if err = rows.Scan(
+			&line.ProductName,
+			&line.Quantity,
+			&line.UnitPrice,
+			&line.Price,
+		); err != nil {
+			fmt.Println("error is while scanning receipt line", err.Error())
+			return models.Receipt{}, dbError(err)
+		}
+
+		receipt.Lines = append(receipt.Lines, line)
+	}
+
+	return receipt, nil
+}
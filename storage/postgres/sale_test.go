@@ -0,0 +1,247 @@
+//go:build integration
+
+package postgres
+
+import (
+	"context"
+	"market/api/models"
+	"market/pkg/errs"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestSaleRepo(t *testing.T) {
+	ctx := context.Background()
+	repo := testStore.Sale()
+	branchID := newTestBranch(t)
+	cashierID := newTestStaff(t, branchID, "cashier")
+	clientName := unique("Dilshod")
+
+	id, err := repo.Create(ctx, models.CreateSale{BranchID: branchID, CashierID: cashierID, ClientName: clientName})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	sale, err := repo.GetByID(ctx, id)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if sale.BranchID != branchID || sale.CashierID != cashierID || sale.ClientName != clientName ||
+		sale.Status != "in_process" || sale.PaymentType != "" || sale.CustomerID != "" || sale.ShiftID != "" {
+		t.Errorf("got %+v, want a sale in process of %s by %s without payment type, customer and shift", sale, clientName, cashierID)
+	}
+
+	if _, err = repo.Update(ctx, models.UpdateSale{ID: id, CashierID: cashierID, PaymentType: "card", Price: 3000, Status: "success", Version: sale.Version}); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+
+	list, err := repo.GetList(ctx, models.GetListRequest{Page: 1, Limit: 10, Search: clientName})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if list.Count != 1 || len(list.Sales) != 1 {
+		t.Fatalf("list got %d sales, want 1", list.Count)
+	}
+	if got := list.Sales[0]; got.Status != "success" || got.PaymentType != "card" || got.Price != 3000 || got.Version != 2 {
+		t.Errorf("after update got %s by %s for %v with version %d, want success by card for 3000 with version 2",
+			got.Status, got.PaymentType, got.Price, got.Version)
+	}
+
+	// values which are not in the enums are rejected
+	for _, update := range []models.UpdateSale{
+		{ID: id, PaymentType: "bitcoin", Status: "success", Version: 2},
+		{ID: id, PaymentType: "cash", Status: "done", Version: 2},
+	} {
+		_, err = repo.Update(ctx, update)
+		wantCode(t, err, errs.CodeValidation)
+	}
+
+	// empty fields are stored as NULL and read back empty
+	if _, err = repo.Update(ctx, models.UpdateSale{ID: id, Status: "cancel", Version: 2}); err != nil {
+		t.Fatalf("update with empty fields: %v", err)
+	}
+
+	if sale, err = repo.GetByID(ctx, id); err != nil {
+		t.Fatalf("get after update with empty fields: %v", err)
+	}
+	if sale.CashierID != "" || sale.PaymentType != "" || sale.Status != "cancel" {
+		t.Errorf("got cashier %q and payment type %q in %s sale, want both empty in cancel", sale.CashierID, sale.PaymentType, sale.Status)
+	}
+
+	clientName = ""
+	if _, err = repo.Patch(ctx, models.PatchSale{ID: id, ClientName: &clientName, Version: sale.Version}); err != nil {
+		t.Fatalf("patch: %v", err)
+	}
+	if sale, err = repo.GetByID(ctx, id); err != nil || sale.ClientName != "" {
+		t.Errorf("after clearing client name got %q with error %v", sale.ClientName, err)
+	}
+
+	if err = repo.Delete(ctx, id); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+
+	_, err = repo.GetByID(ctx, id)
+	wantNotFound(t, err)
+
+	if err = repo.Restore(ctx, id); err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+
+	for _, sale := range []models.CreateSale{
+		{BranchID: uuid.NewString()},
+		{BranchID: branchID, CustomerID: uuid.NewString()},
+		{BranchID: branchID, ShiftID: uuid.NewString()},
+	} {
+		_, err = repo.Create(ctx, sale)
+		wantCode(t, err, errs.CodeValidation)
+	}
+}
+
+func TestBasketRepo(t *testing.T) {
+	ctx := context.Background()
+	repo := testStore.Basket()
+	branchID := newTestBranch(t)
+	saleID := newTestSale(t, branchID)
+	productID := newTestProduct(t, 1500)
+
+	id, err := repo.Create(ctx, models.CreateBasket{SaleID: saleID, ProductID: productID, Quantity: 2, Price: 3000})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	basket, err := repo.GetByID(ctx, models.PrimaryKey{ID: id})
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if basket.SaleID != saleID || basket.ProductID != productID || basket.Quantity != 2 || basket.Price != 3000 || basket.CreatedAt == "" {
+		t.Errorf("got %+v, want 2 of %s for 3000 in %s", basket, productID, saleID)
+	}
+
+	if _, err = repo.Update(ctx, models.UpdateBasket{ID: id, SaleID: saleID, ProductID: productID, Quantity: 3, Price: 4500, Version: basket.Version}); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+
+	secondID, err := repo.Create(ctx, models.CreateBasket{SaleID: saleID, ProductID: newTestProduct(t, 800), Quantity: 1, Price: 800})
+	if err != nil {
+		t.Fatalf("create second: %v", err)
+	}
+
+	list, err := repo.GetList(ctx, models.GetListRequest{Page: 1, Limit: 10, Search: saleID})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if list.Count != 2 || len(list.Baskets) != 2 {
+		t.Fatalf("list got %d baskets, want 2", list.Count)
+	}
+
+	receipt, err := testStore.Sale().GetReceipt(ctx, saleID)
+	if err != nil {
+		t.Fatalf("receipt: %v", err)
+	}
+	if len(receipt.Lines) != 2 || receipt.Lines[0].Quantity != 3 || receipt.Lines[0].UnitPrice != 1500 || receipt.Lines[0].Price != 4500 {
+		t.Errorf("receipt lines are %+v, want 3 for 1500 each first", receipt.Lines)
+	}
+
+	if err = repo.Delete(ctx, models.PrimaryKey{ID: secondID}); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+
+	_, err = repo.GetByID(ctx, models.PrimaryKey{ID: secondID})
+	wantNotFound(t, err)
+
+	if list, err = repo.GetList(ctx, models.GetListRequest{Page: 1, Limit: 10, Search: saleID}); err != nil {
+		t.Fatalf("list after delete: %v", err)
+	}
+	if list.Count != 1 || list.Baskets[0].ID != id {
+		t.Errorf("list after delete got %+v, want only %s", list, id)
+	}
+
+	if err = repo.Restore(ctx, secondID); err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+
+	_, err = repo.Create(ctx, models.CreateBasket{SaleID: uuid.NewString(), ProductID: productID, Quantity: 1})
+	wantCode(t, err, errs.CodeValidation)
+
+	_, err = repo.Create(ctx, models.CreateBasket{SaleID: saleID, ProductID: uuid.NewString(), Quantity: 1})
+	wantCode(t, err, errs.CodeValidation)
+}
+
+func TestCustomerRepo(t *testing.T) {
+	ctx := context.Background()
+	repo := testStore.Customer()
+	branchID := newTestBranch(t)
+	phone := unique("+99890")
+
+	id, err := repo.Create(ctx, models.CreateCustomer{Phone: phone, Name: "Nodira", BranchID: branchID})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	customer, err := repo.GetByID(ctx, id)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if customer.Phone != phone || customer.Name != "Nodira" || customer.BranchID != branchID || customer.Points != 0 {
+		t.Errorf("got %+v, want Nodira with %s and no points", customer, phone)
+	}
+
+	_, err = repo.Create(ctx, models.CreateCustomer{Phone: phone, BranchID: branchID})
+	wantCode(t, err, errs.CodeConflict)
+
+	_, err = repo.Create(ctx, models.CreateCustomer{Phone: unique("+99891"), BranchID: uuid.NewString()})
+	wantCode(t, err, errs.CodeValidation)
+
+	if err = repo.AddPoints(ctx, id, 50); err != nil {
+		t.Fatalf("add points: %v", err)
+	}
+	wantCode(t, repo.AddPoints(ctx, id, -60), errs.CodeValidation)
+	if err = repo.AddPoints(ctx, id, -20); err != nil {
+		t.Fatalf("withdraw points: %v", err)
+	}
+
+	// the name is optional
+	if _, err = repo.Update(ctx, models.UpdateCustomer{ID: id, Phone: phone, Version: 3}); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+
+	list, err := repo.GetList(ctx, models.GetListRequest{Page: 1, Limit: 10, Search: phone})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if list.Count != 1 || len(list.Customers) != 1 {
+		t.Fatalf("list got %d customers, want 1", list.Count)
+	}
+	if got := list.Customers[0]; got.Name != "" || got.Points != 30 || got.Version != 4 {
+		t.Errorf("list got %q with %d points and version %d, want no name with 30 points and version 4", got.Name, got.Points, got.Version)
+	}
+
+	saleID, err := testStore.Sale().Create(ctx, models.CreateSale{BranchID: branchID, CustomerID: id})
+	if err != nil {
+		t.Fatalf("create sale: %v", err)
+	}
+	if _, err = testStore.Basket().Create(ctx, models.CreateBasket{SaleID: saleID, ProductID: newTestProduct(t, 500), Quantity: 4, Price: 2000}); err != nil {
+		t.Fatalf("create basket: %v", err)
+	}
+
+	history, count, err := repo.SaleHistory(ctx, id, models.GetListRequest{Page: 1, Limit: 10})
+	if err != nil {
+		t.Fatalf("sale history: %v", err)
+	}
+	if count != 1 || len(history) != 1 || history[0].Sale.ID != saleID || len(history[0].Baskets) != 1 {
+		t.Errorf("sale history got %d sales %+v, want %s with one basket", count, history, saleID)
+	}
+
+	if err = repo.Delete(ctx, id); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+
+	_, err = repo.GetByID(ctx, id)
+	wantNotFound(t, err)
+	wantCode(t, repo.AddPoints(ctx, id, 10), errs.CodeValidation)
+
+	if err = repo.Restore(ctx, id); err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+}
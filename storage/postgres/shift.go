@@ -0,0 +1,232 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"market/api/models"
+	"market/pkg/errs"
+	"market/pkg/logger"
+	"market/storage"
+
+	"github.com/google/uuid"
+)
+
+type shiftRepo struct {
+	db  Querier
+	log logger.ILogger
+}
+
+func NewShiftRepo(db Querier, log logger.ILogger) storage.IShiftStorage {
+	return shiftRepo{
+		db:  db,
+		log: log,
+	}
+}
+
+func (s shiftRepo) Open(ctx context.Context, shift models.OpenShift) (string, error) {
+	id := uuid.New()
+	query := `INSERT INTO shifts (id, branch_id, cashier_id, opening_cash) VALUES($1, $2, $3, $4)`
+
+	if _, err := s.db.Exec(ctx, query,
+		id,
+		shift.BranchID,
+		shift.CashierID,
+		shift.OpeningCash,
+	); err != nil {
+		s.log.Error("error is while opening shift", logger.Error(err))
+		return "", dbError(err)
+	}
+
+	return id.String(), nil
+}
+
+func (s shiftRepo) GetByID(ctx context.Context, id string) (models.Shift, error) {
+	query := `SELECT id, branch_id, cashier_id, status, opening_cash, expected_cash, counted_cash,
+					opened_at, closed_at, created_at, updated_at FROM shifts WHERE id = $1 AND deleted_at = 0`
+
+	shift, err := s.scan(s.db.QueryRow(ctx, query, id))
+	if err != nil {
+		s.log.Error("error is while selecting shift by id", logger.Error(err))
+		return models.Shift{}, dbError(err)
+	}
+
+	return shift, nil
+}
+
+func (s shiftRepo) GetOpenByCashier(ctx context.Context, cashierID string) (models.Shift, error) {
+	query := `SELECT id, branch_id, cashier_id, status, opening_cash, expected_cash, counted_cash,
+					opened_at, closed_at, created_at, updated_at FROM shifts
+					WHERE cashier_id = $1 AND status = 'open' AND deleted_at = 0`
+
+	shift, err := s.scan(s.db.QueryRow(ctx, query, cashierID))
+	if err != nil {
+		return models.Shift{}, dbError(err)
+	}
+
+	return shift, nil
+}
+
+// ForUpdate locks the shift, so that it is closed only after the checkouts in it have ended.
+func (s shiftRepo) ForUpdate(ctx context.Context, id string) (models.Shift, error) {
+	query := `SELECT id, branch_id, cashier_id, status, opening_cash, expected_cash, counted_cash,
+					opened_at, closed_at, created_at, updated_at FROM shifts WHERE id = $1 AND deleted_at = 0 FOR UPDATE`
+
+	shift, err := s.scan(s.db.QueryRow(ctx, query, id))
+	if err != nil {
+		s.log.Error("error is while selecting shift for update", logger.Error(err))
+		return models.Shift{}, dbError(err)
+	}
+
+	return shift, nil
+}
+
+// OpenByCashierForShare locks the open shift of the cashier for share, so that sales are
+// made in it while it can not be closed. A shift closed while waiting for the lock is not found.
+func (s shiftRepo) OpenByCashierForShare(ctx context.Context, cashierID string) (models.Shift, error) {
+	query := `SELECT id, branch_id, cashier_id, status, opening_cash, expected_cash, counted_cash,
+					opened_at, closed_at, created_at, updated_at FROM shifts
+					WHERE cashier_id = $1 AND status = 'open' AND deleted_at = 0 FOR SHARE`
+
+	shift, err := s.scan(s.db.QueryRow(ctx, query, cashierID))
+	if err != nil {
+		return models.Shift{}, dbError(err)
+	}
+
+	return shift, nil
+}
+
+func (s shiftRepo) GetList(ctx context.Context, request models.GetListRequest) (models.ShiftsResponse, error) {
+	var (
+		offset = (request.Page - 1) * request.Limit
+		count  = 0
+		shifts = []models.Shift{}
+	)
+
+	countQuery := `SELECT COUNT(*) FROM shifts WHERE deleted_at = 0 AND ($1 = '' OR cashier_id::text = $1)`
+	if err := s.db.QueryRow(ctx, countQuery, request.Search).Scan(&count); err != nil {
+		s.log.Error("error is while scanning count of shifts", logger.Error(err))
+		return models.ShiftsResponse{}, dbError(err)
+	}
+
+	query := `SELECT id, branch_id, cashier_id, status, opening_cash, expected_cash, counted_cash,
+					opened_at, closed_at, created_at, updated_at FROM shifts
+					WHERE deleted_at = 0 AND ($1 = '' OR cashier_id::text = $1)`
+	query, args := pagingBy(query, []any{request.Search}, "opened_at DESC", request.After, request.Limit, offset)
+
+	rows, err := s.db.Query(ctx, query, args...)
+	if err != nil {
+		s.log.Error("error is while selecting shifts", logger.Error(err))
+		return models.ShiftsResponse{}, dbError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		shift, err := s.scan(rows)
+		if err != nil {
+			s.log.Error("error is while scanning shift", logger.Error(err))
+			return models.ShiftsResponse{}, dbError(err)
+		}
+
+		shifts = append(shifts, shift)
+	}
+
+	return models.ShiftsResponse{
+		Shifts: shifts,
+		Count:  count,
+	}, nil
+}
+
+func (s shiftRepo) Close(ctx context.Context, shift models.CloseShift) (string, error) {
+	query := `UPDATE shifts SET status = 'closed', expected_cash = $1, counted_cash = $2,
+				closed_at = NOW(), updated_at = NOW() WHERE id = $3 AND status = 'open' AND deleted_at = 0`
+
+	tag, err := s.db.Exec(ctx, query,
+		shift.ExpectedCash,
+		shift.CountedCash,
+		shift.ID,
+	)
+	if err != nil {
+		s.log.Error("error is while closing shift", logger.Error(err))
+		return "", dbError(err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return "", errs.Conflict("shift %s is not open", shift.ID)
+	}
+
+	return shift.ID, nil
+}
+
+// ZReport sums up the sales made in the shift.
+func (s shiftRepo) ZReport(ctx context.Context, shift models.Shift) (models.ZReport, error) {
+	report := models.ZReport{
+		ShiftID:     shift.ID,
+		BranchID:    shift.BranchID,
+		CashierID:   shift.CashierID,
+		OpenedAt:    shift.OpenedAt,
+		ClosedAt:    shift.ClosedAt,
+		OpeningCash: shift.OpeningCash,
+		CountedCash: shift.CountedCash,
+	}
+
+	query := `SELECT
+				COUNT(*) FILTER (WHERE status = 'success'),
+				COALESCE(SUM(price) FILTER (WHERE status = 'success' AND payment_type = 'cash'), 0),
+				COALESCE(SUM(price) FILTER (WHERE status = 'success' AND payment_type = 'card'), 0),
+				COUNT(*) FILTER (WHERE status = 'cancel'),
+				COALESCE(SUM(price) FILTER (WHERE status = 'cancel'), 0)
+			FROM sales
+			WHERE shift_id = $1 AND deleted_at = 0`
+
+	if err := s.db.QueryRow(ctx, query, shift.ID).Scan(
+		&report.SalesCount,
+		&report.CashTotal,
+		&report.CardTotal,
+		&report.CancelledCount,
+		&report.CancelledTotal,
+	); err != nil {
+		s.log.Error("error is while calculating z-report", logger.Error(err))
+		return models.ZReport{}, dbError(err)
+	}
+
+	report.Total = report.CashTotal + report.CardTotal
+	report.ExpectedCash = report.OpeningCash + report.CashTotal
+	if shift.Status == "closed" {
+		report.Difference = report.CountedCash - report.ExpectedCash
+	}
+
+	return report, nil
+}
+
+func (s shiftRepo) scan(row interface{ Scan(...any) error }) (models.Shift, error) {
+	var (
+		shift               = models.Shift{}
+		closedAt, updatedAt sql.NullTime
+	)
+
+	if err := row.Scan(
+		&shift.ID,
+		&shift.BranchID,
+		&shift.CashierID,
+		&shift.Status,
+		&shift.OpeningCash,
+		&shift.ExpectedCash,
+		&shift.CountedCash,
+		&shift.OpenedAt,
+		&closedAt,
+		&shift.CreatedAt,
+		&updatedAt,
+	); err != nil {
+		return models.Shift{}, dbError(err)
+	}
+
+	if closedAt.Valid {
+		shift.ClosedAt = closedAt.Time
+	}
+
+	if updatedAt.Valid {
+		shift.UpdatedAt = updatedAt.Time
+	}
+
+	return shift, nil
+}
@@ -0,0 +1,146 @@
+//go:build integration
+
+package postgres
+
+import (
+	"context"
+	"market/api/models"
+	"market/pkg/errs"
+	"market/storage"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestShiftRepo(t *testing.T) {
+	ctx := context.Background()
+	repo := testStore.Shift()
+	branchID := newTestBranch(t)
+	cashierID := newTestStaff(t, branchID, "cashier")
+
+	id, err := repo.Open(ctx, models.OpenShift{BranchID: branchID, CashierID: cashierID, OpeningCash: 50000})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+
+	shift, err := repo.GetByID(ctx, id)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if shift.BranchID != branchID || shift.CashierID != cashierID || shift.Status != "open" || shift.OpeningCash != 50000 || !shift.ClosedAt.IsZero() {
+		t.Errorf("got %+v, want open shift of %s with 50000", shift, cashierID)
+	}
+
+	if open, err := repo.GetOpenByCashier(ctx, cashierID); err != nil || open.ID != id {
+		t.Errorf("open shift of the cashier is %s with error %v, want %s", open.ID, err, id)
+	}
+
+	// a cashier has one open shift at a time
+	_, err = repo.Open(ctx, models.OpenShift{BranchID: branchID, CashierID: cashierID})
+	wantCode(t, err, errs.CodeConflict)
+
+	_, err = repo.Open(ctx, models.OpenShift{BranchID: branchID, CashierID: uuid.NewString()})
+	wantCode(t, err, errs.CodeValidation)
+
+	sales := []struct {
+		paymentType string
+		status      string
+		price       float32
+	}{
+		{"cash", "success", 12000},
+		{"card", "success", 30000},
+		{"cash", "cancel", 5000},
+		{"", "in_process", 0},
+	}
+	for _, sale := range sales {
+		saleID, err := testStore.Sale().Create(ctx, models.CreateSale{BranchID: branchID, CashierID: cashierID, ShiftID: id})
+		if err != nil {
+			t.Fatalf("create sale: %v", err)
+		}
+
+		if _, err = testStore.Sale().Update(ctx, models.UpdateSale{ID: saleID, CashierID: cashierID, PaymentType: sale.paymentType,
+			Price: sale.price, Status: sale.status, ShiftID: id, Version: 1}); err != nil {
+			t.Fatalf("update sale: %v", err)
+		}
+	}
+
+	if _, err = repo.Close(ctx, models.CloseShift{ID: id, CountedCash: 61000, ExpectedCash: 62000}); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	_, err = repo.Close(ctx, models.CloseShift{ID: id, CountedCash: 61000, ExpectedCash: 62000})
+	wantCode(t, err, errs.CodeConflict)
+
+	_, err = repo.GetOpenByCashier(ctx, cashierID)
+	wantNotFound(t, err)
+
+	if shift, err = repo.GetByID(ctx, id); err != nil {
+		t.Fatalf("get after close: %v", err)
+	}
+	if shift.Status != "closed" || shift.CountedCash != 61000 || shift.ExpectedCash != 62000 || shift.ClosedAt.IsZero() {
+		t.Errorf("after close got %+v, want closed with 61000 counted of 62000", shift)
+	}
+
+	report, err := repo.ZReport(ctx, shift)
+	if err != nil {
+		t.Fatalf("z-report: %v", err)
+	}
+	if report.SalesCount != 2 || report.CashTotal != 12000 || report.CardTotal != 30000 || report.Total != 42000 ||
+		report.CancelledCount != 1 || report.CancelledTotal != 5000 || report.ExpectedCash != 62000 || report.Difference != -1000 {
+		t.Errorf("z-report is %+v, want 2 sales for 42000, 1 cancelled for 5000 and 1000 short", report)
+	}
+
+	// the cashier can open the next shift once the last one is closed
+	nextID, err := repo.Open(ctx, models.OpenShift{BranchID: branchID, CashierID: cashierID})
+	if err != nil {
+		t.Fatalf("open next: %v", err)
+	}
+
+	list, err := repo.GetList(ctx, models.GetListRequest{Page: 1, Limit: 10, Search: cashierID})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if list.Count != 2 || len(list.Shifts) != 2 || list.Shifts[0].ID != nextID || list.Shifts[1].ID != id {
+		t.Errorf("list got %+v, want %s and %s newest first", list, nextID, id)
+	}
+}
+
+func TestShiftCloseWaitsForCheckouts(t *testing.T) {
+	ctx := context.Background()
+	branchID := newTestBranch(t)
+	cashierID := newTestStaff(t, branchID, "cashier")
+
+	id, err := testStore.Shift().Open(ctx, models.OpenShift{BranchID: branchID, CashierID: cashierID})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+
+	// a checkout shares the shift, so it can not be locked for closing until the checkout ends
+	if err = testStore.WithTx(ctx, func(checkout storage.IStorage) error {
+		if _, err := checkout.Shift().OpenByCashierForShare(ctx, cashierID); err != nil {
+			return err
+		}
+
+		if _, err := testStore.Shift().OpenByCashierForShare(ctx, cashierID); err != nil {
+			t.Errorf("another checkout in the shift: %v, want it shared", err)
+		}
+
+		waitCtx, cancel := context.WithTimeout(ctx, 200*time.Millisecond)
+		defer cancel()
+
+		if err := testStore.WithTx(waitCtx, func(closing storage.IStorage) error {
+			_, err := closing.Shift().ForUpdate(waitCtx, id)
+			return err
+		}); err == nil {
+			t.Error("shift is locked for closing during a checkout")
+		}
+
+		return nil
+	}); err != nil {
+		t.Fatalf("checkout: %v", err)
+	}
+
+	if _, err = testStore.Shift().ForUpdate(ctx, id); err != nil {
+		t.Errorf("lock after the checkout: %v", err)
+	}
+}
@@ -0,0 +1,71 @@
+package postgres
+
+import (
+	"context"
+	"market/pkg/errs"
+)
+
+// deletedFilter is the soft delete condition of list queries, rows with deleted_at set
+// are listed only when includeDeleted is true.
+func deletedFilter(includeDeleted bool) string {
+	if includeDeleted {
+		return `TRUE`
+	}
+
+	return `deleted_at = 0`
+}
+
+// restore clears deleted_at of the soft deleted row of table.
+func restore(ctx context.Context, db Querier, table, id string) error {
+	query := `UPDATE ` + table + ` SET deleted_at = 0, updated_at = NOW(), version = version + 1
+				WHERE id::text = $1 AND deleted_at <> 0`
+
+	tag, err := db.Exec(ctx, query, id)
+	if err != nil {
+		return dbError(err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return errs.NotFound("deleted record not found")
+	}
+
+	return nil
+}
+
+// deleteGuard is an EXISTS condition on the id ($1) of the row being deleted,
+// the row can not be deleted while it is true.
+type deleteGuard struct {
+	condition string
+	reason    string
+}
+
+// softDelete sets deleted_at of the row of table unless one of guards holds for it.
+// Deleting a missing or already deleted row does nothing.
+func softDelete(ctx context.Context, db Querier, table, id string, guards ...deleteGuard) error {
+	query := `UPDATE ` + table + ` SET deleted_at = extract(epoch from current_timestamp) WHERE id::text = $1 AND deleted_at = 0`
+	for _, guard := range guards {
+		query += ` AND NOT ` + guard.condition
+	}
+
+	tag, err := db.Exec(ctx, query, id)
+	if err != nil {
+		return dbError(err)
+	}
+
+	if tag.RowsAffected() > 0 {
+		return nil
+	}
+
+	for _, guard := range guards {
+		var blocked bool
+		if err = db.QueryRow(ctx, `SELECT `+guard.condition, id).Scan(&blocked); err != nil {
+			return dbError(err)
+		}
+
+		if blocked {
+			return errs.Conflict("%s", guard.reason)
+		}
+	}
+
+	return nil
+}
@@ -6,20 +6,20 @@ import (
 	"fmt"
 	"log"
 	"market/api/models"
+	"market/pkg/errs"
 	"market/pkg/logger"
 	"market/storage"
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type staffRepo struct {
-	DB *pgxpool.Pool
+	DB Querier
 	log logger.ILogger
 }
 
-func NewStaffRepo(DB *pgxpool.Pool, log logger.ILogger) storage.IStaffRepo {
+func NewStaffRepo(DB Querier, log logger.ILogger) storage.IStaffRepo {
 	return &staffRepo{
 		DB: DB,
 		log: log,
@@ -32,7 +32,7 @@ func (s *staffRepo) Create(ctx context.Context, staff models.CreateStaff) (strin
 	birthDate, err := time.Parse("2006-01-02", staff.BirthDate)
 	if err != nil {
 		log.Println("Error parsing birth date:", err)
-		return "", err
+		return "", dbError(err)
 	}
 	age := uint(time.Since(birthDate).Hours() / 24 / 365)
 
@@ -51,7 +51,7 @@ func (s *staffRepo) Create(ctx context.Context, staff models.CreateStaff) (strin
 		staff.Password,
 	); err != nil {
 		log.Println("Error while inserting data ", err)
-		return "", err
+		return "", dbError(err)
 	}
 	return id, nil
 }
@@ -59,7 +59,7 @@ func (s *staffRepo) Create(ctx context.Context, staff models.CreateStaff) (strin
 func (s *staffRepo) StaffByID(ctx context.Context, id models.PrimaryKey) (models.Staff, error) {
 	var updatedAt sql.NullTime
 	staff := models.Staff{}
-	query := `SELECT id, branch_id, tariff_id, staff_type, name, balance, age, birth_date, login, created_at, updated_at FROM staffs WHERE id = $1`
+	query := `SELECT id, branch_id, tariff_id, staff_type, name, balance, age, birth_date, login, version, created_at, updated_at FROM staffs WHERE id = $1`
 
 	err := s.DB.QueryRow(ctx, query, id.ID).Scan(
 		&staff.ID,
@@ -71,12 +71,13 @@ func (s *staffRepo) StaffByID(ctx context.Context, id models.PrimaryKey) (models
 		&staff.Age,
 		&staff.BirthDate,
 		&staff.Login,
+		&staff.Version,
 		&staff.CreatedAt,
 		&updatedAt,
 	)
 	if err != nil {
 		log.Println("Error while selecting staff by ID:", err)
-		return models.Staff{}, err
+		return models.Staff{}, dbError(err)
 	}
 
 	if updatedAt.Valid {
@@ -93,27 +94,27 @@ func (s *staffRepo) GetStaffTList(ctx context.Context, request models.GetListReq
 		updatedAt sql.NullTime
 	)
 
-	countQuery := `SELECT COUNT(*) FROM staffs where deleted_at = 0`
+	countQuery := `SELECT COUNT(*) FROM staffs WHERE ` + deletedFilter(request.IncludeDeleted) + ` `
 	if request.Search != "" {
-		countQuery += fmt.Sprintf(` AND name ILIKE '%%%s%%' or login ilike '%%%s%%'`, request.Search, request.Search)
+		countQuery += fmt.Sprintf(` AND (name ILIKE '%%%s%%' or login ilike '%%%s%%')`, request.Search, request.Search)
 	}
 
 	err := s.DB.QueryRow(ctx, countQuery).Scan(&count)
 	if err != nil {
 		log.Println("Error while scanning count of staffs:", err)
-		return models.StaffsResponse{}, err
+		return models.StaffsResponse{}, dbError(err)
 	}
 
-	query := `SELECT id, branch_id, tariff_id, staff_type, name, balance, age, birth_date, login, created_at, updated_at FROM staffs where deleted_at = 0`
+	query := `SELECT id, branch_id, tariff_id, staff_type, name, balance, age, birth_date, login, version, created_at, updated_at, deleted_at FROM staffs WHERE ` + deletedFilter(request.IncludeDeleted) + ` `
 	if request.Search != "" {
-		query += fmt.Sprintf(` AND name ILIKE '%%%s%%' or login ilike '%%%s%%'`, request.Search, request.Search)
+		query += fmt.Sprintf(` AND (name ILIKE '%%%s%%' or login ilike '%%%s%%')`, request.Search, request.Search)
 	}
-	query += ` LIMIT $1 OFFSET $2`
+	query, args := paging(query, request.After, request.Limit, (request.Page-1)*request.Limit)
 
-	rows, err := s.DB.Query(ctx, query, request.Limit, (request.Page-1)*request.Limit)
+	rows, err := s.DB.Query(ctx, query, args...)
 	if err != nil {
 		log.Println("Error while querying staff :", err)
-		return models.StaffsResponse{}, err
+		return models.StaffsResponse{}, dbError(err)
 	}
 	defer rows.Close()
 
@@ -129,12 +130,14 @@ func (s *staffRepo) GetStaffTList(ctx context.Context, request models.GetListReq
 			&staff.Age,
 			&staff.BirthDate,
 			&staff.Login,
+			&staff.Version,
 			&staff.CreatedAt,
 			&updatedAt,
+			&staff.DeletedAt,
 		)
 		if err != nil {
 			log.Println("Error while scanning row of staffs:", err)
-			return models.StaffsResponse{}, err
+			return models.StaffsResponse{}, dbError(err)
 		}
 
 		if updatedAt.Valid {
@@ -151,19 +154,31 @@ func (s *staffRepo) GetStaffTList(ctx context.Context, request models.GetListReq
 }
 
 func (s *staffRepo) UpdateStaff(ctx context.Context, staff models.UpdateStaff) (string, error) {
-	query := `UPDATE staffs SET branch_id = $1, tariff_id = $2, staff_type = $3, name = $4, balance = $5, login = $6, updated_at = NOW() WHERE id = $7`
+	return s.PatchStaff(ctx, models.PatchStaff{
+		ID:        staff.ID,
+		BranchID:  &staff.BranchID,
+		TariffID:  &staff.TariffID,
+		StaffType: &staff.StaffType,
+		Name:      &staff.Name,
+		Balance:   &staff.Balance,
+		Login:     &staff.Login,
+		Version:   staff.Version,
+	})
+}
 
-	_, err := s.DB.Exec(ctx, query,
-		&staff.BranchID,
-		&staff.TariffID,
-		&staff.StaffType,
-		&staff.Name,
-		&staff.Balance,
-		&staff.Login,
-		staff.ID,
-	)
-	if err != nil {
-		log.Println("Error while updating Staff :", err)
+// PatchStaff updates only the given fields of staffs.
+func (s *staffRepo) PatchStaff(ctx context.Context, staff models.PatchStaff) (string, error) {
+	fields := patch{}
+	set(&fields, "branch_id", staff.BranchID)
+	set(&fields, "tariff_id", staff.TariffID)
+	set(&fields, "staff_type", staff.StaffType)
+	set(&fields, "name", staff.Name)
+	setNullable(&fields, "birth_date", staff.BirthDate, "::date")
+	set(&fields, "balance", staff.Balance)
+	set(&fields, "login", staff.Login)
+
+	if err := fields.exec(ctx, s.DB, "staffs", staff.ID, staff.Version); err != nil {
+		log.Println("Error while patching Staff :", err)
 		return "", err
 	}
 
@@ -176,6 +191,15 @@ func (s *staffRepo) DeleteStaff(ctx context.Context, id string) error {
 	_, err := s.DB.Exec(ctx, query, id)
 	if err != nil {
 		log.Println("Error while deleting Staff :", err)
+		return dbError(err)
+	}
+
+	return nil
+}
+
+func (s *staffRepo) RestoreStaff(ctx context.Context, id string) error {
+	if err := restore(ctx, s.DB, "staffs", id); err != nil {
+		log.Println("Error while restoring Staff :", err)
 		return err
 	}
 
@@ -191,7 +215,7 @@ func (s *staffRepo) GetPassword(ctx context.Context, id string) (string, error)
 
 	if err := s.DB.QueryRow(ctx, query, id).Scan(&password); err != nil {
 		fmt.Println("Error while scanning password from users", err.Error())
-		return "", err
+		return "", dbError(err)
 	}
 
 	return password, nil
@@ -200,13 +224,63 @@ func (s *staffRepo) GetPassword(ctx context.Context, id string) (string, error)
 func (s *staffRepo) UpdatePassword(ctx context.Context, request models.UpdateStaffPassword) error {
 	query := `
 		update staffs 
-				set password = $1
+				set password = $1, version = version + 1
 					where id = $2`
 
 	if _, err := s.DB.Exec(ctx, query, request.NewPassword, request.ID); err != nil {
 		fmt.Println("error while updating password for staff", err.Error())
-		return err
+		return dbError(err)
 	}
 
 	return nil
 }
+
+// AdjustBalance changes staff balance by a bonus or penalty and records it
+// in transactions, both in one db transaction. Withdrawals which would make
+// the balance negative are rejected.
+func (s *staffRepo) AdjustBalance(ctx context.Context, request models.StaffBalanceAdjustment) (string, error) {
+	id := uuid.New().String()
+
+	amount := int(request.Amount)
+	if request.TransactionType == "withdraw" {
+		amount = -amount
+	}
+
+	tx, err := s.DB.Begin(ctx)
+	if err != nil {
+		log.Println("Error while beginning transaction:", err)
+		return "", dbError(err)
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `UPDATE staffs SET balance = balance + $1, updated_at = NOW(), version = version + 1
+			WHERE id = $2 AND deleted_at = 0 AND balance + $1 >= 0`, amount, request.StaffID)
+	if err != nil {
+		log.Println("Error while updating staff balance:", err)
+		return "", dbError(err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return "", errs.Validation("staff not found or balance is not enough")
+	}
+
+	if _, err = tx.Exec(ctx, `INSERT INTO transactions
+		(id, staff_id, transaction_type, source_type, amount, description)
+			VALUES ($1, $2, $3, 'bonus', $4, $5)`,
+		id,
+		request.StaffID,
+		request.TransactionType,
+		request.Amount,
+		request.Description,
+	); err != nil {
+		log.Println("Error while inserting balance transaction:", err)
+		return "", dbError(err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		log.Println("Error while committing balance adjustment:", err)
+		return "", dbError(err)
+	}
+
+	return id, nil
+}
@@ -11,15 +11,14 @@ import (
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type staffTarifRepo struct {
-	DB *pgxpool.Pool
+	DB Querier
 	log logger.ILogger
 }
 
-func NewStaffTarifRepo(DB *pgxpool.Pool, log logger.ILogger) storage.IStaffTariffRepo {
+func NewStaffTarifRepo(DB Querier, log logger.ILogger) storage.IStaffTariffRepo {
 	return &staffTarifRepo{
 		DB: DB,
 		log: log,
@@ -41,7 +40,7 @@ func (s *staffTarifRepo) Create(ctx context.Context, tarif models.CreateStaffTar
 			createdAt,
 	); err != nil {
 		log.Println("Error while inserting staff tarif data:", err)
-		return "", err
+		return "", dbError(err)
 	}
 
 	return id, nil
@@ -50,19 +49,20 @@ func (s *staffTarifRepo) Create(ctx context.Context, tarif models.CreateStaffTar
 func (s *staffTarifRepo) GetStaffTariffByID(ctx context.Context, id models.PrimaryKey) (models.StaffTarif, error) {
 	var updatedAt sql.NullTime
 	staffTarif := models.StaffTarif{}
-	query := `SELECT id, name, tarif_type, amount_for_cash, amount_for_card, created_at, updated_at FROM staff_tarifs WHERE id = $1 AND deleted_at = 0 `
+	query := `SELECT id, name, tarif_type, amount_for_cash, amount_for_card, version, created_at, updated_at FROM staff_tarifs WHERE id = $1 AND deleted_at = 0 `
 	err := s.DB.QueryRow(ctx, query, id.ID).Scan(
 		&staffTarif.ID,
 		&staffTarif.Name,
 		&staffTarif.TarifType,
 		&staffTarif.AmountForCash,
 		&staffTarif.AmountForCard,
+		&staffTarif.Version,
 		&staffTarif.CreatedAt,
 		&updatedAt,
 	)
 	if err != nil {
 		log.Println("Error while selecting staff tariff by ID:", err)
-		return models.StaffTarif{}, err
+		return models.StaffTarif{}, dbError(err)
 	}
 
 	if updatedAt.Valid {
@@ -80,27 +80,27 @@ func (s *staffTarifRepo) GetStaffTariffList(ctx context.Context, request models.
 		updatedAt   sql.NullTime
 	)
 
-	countQuery := `SELECT COUNT(*) FROM staff_tarifs`
+	countQuery := `SELECT COUNT(*) FROM staff_tarifs WHERE ` + deletedFilter(request.IncludeDeleted)
 	if request.Search != "" {
-		countQuery += fmt.Sprintf(` WHERE name ILIKE '%%%s%%'`, request.Search)
+		countQuery += fmt.Sprintf(` AND name ILIKE '%%%s%%'`, request.Search)
 	}
 
 	err := s.DB.QueryRow(ctx, countQuery).Scan(&count)
 	if err != nil {
 		log.Println("Error while scanning count of staff tariffs:", err)
-		return models.StaffTarifResponse{}, err
+		return models.StaffTarifResponse{}, dbError(err)
 	}
 
-	query := ` SELECT id, name, tarif_type, amount_for_cash, amount_for_card, created_at, updated_at FROM staff_tarifs where deleted_at = 0 `
+	query := ` SELECT id, name, tarif_type, amount_for_cash, amount_for_card, version, created_at, updated_at, deleted_at FROM staff_tarifs WHERE ` + deletedFilter(request.IncludeDeleted) + ` `
 	if request.Search != "" {
-		query += fmt.Sprintf(` WHERE name ILIKE '%%%s%%'`, request.Search)
+		query += fmt.Sprintf(` AND name ILIKE '%%%s%%'`, request.Search)
 	}
-	query += ` LIMIT $1 OFFSET $2`
+	query, args := paging(query, request.After, request.Limit, (request.Page-1)*request.Limit)
 
-	rows, err := s.DB.Query(ctx, query, request.Limit, (request.Page-1)*request.Limit)
+	rows, err := s.DB.Query(ctx, query, args...)
 	if err != nil {
 		log.Println("Error while querying staff tariffs:", err)
-		return models.StaffTarifResponse{}, err
+		return models.StaffTarifResponse{}, dbError(err)
 	}
 	defer rows.Close()
 
@@ -112,12 +112,14 @@ func (s *staffTarifRepo) GetStaffTariffList(ctx context.Context, request models.
 			&staffTarif.TarifType,
 			&staffTarif.AmountForCash,
 			&staffTarif.AmountForCard,
+			&staffTarif.Version,
 			&staffTarif.CreatedAt,
 			&staffTarif.UpdatedAt,
+			&staffTarif.DeletedAt,
 		)
 		if err != nil {
 			log.Println("Error while scanning row of staff tariffs:", err)
-			return models.StaffTarifResponse{}, err
+			return models.StaffTarifResponse{}, dbError(err)
 		}
 
 		if updatedAt.Valid {
@@ -134,17 +136,26 @@ func (s *staffTarifRepo) GetStaffTariffList(ctx context.Context, request models.
 }
 
 func (s *staffTarifRepo) UpdateStaffTariff(ctx context.Context, starif models.UpdateStaffTarif) (string, error) {
-	query := `UPDATE staff_tarifs SET name = $1, tarif_type = $2, amount_for_cash = $3, amount_for_card = $4, updated_at = NOW() WHERE id = $5`
-
-	_, err := s.DB.Exec(ctx, query,
-		starif.Name,
-		starif.TarifType,
-		starif.AmountForCash,
-		starif.AmountForCard,
-		starif.ID,
-	)
-	if err != nil {
-		log.Println("Error while updating Staff Tarif:", err)
+	return s.PatchStaffTariff(ctx, models.PatchStaffTarif{
+		ID:            starif.ID,
+		Name:          &starif.Name,
+		TarifType:     &starif.TarifType,
+		AmountForCash: &starif.AmountForCash,
+		AmountForCard: &starif.AmountForCard,
+		Version:       starif.Version,
+	})
+}
+
+// PatchStaffTariff updates only the given fields of staff tarifs.
+func (s *staffTarifRepo) PatchStaffTariff(ctx context.Context, starif models.PatchStaffTarif) (string, error) {
+	fields := patch{}
+	set(&fields, "name", starif.Name)
+	set(&fields, "tarif_type", starif.TarifType)
+	set(&fields, "amount_for_cash", starif.AmountForCash)
+	set(&fields, "amount_for_card", starif.AmountForCard)
+
+	if err := fields.exec(ctx, s.DB, "staff_tarifs", starif.ID, starif.Version); err != nil {
+		log.Println("Error while patching Staff Tarif:", err)
 		return "", err
 	}
 
@@ -157,6 +168,15 @@ func (s *staffTarifRepo) DeleteStaffTariff(ctx context.Context, id string) error
 	_, err := s.DB.Exec(ctx, query, id)
 	if err != nil {
 		log.Println("Error while deleting Staff Tarif:", err)
+		return dbError(err)
+	}
+
+	return nil
+}
+
+func (s *staffTarifRepo) RestoreStaffTariff(ctx context.Context, id string) error {
+	if err := restore(ctx, s.DB, "staff_tarifs", id); err != nil {
+		log.Println("Error while restoring Staff Tarif:", err)
 		return err
 	}
 
@@ -0,0 +1,294 @@
+//go:build integration
+
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"market/api/models"
+	"market/pkg/errs"
+	"strconv"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestStaffTariffRepo(t *testing.T) {
+	ctx := context.Background()
+	repo := testStore.StaffTariff()
+	name := unique("Bonus")
+
+	id, err := repo.Create(ctx, models.CreateStaffTarif{Name: name, TarifType: "fixed", AmountForCash: 1000, AmountForCard: 1500})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	tariff, err := repo.GetStaffTariffByID(ctx, models.PrimaryKey{ID: id})
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if tariff.Name != name || tariff.TarifType != "fixed" || tariff.AmountForCash != 1000 || tariff.AmountForCard != 1500 {
+		t.Errorf("got %+v, want fixed %s for 1000 and 1500", tariff, name)
+	}
+
+	_, err = repo.Create(ctx, models.CreateStaffTarif{Name: name, TarifType: "fixed"})
+	wantCode(t, err, errs.CodeConflict)
+
+	_, err = repo.Create(ctx, models.CreateStaffTarif{Name: unique("Bonus"), TarifType: "hourly"})
+	wantCode(t, err, errs.CodeValidation)
+
+	if _, err = repo.UpdateStaffTariff(ctx, models.UpdateStaffTarif{ID: id, Name: name, TarifType: "percent", AmountForCash: 2, AmountForCard: 1, Version: tariff.Version}); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+
+	list, err := repo.GetStaffTariffList(ctx, models.GetListRequest{Page: 1, Limit: 10, Search: name})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if list.Count != 1 || len(list.StaffTarifs) != 1 || list.StaffTarifs[0].TarifType != "percent" || list.StaffTarifs[0].Version != 2 {
+		t.Errorf("list got %+v, want the percent tariff with version 2", list)
+	}
+
+	if err = repo.DeleteStaffTariff(ctx, id); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+
+	_, err = repo.GetStaffTariffByID(ctx, models.PrimaryKey{ID: id})
+	wantNotFound(t, err)
+
+	if err = repo.RestoreStaffTariff(ctx, id); err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+}
+
+func TestStaffRepo(t *testing.T) {
+	ctx := context.Background()
+	repo := testStore.Staff()
+	branchID := newTestBranch(t)
+	tariffID := newTestTariff(t)
+	login := fmt.Sprintf("kassa%04d", testSeq.Add(1))
+
+	id, err := repo.Create(ctx, models.CreateStaff{
+		BranchID:  branchID,
+		TariffID:  tariffID,
+		StaffType: "shop_assistant",
+		Name:      "Malika",
+		BirthDate: "2000-01-31",
+		Login:     login,
+		Password:  "secret123",
+	})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	staff, err := repo.StaffByID(ctx, models.PrimaryKey{ID: id})
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if staff.BranchID != branchID || staff.TariffID != tariffID || staff.StaffType != "shop_assistant" ||
+		staff.Login != login || staff.BirthDate.Format("2006-01-02") != "2000-01-31" || staff.Age == 0 {
+		t.Errorf("got %+v, want shop assistant %s born 2000-01-31", staff, login)
+	}
+
+	for _, create := range []models.CreateStaff{
+		{BranchID: branchID, TariffID: tariffID, StaffType: "manager", Name: "Olim", BirthDate: "2000-01-01", Login: unique("a")},
+		{BranchID: uuid.NewString(), TariffID: tariffID, StaffType: "cashier", Name: "Olim", BirthDate: "2000-01-01", Login: unique("b")},
+		{BranchID: branchID, TariffID: uuid.NewString(), StaffType: "cashier", Name: "Olim", BirthDate: "2000-01-01", Login: unique("c")},
+	} {
+		_, err = repo.Create(ctx, create)
+		wantCode(t, err, errs.CodeValidation)
+	}
+
+	_, err = repo.Create(ctx, models.CreateStaff{BranchID: branchID, TariffID: tariffID, StaffType: "cashier", Name: "Olim", BirthDate: "2000-01-01", Login: login})
+	wantCode(t, err, errs.CodeConflict)
+
+	if _, err = repo.UpdateStaff(ctx, models.UpdateStaff{ID: id, BranchID: branchID, TariffID: tariffID, StaffType: "cashier", Name: "Malika", Login: login, Version: staff.Version}); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+
+	list, err := repo.GetStaffTList(ctx, models.GetListRequest{Page: 1, Limit: 10, Search: login})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if list.Count != 1 || len(list.Staffs) != 1 || list.Staffs[0].StaffType != "cashier" || list.Staffs[0].Version != 2 {
+		t.Errorf("list got %+v, want the cashier with version 2", list)
+	}
+
+	if err = repo.UpdatePassword(ctx, models.UpdateStaffPassword{ID: id, NewPassword: "secret456"}); err != nil {
+		t.Fatalf("update password: %v", err)
+	}
+	if password, err := repo.GetPassword(ctx, id); err != nil || password != "secret456" {
+		t.Errorf("password is %q with error %v, want the new one", password, err)
+	}
+
+	if _, err = repo.AdjustBalance(ctx, models.StaffBalanceAdjustment{StaffID: id, TransactionType: "topup", Amount: 5000, Description: "bonus"}); err != nil {
+		t.Fatalf("top up: %v", err)
+	}
+	_, err = repo.AdjustBalance(ctx, models.StaffBalanceAdjustment{StaffID: id, TransactionType: "withdraw", Amount: 6000, Description: "penalty"})
+	wantCode(t, err, errs.CodeValidation)
+
+	transactionID, err := repo.AdjustBalance(ctx, models.StaffBalanceAdjustment{StaffID: id, TransactionType: "withdraw", Amount: 2000, Description: "penalty"})
+	if err != nil {
+		t.Fatalf("withdraw: %v", err)
+	}
+
+	if staff, err = repo.StaffByID(ctx, models.PrimaryKey{ID: id}); err != nil || staff.Balance != 3000 {
+		t.Errorf("balance is %d with error %v, want 3000", staff.Balance, err)
+	}
+
+	transaction, err := testStore.Transaction().GetByID(ctx, transactionID)
+	if err != nil {
+		t.Fatalf("get balance transaction: %v", err)
+	}
+	if transaction.StaffID != id || transaction.SaleID != "" || transaction.TransactionType != "withdraw" || transaction.SourceType != "bonus" || transaction.Amount != 2000 {
+		t.Errorf("balance transaction is %+v, want bonus withdraw of 2000 without sale", transaction)
+	}
+
+	if err = repo.DeleteStaff(ctx, id); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+
+	if list, err = repo.GetStaffTList(ctx, models.GetListRequest{Page: 1, Limit: 10, Search: login}); err != nil {
+		t.Fatalf("list after delete: %v", err)
+	}
+	if list.Count != 0 {
+		t.Errorf("list after delete has %d staff, want 0", list.Count)
+	}
+
+	if err = repo.RestoreStaff(ctx, id); err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+}
+
+func TestTransactionRepo(t *testing.T) {
+	ctx := context.Background()
+	repo := testStore.Transaction()
+	branchID := newTestBranch(t)
+	staffID := newTestStaff(t, branchID, "cashier")
+	saleID := newTestSale(t, branchID)
+	amount := float64(uniqueNumber()) + 0.5
+
+	id, err := repo.Create(ctx, models.CreateTransaction{SaleID: saleID, StaffID: staffID, TransactionType: "topup", SourceType: "sales", Amount: amount, Description: "sotuv"})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	transaction, err := repo.GetByID(ctx, id)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if transaction.SaleID != saleID || transaction.StaffID != staffID || transaction.TransactionType != "topup" ||
+		transaction.SourceType != "sales" || transaction.Amount != amount || transaction.Description != "sotuv" {
+		t.Errorf("got %+v, want sales topup of %v for %s", transaction, amount, saleID)
+	}
+
+	// the sale and the description are optional
+	withoutSale, err := repo.Create(ctx, models.CreateTransaction{StaffID: staffID, TransactionType: "withdraw", SourceType: "payout", Amount: amount})
+	if err != nil {
+		t.Fatalf("create without sale: %v", err)
+	}
+
+	for _, create := range []models.CreateTransaction{
+		{StaffID: staffID, TransactionType: "refund", SourceType: "sales", Amount: 1},
+		{StaffID: staffID, TransactionType: "topup", SourceType: "gift", Amount: 1},
+		{StaffID: uuid.NewString(), TransactionType: "topup", SourceType: "sales", Amount: 1},
+		{SaleID: uuid.NewString(), StaffID: staffID, TransactionType: "topup", SourceType: "sales", Amount: 1},
+	} {
+		_, err = repo.Create(ctx, create)
+		wantCode(t, err, errs.CodeValidation)
+	}
+
+	if _, err = repo.Update(ctx, models.UpdateTransaction{ID: id, StaffID: staffID, TransactionType: "topup", SourceType: "bonus", Amount: amount, Version: transaction.Version}); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+
+	if transaction, err = repo.GetByID(ctx, id); err != nil {
+		t.Fatalf("get after update: %v", err)
+	}
+	if transaction.SaleID != "" || transaction.Description != "" || transaction.SourceType != "bonus" || transaction.Version != 2 {
+		t.Errorf("after update got %+v, want bonus without sale and description with version 2", transaction)
+	}
+
+	list, err := repo.GetList(ctx, models.TransactionGetListRequest{Page: 1, Limit: 10, FromAmount: amount, ToAmount: amount})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if list.Count != 2 || len(list.Transactions) != 2 {
+		t.Errorf("list of amount %v got %d transactions, want 2", amount, list.Count)
+	}
+
+	if err = repo.Delete(ctx, withoutSale); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+
+	_, err = repo.GetByID(ctx, withoutSale)
+	wantNotFound(t, err)
+
+	if list, err = repo.GetList(ctx, models.TransactionGetListRequest{Page: 1, Limit: 10, FromAmount: amount, ToAmount: amount, IncludeDeleted: true}); err != nil {
+		t.Fatalf("list with deleted: %v", err)
+	}
+	if list.Count != 2 {
+		t.Errorf("list with deleted got %d transactions, want 2", list.Count)
+	}
+
+	if err = repo.Restore(ctx, withoutSale); err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+}
+
+func TestRepositoryTransactionRepo(t *testing.T) {
+	ctx := context.Background()
+	repo := testStore.RTransaction()
+	staffID := newTestStaff(t, newTestBranch(t), "shop_assistant")
+	productID := newTestProduct(t, 1500)
+	quantity := uniqueNumber()
+
+	id, err := repo.Create(ctx, models.CreateRepositoryTransaction{StaffID: staffID, ProductID: productID, RepositoryTransactionType: "plus", Price: 1200, Quantity: quantity})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	transaction, err := repo.GetByID(ctx, models.PrimaryKey{ID: id})
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if transaction.StaffID != staffID || transaction.ProductID != productID || transaction.RepositoryTransactionType != "plus" || transaction.Quantity != quantity {
+		t.Errorf("got %+v, want plus of %d of %s", transaction, quantity, productID)
+	}
+
+	for _, create := range []models.CreateRepositoryTransaction{
+		{StaffID: staffID, ProductID: productID, RepositoryTransactionType: "move", Quantity: 1},
+		{StaffID: uuid.NewString(), ProductID: productID, RepositoryTransactionType: "plus", Quantity: 1},
+		{StaffID: staffID, ProductID: uuid.NewString(), RepositoryTransactionType: "plus", Quantity: 1},
+	} {
+		_, err = repo.Create(ctx, create)
+		wantCode(t, err, errs.CodeValidation)
+	}
+
+	if _, err = repo.Update(ctx, models.UpdateRepositoryTransaction{ID: id, StaffID: staffID, ProductID: productID, RepositoryTransactionType: "minus", Price: 1200, Quantity: quantity, Version: transaction.Version}); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+
+	list, err := repo.GetList(ctx, models.GetListRequest{Page: 1, Limit: 10, Search: strconv.Itoa(quantity)})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if list.Count != 1 || len(list.RepositoryTransactions) != 1 || list.RepositoryTransactions[0].RepositoryTransactionType != "minus" {
+		t.Errorf("list got %+v, want the minus transaction", list)
+	}
+
+	if err = repo.Delete(ctx, id); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+
+	if list, err = repo.GetList(ctx, models.GetListRequest{Page: 1, Limit: 10, Search: strconv.Itoa(quantity)}); err != nil {
+		t.Fatalf("list after delete: %v", err)
+	}
+	if list.Count != 0 {
+		t.Errorf("list after delete has %d transactions, want 0", list.Count)
+	}
+
+	if err = repo.Restore(ctx, id); err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+}
@@ -0,0 +1,214 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"market/pkg/logger"
+	"market/storage"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type summaryRepo struct {
+	db  Querier
+	log logger.ILogger
+}
+
+func NewSummaryRepo(db Querier, log logger.ILogger) storage.ISummaryStorage {
+	return summaryRepo{
+		db:  db,
+		log: log,
+	}
+}
+
+// ApplySale adds finished sale to the daily summaries of its creation day.
+// The sale is marked as summarized in the same db transaction, so applying
+// the same sale twice does nothing.
+func (s summaryRepo) ApplySale(ctx context.Context, id string) error {
+	return s.change(ctx, id, 1, `UPDATE sales SET summarized = true
+				WHERE id = $1 AND NOT summarized AND deleted_at = 0 AND status IN ('success', 'cancel')
+				RETURNING created_at::date, branch_id, COALESCE(payment_type::text, ''), status, COALESCE(price, 0)`)
+}
+
+// RemoveSale takes summarized sale out of the daily summaries, it is called before the
+// sale is deleted. The sale is marked as not summarized, so it is applied again once
+// it is restored.
+func (s summaryRepo) RemoveSale(ctx context.Context, id string) error {
+	return s.change(ctx, id, -1, `UPDATE sales SET summarized = false
+				WHERE id = $1 AND summarized
+				RETURNING created_at::date, branch_id, COALESCE(payment_type::text, ''), status, COALESCE(price, 0)`)
+}
+
+// change marks the sale with mark query and adds it to the summaries sign times, nothing
+// is changed if mark returns no row.
+func (s summaryRepo) change(ctx context.Context, id string, sign int, mark string) error {
+	var (
+		day         time.Time
+		branchID    sql.NullString
+		paymentType string
+		status      string
+		price       float64
+	)
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		s.log.Error("error is while beginning summary transaction", logger.Error(err))
+		return dbError(err)
+	}
+	defer tx.Rollback(ctx)
+
+	// summaries are locked before the sale, so a running rebuild is waited for instead of deadlocking with it
+	if _, err = tx.Exec(ctx, `LOCK TABLE daily_branch_summaries, daily_product_summaries IN ROW EXCLUSIVE MODE`); err != nil {
+		s.log.Error("error is while locking summaries", logger.Error(err))
+		return dbError(err)
+	}
+
+	if err = tx.QueryRow(ctx, mark, id).Scan(&day, &branchID, &paymentType, &status, &price); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		s.log.Error("error is while marking sale for summaries", logger.Error(err))
+		return dbError(err)
+	}
+
+	if branchID.Valid {
+		var (
+			salesCount, cancelledCount int
+			revenue, cancelledTotal    float64
+		)
+
+		if status == "success" {
+			salesCount, revenue = sign, float64(sign)*price
+		} else {
+			cancelledCount, cancelledTotal = sign, float64(sign)*price
+		}
+
+		if _, err = tx.Exec(ctx, `INSERT INTO daily_branch_summaries AS d
+				(day, branch_id, payment_type, sales_count, revenue, cancelled_count, cancelled_total)
+					VALUES ($1, $2, $3, $4, $5, $6, $7)
+				ON CONFLICT (day, branch_id, payment_type) DO UPDATE SET
+					sales_count = d.sales_count + EXCLUDED.sales_count,
+					revenue = d.revenue + EXCLUDED.revenue,
+					cancelled_count = d.cancelled_count + EXCLUDED.cancelled_count,
+					cancelled_total = d.cancelled_total + EXCLUDED.cancelled_total`,
+			day, branchID.String, paymentType, salesCount, revenue, cancelledCount, cancelledTotal); err != nil {
+			s.log.Error("error is while updating daily branch summary", logger.Error(err))
+			return dbError(err)
+		}
+
+		if status == "success" {
+			if _, err = tx.Exec(ctx, `INSERT INTO daily_product_summaries AS d (day, branch_id, product_id, quantity, revenue)
+					SELECT $1, $2, product_id, $4 * SUM(quantity), $4 * SUM(price)
+						FROM baskets WHERE sale_id = $3 AND deleted_at = 0 AND product_id IS NOT NULL
+						GROUP BY product_id
+					ON CONFLICT (day, branch_id, product_id) DO UPDATE SET
+						quantity = d.quantity + EXCLUDED.quantity,
+						revenue = d.revenue + EXCLUDED.revenue`,
+				day, branchID.String, id, sign); err != nil {
+				s.log.Error("error is while updating daily product summary", logger.Error(err))
+				return dbError(err)
+			}
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		s.log.Error("error is while committing summary", logger.Error(err))
+		return dbError(err)
+	}
+
+	return nil
+}
+
+// Pending returns finished sales which are not in the summaries yet, oldest first.
+func (s summaryRepo) Pending(ctx context.Context, limit int) ([]string, error) {
+	ids := []string{}
+
+	query := `SELECT id FROM sales
+				WHERE NOT summarized AND deleted_at = 0 AND status IN ('success', 'cancel')
+				ORDER BY created_at LIMIT $1`
+
+	rows, err := s.db.Query(ctx, query, limit)
+	if err != nil {
+		s.log.Error("error is while selecting not summarized sales", logger.Error(err))
+		return nil, dbError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id string
+		if err = rows.Scan(&id); err != nil {
+			s.log.Error("error is while scanning not summarized sale", logger.Error(err))
+			return nil, dbError(err)
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+// Rebuild recalculates the summaries of days between from and to (both inclusive)
+// from the sales and baskets tables.
+func (s summaryRepo) Rebuild(ctx context.Context, from, to string) error {
+	const filter = `deleted_at = 0 AND status IN ('success', 'cancel') AND branch_id IS NOT NULL
+				AND created_at >= $1::date AND created_at < $2::date + 1`
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		s.log.Error("error is while beginning summary rebuild", logger.Error(err))
+		return dbError(err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err = tx.Exec(ctx, `LOCK TABLE daily_branch_summaries, daily_product_summaries IN EXCLUSIVE MODE`); err != nil {
+		s.log.Error("error is while locking summaries", logger.Error(err))
+		return dbError(err)
+	}
+
+	if _, err = tx.Exec(ctx, `DELETE FROM daily_branch_summaries WHERE day BETWEEN $1::date AND $2::date`, from, to); err != nil {
+		s.log.Error("error is while deleting daily branch summaries", logger.Error(err))
+		return dbError(err)
+	}
+
+	if _, err = tx.Exec(ctx, `DELETE FROM daily_product_summaries WHERE day BETWEEN $1::date AND $2::date`, from, to); err != nil {
+		s.log.Error("error is while deleting daily product summaries", logger.Error(err))
+		return dbError(err)
+	}
+
+	if _, err = tx.Exec(ctx, `INSERT INTO daily_branch_summaries
+			(day, branch_id, payment_type, sales_count, revenue, cancelled_count, cancelled_total)
+				SELECT created_at::date, branch_id, COALESCE(payment_type::text, ''),
+					COUNT(*) FILTER (WHERE status = 'success'),
+					COALESCE(SUM(price) FILTER (WHERE status = 'success'), 0),
+					COUNT(*) FILTER (WHERE status = 'cancel'),
+					COALESCE(SUM(price) FILTER (WHERE status = 'cancel'), 0)
+				FROM sales WHERE `+filter+`
+				GROUP BY 1, 2, 3`, from, to); err != nil {
+		s.log.Error("error is while rebuilding daily branch summaries", logger.Error(err))
+		return dbError(err)
+	}
+
+	if _, err = tx.Exec(ctx, `INSERT INTO daily_product_summaries (day, branch_id, product_id, quantity, revenue)
+				SELECT s.created_at::date, s.branch_id, b.product_id, SUM(b.quantity), SUM(b.price)
+				FROM baskets b
+				JOIN (SELECT id, branch_id, created_at FROM sales WHERE status = 'success' AND `+filter+`) s ON s.id = b.sale_id
+				WHERE b.deleted_at = 0 AND b.product_id IS NOT NULL
+				GROUP BY 1, 2, 3`, from, to); err != nil {
+		s.log.Error("error is while rebuilding daily product summaries", logger.Error(err))
+		return dbError(err)
+	}
+
+	if _, err = tx.Exec(ctx, `UPDATE sales SET summarized = true WHERE NOT summarized AND `+filter, from, to); err != nil {
+		s.log.Error("error is while marking rebuilt sales as summarized", logger.Error(err))
+		return dbError(err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		s.log.Error("error is while committing summary rebuild", logger.Error(err))
+		return dbError(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,86 @@
+//go:build integration
+
+package postgres
+
+import (
+	"context"
+	"market/api/models"
+	"testing"
+)
+
+func TestSummaryRepo(t *testing.T) {
+	ctx := context.Background()
+	repo := testStore.Summary()
+	branchID, productID := newTestBranch(t), newTestProduct(t, 1500)
+	day := today(t)
+
+	sold := newTestFinishedSale(t, branchID, productID, "", "", "card", "success", 2, 3000)
+	cancelled := newTestFinishedSale(t, branchID, productID, "", "", "cash", "cancel", 1, 1500)
+	inProcess := newTestSale(t, branchID)
+
+	pending, err := repo.Pending(ctx, 10000)
+	if err != nil {
+		t.Fatalf("pending: %v", err)
+	}
+	found := map[string]bool{}
+	for _, id := range pending {
+		found[id] = true
+	}
+	if !found[sold] || !found[cancelled] || found[inProcess] {
+		t.Fatalf("pending are %v, want the finished sales only", pending)
+	}
+
+	// applying a sale twice counts it once
+	for _, id := range []string{sold, sold, cancelled} {
+		if err = repo.ApplySale(ctx, id); err != nil {
+			t.Fatalf("apply sale: %v", err)
+		}
+	}
+
+	wantRevenue := func(when string, count int, revenue float64) {
+		t.Helper()
+
+		reports, err := testStore.Report().Revenue(ctx, models.ReportRequest{BranchID: branchID, From: day, To: day, Group: "day"})
+		if err != nil {
+			t.Fatalf("%s: revenue: %v", when, err)
+		}
+
+		gotCount, gotRevenue := 0, 0.0
+		for _, report := range reports {
+			gotCount, gotRevenue = gotCount+report.SalesCount, gotRevenue+report.Revenue
+		}
+		if gotCount != count || gotRevenue != revenue {
+			t.Errorf("%s: revenue is %d sales for %v, want %d for %v", when, gotCount, gotRevenue, count, revenue)
+		}
+	}
+
+	wantRevenue("applied", 1, 3000)
+
+	if pending, err = repo.Pending(ctx, 10000); err != nil {
+		t.Fatalf("pending after apply: %v", err)
+	}
+	for _, id := range pending {
+		if id == sold || id == cancelled {
+			t.Errorf("applied sale %s is pending", id)
+		}
+	}
+
+	if err = repo.RemoveSale(ctx, sold); err != nil {
+		t.Fatalf("remove sale: %v", err)
+	}
+	wantRevenue("removed", 0, 0)
+
+	// rebuild counts the sales of the days again from the sales table
+	if err = repo.Rebuild(ctx, day, day); err != nil {
+		t.Fatalf("rebuild: %v", err)
+	}
+	wantRevenue("rebuilt", 1, 3000)
+
+	top, err := testStore.Report().TopProducts(ctx, models.ReportRequest{BranchID: branchID, From: day, To: day, Limit: 10})
+	if err != nil {
+		t.Fatalf("top products: %v", err)
+	}
+	if len(top) != 1 || top[0].ProductID != productID || top[0].Quantity != 2 || top[0].Revenue != 3000 {
+		t.Errorf("top products after rebuild are %+v, want 2 pieces of %s for 3000", top, productID)
+	}
+}
@@ -10,15 +10,14 @@ import (
 	"strconv"
 
 	"github.com/google/uuid"
-	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type transactionRepo struct {
-	db *pgxpool.Pool
+	db Querier
 	log logger.ILogger
 }
 
-func NewTransactionRepo(db *pgxpool.Pool, log logger.ILogger) storage.ITransactionStorage {
+func NewTransactionRepo(db Querier, log logger.ILogger) storage.ITransactionStorage {
 	return transactionRepo{
 		db: db,
 		log: log,
@@ -29,7 +28,7 @@ func (t transactionRepo) Create(ctx context.Context, trans models.CreateTransact
 	id := uuid.New()
 	query := `INSERT INTO transactions 
     					(id, sale_id, staff_id, transaction_type, source_type, amount, description) 
-						VALUES ($1, $2, $3, $4, $5, $6, $7)`
+						VALUES ($1, NULLIF($2, '')::uuid, $3, $4, $5, $6, $7)`
 	if _, err := t.db.Exec(ctx, query, id,
 		trans.SaleID,
 		trans.StaffID,
@@ -39,31 +38,43 @@ func (t transactionRepo) Create(ctx context.Context, trans models.CreateTransact
 		trans.Description,
 		); err != nil {
 		fmt.Println("error is while inserting data", err.Error())
-		return "", err
+		return "", dbError(err)
 	}
 	return id.String(), nil
 }
 
 func (t transactionRepo) GetByID(ctx context.Context, id string) (models.Transaction, error) {
-	var updatedAt sql.NullTime
+	var (
+		updatedAt   sql.NullTime
+		saleID      sql.NullString
+		description sql.NullString
+	)
 	trans := models.Transaction{}
 	query := `SELECT id, sale_id, staff_id, transaction_type, source_type, amount,
-       						description, created_at, updated_at
+       						description, version, created_at, updated_at
 							FROM transactions where deleted_at = 0 AND id = $1`
 	if err := t.db.QueryRow(ctx, query, id).Scan(
 		&trans.ID,
-		&trans.SaleID,
+		&saleID,
 		&trans.StaffID,
 		&trans.TransactionType,
 		&trans.SourceType,
 		&trans.Amount,
-		&trans.Description,
+		&description,
+		&trans.Version,
 		&trans.CreatedAt,
 		&updatedAt,
 		); err != nil {
 		fmt.Println("error is while selecting by id", err.Error())
-		return models.Transaction{}, err
+		return models.Transaction{}, dbError(err)
+	}
+
+	trans.SaleID = saleID.String
+	trans.Description = description.String
+	if updatedAt.Valid {
+		trans.UpdatedAt = updatedAt.Time
 	}
+
 	return trans, nil
 }
 
@@ -77,9 +88,11 @@ func (t transactionRepo) GetList(ctx context.Context, request models.Transaction
 		count             = 0
 		query, countQuery string
 		updatedAt         sql.NullTime
+		saleID            sql.NullString
+		description       sql.NullString
 	)
 
-	countQuery = `SELECT COUNT(1) FROM transactions WHERE deleted_at = 0 `
+	countQuery = `SELECT COUNT(1) FROM transactions WHERE ` + deletedFilter(request.IncludeDeleted) + ` `
 	if fromAmount != 0 && toAmount != 0 {
 		countQuery += fmt.Sprintf(` AND amount between %f and %f`, fromAmount, toAmount)
 	} else if fromAmount != 0 {
@@ -90,46 +103,50 @@ func (t transactionRepo) GetList(ctx context.Context, request models.Transaction
 	}
 	if err := t.db.QueryRow(ctx, countQuery).Scan(&count); err != nil {
 		fmt.Println("error is while scanning row", err.Error())
-		return models.TransactionResponse{}, err
+		return models.TransactionResponse{}, dbError(err)
 	}
 
 	query = `SELECT id, sale_id, staff_id, transaction_type, source_type, amount,
-       						description, created_at, updated_at FROM transactions WHERE deleted_at = 0 `
+       						description, version, created_at, updated_at, deleted_at FROM transactions WHERE ` + deletedFilter(request.IncludeDeleted) + ` `
 
 	if fromAmount != 0 && toAmount != 0 {
-		query += fmt.Sprintf(` AND amount between %f and %f  order by amount asc, `, fromAmount, toAmount)
+		query += fmt.Sprintf(` AND amount between %f and %f `, fromAmount, toAmount)
 	} else if fromAmount != 0 {
-		query += ` AND amount <= ` + strconv.FormatFloat(fromAmount, 'f', 2, 64) + `  order by amount asc, `
+		query += ` AND amount <= ` + strconv.FormatFloat(fromAmount, 'f', 2, 64) + ` `
 	} else {
-		query += ` AND amount >= ` + strconv.FormatFloat(toAmount, 'f', 2, 64) + ` order by amount asc, `
+		query += ` AND amount >= ` + strconv.FormatFloat(toAmount, 'f', 2, 64) + ` `
 
 	}
 
-	query += ` created_at desc LIMIT $1 OFFSET $2 `
+	query, args := pagingBy(query, nil, "amount asc, created_at desc", request.After, request.Limit, offset)
 
-	rows, err := t.db.Query(ctx, query, request.Limit, offset)
+	rows, err := t.db.Query(ctx, query, args...)
 	if err != nil {
 		fmt.Println("error is while selecting all from transactions", err.Error())
-		return models.TransactionResponse{}, err
+		return models.TransactionResponse{}, dbError(err)
 	}
 
 	for rows.Next() {
 		trans := models.Transaction{}
 		if err = rows.Scan(
 			&trans.ID,
-			&trans.SaleID,
+			&saleID,
 			&trans.StaffID,
 			&trans.TransactionType,
 			&trans.SourceType,
 			&trans.Amount,
-			&trans.Description,
+			&description,
+			&trans.Version,
 			&trans.CreatedAt,
 			&updatedAt,
+			&trans.DeletedAt,
 			); err != nil {
 			fmt.Println("error is while scanning rows", err.Error())
-			return models.TransactionResponse{}, err
+			return models.TransactionResponse{}, dbError(err)
 		}
 
+		trans.SaleID = saleID.String
+		trans.Description = description.String
 		if updatedAt.Valid {
 			trans.UpdatedAt = updatedAt.Time
 		}
@@ -143,21 +160,33 @@ func (t transactionRepo) GetList(ctx context.Context, request models.Transaction
 }
 
 func (t transactionRepo) Update(ctx context.Context, transaction models.UpdateTransaction) (string, error) {
-	query := `UPDATE transactions SET sale_id = $1, staff_id = $2, transaction_type = $3, source_type = $4, amount = $5,
-								description = $6, updated_at = NOW() 
-                    			WHERE id = $7 AND deleted_at = 0`
-	if _, err := t.db.Exec(ctx, query,
-		&transaction.SaleID,
-		&transaction.StaffID,
-		&transaction.TransactionType,
-		&transaction.SourceType,
-		&transaction.Amount,
-		&transaction.Description,
-		&transaction.ID,
-		); err != nil {
-		fmt.Println("error is while updating transaction: ", err.Error())
+	return t.Patch(ctx, models.PatchTransaction{
+		ID:              transaction.ID,
+		SaleID:          &transaction.SaleID,
+		StaffID:         &transaction.StaffID,
+		TransactionType: &transaction.TransactionType,
+		SourceType:      &transaction.SourceType,
+		Amount:          &transaction.Amount,
+		Description:     &transaction.Description,
+		Version:         transaction.Version,
+	})
+}
+
+// Patch updates only the given fields of transactions.
+func (t transactionRepo) Patch(ctx context.Context, transaction models.PatchTransaction) (string, error) {
+	fields := patch{}
+	setNullable(&fields, "sale_id", transaction.SaleID, "::uuid")
+	set(&fields, "staff_id", transaction.StaffID)
+	set(&fields, "transaction_type", transaction.TransactionType)
+	set(&fields, "source_type", transaction.SourceType)
+	set(&fields, "amount", transaction.Amount)
+	setNullable(&fields, "description", transaction.Description, "")
+
+	if err := fields.exec(ctx, t.db, "transactions", transaction.ID, transaction.Version); err != nil {
+		fmt.Println("error is while patching transaction: ", err.Error())
 		return "", err
 	}
+
 	return transaction.ID, nil
 }
 
@@ -165,7 +194,16 @@ func (t transactionRepo) Delete(ctx context.Context, id string) error {
 	query := `UPDATE transactions SET deleted_at = extract(epoch from current_timestamp) WHERE id = $1`
 	if _, err := t.db.Exec(ctx, query, id); err != nil {
 		fmt.Println("error is while deleting transaction: ", err.Error())
+		return dbError(err)
+	}
+	return nil
+}
+
+func (t transactionRepo) Restore(ctx context.Context, id string) error {
+	if err := restore(ctx, t.db, "transactions", id); err != nil {
+		fmt.Println("error is while restoring transaction: ", err.Error())
 		return err
 	}
+
 	return nil
-}
\ No newline at end of file
+}
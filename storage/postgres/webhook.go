@@ -0,0 +1,334 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"market/api/models"
+	"market/pkg/logger"
+	"market/storage"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+type webhookRepo struct {
+	db  Querier
+	log logger.ILogger
+}
+
+func NewWebhookRepo(db Querier, log logger.ILogger) storage.IWebhookStorage {
+	return webhookRepo{
+		db:  db,
+		log: log,
+	}
+}
+
+const webhookColumns = `id, url, secret, event_types, active, version, created_at, updated_at, deleted_at`
+
+func scanWebhook(row pgx.Row) (models.Webhook, error) {
+	var (
+		webhook   = models.Webhook{}
+		updatedAt sql.NullTime
+	)
+
+	if err := row.Scan(
+		&webhook.ID,
+		&webhook.URL,
+		&webhook.Secret,
+		&webhook.EventTypes,
+		&webhook.Active,
+		&webhook.Version,
+		&webhook.CreatedAt,
+		&updatedAt,
+		&webhook.DeletedAt,
+	); err != nil {
+		return models.Webhook{}, err
+	}
+
+	webhook.UpdatedAt = updatedAt.Time
+
+	return webhook, nil
+}
+
+func (w webhookRepo) Create(ctx context.Context, webhook models.CreateWebhook) (string, error) {
+	id := uuid.New().String()
+	if _, err := w.db.Exec(ctx, `INSERT INTO webhooks (id, url, secret, event_types) VALUES ($1, $2, $3, $4)`,
+		id,
+		webhook.URL,
+		webhook.Secret,
+		webhook.EventTypes,
+	); err != nil {
+		w.log.Error("error is while inserting webhook", logger.Error(err))
+		return "", dbError(err)
+	}
+
+	return id, nil
+}
+
+func (w webhookRepo) GetByID(ctx context.Context, id string) (models.Webhook, error) {
+	webhook, err := scanWebhook(w.db.QueryRow(ctx, `SELECT `+webhookColumns+` FROM webhooks WHERE id = $1 AND deleted_at = 0`, id))
+	if err != nil {
+		w.log.Error("error is while selecting webhook by id", logger.Error(err))
+		return models.Webhook{}, dbError(err)
+	}
+
+	return webhook, nil
+}
+
+func (w webhookRepo) GetList(ctx context.Context, request models.GetListRequest) (models.WebhooksResponse, error) {
+	var (
+		offset   = (request.Page - 1) * request.Limit
+		count    = 0
+		webhooks = []models.Webhook{}
+		filter   = deletedFilter(request.IncludeDeleted) + ` AND url ILIKE '%' || $1 || '%'`
+	)
+
+	if err := w.db.QueryRow(ctx, `SELECT COUNT(*) FROM webhooks WHERE `+filter, request.Search).Scan(&count); err != nil {
+		w.log.Error("error is while scanning count of webhooks", logger.Error(err))
+		return models.WebhooksResponse{}, dbError(err)
+	}
+
+	rows, err := w.db.Query(ctx, `SELECT `+webhookColumns+` FROM webhooks WHERE `+filter+`
+			ORDER BY created_at LIMIT $2 OFFSET $3`, request.Search, request.Limit, offset)
+	if err != nil {
+		w.log.Error("error is while selecting webhooks", logger.Error(err))
+		return models.WebhooksResponse{}, dbError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		webhook, err := scanWebhook(rows)
+		if err != nil {
+			w.log.Error("error is while scanning webhook", logger.Error(err))
+			return models.WebhooksResponse{}, dbError(err)
+		}
+
+		webhooks = append(webhooks, webhook)
+	}
+
+	return models.WebhooksResponse{
+		Webhooks: webhooks,
+		Count:    count,
+	}, nil
+}
+
+func (w webhookRepo) Update(ctx context.Context, webhook models.UpdateWebhook) (string, error) {
+	fields := patch{}
+	set(&fields, "url", &webhook.URL)
+	set(&fields, "event_types", &webhook.EventTypes)
+	set(&fields, "active", &webhook.Active)
+	if webhook.Secret != "" {
+		set(&fields, "secret", &webhook.Secret)
+	}
+
+	if err := fields.exec(ctx, w.db, "webhooks", webhook.ID, webhook.Version); err != nil {
+		w.log.Error("error is while updating webhook", logger.Error(err))
+		return "", err
+	}
+
+	return webhook.ID, nil
+}
+
+func (w webhookRepo) Delete(ctx context.Context, id string) error {
+	if err := softDelete(ctx, w.db, "webhooks", id); err != nil {
+		w.log.Error("error is while deleting webhook", logger.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+func (w webhookRepo) Restore(ctx context.Context, id string) error {
+	if err := restore(ctx, w.db, "webhooks", id); err != nil {
+		w.log.Error("error is while restoring webhook", logger.Error(err))
+		return err
+	}
+
+	return nil
+}
+
+// Subscribed returns active webhooks which are subscribed to the event type.
+func (w webhookRepo) Subscribed(ctx context.Context, eventType string) ([]models.Webhook, error) {
+	rows, err := w.db.Query(ctx, `SELECT `+webhookColumns+` FROM webhooks
+			WHERE deleted_at = 0 AND active AND $1 = ANY(event_types) ORDER BY created_at`, eventType)
+	if err != nil {
+		w.log.Error("error is while selecting subscribed webhooks", logger.Error(err))
+		return nil, dbError(err)
+	}
+	defer rows.Close()
+
+	webhooks := []models.Webhook{}
+	for rows.Next() {
+		webhook, err := scanWebhook(rows)
+		if err != nil {
+			w.log.Error("error is while scanning webhook", logger.Error(err))
+			return nil, dbError(err)
+		}
+
+		webhooks = append(webhooks, webhook)
+	}
+
+	return webhooks, nil
+}
+
+const deliveryColumns = `id, webhook_id, event_id, event_type, payload, status, attempts, response_code,
+		last_error, next_attempt_at, created_at, delivered_at`
+
+func scanDelivery(row pgx.Row) (models.WebhookDelivery, error) {
+	var (
+		delivery     = models.WebhookDelivery{}
+		responseCode sql.NullInt64
+		lastError    sql.NullString
+		deliveredAt  sql.NullTime
+	)
+
+	if err := row.Scan(
+		&delivery.ID,
+		&delivery.WebhookID,
+		&delivery.EventID,
+		&delivery.EventType,
+		&delivery.Payload,
+		&delivery.Status,
+		&delivery.Attempts,
+		&responseCode,
+		&lastError,
+		&delivery.NextAttemptAt,
+		&delivery.CreatedAt,
+		&deliveredAt,
+	); err != nil {
+		return models.WebhookDelivery{}, err
+	}
+
+	delivery.ResponseCode = int(responseCode.Int64)
+	delivery.LastError = lastError.String
+	delivery.DeliveredAt = deliveredAt.Time
+
+	return delivery, nil
+}
+
+// AddDelivery queues the event for the webhook and returns the id of the delivery.
+// The same event is queued for a webhook only once, then the returned id is empty.
+func (w webhookRepo) AddDelivery(ctx context.Context, delivery models.CreateWebhookDelivery) (string, error) {
+	id := uuid.New().String()
+	tag, err := w.db.Exec(ctx, `INSERT INTO webhook_deliveries (id, webhook_id, event_id, event_type, payload, next_attempt_at)
+			VALUES ($1, $2, $3, $4, $5, NOW() + $6::interval) ON CONFLICT (webhook_id, event_id) DO NOTHING`,
+		id,
+		delivery.WebhookID,
+		delivery.EventID,
+		delivery.EventType,
+		delivery.Payload,
+		delivery.Delay,
+	)
+	if err != nil {
+		w.log.Error("error is while inserting webhook delivery", logger.Error(err))
+		return "", dbError(err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return "", nil
+	}
+
+	return id, nil
+}
+
+func (w webhookRepo) DeliveryByID(ctx context.Context, id string) (models.WebhookDelivery, error) {
+	delivery, err := scanDelivery(w.db.QueryRow(ctx, `SELECT `+deliveryColumns+` FROM webhook_deliveries WHERE id = $1`, id))
+	if err != nil {
+		w.log.Error("error is while selecting webhook delivery by id", logger.Error(err))
+		return models.WebhookDelivery{}, dbError(err)
+	}
+
+	return delivery, nil
+}
+
+// ClaimDue returns at most limit pending deliveries whose next attempt is due, longest
+// waiting first. They are not due again until lease has passed by the db clock, so other
+// senders skip them while they are sent, and rows another sender is claiming right now
+// are skipped as well.
+func (w webhookRepo) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]models.WebhookDelivery, error) {
+	rows, err := w.db.Query(ctx, `UPDATE webhook_deliveries SET next_attempt_at = NOW() + $2::interval
+			WHERE id IN (SELECT id FROM webhook_deliveries
+					WHERE status = 'pending' AND next_attempt_at <= NOW()
+					ORDER BY next_attempt_at LIMIT $1 FOR UPDATE SKIP LOCKED)
+			RETURNING `+deliveryColumns, limit, lease)
+	if err != nil {
+		w.log.Error("error is while claiming due webhook deliveries", logger.Error(err))
+		return nil, dbError(err)
+	}
+	defer rows.Close()
+
+	deliveries := []models.WebhookDelivery{}
+	for rows.Next() {
+		delivery, err := scanDelivery(rows)
+		if err != nil {
+			w.log.Error("error is while scanning webhook delivery", logger.Error(err))
+			return nil, dbError(err)
+		}
+
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, nil
+}
+
+func (w webhookRepo) RecordAttempt(ctx context.Context, attempt models.WebhookAttempt) error {
+	if _, err := w.db.Exec(ctx, `UPDATE webhook_deliveries SET
+				attempts = attempts + 1,
+				status = $2,
+				response_code = NULLIF($3, 0),
+				last_error = NULLIF($4, ''),
+				next_attempt_at = CASE WHEN $2 = 'pending' THEN NOW() + $5::interval ELSE next_attempt_at END,
+				delivered_at = CASE WHEN $2 = 'delivered' THEN NOW() ELSE delivered_at END
+			WHERE id = $1`,
+		attempt.ID,
+		attempt.Status,
+		attempt.ResponseCode,
+		attempt.Error,
+		attempt.Backoff,
+	); err != nil {
+		w.log.Error("error is while recording webhook delivery attempt", logger.Error(err))
+		return dbError(err)
+	}
+
+	return nil
+}
+
+// Deliveries returns the delivery log newest first.
+func (w webhookRepo) Deliveries(ctx context.Context, request models.WebhookDeliveryListRequest) (models.WebhookDeliveriesResponse, error) {
+	var (
+		offset     = (request.Page - 1) * request.Limit
+		count      = 0
+		deliveries = []models.WebhookDelivery{}
+		filter     = `($1 = '' OR webhook_id::text = $1) AND ($2 = '' OR status = $2)`
+	)
+
+	if err := w.db.QueryRow(ctx, `SELECT COUNT(*) FROM webhook_deliveries WHERE `+filter,
+		request.WebhookID, request.Status).Scan(&count); err != nil {
+		w.log.Error("error is while scanning count of webhook deliveries", logger.Error(err))
+		return models.WebhookDeliveriesResponse{}, dbError(err)
+	}
+
+	rows, err := w.db.Query(ctx, `SELECT `+deliveryColumns+` FROM webhook_deliveries WHERE `+filter+`
+			ORDER BY created_at DESC LIMIT $3 OFFSET $4`, request.WebhookID, request.Status, request.Limit, offset)
+	if err != nil {
+		w.log.Error("error is while selecting webhook deliveries", logger.Error(err))
+		return models.WebhookDeliveriesResponse{}, dbError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		delivery, err := scanDelivery(rows)
+		if err != nil {
+			w.log.Error("error is while scanning webhook delivery", logger.Error(err))
+			return models.WebhookDeliveriesResponse{}, dbError(err)
+		}
+
+		deliveries = append(deliveries, delivery)
+	}
+
+	return models.WebhookDeliveriesResponse{
+		Deliveries: deliveries,
+		Count:      count,
+	}, nil
+}
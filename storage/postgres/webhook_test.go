@@ -0,0 +1,181 @@
+//go:build integration
+
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"market/api/models"
+	"market/pkg/errs"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestWebhookRepo(t *testing.T) {
+	ctx := context.Background()
+	repo := testStore.Webhook()
+	url := "https://example.com/" + uuid.NewString()
+	eventType := unique("sale.completed")
+
+	id, err := repo.Create(ctx, models.CreateWebhook{URL: url, Secret: "0123456789abcdef", EventTypes: []string{eventType, "sale.cancelled"}})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	webhook, err := repo.GetByID(ctx, id)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if webhook.URL != url || webhook.Secret != "0123456789abcdef" || len(webhook.EventTypes) != 2 || !webhook.Active || webhook.Version != 1 {
+		t.Errorf("got %+v, want active webhook to %s with two event types", webhook, url)
+	}
+
+	subscribed, err := repo.Subscribed(ctx, eventType)
+	if err != nil {
+		t.Fatalf("subscribed: %v", err)
+	}
+	if len(subscribed) != 1 || subscribed[0].ID != id {
+		t.Errorf("subscribed to %s are %+v, want only %s", eventType, subscribed, id)
+	}
+
+	// an empty secret keeps the current one
+	if _, err = repo.Update(ctx, models.UpdateWebhook{ID: id, URL: url, EventTypes: []string{eventType}, Active: false, Version: webhook.Version}); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+
+	list, err := repo.GetList(ctx, models.GetListRequest{Page: 1, Limit: 10, Search: url})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if list.Count != 1 || len(list.Webhooks) != 1 {
+		t.Fatalf("list got %d webhooks, want 1", list.Count)
+	}
+	if got := list.Webhooks[0]; got.Active || got.Secret != "0123456789abcdef" || len(got.EventTypes) != 1 || got.Version != 2 {
+		t.Errorf("after update got %+v, want inactive with the same secret and version 2", got)
+	}
+
+	if subscribed, err = repo.Subscribed(ctx, eventType); err != nil || len(subscribed) != 0 {
+		t.Errorf("inactive webhook is subscribed: %+v with error %v", subscribed, err)
+	}
+
+	if err = repo.Delete(ctx, id); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+
+	_, err = repo.GetByID(ctx, id)
+	wantNotFound(t, err)
+
+	if err = repo.Restore(ctx, id); err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+}
+
+func TestWebhookDeliveries(t *testing.T) {
+	ctx := context.Background()
+	repo := testStore.Webhook()
+
+	webhookID, err := repo.Create(ctx, models.CreateWebhook{URL: "https://example.com/" + uuid.NewString(), Secret: "0123456789abcdef", EventTypes: []string{"sale.completed"}})
+	if err != nil {
+		t.Fatalf("create webhook: %v", err)
+	}
+
+	eventID := uuid.NewString()
+	delivery := models.CreateWebhookDelivery{WebhookID: webhookID, EventID: eventID, EventType: "sale.completed", Payload: json.RawMessage(`{"sale_id": "1"}`)}
+
+	id, err := repo.AddDelivery(ctx, delivery)
+	if err != nil || id == "" {
+		t.Fatalf("add delivery: %q, %v", id, err)
+	}
+
+	// the same event is queued for the webhook once
+	if again, err := repo.AddDelivery(ctx, delivery); err != nil || again != "" {
+		t.Errorf("adding the event again returned %q with error %v, want empty id", again, err)
+	}
+
+	_, err = repo.AddDelivery(ctx, models.CreateWebhookDelivery{WebhookID: uuid.NewString(), EventID: eventID, EventType: "sale.completed", Payload: json.RawMessage(`{}`)})
+	wantCode(t, err, errs.CodeValidation)
+
+	// a delivery which is not due yet is not claimed
+	laterID, err := repo.AddDelivery(ctx, models.CreateWebhookDelivery{WebhookID: webhookID, EventID: uuid.NewString(), EventType: "sale.cancelled",
+		Payload: json.RawMessage(`{}`), Delay: 24 * time.Hour})
+	if err != nil {
+		t.Fatalf("add later delivery: %v", err)
+	}
+
+	claimed := claimDeliveries(t, webhookID)
+	if len(claimed) != 1 || claimed[0].ID != id || claimed[0].Status != "pending" || string(claimed[0].Payload) != `{"sale_id": "1"}` {
+		t.Fatalf("claimed %+v, want only %s", claimed, id)
+	}
+
+	// a claimed delivery is not claimed again until it is due
+	if claimed = claimDeliveries(t, webhookID); len(claimed) != 0 {
+		t.Errorf("claimed %+v again, want none", claimed)
+	}
+
+	if err = repo.RecordAttempt(ctx, models.WebhookAttempt{ID: id, Status: "pending", ResponseCode: 502, Error: "bad gateway",
+		Backoff: -24 * time.Hour}); err != nil {
+		t.Fatalf("record failed attempt: %v", err)
+	}
+
+	if claimed = claimDeliveries(t, webhookID); len(claimed) != 1 || claimed[0].Attempts != 1 || claimed[0].ResponseCode != 502 || claimed[0].LastError != "bad gateway" {
+		t.Fatalf("claimed after failed attempt %+v, want the delivery with one attempt", claimed)
+	}
+
+	if err = repo.RecordAttempt(ctx, models.WebhookAttempt{ID: id, Status: "delivered", ResponseCode: 200}); err != nil {
+		t.Fatalf("record delivered attempt: %v", err)
+	}
+
+	got, err := repo.DeliveryByID(ctx, id)
+	if err != nil {
+		t.Fatalf("get delivery: %v", err)
+	}
+	if got.Status != "delivered" || got.Attempts != 2 || got.ResponseCode != 200 || got.LastError != "" || got.DeliveredAt.IsZero() {
+		t.Errorf("delivered got %+v, want delivered on the second attempt", got)
+	}
+
+	for _, request := range []struct {
+		status string
+		want   string
+	}{
+		{"delivered", id},
+		{"pending", laterID},
+	} {
+		list, err := repo.Deliveries(ctx, models.WebhookDeliveryListRequest{Page: 1, Limit: 10, WebhookID: webhookID, Status: request.status})
+		if err != nil {
+			t.Fatalf("%s deliveries: %v", request.status, err)
+		}
+		if list.Count != 1 || len(list.Deliveries) != 1 || list.Deliveries[0].ID != request.want {
+			t.Errorf("%s deliveries are %+v, want only %s", request.status, list, request.want)
+		}
+	}
+
+	list, err := repo.Deliveries(ctx, models.WebhookDeliveryListRequest{Page: 1, Limit: 10, WebhookID: webhookID})
+	if err != nil {
+		t.Fatalf("deliveries: %v", err)
+	}
+	if list.Count != 2 || list.Deliveries[0].ID != laterID {
+		t.Errorf("deliveries are %+v, want both newest first", list)
+	}
+}
+
+// claimDeliveries claims the due deliveries and returns the ones of the webhook,
+// the queue is shared by the tests.
+func claimDeliveries(t *testing.T, webhookID string) []models.WebhookDelivery {
+	t.Helper()
+
+	deliveries, err := testStore.Webhook().ClaimDue(context.Background(), 100, 24*time.Hour)
+	if err != nil {
+		t.Fatalf("claim due deliveries: %v", err)
+	}
+
+	claimed := []models.WebhookDelivery{}
+	for _, delivery := range deliveries {
+		if delivery.WebhookID == webhookID {
+			claimed = append(claimed, delivery)
+		}
+	}
+
+	return claimed
+}
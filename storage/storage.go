@@ -3,10 +3,14 @@ package storage
 import (
 	"context"
 	"market/api/models"
+	"time"
 )
 
 type IStorage interface {
 	Close()
+	// Ping reports whether the store can serve requests.
+	Ping(context.Context) error
+	WithTx(context.Context, func(IStorage) error) error
 	StaffTariff() IStaffTariffRepo
 	Staff() IStaffRepo
 	Repository() IRepositoryRepo
@@ -17,6 +21,16 @@ type IStorage interface {
 	Branch() IBranchStorage
 	Sale() ISaleStorage
 	Transaction() ITransactionStorage
+	Shift() IShiftStorage
+	Customer() ICustomerStorage
+	Payout() IPayoutStorage
+	Report() IReportStorage
+	Summary() ISummaryStorage
+	Idempotency() IIdempotencyStorage
+	Purge() IPurgeStorage
+	Audit() IAuditStorage
+	Outbox() IOutboxStorage
+	Webhook() IWebhookStorage
 }
 
 type IStaffTariffRepo interface {
@@ -24,7 +38,9 @@ type IStaffTariffRepo interface {
 	GetStaffTariffByID(context.Context, models.PrimaryKey) (models.StaffTarif, error)
 	GetStaffTariffList(context.Context, models.GetListRequest) (models.StaffTarifResponse, error)
 	UpdateStaffTariff(context.Context, models.UpdateStaffTarif) (string, error)
+	PatchStaffTariff(context.Context, models.PatchStaffTarif) (string, error)
 	DeleteStaffTariff(context.Context, string) error
+	RestoreStaffTariff(context.Context, string) error
 }
 
 type IStaffRepo interface {
@@ -32,18 +48,26 @@ type IStaffRepo interface {
 	StaffByID(context.Context, models.PrimaryKey) (models.Staff, error)
 	GetStaffTList(context.Context, models.GetListRequest) (models.StaffsResponse, error)
 	UpdateStaff(context.Context, models.UpdateStaff) (string, error)
+	PatchStaff(context.Context, models.PatchStaff) (string, error)
 	DeleteStaff(context.Context, string) error
+	RestoreStaff(context.Context, string) error
 	GetPassword(context.Context, string) (string, error)
 	UpdatePassword(context.Context, models.UpdateStaffPassword) error
+	AdjustBalance(context.Context, models.StaffBalanceAdjustment) (string, error)
 }
 
 type IRepositoryRepo interface {
 	Create(context.Context, models.CreateRepository) (string, error)
 	GetByID(context.Context, models.PrimaryKey) (models.Repository, error)
 	ProductByID(context.Context, string) (int, error)
+	// ForUpdate returns the repository of the product (second argument) in the branch
+	// (first argument) and locks it until the transaction ends.
+	ForUpdate(context.Context, string, string) (models.Repository, error)
 	GetList(context.Context, models.GetListRequest) (models.RepositoriesResponse, error)
 	Update(context.Context, models.UpdateRepository) (string, error)
+	Patch(context.Context, models.PatchRepository) (string, error)
 	Delete(context.Context, string) error
+	Restore(context.Context, string) error
 }
 
 type IBasketRepo interface {
@@ -51,7 +75,9 @@ type IBasketRepo interface {
 	GetByID(context.Context, models.PrimaryKey) (models.Basket, error)
 	GetList(context.Context, models.GetListRequest) (models.BasketsResponse, error)
 	Update(context.Context, models.UpdateBasket) (string, error)
+	Patch(context.Context, models.PatchBasket) (string, error)
 	Delete(context.Context, models.PrimaryKey) error
+	Restore(context.Context, string) error
 }
 
 type IRepositoryTransactionRepo interface {
@@ -59,7 +85,9 @@ type IRepositoryTransactionRepo interface {
 	GetByID(context.Context, models.PrimaryKey) (models.RepositoryTransaction, error)
 	GetList(context.Context, models.GetListRequest) (models.RepositoryTransactionsResponse, error)
 	Update(context.Context, models.UpdateRepositoryTransaction) (string, error)
+	Patch(context.Context, models.PatchRepositoryTransaction) (string, error)
 	Delete(context.Context, string) error
+	Restore(context.Context, string) error
 }
 
 type ICategory interface {
@@ -67,7 +95,9 @@ type ICategory interface {
 	GetByID(context.Context, models.PrimaryKey) (models.Category, error)
 	GetList(context.Context, models.GetListRequest) (models.CategoryResponse, error)
 	Update(context.Context, models.UpdateCategory) (string, error)
+	Patch(context.Context, models.PatchCategory) (string, error)
 	Delete(context.Context, string) error
+	Restore(context.Context, string) error
 }
 
 type IProducts interface {
@@ -75,7 +105,11 @@ type IProducts interface {
 	GetByID(context.Context, string) (models.Product, error)
 	GetList(context.Context, models.ProductGetListRequest) (models.ProductResponse, error)
 	Update(context.Context, models.UpdateProduct) (string, error)
+	Patch(context.Context, models.PatchProduct) (string, error)
 	Delete(context.Context, string) error
+	Restore(context.Context, string) error
+	Existing(context.Context, []string, []int) ([]string, []int, error)
+	Import(context.Context, []models.ImportProduct) error
 }
 
 type IBranchStorage interface {
@@ -83,7 +117,9 @@ type IBranchStorage interface {
 	GetByID(context.Context, string) (models.Branch, error)
 	GetList(context.Context, models.GetListRequest) (models.BranchResponse, error)
 	Update(context.Context, models.UpdateBranch) (string, error)
+	Patch(context.Context, models.PatchBranch) (string, error)
 	Delete(context.Context, string) error
+	Restore(context.Context, string) error
 }
 
 type ISaleStorage interface {
@@ -91,7 +127,10 @@ type ISaleStorage interface {
 	GetByID(context.Context, string) (models.Sale, error)
 	GetList(context.Context, models.GetListRequest) (models.SaleResponse, error)
 	Update(context.Context, models.UpdateSale) (string, error)
+	Patch(context.Context, models.PatchSale) (string, error)
 	Delete(context.Context, string) error
+	Restore(context.Context, string) error
+	GetReceipt(context.Context, string) (models.Receipt, error)
 }
 
 type ITransactionStorage interface {
@@ -99,5 +138,95 @@ type ITransactionStorage interface {
 	GetByID(context.Context, string) (models.Transaction, error)
 	GetList(context.Context, models.TransactionGetListRequest) (models.TransactionResponse, error)
 	Update(context.Context, models.UpdateTransaction) (string, error)
+	Patch(context.Context, models.PatchTransaction) (string, error)
 	Delete(context.Context, string) error
-}
\ No newline at end of file
+	Restore(context.Context, string) error
+}
+
+type IShiftStorage interface {
+	Open(context.Context, models.OpenShift) (string, error)
+	GetByID(context.Context, string) (models.Shift, error)
+	GetOpenByCashier(context.Context, string) (models.Shift, error)
+	// ForUpdate returns the shift and locks it until the end of the transaction,
+	// OpenByCashierForShare does the same with the open shift of the cashier, but many
+	// transactions can hold it at once. Checkouts share the shift, closing it waits for them.
+	ForUpdate(context.Context, string) (models.Shift, error)
+	OpenByCashierForShare(context.Context, string) (models.Shift, error)
+	GetList(context.Context, models.GetListRequest) (models.ShiftsResponse, error)
+	Close(context.Context, models.CloseShift) (string, error)
+	ZReport(context.Context, models.Shift) (models.ZReport, error)
+}
+
+type ICustomerStorage interface {
+	Create(context.Context, models.CreateCustomer) (string, error)
+	GetByID(context.Context, string) (models.Customer, error)
+	GetList(context.Context, models.GetListRequest) (models.CustomersResponse, error)
+	Update(context.Context, models.UpdateCustomer) (string, error)
+	Patch(context.Context, models.PatchCustomer) (string, error)
+	Delete(context.Context, string) error
+	Restore(context.Context, string) error
+	AddPoints(context.Context, string, int) error
+	SaleHistory(context.Context, string, models.GetListRequest) ([]models.CustomerSale, int, error)
+}
+
+type IPayoutStorage interface {
+	Run(context.Context, models.CreatePayout) (string, error)
+	GetByID(context.Context, string) (models.Payout, error)
+	GetList(context.Context, models.GetListRequest) (models.PayoutsResponse, error)
+	Statement(context.Context, string, string) (models.PayrollStatement, error)
+}
+
+type IReportStorage interface {
+	Revenue(context.Context, models.ReportRequest) ([]models.RevenueReport, error)
+	TopProducts(context.Context, models.ReportRequest) ([]models.ProductReport, error)
+	StaffSales(context.Context, models.ReportRequest) ([]models.StaffSalesReport, error)
+	PaymentTypes(context.Context, models.ReportRequest) ([]models.PaymentTypeReport, error)
+	StaffStats(context.Context, string, models.ReportRequest) (models.StaffStats, error)
+}
+
+type ISummaryStorage interface {
+	ApplySale(context.Context, string) error
+	RemoveSale(context.Context, string) error
+	Pending(context.Context, int) ([]string, error)
+	Rebuild(context.Context, string, string) error
+}
+
+type IIdempotencyStorage interface {
+	Reserve(context.Context, models.IdempotentResponse, time.Duration, time.Duration) (models.IdempotentResponse, bool, error)
+	Save(context.Context, models.IdempotentResponse) error
+	Release(context.Context, models.IdempotentResponse) error
+	DeleteExpired(context.Context, time.Duration) (int64, error)
+}
+
+type IPurgeStorage interface {
+	Purge(context.Context, time.Time) (int64, error)
+}
+
+type IAuditStorage interface {
+	Create(context.Context, models.CreateAuditLog) error
+	GetList(context.Context, models.AuditListRequest) (models.AuditLogsResponse, error)
+}
+
+type IOutboxStorage interface {
+	Add(context.Context, models.CreateEvent) error
+	Claim(context.Context, int, int, time.Duration) ([]models.Event, error)
+	MarkDelivered(context.Context, string, string) error
+	MarkDispatched(context.Context, string) error
+	MarkFailed(context.Context, string, string, time.Duration) error
+	DeleteDispatched(context.Context, time.Duration) (int64, error)
+}
+
+type IWebhookStorage interface {
+	Create(context.Context, models.CreateWebhook) (string, error)
+	GetByID(context.Context, string) (models.Webhook, error)
+	GetList(context.Context, models.GetListRequest) (models.WebhooksResponse, error)
+	Update(context.Context, models.UpdateWebhook) (string, error)
+	Delete(context.Context, string) error
+	Restore(context.Context, string) error
+	Subscribed(context.Context, string) ([]models.Webhook, error)
+	AddDelivery(context.Context, models.CreateWebhookDelivery) (string, error)
+	DeliveryByID(context.Context, string) (models.WebhookDelivery, error)
+	ClaimDue(context.Context, int, time.Duration) ([]models.WebhookDelivery, error)
+	RecordAttempt(context.Context, models.WebhookAttempt) error
+	Deliveries(context.Context, models.WebhookDeliveryListRequest) (models.WebhookDeliveriesResponse, error)
+}